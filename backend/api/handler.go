@@ -1,19 +1,31 @@
 package api
 
 import (
+	"backend/db/repository/identityRepository"
+	"backend/db/repository/oauthStateRepository"
+	"backend/db/repository/sessionRepository"
+	"backend/db/repository/settingRepository"
 	"backend/db/repository/userRepository"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type UserHandler struct {
-	DB       *mongo.Database
-	UserRepo userRepository.UsersRepository
+	DB             *mongo.Database
+	UserRepo       userRepository.UsersRepository
+	SessionRepo    sessionRepository.SessionRepository
+	SettingRepo    settingRepository.SettingRepository
+	OAuthStateRepo oauthStateRepository.OAuthStateRepository
+	IdentityRepo   identityRepository.IdentityRepository
 }
 
 // NewUserHandler 新しいLiquorHandlerを作成するコンストラクタ
-func NewUserHandler(db *mongo.Database, userRepo userRepository.UsersRepository) *UserHandler {
+func NewUserHandler(db *mongo.Database, userRepo userRepository.UsersRepository, sessionRepo sessionRepository.SessionRepository, settingRepo settingRepository.SettingRepository, oauthStateRepo oauthStateRepository.OAuthStateRepository, identityRepo identityRepository.IdentityRepository) *UserHandler {
 	return &UserHandler{
-		DB:       db,
-		UserRepo: userRepo,
+		DB:             db,
+		UserRepo:       userRepo,
+		SessionRepo:    sessionRepo,
+		SettingRepo:    settingRepo,
+		OAuthStateRepo: oauthStateRepo,
+		IdentityRepo:   identityRepo,
 	}
 }
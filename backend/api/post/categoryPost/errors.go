@@ -15,6 +15,7 @@ const (
 	InvalidParent  = "CATEGORY-POST-003-InvalidParent"
 	InvalidVersion = "CATEGORY-POST-004-InvalidVersion"
 	InvalidFile    = "CATEGORY-POST-005-InvalidFile"
+	Readonly       = "CATEGORY-POST-006-Readonly"
 )
 
 func errInvalidInput(c *gin.Context, err error) *customError.Error {
@@ -65,3 +66,13 @@ func errInvalidFile(err error, input RequestData) *customError.Error {
 		Input:      input,
 	})
 }
+
+func errReadonly(input RequestData) *customError.Error {
+	return customError.NewError(errors.New("移動不可のカテゴリの親・名前を変更しようとしました"), customError.Params{
+		StatusCode: http.StatusForbidden,
+		ErrCode:    Readonly,
+		UserMsg:    "このカテゴリの親カテゴリや名前は変更できません",
+		Level:      logrus.InfoLevel,
+		Input:      input,
+	})
+}
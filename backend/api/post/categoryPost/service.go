@@ -3,10 +3,12 @@ package categoryPost
 import (
 	"backend/db"
 	"backend/db/repository/categoriesRepository"
+	"backend/db/repository/settingRepository"
 	"backend/db/repository/userRepository"
 	"backend/middlewares/auth"
 	"backend/middlewares/customError"
 	"backend/service/categoryService"
+	"backend/service/userService"
 	"backend/util/amazon/s3"
 	"backend/util/helper"
 	"errors"
@@ -17,7 +19,7 @@ import (
 	"time"
 )
 
-func (h *Handler) Post(c *gin.Context, ur *userRepository.UsersRepository) (*int, *customError.Error) {
+func (h *Handler) Post(c *gin.Context, ur *userRepository.UsersRepository, sr *settingRepository.SettingRepository) (*int, *customError.Error) {
 	ctx := c.Request.Context()
 
 	var request RequestData
@@ -63,6 +65,17 @@ func (h *Handler) Post(c *gin.Context, ur *userRepository.UsersRepository) (*int
 		if *old.VersionNo != *request.VersionNo {
 			return nil, errInvalidVersion(request)
 		}
+
+		//移動不可のカテゴリは、管理者以外は親の変更・名前の変更ができない
+		if old.Readonly && (old.Parent == nil || *old.Parent != request.Parent || old.Name != request.Name) {
+			isAdmin, err := userService.IsAdmin(ctx, *ur, *sr)
+			if err != nil {
+				return nil, err
+			}
+			if !isAdmin {
+				return nil, errReadonly(request)
+			}
+		}
 	}
 
 	// フォームからファイルを取得
@@ -152,9 +165,14 @@ func (h *Handler) Post(c *gin.Context, ur *userRepository.UsersRepository) (*int
 
 	var cId *primitive.ObjectID
 	var cName *string
+	var order *int
+	var readonly bool
 	if old != nil {
 		cId = old.CreateUserId
 		cName = old.CreateUserName
+		//並び順と移動不可フラグはこのAPIでは変更しないので引き継ぐ
+		order = old.Order
+		readonly = old.Readonly
 	} else {
 		cId = uId
 		cName = uName
@@ -174,6 +192,8 @@ func (h *Handler) Post(c *gin.Context, ur *userRepository.UsersRepository) (*int
 		UpdateUserName: uName,
 		UpdatedAt:      time.Now(),
 		VersionNo:      &newVersionNo,
+		Order:          order,
+		Readonly:       readonly,
 	}
 
 	//トランザクション
@@ -1,28 +1,37 @@
 package liquorPost
 
 import (
+	"backend/db/repository/activityRepository"
 	"backend/db/repository/categoriesRepository"
 	"backend/db/repository/liquorRepository"
+	"backend/db/repository/notificationRepository"
 	"backend/db/repository/userRepository"
+	"backend/util/pubsub"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type Handler struct {
-	DB           *mongo.Database
-	S3Client     *s3.S3
-	CategoryRepo categoriesRepository.CategoryRepository
-	LiquorsRepo  liquorRepository.LiquorsRepository
-	UserRepo     userRepository.UsersRepository
+	DB               *mongo.Database
+	S3Client         *s3.S3
+	CategoryRepo     categoriesRepository.CategoryRepository
+	LiquorsRepo      liquorRepository.LiquorsRepository
+	UserRepo         userRepository.UsersRepository
+	ActivityRepo     activityRepository.ActivityRepository
+	NotificationRepo notificationRepository.NotificationRepository
+	PubSub           pubsub.PubSub
 }
 
 // NewHandler 新しいLiquorHandlerを作成するコンストラクタ
-func NewHandler(db *mongo.Database, s3Client *s3.S3, categoryRepo categoriesRepository.CategoryRepository, liquorsRepo liquorRepository.LiquorsRepository, userRepo userRepository.UsersRepository) *Handler {
+func NewHandler(db *mongo.Database, s3Client *s3.S3, categoryRepo categoriesRepository.CategoryRepository, liquorsRepo liquorRepository.LiquorsRepository, userRepo userRepository.UsersRepository, activityRepo activityRepository.ActivityRepository, notificationRepo notificationRepository.NotificationRepository, ps pubsub.PubSub) *Handler {
 	return &Handler{
-		DB:           db,
-		S3Client:     s3Client,
-		CategoryRepo: categoryRepo,
-		LiquorsRepo:  liquorsRepo,
-		UserRepo:     userRepo,
+		DB:               db,
+		S3Client:         s3Client,
+		CategoryRepo:     categoryRepo,
+		LiquorsRepo:      liquorsRepo,
+		UserRepo:         userRepo,
+		ActivityRepo:     activityRepo,
+		NotificationRepo: notificationRepo,
+		PubSub:           ps,
 	}
 }
@@ -2,10 +2,14 @@ package liquorPost
 
 import (
 	"backend/db"
+	"backend/db/repository/activityRepository"
 	"backend/db/repository/liquorRepository"
+	"backend/db/repository/notificationRepository"
 	"backend/db/repository/userRepository"
 	"backend/middlewares/auth"
 	"backend/middlewares/customError"
+	"backend/service/activityService"
+	"backend/service/notificationService"
 	"backend/util/amazon/s3"
 	"backend/util/helper"
 	"errors"
@@ -235,6 +239,16 @@ func (h *Handler) Post(c *gin.Context, ur *userRepository.UsersRepository) (*str
 	if err != nil {
 		return nil, err
 	}
+	if uId != nil {
+		activityType := activityRepository.TypeLiquorUpdate
+		if old == nil {
+			activityType = activityRepository.TypeLiquorCreate
+		}
+		activityService.Record(ctx, &h.ActivityRepo, *uId, activityType, *id, nil, nil)
+	}
+	if old != nil {
+		notificationService.NotifyLiquorCreator(ctx, &h.NotificationRepo, h.PubSub, old, notificationRepository.TypeLiquorEdited, uId, nil, nil)
+	}
 	return newId, nil
 }
 
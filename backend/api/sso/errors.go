@@ -0,0 +1,143 @@
+package sso
+
+import (
+	"backend/db/repository/identityRepository"
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/errorMsg"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+const (
+	UnknownProvider       = "SSO-001-UnknownProvider"
+	ProviderNotConfigured = "SSO-002-ProviderNotConfigured"
+	AuthorizationDenied   = "SSO-003-AuthorizationDenied"
+	MissCode              = "SSO-004-MissCode"
+	InvalidState          = "SSO-005-InvalidState"
+	ExchangeToken         = "SSO-006-ExchangeToken"
+	GetUserInfoFail       = "SSO-007-GetUserInfoFail"
+	GetUserInfoBadStatus  = "SSO-008-GetUserInfoBadStatus"
+	ReadResponseBody      = "SSO-009-ReadResponseBody"
+	UnMarshalResponseBody = "SSO-010-UnMarshalResponseBody"
+	GetData               = "SSO-011-GetData"
+	IdentityUserNotFound  = "SSO-012-IdentityUserNotFound"
+)
+
+func errUnknownProvider(name string) *customError.Error {
+	return customError.NewError(errors.New("未対応のプロバイダー"), customError.Params{
+		StatusCode: http.StatusNotFound,
+		ErrCode:    UnknownProvider,
+		UserMsg:    "このサービスでのログインには対応していません",
+		Level:      logrus.InfoLevel,
+		Input:      name,
+	})
+}
+
+func errProviderNotConfigured(name string) *customError.Error {
+	return customError.NewError(errors.New("クライアントIDが未設定"), customError.Params{
+		StatusCode: http.StatusNotFound,
+		ErrCode:    ProviderNotConfigured,
+		UserMsg:    "このサービスでのログインには対応していません",
+		Level:      logrus.WarnLevel,
+		Input:      name,
+	})
+}
+
+func errAuthorizationDenied(name string, reason string) *customError.Error {
+	return customError.NewError(errors.New("認可画面で拒否された"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    AuthorizationDenied,
+		UserMsg:    "ログインがキャンセルされました",
+		Level:      logrus.InfoLevel,
+		Input:      map[string]interface{}{"provider": name, "reason": reason},
+	})
+}
+
+func errMissCode(name string) *customError.Error {
+	return customError.NewError(errors.New("missing code"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    MissCode,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.InfoLevel,
+		Input:      name,
+	})
+}
+
+func errInvalidState(name string) *customError.Error {
+	return customError.NewError(errors.New("stateが不正か期限切れ"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    InvalidState,
+		UserMsg:    "ログインの有効期限が切れました。最初からやり直してください",
+		Level:      logrus.WarnLevel,
+		Input:      name,
+	})
+}
+
+func errExchangeToken(err error, name string) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    ExchangeToken,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      name,
+	})
+}
+
+func errGetUserInfo(err error, url string) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    GetUserInfoFail,
+		UserMsg:    "ユーザー情報取得に失敗しました",
+		Level:      logrus.ErrorLevel,
+		Input:      url,
+	})
+}
+
+func errGetUserInfoBadStatus(url string, status int, errBody []byte) *customError.Error {
+	return customError.NewError(errors.New("failed to fetch user info"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    GetUserInfoBadStatus,
+		UserMsg:    "ユーザー情報取得に失敗しました",
+		Level:      logrus.ErrorLevel,
+		Input:      map[string]interface{}{"url": url, "status": status, "body": string(errBody)},
+	})
+}
+
+func errReadResponseBody(err error) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    ReadResponseBody,
+		UserMsg:    "サーバーのレスポンスが不正です",
+		Level:      logrus.ErrorLevel,
+	})
+}
+
+func errUnMarshalResponseBody(err error) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    UnMarshalResponseBody,
+		UserMsg:    "サーバーのレスポンスが不正です",
+		Level:      logrus.ErrorLevel,
+	})
+}
+
+func errGetData(name string) *customError.Error {
+	return customError.NewError(errors.New("failed reading data"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    GetData,
+		UserMsg:    "サーバーのレスポンスが不正です",
+		Level:      logrus.ErrorLevel,
+		Input:      name,
+	})
+}
+
+func errIdentityUserNotFound(identity *identityRepository.Model) *customError.Error {
+	return customError.NewError(errors.New("紐付け先のユーザーが存在しない"), customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    IdentityUserNotFound,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      map[string]interface{}{"provider": identity.Provider, "subject": identity.Subject, "userId": identity.UserID},
+	})
+}
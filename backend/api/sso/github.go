@@ -0,0 +1,83 @@
+package sso
+
+import (
+	"backend/middlewares/customError"
+	"context"
+	"golang.org/x/oauth2"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+type gitHubProvider struct {
+	config *oauth2.Config
+}
+
+func newGitHubProvider() Provider {
+	return &gitHubProvider{config: &oauth2.Config{
+		ClientID:     os.Getenv("GITHUB_OAUTH_CLIENT"),
+		ClientSecret: os.Getenv("GITHUB_OAUTH_SECRET"),
+		RedirectURL:  callbackURL(ProviderGitHub),
+		Scopes:       []string{"read:user", "user:email"},
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  "https://github.com/login/oauth/authorize",
+			TokenURL: "https://github.com/login/oauth/access_token",
+		},
+	}}
+}
+
+func (p *gitHubProvider) Name() string {
+	return ProviderGitHub
+}
+
+func (p *gitHubProvider) Config() *oauth2.Config {
+	return p.config
+}
+
+// FetchProfile GitHubはOpenID Connectに対応していないため、REST APIから取得する
+func (p *gitHubProvider) FetchProfile(ctx context.Context, client *http.Client) (*Profile, *customError.Error) {
+	var user struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := getJSON(ctx, client, "https://api.github.com/user", &user); err != nil {
+		return nil, err
+	}
+	if user.ID == 0 {
+		return nil, errGetData(ProviderGitHub)
+	}
+
+	var emails []gitHubEmail
+	if err := getJSON(ctx, client, "https://api.github.com/user/emails", &emails); err != nil {
+		return nil, err
+	}
+
+	name := user.Name
+	if name == "" {
+		name = user.Login
+	}
+	return &Profile{
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Name:     name,
+		Email:    primaryVerifiedEmail(emails),
+		ImageURL: user.AvatarURL,
+	}, nil
+}
+
+type gitHubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+// primaryVerifiedEmail プライマリかつ確認済みのメールアドレス(なければnil)
+func primaryVerifiedEmail(emails []gitHubEmail) *string {
+	for _, e := range emails {
+		if e.Primary && e.Verified {
+			return &e.Email
+		}
+	}
+	return nil
+}
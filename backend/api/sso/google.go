@@ -0,0 +1,63 @@
+package sso
+
+import (
+	"backend/middlewares/customError"
+	"context"
+	"golang.org/x/oauth2"
+	"net/http"
+	"os"
+)
+
+type googleProvider struct {
+	config *oauth2.Config
+}
+
+func newGoogleProvider() Provider {
+	return &googleProvider{config: &oauth2.Config{
+		ClientID:     os.Getenv("GOOGLE_OAUTH_CLIENT"),
+		ClientSecret: os.Getenv("GOOGLE_OAUTH_SECRET"),
+		RedirectURL:  callbackURL(ProviderGoogle),
+		Scopes:       []string{"openid", "email", "profile"},
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  "https://accounts.google.com/o/oauth2/v2/auth",
+			TokenURL: "https://oauth2.googleapis.com/token",
+		},
+	}}
+}
+
+func (p *googleProvider) Name() string {
+	return ProviderGoogle
+}
+
+func (p *googleProvider) Config() *oauth2.Config {
+	return p.config
+}
+
+// FetchProfile OpenID ConnectのUserInfoエンドポイントから取得する
+func (p *googleProvider) FetchProfile(ctx context.Context, client *http.Client) (*Profile, *customError.Error) {
+	var result oidcUserInfo
+	if err := getJSON(ctx, client, "https://openidconnect.googleapis.com/v1/userinfo", &result); err != nil {
+		return nil, err
+	}
+	return result.toProfile(ProviderGoogle)
+}
+
+// oidcUserInfo OpenID ConnectのUserInfoレスポンス(GoogleとLINEで共通)
+type oidcUserInfo struct {
+	Sub           string `json:"sub"`
+	Name          string `json:"name"`
+	Picture       string `json:"picture"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+func (u *oidcUserInfo) toProfile(provider string) (*Profile, *customError.Error) {
+	if u.Sub == "" {
+		return nil, errGetData(provider)
+	}
+	profile := &Profile{Subject: u.Sub, Name: u.Name, ImageURL: u.Picture}
+	if u.Email != "" && u.EmailVerified {
+		profile.Email = &u.Email
+	}
+	return profile, nil
+}
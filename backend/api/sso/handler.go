@@ -0,0 +1,150 @@
+package sso
+
+import (
+	"backend/db/repository/oauthStateRepository"
+	"backend/di/handlers"
+	"backend/middlewares/customError"
+	"backend/service/authService"
+	"context"
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/oauth2"
+	"net/http"
+	"time"
+)
+
+const (
+	stateExpire     = 10 * time.Minute //認可画面で操作できる時間
+	stateCookieName = "sso_state"
+)
+
+// GenerateAuthURL 認証用のURLを生成する。uIdを指定した場合は、ログインではなくそのユーザーへの連携になる
+// stateとcode_verifierはサーバー側に保存し、stateはクッキーでもブラウザに紐付ける(別のブラウザで始めたログインを完了させないため)
+func GenerateAuthURL(ctx context.Context, writer http.ResponseWriter, sr *oauthStateRepository.OAuthStateRepository, name string, uId *primitive.ObjectID) (*string, *customError.Error) {
+	p, err := GetProvider(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// memo:GenerateVerifierは32バイトの乱数なので、stateにもそのまま使う
+	now := time.Now()
+	state := &oauthStateRepository.Model{
+		ID:        oauth2.GenerateVerifier(),
+		Provider:  p.Name(),
+		Verifier:  oauth2.GenerateVerifier(),
+		UserID:    uId,
+		CreatedAt: now,
+		ExpiresAt: now.Add(stateExpire),
+	}
+	if err = sr.Insert(ctx, state); err != nil {
+		return nil, err
+	}
+	setStateCookie(writer, state.ID, state.ExpiresAt)
+
+	url := p.Config().AuthCodeURL(state.ID, oauth2.S256ChallengeOption(state.Verifier))
+	return &url, nil
+}
+
+// CallbackResult コールバックの結果(ログインか連携のどちらか)
+type CallbackResult struct {
+	Login    *authService.LoginResult
+	Linked   bool
+	Conflict *authService.IdentityConflict //別のユーザーに連携済みだった場合
+}
+
+// Callback コールバックで受け取った認可コードでユーザー情報を取得し、ログインまたは連携する
+func Callback(c *gin.Context, h *handlers.Handlers, name string) (*CallbackResult, *customError.Error) {
+	p, err := GetProvider(name)
+	if err != nil {
+		return nil, err
+	}
+	profile, state, err := getProfile(c, h, p)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := c.Request.Context()
+	if state.UserID != nil {
+		conflict, err := authService.LinkIdentity(ctx, &h.UserHandler.UserRepo, &h.UserHandler.IdentityRepo, *h.TokenConfig, *state.UserID, profile.toIdentity(p.Name()))
+		if err != nil {
+			return nil, err
+		}
+		return &CallbackResult{Linked: conflict == nil, Conflict: conflict}, nil
+	}
+
+	user, err := findOrCreateUser(ctx, h, p.Name(), profile)
+	if err != nil {
+		return nil, err
+	}
+	// 2段階認証が有効な場合はコードの確認が必要
+	client := authService.NewClient(c.Request, c.ClientIP())
+	client.Provider = p.Name()
+	login, err := authService.StartLogin(ctx, user, c.Writer, *h.TokenConfig, &h.UserHandler.SessionRepo, client)
+	if err != nil {
+		return nil, err
+	}
+	return &CallbackResult{Login: login}, nil
+}
+
+// getProfile stateを確認してから認可コードをトークンに交換し、ユーザー情報を取得する
+func getProfile(c *gin.Context, h *handlers.Handlers, p Provider) (*Profile, *oauthStateRepository.Model, *customError.Error) {
+	if reason := c.Query("error"); reason != "" {
+		return nil, nil, errAuthorizationDenied(p.Name(), reason)
+	}
+	code := c.Query("code")
+	if code == "" {
+		return nil, nil, errMissCode(p.Name())
+	}
+
+	// stateは成否に関わらず使い捨てにする
+	state := c.Query("state")
+	cookie, _ := c.Cookie(stateCookieName)
+	clearStateCookie(c.Writer)
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(cookie)) != 1 {
+		return nil, nil, errInvalidState(p.Name())
+	}
+	ctx := c.Request.Context()
+	saved, err := h.UserHandler.OAuthStateRepo.Consume(ctx, state, p.Name(), time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	if saved == nil {
+		return nil, nil, errInvalidState(p.Name())
+	}
+
+	token, rawErr := p.Config().Exchange(ctx, code, oauth2.VerifierOption(saved.Verifier))
+	if rawErr != nil {
+		return nil, nil, errExchangeToken(rawErr, p.Name())
+	}
+	profile, err := p.FetchProfile(ctx, p.Config().Client(ctx, token))
+	if err != nil {
+		return nil, nil, err
+	}
+	return profile, saved, nil
+}
+
+func setStateCookie(writer http.ResponseWriter, state string, expiresAt time.Time) {
+	http.SetCookie(writer, &http.Cookie{
+		Name:     stateCookieName,
+		Value:    state,
+		Expires:  expiresAt,
+		Path:     "/",
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode, // プロバイダーからのリダイレクト(トップレベルのGET)では送られる
+	})
+}
+
+func clearStateCookie(writer http.ResponseWriter) {
+	http.SetCookie(writer, &http.Cookie{
+		Name:     stateCookieName,
+		Value:    "",
+		Expires:  time.Unix(0, 0),
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
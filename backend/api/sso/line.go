@@ -0,0 +1,45 @@
+package sso
+
+import (
+	"backend/middlewares/customError"
+	"context"
+	"golang.org/x/oauth2"
+	"net/http"
+	"os"
+)
+
+type lineProvider struct {
+	config *oauth2.Config
+}
+
+func newLineProvider() Provider {
+	return &lineProvider{config: &oauth2.Config{
+		ClientID:     os.Getenv("LINE_CHANNEL_ID"),
+		ClientSecret: os.Getenv("LINE_CHANNEL_SECRET"),
+		RedirectURL:  callbackURL(ProviderLine),
+		Scopes:       []string{"openid", "profile"},
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   "https://access.line.me/oauth2/v2.1/authorize",
+			TokenURL:  "https://api.line.me/oauth2/v2.1/token",
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+	}}
+}
+
+func (p *lineProvider) Name() string {
+	return ProviderLine
+}
+
+func (p *lineProvider) Config() *oauth2.Config {
+	return p.config
+}
+
+// FetchProfile OpenID ConnectのUserInfoエンドポイントから取得する
+// memo:メールアドレスはLINE側での申請が必要なため取得しない
+func (p *lineProvider) FetchProfile(ctx context.Context, client *http.Client) (*Profile, *customError.Error) {
+	var result oidcUserInfo
+	if err := getJSON(ctx, client, "https://api.line.me/oauth2/v2.1/userinfo", &result); err != nil {
+		return nil, err
+	}
+	return result.toProfile(ProviderLine)
+}
@@ -0,0 +1,98 @@
+package sso
+
+import (
+	"backend/db/repository/identityRepository"
+	"backend/middlewares/customError"
+	"context"
+	"encoding/json"
+	"golang.org/x/oauth2"
+	"io"
+	"net/http"
+	"os"
+)
+
+// Profile プロバイダーから取得したユーザー情報
+type Profile struct {
+	Subject  string //プロバイダー内で一意なID
+	Name     string
+	Email    *string //プロバイダーで確認済みのもののみ
+	ImageURL string
+}
+
+// toIdentity ユーザーとの紐付け(UserIDと日時は呼び出し側で設定する)
+func (p *Profile) toIdentity(provider string) *identityRepository.Model {
+	return &identityRepository.Model{
+		Provider: provider,
+		Subject:  p.Subject,
+		Name:     p.Name,
+		Email:    p.Email,
+	}
+}
+
+// Provider OAuth2.0/OpenID Connectでログインできる外部サービス
+type Provider interface {
+	// Name URLやDBに保存する名前
+	Name() string
+	Config() *oauth2.Config
+	// FetchProfile アクセストークン付きのクライアントでユーザー情報を取得する
+	FetchProfile(ctx context.Context, client *http.Client) (*Profile, *customError.Error)
+}
+
+const (
+	ProviderX      = identityRepository.ProviderX
+	ProviderGoogle = identityRepository.ProviderGoogle
+	ProviderLine   = identityRepository.ProviderLine
+	ProviderGitHub = identityRepository.ProviderGitHub
+)
+
+// memo:環境変数は呼び出すたびに読む(テストで差し替えられるように)
+var providers = map[string]func() Provider{
+	ProviderX:      newXProvider,
+	ProviderGoogle: newGoogleProvider,
+	ProviderLine:   newLineProvider,
+	ProviderGitHub: newGitHubProvider,
+}
+
+// GetProvider 名前からプロバイダーを取得する。未対応やクライアントIDが未設定の場合はエラー
+func GetProvider(name string) (Provider, *customError.Error) {
+	newProvider, ok := providers[name]
+	if !ok {
+		return nil, errUnknownProvider(name)
+	}
+	p := newProvider()
+	if p.Config().ClientID == "" {
+		return nil, errProviderNotConfigured(name)
+	}
+	return p, nil
+}
+
+// callbackURL プロバイダーの管理画面に登録するコールバックURL
+func callbackURL(name string) string {
+	return os.Getenv("BACK_URI") + "/sso/" + name + "/callback"
+}
+
+// getJSON ユーザー情報のAPIを呼び出し、レスポンスをoutに読み込む
+func getJSON(ctx context.Context, client *http.Client, url string, out interface{}) *customError.Error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errGetUserInfo(err, url)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return errGetUserInfo(err, url)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errReadResponseBody(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errGetUserInfoBadStatus(url, resp.StatusCode, body)
+	}
+	if err = json.Unmarshal(body, out); err != nil {
+		return errUnMarshalResponseBody(err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package sso
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetProvider_正常系_クライアントIDが設定されたプロバイダーを取得できること(t *testing.T) {
+	t.Setenv("BACK_URI", "https://example.com/api")
+	t.Setenv("GOOGLE_OAUTH_CLIENT", "client")
+
+	p, err := GetProvider(ProviderGoogle)
+	assert.Nil(t, err)
+	assert.Equal(t, ProviderGoogle, p.Name())
+	assert.Equal(t, "https://example.com/api/sso/google/callback", p.Config().RedirectURL)
+}
+
+func TestGetProvider_異常系_未対応や未設定のプロバイダーはエラーになること(t *testing.T) {
+	_, err := GetProvider("facebook")
+	assert.Equal(t, UnknownProvider, err.ErrorCode)
+
+	t.Setenv("GITHUB_OAUTH_CLIENT", "")
+	_, err = GetProvider(ProviderGitHub)
+	assert.Equal(t, ProviderNotConfigured, err.ErrorCode)
+}
+
+func TestOidcUserInfoToProfile_正常系_確認済みのメールアドレスのみ使うこと(t *testing.T) {
+	verified := &oidcUserInfo{Sub: "123", Name: "name", Email: "user@example.com", EmailVerified: true}
+	profile, err := verified.toProfile(ProviderGoogle)
+	assert.Nil(t, err)
+	assert.Equal(t, "123", profile.Subject)
+	assert.Equal(t, "user@example.com", *profile.Email)
+
+	unverified := &oidcUserInfo{Sub: "123", Email: "user@example.com"}
+	profile, err = unverified.toProfile(ProviderGoogle)
+	assert.Nil(t, err)
+	assert.Nil(t, profile.Email)
+
+	_, err = (&oidcUserInfo{}).toProfile(ProviderLine)
+	assert.Equal(t, GetData, err.ErrorCode)
+}
+
+func TestPrimaryVerifiedEmail_正常系_プライマリかつ確認済みのものを返すこと(t *testing.T) {
+	email := primaryVerifiedEmail([]gitHubEmail{
+		{Email: "unverified@example.com", Primary: true},
+		{Email: "secondary@example.com", Verified: true},
+	})
+	assert.Nil(t, email)
+
+	email = primaryVerifiedEmail([]gitHubEmail{
+		{Email: "secondary@example.com", Verified: true},
+		{Email: "primary@example.com", Primary: true, Verified: true},
+	})
+	assert.Equal(t, "primary@example.com", *email)
+}
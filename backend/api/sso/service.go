@@ -0,0 +1,94 @@
+package sso
+
+import (
+	"backend/db/repository/userRepository"
+	"backend/di/handlers"
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/logger"
+	"backend/service/authService"
+	"backend/util/helper"
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// findOrCreateUser プロバイダーのアカウントに紐付いたユーザーを取得する。紐付けがなければユーザーを作成して紐付ける
+// memo:メールアドレスが一致する既存ユーザーへの自動での紐付けはしない(プロバイダー側のアカウントを乗っ取られた場合の被害を広げないため)
+func findOrCreateUser(ctx context.Context, h *handlers.Handlers, provider string, profile *Profile) (*userRepository.Model, *customError.Error) {
+	ir := &h.UserHandler.IdentityRepo
+	ur := &h.UserHandler.UserRepo
+	now := time.Now()
+
+	identity, err := ir.GetByProviderSubject(ctx, provider, profile.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if identity != nil {
+		user, err := ur.GetById(ctx, identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, errIdentityUserNotFound(identity)
+		}
+		// 表示名などの更新に失敗してもログインは続ける
+		if err = ir.Touch(ctx, identity.ID, profile.Name, profile.Email, now); err != nil {
+			logger.LogError(ctx, err)
+		}
+		return user, nil
+	}
+
+	// usersのtwitter_idで連携していたユーザーは、紐付けを移行する
+	if provider == ProviderX {
+		legacy, err := ur.GetByTwitterId(ctx, profile.Subject)
+		if err != nil {
+			return nil, err
+		}
+		if legacy != nil {
+			if identity, err = authService.MigrateTwitterId(ctx, ur, ir, legacy, now); err != nil {
+				return nil, err
+			}
+			if err = ir.Touch(ctx, identity.ID, profile.Name, profile.Email, now); err != nil {
+				logger.LogError(ctx, err)
+			}
+			return legacy, nil
+		}
+	}
+
+	user, err := createNewUser(ctx, ur, profile)
+	if err != nil {
+		return nil, err
+	}
+	identity = profile.toIdentity(provider)
+	identity.UserID = user.ID
+	identity.CreatedAt = now
+	identity.LastUsedAt = now
+	if err = ir.Insert(ctx, identity); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// createNewUser プロバイダーの情報からユーザーを作成する
+// memo:メールアドレスは既存ユーザーと重複しうるので設定しない(ユーザー自身が後から登録・確認する)
+func createNewUser(ctx context.Context, ur *userRepository.UsersRepository, profile *Profile) (*userRepository.Model, *customError.Error) {
+	var image *string
+	if profile.ImageURL != "" {
+		img, err := helper.FetchImageFromURL(profile.ImageURL)
+		if err != nil {
+			return nil, err
+		}
+		if image, err = helper.ImageToBase64(img, helper.GenerateBase64Option(100, 100)); err != nil {
+			return nil, err
+		}
+	}
+
+	user := &userRepository.Model{
+		ID:          primitive.NewObjectID(),
+		Name:        profile.Name,
+		Email:       nil,
+		Password:    []byte(helper.RandomStr(8)), // 暫定で入れておく(が、ハッシュ化してないので無意味な値)
+		ImageBase64: image,
+	}
+	return ur.Register(ctx, user)
+}
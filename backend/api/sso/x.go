@@ -0,0 +1,56 @@
+package sso
+
+import (
+	"backend/middlewares/customError"
+	"context"
+	"golang.org/x/oauth2"
+	"net/http"
+	"os"
+)
+
+type xProvider struct {
+	config *oauth2.Config
+}
+
+func newXProvider() Provider {
+	return &xProvider{config: &oauth2.Config{
+		ClientID:     os.Getenv("TWITTER_OAUTH_CLIENT"),
+		ClientSecret: os.Getenv("TWITTER_OAUTH_SECRET"),
+		RedirectURL:  callbackURL(ProviderX),
+		Scopes:       []string{"users.read", "tweet.read"}, // tweet.readは必須らしい(Forbiddenになってしまう)
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  "https://twitter.com/i/oauth2/authorize",
+			TokenURL: "https://api.twitter.com/2/oauth2/token",
+		},
+	}}
+}
+
+func (p *xProvider) Name() string {
+	return ProviderX
+}
+
+func (p *xProvider) Config() *oauth2.Config {
+	return p.config
+}
+
+func (p *xProvider) FetchProfile(ctx context.Context, client *http.Client) (*Profile, *customError.Error) {
+	var result struct {
+		Data struct {
+			ID              string `json:"id"`
+			Name            string `json:"name"`
+			ProfileImageURL string `json:"profile_image_url"`
+		} `json:"data"`
+	}
+	if err := getJSON(ctx, client, "https://api.twitter.com/2/users/me?user.fields=profile_image_url", &result); err != nil {
+		return nil, err
+	}
+	if result.Data.ID == "" {
+		return nil, errGetData(ProviderX)
+	}
+	// memo:Xはメールアドレスを返さない
+	return &Profile{
+		Subject:  result.Data.ID,
+		Name:     result.Data.Name,
+		ImageURL: result.Data.ProfileImageURL,
+	}, nil
+}
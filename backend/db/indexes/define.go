@@ -1,13 +1,27 @@
 package indexes
 
 import (
+	"backend/db/repository/activityRepository"
+	"backend/db/repository/attemptRepository"
 	"backend/db/repository/bookmarkRepository"
 	"backend/db/repository/categoriesRepository"
+	"backend/db/repository/drinkRepository"
+	"backend/db/repository/flavorMapRepository"
+	"backend/db/repository/identityRepository"
 	"backend/db/repository/liquorRepository"
+	"backend/db/repository/listRepository"
+	"backend/db/repository/mailQueueRepository"
+	"backend/db/repository/notificationRepository"
+	"backend/db/repository/oauthStateRepository"
+	"backend/db/repository/sessionRepository"
+	"backend/db/repository/similarityRepository"
 	"backend/db/repository/userRepository"
 	"go.mongodb.org/mongo-driver/bson"
 )
 
+// expireImmediately TTLインデックスで、フィールドの日時を過ぎたら削除する
+var expireImmediately int32 = 0
+
 // IndexDefinitions インデックス定義のスライス
 var IndexDefinitions = []IndexDefinition{
 	//memo:_idには明示的にインデックスをつける必要はない(最初からユニーク制約インデックスがついてる)
@@ -57,6 +71,11 @@ var IndexDefinitions = []IndexDefinition{
 		//	{liquorRepository.UserID, bson.D{{"$ne", nil}}}, // UserIDがnullでない場合にのみ適用
 		//},
 	},
+	{
+		CollectionName: liquorRepository.BoardCollectionName,
+		IndexKeys:      bson.D{{liquorRepository.LiquorID, 1}, {liquorRepository.GuestID, 1}}, //ゲストの再投稿の判定用
+		IsNonUnique:    true,
+	},
 	{
 		CollectionName: liquorRepository.TagCollectionName,
 		IndexKeys:      bson.D{{liquorRepository.LiquorID, 1}},
@@ -69,10 +88,198 @@ var IndexDefinitions = []IndexDefinition{
 		IndexKeys:      bson.D{{liquorRepository.UserID, 1}},
 		IsNonUnique:    true,
 	},
+	{
+		CollectionName: liquorRepository.BoardCollectionName,
+		IndexKeys:      bson.D{{liquorRepository.UpdatedAt, -1}}, //週間ダイジェストの集計用
+		IsNonUnique:    true,
+	},
+
+	//フレーバーマップ
+	{
+		CollectionName: flavorMapRepository.FlavorMapCollectionName,
+		IndexKeys:      bson.D{{flavorMapRepository.LiquorID, 1}, {flavorMapRepository.CategoryID, 1}, {flavorMapRepository.UserID, 1}}, //1ユーザーにつき1票
+		//ゲストはuser_idがnullで複数存在するので、$typeで除外する($neや$existsではnullを除外できない)
+		PartialFilter: bson.D{{flavorMapRepository.UserID, bson.D{{"$type", "objectId"}}}},
+		Name:          "liquor_category_user_unique", //以前の非ユニークのインデックスと名前が重ならないようにする
+	},
+	{
+		CollectionName: flavorMapRepository.FlavorMapCollectionName,
+		IndexKeys:      bson.D{{flavorMapRepository.LiquorID, 1}, {flavorMapRepository.CategoryID, 1}, {flavorMapRepository.GuestID, 1}}, //1ゲストにつき1票
+		//投票者の判定(voterFilter)と揃え、user_idがnullでguest_idがあるものだけを対象にする
+		PartialFilter: bson.D{{flavorMapRepository.UserID, bson.D{{"$type", "null"}}}, {flavorMapRepository.GuestID, bson.D{{"$type", "string"}}}},
+		Name:          "liquor_category_guest_unique",
+	},
+	{
+		CollectionName: flavorMapRepository.FlavorMapArchivesCollectionName,
+		IndexKeys:      bson.D{{flavorMapRepository.CategoryID, 1}},
+		IsNonUnique:    true,
+	},
+	{
+		CollectionName: flavorMapRepository.FlavorMapMasterCollectionName,
+		IndexKeys:      bson.D{{flavorMapRepository.CategoryID, 1}}, //1カテゴリにつき1つ
+	},
+	{
+		CollectionName: flavorMapRepository.FlavorMapMasterLogsCollectionName,
+		IndexKeys:      bson.D{{flavorMapRepository.CategoryID, 1}, {flavorMapRepository.VersionNo, 1}},
+	},
+	{
+		CollectionName: flavorMapRepository.FlavorMapToLiquorsCollectionNAme,
+		IndexKeys:      bson.D{{flavorMapRepository.LiquorID, 1}, {flavorMapRepository.CategoryID, 1}}, //差分集計の対象が重複しないようにする
+	},
+	{
+		CollectionName: flavorMapRepository.FlavorMapNeighboursCollectionName,
+		IndexKeys:      bson.D{{flavorMapRepository.LiquorID, 1}, {flavorMapRepository.CategoryID, 1}},
+	},
+	{
+		CollectionName: flavorMapRepository.FlavorMapNeighboursCollectionName,
+		IndexKeys:      bson.D{{flavorMapRepository.CategoryID, 1}},
+		IsNonUnique:    true,
+	},
+
+	//飲みたいリスト・飲んだ記録
+	{
+		CollectionName: drinkRepository.WishCollectionName,
+		IndexKeys:      bson.D{{drinkRepository.UserID, 1}, {drinkRepository.LiquorID, 1}},
+	},
+	{
+		CollectionName: drinkRepository.CheckInCollectionName,
+		IndexKeys:      bson.D{{drinkRepository.UserID, 1}, {drinkRepository.DrankAt, -1}},
+		IsNonUnique:    true,
+	},
+	{
+		CollectionName: drinkRepository.CheckInCollectionName,
+		IndexKeys:      bson.D{{drinkRepository.LiquorID, 1}},
+		IsNonUnique:    true,
+	},
+
+	//ユーザーが作成したリスト
+	{
+		CollectionName: listRepository.CollectionName,
+		IndexKeys:      bson.D{{listRepository.UserID, 1}, {listRepository.UpdatedAt, -1}},
+		IsNonUnique:    true,
+	},
+	{
+		CollectionName: listRepository.CollectionName,
+		IndexKeys:      bson.D{{listRepository.IsPublic, 1}, {listRepository.FollowerCount, -1}},
+		IsNonUnique:    true,
+	},
+	{
+		CollectionName: listRepository.CollectionName,
+		IndexKeys:      bson.D{{listRepository.EntryLiquorID, 1}},
+		IsNonUnique:    true,
+	},
+	{
+		CollectionName: listRepository.BookmarkCollectionName,
+		IndexKeys:      bson.D{{listRepository.UserID, 1}, {listRepository.ListID, 1}},
+	},
+	{
+		CollectionName: listRepository.BookmarkCollectionName,
+		IndexKeys:      bson.D{{listRepository.ListID, 1}},
+		IsNonUnique:    true,
+	},
+
+	//おすすめ
+	{
+		CollectionName: similarityRepository.CollectionName,
+		IndexKeys:      bson.D{{similarityRepository.LiquorID, 1}},
+	},
+	{
+		CollectionName: similarityRepository.CollectionName,
+		IndexKeys:      bson.D{{similarityRepository.UpdatedAt, 1}},
+		IsNonUnique:    true,
+	},
+
+	//タイムライン
+	{
+		CollectionName: activityRepository.CollectionName,
+		IndexKeys:      bson.D{{activityRepository.UserID, 1}, {activityRepository.ID, -1}},
+		IsNonUnique:    true,
+	},
+	{
+		CollectionName: activityRepository.CollectionName,
+		IndexKeys:      bson.D{{activityRepository.UserID, 1}, {activityRepository.Type, 1}, {activityRepository.LiquorID, 1}},
+		IsNonUnique:    true,
+	},
+
+	//通知
+	{
+		CollectionName: notificationRepository.CollectionName,
+		IndexKeys:      bson.D{{notificationRepository.UserID, 1}, {notificationRepository.ID, -1}},
+		IsNonUnique:    true,
+	},
+	{
+		CollectionName: notificationRepository.CollectionName,
+		IndexKeys:      bson.D{{notificationRepository.UserID, 1}, {notificationRepository.Read, 1}},
+		IsNonUnique:    true,
+	},
+	{
+		CollectionName: notificationRepository.PreferenceCollectionName,
+		IndexKeys:      bson.D{{notificationRepository.UserID, 1}},
+	},
 
 	//ユーザー系
 	{
 		CollectionName: userRepository.CollectionName,
 		IndexKeys:      bson.D{{userRepository.Email, 1}},
 	},
+	{
+		CollectionName: userRepository.CollectionName,
+		IndexKeys:      bson.D{{userRepository.DigestSentAt, 1}}, //週間ダイジェストの送信対象の抽出用
+		IsNonUnique:    true,
+	},
+	{
+		CollectionName: userRepository.CollectionName,
+		IndexKeys:      bson.D{{userRepository.PasswordResetToken, 1}}, //パスワードリセットのトークン(ハッシュ)からの検索用
+		IsNonUnique:    true,
+	},
+
+	//メールの再送キュー
+	{
+		CollectionName: mailQueueRepository.CollectionName,
+		IndexKeys:      bson.D{{mailQueueRepository.Status, 1}, {mailQueueRepository.NextAttemptAt, 1}},
+		IsNonUnique:    true,
+	},
+
+	//ログインセッション
+	{
+		CollectionName: sessionRepository.CollectionName,
+		IndexKeys:      bson.D{{sessionRepository.UserID, 1}, {sessionRepository.LastUsedAt, -1}},
+		IsNonUnique:    true,
+	},
+	{
+		CollectionName: sessionRepository.CollectionName,
+		IndexKeys:      bson.D{{sessionRepository.ExpiresAt, 1}}, //期限切れのセッションを自動削除
+		IsNonUnique:    true,
+		ExpireAfter:    &expireImmediately,
+	},
+	{
+		CollectionName: attemptRepository.CollectionName,
+		IndexKeys:      bson.D{{attemptRepository.ExpiresAt, 1}}, //一定期間失敗がなければ自動削除
+		IsNonUnique:    true,
+		ExpireAfter:    &expireImmediately,
+	},
+	{
+		CollectionName: attemptRepository.CollectionName,
+		IndexKeys:      bson.D{{attemptRepository.Kind, 1}, {attemptRepository.Value, 1}}, //管理者によるロック解除
+		IsNonUnique:    true,
+	},
+	{
+		CollectionName: attemptRepository.CollectionName,
+		IndexKeys:      bson.D{{attemptRepository.LockedUntil, 1}}, //ロック中の一覧
+		IsNonUnique:    true,
+	},
+	{
+		CollectionName: oauthStateRepository.CollectionName,
+		IndexKeys:      bson.D{{oauthStateRepository.ExpiresAt, 1}}, //使われなかったstateを自動削除
+		IsNonUnique:    true,
+		ExpireAfter:    &expireImmediately,
+	},
+	{
+		CollectionName: identityRepository.CollectionName,
+		IndexKeys:      bson.D{{identityRepository.Provider, 1}, {identityRepository.Subject, 1}}, //同じアカウントを複数のユーザーに紐付けない
+	},
+	{
+		CollectionName: identityRepository.CollectionName,
+		IndexKeys:      bson.D{{identityRepository.UserID, 1}, {identityRepository.Provider, 1}}, //1つのサービスにつき1アカウントまで
+	},
 }
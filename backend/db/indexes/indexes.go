@@ -16,6 +16,8 @@ type IndexDefinition struct {
 	IndexKeys      bson.D
 	IsNonUnique    bool   //未指定がfalseなので、NonUniqueとしている(基本はユニーク制約をつける想定)
 	PartialFilter  bson.D // Optional: nullの場合ユニーク制約を外すためのフィルター
+	ExpireAfter    *int32 // Optional: 指定するとTTLインデックスになる(日時のフィールドから指定秒数後に自動削除)
+	Name           string // Optional: 既存のインデックスと同じキーでオプションを変えて作り直す場合に指定する(未指定はキーから自動で付く)
 }
 
 func AddIndexes() error {
@@ -51,6 +53,15 @@ func createIndexForCollection(ctx context.Context, indexData IndexDefinition) er
 		indexOptions.SetPartialFilterExpression(indexData.PartialFilter)
 	}
 
+	// ExpireAfterが設定されている場合、TTLインデックスにする
+	if indexData.ExpireAfter != nil {
+		indexOptions.SetExpireAfterSeconds(*indexData.ExpireAfter)
+	}
+
+	if indexData.Name != "" {
+		indexOptions.SetName(indexData.Name)
+	}
+
 	indexModel := mongo.IndexModel{
 		Keys:    indexData.IndexKeys,
 		Options: indexOptions,
@@ -0,0 +1,34 @@
+package activityRepository
+
+import (
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/errorMsg"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+)
+
+const (
+	Insert      = "REPO-ACTIVITY-001-Insert"
+	ListByUsers = "REPO-ACTIVITY-002-ListByUsers"
+)
+
+func errInsert(err error, m *Model) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    Insert,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      m,
+	})
+}
+
+func errListByUsers(err error, uIds []primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    ListByUsers,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      uIds,
+	})
+}
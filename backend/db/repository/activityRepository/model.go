@@ -0,0 +1,40 @@
+package activityRepository
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	CollectionName = "activities"
+	ID             = "_id"
+	UserID         = "user_id"
+	Type           = "type"
+	LiquorID       = "liquor_id"
+	CreatedAt      = "created_at"
+)
+
+// アクティビティの種類
+const (
+	TypeBoardPost    = "BOARD_POST"
+	TypeLiquorCreate = "LIQUOR_CREATE"
+	TypeLiquorUpdate = "LIQUOR_UPDATE"
+	TypeTag          = "TAG"
+	TypeFlavorVote   = "FLAVOR_VOTE"
+)
+
+// Model ユーザーの行動履歴。タイムラインは読み出し時にフォロー中のユーザー分をまとめて取得する
+type Model struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"` //生成順に並ぶので、ページングのカーソルに使う
+	UserID    primitive.ObjectID `bson:"user_id"`
+	Type      string             `bson:"type"`
+	LiquorID  primitive.ObjectID `bson:"liquor_id"`
+	Text      *string            `bson:"text,omitempty"` //掲示板の本文・タグ
+	Rate      *int               `bson:"rate,omitempty"` //掲示板の評価
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+// replaceable 同じお酒への再投稿・再投票は上書きされるので、アクティビティも最新の1件だけ残す
+func (m *Model) replaceable() bool {
+	return m.Type == TypeBoardPost || m.Type == TypeFlavorVote
+}
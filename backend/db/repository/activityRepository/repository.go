@@ -0,0 +1,61 @@
+package activityRepository
+
+import (
+	"backend/db"
+	"backend/middlewares/customError"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ActivityRepository struct {
+	db         *db.DB
+	collection *mongo.Collection
+}
+
+func NewActivityRepository(db *db.DB) ActivityRepository {
+	return ActivityRepository{
+		db:         db,
+		collection: db.Collection(CollectionName),
+	}
+}
+
+// Insert アクティビティを記録する。上書き型の行動は、同じお酒に対する以前のアクティビティを消してから記録する
+func (r *ActivityRepository) Insert(ctx context.Context, m *Model) *customError.Error {
+	m.ID = primitive.NewObjectID()
+	if m.replaceable() {
+		_, err := r.collection.DeleteMany(ctx, bson.M{UserID: m.UserID, Type: m.Type, LiquorID: m.LiquorID})
+		if err != nil {
+			return errInsert(err, m)
+		}
+	}
+	if _, err := r.collection.InsertOne(ctx, m); err != nil {
+		return errInsert(err, m)
+	}
+	return nil
+}
+
+// ListByUsers 指定したユーザーたちのアクティビティを新しい順に取得する。afterを指定した場合はそれより古いものを取得する
+func (r *ActivityRepository) ListByUsers(ctx context.Context, uIds []primitive.ObjectID, after *primitive.ObjectID, limit int64) ([]*Model, *customError.Error) {
+	if len(uIds) == 0 {
+		return nil, nil
+	}
+	filter := bson.M{UserID: bson.M{"$in": uIds}}
+	if after != nil {
+		filter[ID] = bson.M{"$lt": *after}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: ID, Value: -1}}).SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errListByUsers(err, uIds)
+	}
+	defer cursor.Close(ctx)
+
+	var models []*Model
+	if err = cursor.All(ctx, &models); err != nil {
+		return nil, errListByUsers(err, uIds)
+	}
+	return models, nil
+}
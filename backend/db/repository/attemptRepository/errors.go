@@ -0,0 +1,76 @@
+package attemptRepository
+
+import (
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/errorMsg"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+const (
+	Increment     = "REPO-ATTEMPT-002-Increment"
+	Lock          = "REPO-ATTEMPT-003-Lock"
+	Delete        = "REPO-ATTEMPT-004-Delete"
+	DeleteByValue = "REPO-ATTEMPT-005-DeleteByValue"
+	ListLocked    = "REPO-ATTEMPT-006-ListLocked"
+	Release       = "REPO-ATTEMPT-007-Release"
+)
+
+func errIncrement(err error, id string) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    Increment,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
+
+func errLock(err error, id string) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    Lock,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
+
+func errDelete(err error, id string) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    Delete,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
+
+func errDeleteByValue(err error, kind string, value string) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    DeleteByValue,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      map[string]interface{}{"kind": kind, "value": value},
+	})
+}
+
+func errListLocked(err error) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    ListLocked,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+	})
+}
+
+func errRelease(err error, id string) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    Release,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
@@ -0,0 +1,66 @@
+package attemptRepository
+
+import (
+	"backend/graph/graphModel"
+	"time"
+)
+
+const (
+	CollectionName = "auth_attempts"
+	ID             = "_id"
+	Action         = "action"
+	Kind           = "kind"
+	Value          = "value"
+	Failures       = "failures"
+	LastFailedAt   = "last_failed_at"
+	LockedUntil    = "locked_until"
+	Emails         = "emails"
+	ExpiresAt      = "expires_at"
+)
+
+// 制限の対象になる操作
+const (
+	ActionLogin    = "LOGIN"
+	ActionReset    = "RESET_EMAIL"
+	ActionRegister = "REGISTER"
+)
+
+// 何ごとに数えるか
+const (
+	KindEmail = "EMAIL"
+	KindIP    = "IP"
+)
+
+// Model 操作・対象ごとの失敗回数(登録・パスワードリセットは試行回数)。一定期間なければTTLで削除される
+type Model struct {
+	ID           string     `bson:"_id"` //action:kind:value
+	Action       string     `bson:"action"`
+	Kind         string     `bson:"kind"`
+	Value        string     `bson:"value"` //メールアドレス(小文字)かIPアドレス
+	Failures     int        `bson:"failures"`
+	LastFailedAt time.Time  `bson:"last_failed_at"`
+	LockedUntil  *time.Time `bson:"locked_until,omitempty"`
+	Emails       []string   `bson:"emails,omitempty"` //IPアドレスのみ。失敗したメールアドレス(直近のもの・リスト型攻撃の検知用)
+	ExpiresAt    time.Time  `bson:"expires_at"`
+}
+
+// Key ドキュメントのID
+func Key(action string, kind string, value string) string {
+	return action + ":" + kind + ":" + value
+}
+
+// IsLocked ロック中かどうか
+func (m *Model) IsLocked(now time.Time) bool {
+	return m.LockedUntil != nil && m.LockedUntil.After(now)
+}
+
+func (m *Model) ToGraphQL() *graphModel.LoginLock {
+	return &graphModel.LoginLock{
+		Action:       m.Action,
+		Kind:         m.Kind,
+		Value:        m.Value,
+		Failures:     m.Failures,
+		LastFailedAt: m.LastFailedAt,
+		LockedUntil:  *m.LockedUntil,
+	}
+}
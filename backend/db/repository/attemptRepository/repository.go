@@ -0,0 +1,122 @@
+package attemptRepository
+
+import (
+	"backend/db"
+	"backend/middlewares/customError"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type AttemptRepository struct {
+	db         *db.DB
+	collection *mongo.Collection
+}
+
+func NewAttemptRepository(db *db.DB) AttemptRepository {
+	return AttemptRepository{
+		db:         db,
+		collection: db.Collection(CollectionName),
+	}
+}
+
+// Increment 回数を加算して更新後の値を返す。前回からwindow以上経っていれば数え直す
+// ロック中の場合は加算せずにそのまま返す(ロック中の試行でロックが伸びないように)
+// emailを指定した場合は、メールアドレスを直近maxEmails件まで記録する
+func (r *AttemptRepository) Increment(ctx context.Context, action string, kind string, value string, email *string, now time.Time, window time.Duration, maxEmails int) (*Model, *customError.Error) {
+	//前回が期間内かどうか(初回はlast_failed_atがないのでfalse)
+	fresh := bson.M{"$gte": bson.A{"$" + LastFailedAt, now.Add(-window)}}
+	//ロック中かどうか(locked_untilがない場合はfalse)
+	locked := bson.M{"$gt": bson.A{"$" + LockedUntil, now}}
+	unlessLocked := func(field string, value interface{}) bson.M {
+		return bson.M{"$cond": bson.A{locked, "$" + field, value}}
+	}
+	set := bson.M{
+		Action:       action,
+		Kind:         kind,
+		Value:        value,
+		Failures:     unlessLocked(Failures, bson.M{"$cond": bson.A{fresh, bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$" + Failures, 0}}, 1}}, 1}}),
+		LockedUntil:  bson.M{"$cond": bson.A{fresh, "$" + LockedUntil, "$$REMOVE"}},
+		LastFailedAt: unlessLocked(LastFailedAt, now),
+		ExpiresAt:    unlessLocked(ExpiresAt, now.Add(window)),
+	}
+	if email != nil {
+		previous := bson.M{"$cond": bson.A{fresh, bson.M{"$ifNull": bson.A{"$" + Emails, bson.A{}}}, bson.A{}}}
+		set[Emails] = unlessLocked(Emails, bson.M{"$slice": bson.A{bson.M{"$setUnion": bson.A{previous, bson.A{*email}}}, -maxEmails}})
+	}
+
+	id := Key(action, kind, value)
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var model Model
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{ID: id}, mongo.Pipeline{{{Key: "$set", Value: set}}}, opts).Decode(&model); err != nil {
+		return nil, errIncrement(err, id)
+	}
+	return &model, nil
+}
+
+// Lock ロック中でなければ、指定した日時までロックする。既に他の試行がロックしていた場合はfalse
+func (r *AttemptRepository) Lock(ctx context.Context, id string, until time.Time, now time.Time) (bool, *customError.Error) {
+	filter := bson.M{ID: id, LockedUntil: bson.M{"$not": bson.M{"$gt": now}}}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{LockedUntil: until}})
+	if err != nil {
+		return false, errLock(err, id)
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// Release Incrementで加算した1回分を取り消す(ログインに成功した場合など)
+// 取り消した後の回数が許容する回数(free)を下回る場合は、今回の試行で掛けたロックも外す
+// emailを指定した場合は、記録したメールアドレスからも取り除く
+func (r *AttemptRepository) Release(ctx context.Context, id string, email *string, free int) *customError.Error {
+	released := bson.M{"$subtract": bson.A{"$" + Failures, 1}}
+	set := bson.M{
+		Failures:    released,
+		LockedUntil: bson.M{"$cond": bson.A{bson.M{"$lt": bson.A{released, free}}, "$$REMOVE", "$" + LockedUntil}},
+	}
+	if email != nil {
+		set[Emails] = bson.M{"$filter": bson.M{
+			"input": bson.M{"$ifNull": bson.A{"$" + Emails, bson.A{}}},
+			"cond":  bson.M{"$ne": bson.A{"$$this", *email}},
+		}}
+	}
+	update := mongo.Pipeline{{{Key: "$set", Value: set}}}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{ID: id, Failures: bson.M{"$gt": 0}}, update); err != nil {
+		return errRelease(err, id)
+	}
+	return nil
+}
+
+// Delete 失敗回数をリセットする(ログインに成功した場合など)
+func (r *AttemptRepository) Delete(ctx context.Context, id string) *customError.Error {
+	if _, err := r.collection.DeleteOne(ctx, bson.M{ID: id}); err != nil {
+		return errDelete(err, id)
+	}
+	return nil
+}
+
+// DeleteByValue メールアドレスかIPアドレスの、すべての操作の失敗回数をリセットする(管理者によるロック解除)
+func (r *AttemptRepository) DeleteByValue(ctx context.Context, kind string, value string) (int64, *customError.Error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{Kind: kind, Value: value})
+	if err != nil {
+		return 0, errDeleteByValue(err, kind, value)
+	}
+	return result.DeletedCount, nil
+}
+
+// ListLocked ロック中のものを、ロックが長く続く順に取得する
+func (r *AttemptRepository) ListLocked(ctx context.Context, now time.Time) ([]*Model, *customError.Error) {
+	opts := options.Find().SetSort(bson.D{{Key: LockedUntil, Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{LockedUntil: bson.M{"$gt": now}}, opts)
+	if err != nil {
+		return nil, errListLocked(err)
+	}
+	defer cursor.Close(ctx)
+
+	var models []*Model
+	if err = cursor.All(ctx, &models); err != nil {
+		return nil, errListLocked(err)
+	}
+	return models, nil
+}
@@ -0,0 +1,47 @@
+package categoriesRepository
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// generation カテゴリコレクションの書き込み世代。書き込みのたびにインクリメントし、キャッシュ側はこの値の変化で古くなったことを検知する
+var generation atomic.Uint64
+
+// ChangePublisher 他インスタンスへカテゴリ更新を通知するフック(categoryService.SyncChangesがPubSubに配信する関数を登録する)
+type ChangePublisher func(ctx context.Context)
+
+var (
+	publisherMu sync.RWMutex
+	publisher   ChangePublisher
+)
+
+// Generation 現在の書き込み世代を返す
+func Generation() uint64 {
+	return generation.Load()
+}
+
+// SetChangePublisher 複数レプリカ構成時に、ローカルでの書き込みを他インスタンスへ伝える関数を登録する
+func SetChangePublisher(p ChangePublisher) {
+	publisherMu.Lock()
+	defer publisherMu.Unlock()
+	publisher = p
+}
+
+// Invalidate 他インスタンスからの通知を受け取った際に呼び出す。世代だけを進め、再度の通知は行わない(通知がループしないように)
+func Invalidate() {
+	generation.Add(1)
+}
+
+// markChanged ローカルで書き込みが発生した際に呼び出す
+func markChanged(ctx context.Context) {
+	generation.Add(1)
+
+	publisherMu.RLock()
+	p := publisher
+	publisherMu.RUnlock()
+	if p != nil {
+		p(ctx)
+	}
+}
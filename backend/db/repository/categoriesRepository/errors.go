@@ -19,7 +19,8 @@ const (
 	UpdateUnMarshal = "REPO-CATEGORY-009-UpdateUnMarshal"
 	UpdateOne       = "REPO-CATEGORY-010-UpdateOne"
 	UpdateOneGetId  = "REPO-CATEGORY-011-UpdateOneGetId"
-	GetMaxID  = "REPO-CATEGORY-012-GetMaxID"
+	GetMaxID        = "REPO-CATEGORY-012-GetMaxID"
+	UpdateOrders    = "REPO-CATEGORY-013-UpdateOrders"
 )
 
 func errFind(err error) *customError.Error {
@@ -132,3 +133,13 @@ func errGetMaxID(err error) *customError.Error {
 		Level:      logrus.ErrorLevel,
 	})
 }
+
+func errUpdateOrders(err error, ids []int) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    UpdateOrders,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      ids,
+	})
+}
@@ -76,6 +76,9 @@ func (r *CategoryRepository) InsertOne(ctx context.Context, category *Model) *cu
 		return errInsertOneGetId(err, category)
 	}
 
+	// カテゴリツリーのキャッシュを無効化する
+	markChanged(ctx)
+
 	return nil
 }
 
@@ -109,6 +112,9 @@ func (r *CategoryRepository) UpdateOne(ctx context.Context, category *Model) *cu
 		return errUpdateOneGetId(err, category)
 	}
 
+	// カテゴリツリーのキャッシュを無効化する
+	markChanged(ctx)
+
 	return nil
 }
 
@@ -128,3 +134,27 @@ func (r *CategoryRepository) GetMaxID(ctx context.Context) (int, *customError.Er
 
 	return result.ID, nil
 }
+
+// UpdateOrders 指定したIDの順番で並び順(order)を振り直す
+func (r *CategoryRepository) UpdateOrders(ctx context.Context, ids []int) *customError.Error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, len(ids))
+	for i, id := range ids {
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{ID: id}).
+			SetUpdate(bson.M{"$set": bson.M{Order: i + 1}})
+	}
+
+	_, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return errUpdateOrders(err, ids)
+	}
+
+	// カテゴリツリーのキャッシュを無効化する
+	markChanged(ctx)
+
+	return nil
+}
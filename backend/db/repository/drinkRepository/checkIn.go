@@ -0,0 +1,106 @@
+package drinkRepository
+
+import (
+	"backend/middlewares/customError"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (r *DrinkRepository) InsertCheckIn(ctx context.Context, checkIn *CheckInModel) *customError.Error {
+	result, err := r.checkInCollection.InsertOne(ctx, checkIn)
+	if err != nil {
+		return errInsertCheckIn(err, checkIn)
+	}
+	checkIn.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// UpdateCheckIn 本人の記録のみ更新する。対象が存在しない場合はfalse
+func (r *DrinkRepository) UpdateCheckIn(ctx context.Context, checkIn *CheckInModel) (bool, *customError.Error) {
+	result, err := r.checkInCollection.UpdateOne(ctx, bson.M{ID: checkIn.ID, UserID: checkIn.UserID}, bson.M{"$set": bson.M{
+		DrankAt:         checkIn.DrankAt,
+		"place":         checkIn.Place,
+		"serving_style": checkIn.ServingStyle,
+		"price":         checkIn.Price,
+		"note":          checkIn.Note,
+	}})
+	if err != nil {
+		return false, errUpdateCheckIn(err, checkIn)
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// DeleteCheckIn 本人の記録のみ削除する。対象が存在しない場合はfalse
+func (r *DrinkRepository) DeleteCheckIn(ctx context.Context, uId primitive.ObjectID, id primitive.ObjectID) (bool, *customError.Error) {
+	result, err := r.checkInCollection.DeleteOne(ctx, bson.M{ID: id, UserID: uId})
+	if err != nil {
+		return false, errDeleteCheckIn(err, id)
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// GetCheckIn 存在しない場合はnil
+func (r *DrinkRepository) GetCheckIn(ctx context.Context, id primitive.ObjectID) (*CheckInModel, *customError.Error) {
+	var model CheckInModel
+	err := r.checkInCollection.FindOne(ctx, bson.M{ID: id}).Decode(&model)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, errGetCheckIn(err, id)
+	}
+	return &model, nil
+}
+
+// ListCheckIns 飲んだ日時の新しい順に取得する。lIdを指定した場合はそのお酒の記録のみ
+func (r *DrinkRepository) ListCheckIns(ctx context.Context, uId primitive.ObjectID, lId *primitive.ObjectID, skip int64, limit int64) ([]*CheckInModel, *customError.Error) {
+	filter := bson.M{UserID: uId}
+	if lId != nil {
+		filter[LiquorID] = *lId
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: DrankAt, Value: -1}, {Key: ID, Value: -1}}).
+		SetSkip(skip).
+		SetLimit(limit)
+	cursor, err := r.checkInCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errListCheckIns(err, uId)
+	}
+	defer cursor.Close(ctx)
+
+	var models []*CheckInModel
+	if err = cursor.All(ctx, &models); err != nil {
+		return nil, errListCheckIns(err, uId)
+	}
+	return models, nil
+}
+
+// CountCheckIns お酒ごとの記録の件数をまとめて数える(記録がないお酒はmapに含まれない)
+func (r *DrinkRepository) CountCheckIns(ctx context.Context, lIds []primitive.ObjectID) (map[primitive.ObjectID]int, *customError.Error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{LiquorID: bson.M{"$in": lIds}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$" + LiquorID, "count": bson.M{"$sum": 1}}}},
+	}
+	cursor, err := r.checkInCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errCountCheckIns(err, lIds)
+	}
+	defer cursor.Close(ctx)
+
+	var counts []struct {
+		LiquorID primitive.ObjectID `bson:"_id"`
+		Count    int                `bson:"count"`
+	}
+	if err = cursor.All(ctx, &counts); err != nil {
+		return nil, errCountCheckIns(err, lIds)
+	}
+	result := make(map[primitive.ObjectID]int, len(counts))
+	for _, c := range counts {
+		result[c.LiquorID] = c.Count
+	}
+	return result, nil
+}
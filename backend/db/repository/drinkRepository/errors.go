@@ -0,0 +1,123 @@
+package drinkRepository
+
+import (
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/errorMsg"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+)
+
+const (
+	AddWish       = "REPO-DRINK-001-AddWish"
+	RemoveWish    = "REPO-DRINK-002-RemoveWish"
+	IsWished      = "REPO-DRINK-003-IsWished"
+	ListWishes    = "REPO-DRINK-004-ListWishes"
+	InsertCheckIn = "REPO-DRINK-005-InsertCheckIn"
+	UpdateCheckIn = "REPO-DRINK-006-UpdateCheckIn"
+	DeleteCheckIn = "REPO-DRINK-007-DeleteCheckIn"
+	GetCheckIn    = "REPO-DRINK-008-GetCheckIn"
+	ListCheckIns  = "REPO-DRINK-009-ListCheckIns"
+	CountCheckIns = "REPO-DRINK-010-CountCheckIns"
+)
+
+func errAddWish(err error, uId primitive.ObjectID, lId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    AddWish,
+		UserMsg:    "飲みたいリストへの追加に失敗しました",
+		Level:      logrus.ErrorLevel,
+		Input:      fmt.Sprintf("{uid:%v,liquorId:%v}", uId, lId),
+	})
+}
+
+func errRemoveWish(err error, uId primitive.ObjectID, lId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    RemoveWish,
+		UserMsg:    "飲みたいリストからの削除に失敗しました",
+		Level:      logrus.ErrorLevel,
+		Input:      fmt.Sprintf("{uid:%v,liquorId:%v}", uId, lId),
+	})
+}
+
+func errIsWished(err error, uId primitive.ObjectID, lId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    IsWished,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      fmt.Sprintf("{uid:%v,liquorId:%v}", uId, lId),
+	})
+}
+
+func errListWishes(err error, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    ListWishes,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      uId,
+	})
+}
+
+func errInsertCheckIn(err error, checkIn *CheckInModel) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    InsertCheckIn,
+		UserMsg:    "記録の保存に失敗しました",
+		Level:      logrus.ErrorLevel,
+		Input:      checkIn,
+	})
+}
+
+func errUpdateCheckIn(err error, checkIn *CheckInModel) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    UpdateCheckIn,
+		UserMsg:    "記録の更新に失敗しました",
+		Level:      logrus.ErrorLevel,
+		Input:      checkIn,
+	})
+}
+
+func errDeleteCheckIn(err error, id primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    DeleteCheckIn,
+		UserMsg:    "記録の削除に失敗しました",
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
+
+func errGetCheckIn(err error, id primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    GetCheckIn,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
+
+func errListCheckIns(err error, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    ListCheckIns,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      uId,
+	})
+}
+
+func errCountCheckIns(err error, lIds []primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    CountCheckIns,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      lIds,
+	})
+}
@@ -0,0 +1,44 @@
+package drinkRepository
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	WishCollectionName    = "liquor_wishes"
+	CheckInCollectionName = "liquor_check_ins"
+	ID                    = "_id"
+	UserID                = "user_id"
+	LiquorID              = "liquor_id"
+	DrankAt               = "drank_at"
+	CreatedAt             = "created_at"
+)
+
+// 飲み方
+const (
+	ServingCold = "COLD" //冷や・ロック
+	ServingRoom = "ROOM" //常温
+	ServingWarm = "WARM" //燗・お湯割り
+)
+
+// WishModel 飲みたいリストの1件
+type WishModel struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	LiquorID  primitive.ObjectID `bson:"liquor_id"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+// CheckInModel 飲んだ記録
+type CheckInModel struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	UserID       primitive.ObjectID `bson:"user_id"`
+	LiquorID     primitive.ObjectID `bson:"liquor_id"`
+	DrankAt      time.Time          `bson:"drank_at"`
+	Place        *string            `bson:"place"`
+	ServingStyle *string            `bson:"serving_style"`
+	Price        *int               `bson:"price"`
+	Note         *string            `bson:"note"` //本人にのみ公開するメモ
+	CreatedAt    time.Time          `bson:"created_at"`
+}
@@ -0,0 +1,20 @@
+package drinkRepository
+
+import (
+	"backend/db"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type DrinkRepository struct {
+	db                *db.DB
+	wishCollection    *mongo.Collection
+	checkInCollection *mongo.Collection
+}
+
+func NewDrinkRepository(db *db.DB) DrinkRepository {
+	return DrinkRepository{
+		db:                db,
+		wishCollection:    db.Collection(WishCollectionName),
+		checkInCollection: db.Collection(CheckInCollectionName),
+	}
+}
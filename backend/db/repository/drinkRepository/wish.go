@@ -0,0 +1,61 @@
+package drinkRepository
+
+import (
+	"backend/middlewares/customError"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// AddWish 飲みたいリストに追加する。既に追加済の場合は何もしない
+func (r *DrinkRepository) AddWish(ctx context.Context, uId primitive.ObjectID, lId primitive.ObjectID) *customError.Error {
+	wish := WishModel{
+		UserID:    uId,
+		LiquorID:  lId,
+		CreatedAt: time.Now(),
+	}
+	_, err := r.wishCollection.UpdateOne(ctx, bson.M{UserID: uId, LiquorID: lId}, bson.M{"$setOnInsert": wish}, options.Update().SetUpsert(true))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return errAddWish(err, uId, lId)
+	}
+	return nil
+}
+
+// RemoveWish 飲みたいリストから外す。追加されていない場合は何もしない
+func (r *DrinkRepository) RemoveWish(ctx context.Context, uId primitive.ObjectID, lId primitive.ObjectID) *customError.Error {
+	_, err := r.wishCollection.DeleteOne(ctx, bson.M{UserID: uId, LiquorID: lId})
+	if err != nil {
+		return errRemoveWish(err, uId, lId)
+	}
+	return nil
+}
+
+func (r *DrinkRepository) IsWished(ctx context.Context, uId primitive.ObjectID, lId primitive.ObjectID) (bool, *customError.Error) {
+	err := r.wishCollection.FindOne(ctx, bson.M{UserID: uId, LiquorID: lId}).Err()
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return false, nil
+		}
+		return false, errIsWished(err, uId, lId)
+	}
+	return true, nil
+}
+
+// ListWishes 飲みたいリストを新しい順に取得する
+func (r *DrinkRepository) ListWishes(ctx context.Context, uId primitive.ObjectID) ([]*WishModel, *customError.Error) {
+	cursor, err := r.wishCollection.Find(ctx, bson.M{UserID: uId}, options.Find().SetSort(bson.D{{Key: CreatedAt, Value: -1}}))
+	if err != nil {
+		return nil, errListWishes(err, uId)
+	}
+	defer cursor.Close(ctx)
+
+	var models []*WishModel
+	if err = cursor.All(ctx, &models); err != nil {
+		return nil, errListWishes(err, uId)
+	}
+	return models, nil
+}
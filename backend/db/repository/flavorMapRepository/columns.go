@@ -6,4 +6,20 @@ const (
 	UserID     = "user_id"
 	X          = "x"
 	Y          = "y"
+
+	GuestID = "guest_id"
+
+	VersionNo = "version_no"
+
+	CellData        = "flavor_cell_data"
+	UserAmount      = "user_amount"
+	GuestAmount     = "guest_amount"
+	UserFullAmount  = "user_full_amount"
+	GuestFullAmount = "guest_full_amount"
+	Score           = "score"
+	ScoreTotal      = "score_total"
+	Weight          = "weight"
+	UpdatedAt       = "updated_at"
+	Seq             = "seq"
+	DecayLandmark   = "decay_landmark"
 )
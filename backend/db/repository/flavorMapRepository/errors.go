@@ -16,6 +16,24 @@ const (
 	Update               = "REPO-FLAVOR-MAP-004-Update"
 	GetVotedDataByLiquor = "REPO-FLAVOR-MAP-005-GetVotedDataByLiquor"
 	Upsert               = "REPO-FLAVOR-MAP-006-Upsert"
+	VotedPairs           = "REPO-FLAVOR-MAP-007-VotedPairs"
+	GetVotes             = "REPO-FLAVOR-MAP-008-GetVotes"
+	EnsureData           = "REPO-FLAVOR-MAP-009-EnsureData"
+	GetData              = "REPO-FLAVOR-MAP-010-GetData"
+	ApplyVote            = "REPO-FLAVOR-MAP-011-ApplyVote"
+	UpdateWeights        = "REPO-FLAVOR-MAP-012-UpdateWeights"
+	GetDataByCategory    = "REPO-FLAVOR-MAP-013-GetDataByCategory"
+	GetNeighbours        = "REPO-FLAVOR-MAP-014-GetNeighbours"
+	GetNeighboursByCat   = "REPO-FLAVOR-MAP-015-GetNeighboursByCategory"
+	SaveNeighbours       = "REPO-FLAVOR-MAP-016-SaveNeighbours"
+	GetVotesByUser       = "REPO-FLAVOR-MAP-017-GetVotesByUser"
+	GetMasterByCategory  = "REPO-FLAVOR-MAP-018-GetMasterByCategory"
+	InsertMaster         = "REPO-FLAVOR-MAP-019-InsertMaster"
+	UpdateMaster         = "REPO-FLAVOR-MAP-020-UpdateMaster"
+	InsertMasterLog      = "REPO-FLAVOR-MAP-021-InsertMasterLog"
+	GetMasterLogs        = "REPO-FLAVOR-MAP-022-GetMasterLogs"
+	MirrorVotes          = "REPO-FLAVOR-MAP-023-MirrorVotes"
+	ArchiveVotes         = "REPO-FLAVOR-MAP-024-ArchiveVotes"
 )
 
 func errMasterFind(err error) *customError.Error {
@@ -75,3 +93,182 @@ func errUpsert(err error, tying TyingModel) *customError.Error {
 		Input:      tying,
 	})
 }
+
+func errVotedPairs(err error) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    VotedPairs,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+	})
+}
+
+func errGetVotes(err error, lId primitive.ObjectID, cId int) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    GetVotes,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      fmt.Sprintf("lId: %s, cId: %d", lId.Hex(), cId),
+	})
+}
+
+func errEnsureData(err error, lId primitive.ObjectID, cId int) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    EnsureData,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      fmt.Sprintf("lId: %s, cId: %d", lId.Hex(), cId),
+	})
+}
+
+func errGetData(err error, lId primitive.ObjectID, cId int) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    GetData,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      fmt.Sprintf("lId: %s, cId: %d", lId.Hex(), cId),
+	})
+}
+
+func errApplyVote(err error, d FlavorMapModel) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    ApplyVote,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      d,
+	})
+}
+
+func errUpdateWeights(err error, count int) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    UpdateWeights,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      count,
+	})
+}
+
+func errGetDataByCategory(err error, cId int) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    GetDataByCategory,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      cId,
+	})
+}
+
+func errGetNeighbours(err error, lId primitive.ObjectID, cId int) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    GetNeighbours,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      fmt.Sprintf("lId: %s, cId: %d", lId.Hex(), cId),
+	})
+}
+
+func errGetNeighboursByCategory(err error, cId int) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    GetNeighboursByCat,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      cId,
+	})
+}
+
+func errSaveNeighbours(err error, count int) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    SaveNeighbours,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      count,
+	})
+}
+
+func errGetVotesByUser(err error, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    GetVotesByUser,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      uId,
+	})
+}
+
+func errGetMasterByCategory(err error, cId int) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    GetMasterByCategory,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      cId,
+	})
+}
+
+func errInsertMaster(err error, m *MasterModel) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    InsertMaster,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      m,
+	})
+}
+
+func errUpdateMaster(err error, m *MasterModel) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    UpdateMaster,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      m,
+	})
+}
+
+func errInsertMasterLog(err error, m *MasterModel) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    InsertMasterLog,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      m,
+	})
+}
+
+func errGetMasterLogs(err error, cId int) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    GetMasterLogs,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      cId,
+	})
+}
+
+func errMirrorVotes(err error, cId int) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    MirrorVotes,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      cId,
+	})
+}
+
+func errArchiveVotes(err error, cId int) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    ArchiveVotes,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      cId,
+	})
+}
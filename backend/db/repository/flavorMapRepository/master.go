@@ -0,0 +1,129 @@
+package flavorMapRepository
+
+import (
+	"backend/middlewares/customError"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// GetMasterByCategory 指定したカテゴリに直接設定されたマスタを取得する(親カテゴリのマスタは辿らない)。存在しない場合はnil
+func (r *FlavorMapMasterRepository) GetMasterByCategory(ctx context.Context, cId int) (*MasterModel, *customError.Error) {
+	var model MasterModel
+	err := r.Collection.FindOne(ctx, bson.M{CategoryID: cId}).Decode(&model)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, errGetMasterByCategory(err, cId)
+	}
+	return &model, nil
+}
+
+func (r *FlavorMapMasterRepository) InsertMaster(ctx context.Context, m *MasterModel) *customError.Error {
+	_, err := r.Collection.InsertOne(ctx, m)
+	if err != nil {
+		return errInsertMaster(err, m)
+	}
+	return nil
+}
+
+// UpdateMaster マスタを上書きする
+// 画面表示中のバージョンから変化していた(=他の管理者が更新した)場合は上書きせず、falseを返す
+func (r *FlavorMapMasterRepository) UpdateMaster(ctx context.Context, m *MasterModel, expectedVersionNo int) (bool, *customError.Error) {
+	var versionFilter interface{} = expectedVersionNo
+	if expectedVersionNo == 0 {
+		//シードデータにはフィールドが存在しない
+		versionFilter = bson.M{"$in": bson.A{0, nil}}
+	}
+	result, err := r.Collection.UpdateOne(ctx, bson.M{
+		CategoryID: m.CategoryID,
+		VersionNo:  versionFilter,
+	}, bson.M{"$set": m})
+	if err != nil {
+		return false, errUpdateMaster(err, m)
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (r *FlavorMapMasterRepository) InsertMasterLog(ctx context.Context, m *MasterModel) *customError.Error {
+	_, err := r.logsCollection.InsertOne(ctx, m)
+	if err != nil {
+		return errInsertMasterLog(err, m)
+	}
+	return nil
+}
+
+// GetMasterLogs 指定したカテゴリのマスタの変更履歴を新しい順に取得する
+func (r *FlavorMapMasterRepository) GetMasterLogs(ctx context.Context, cId int) ([]*MasterModel, *customError.Error) {
+	cursor, err := r.logsCollection.Find(ctx, bson.M{CategoryID: cId}, options.Find().SetSort(bson.D{{Key: VersionNo, Value: -1}}))
+	if err != nil {
+		return nil, errGetMasterLogs(err, cId)
+	}
+	defer cursor.Close(ctx)
+
+	var models []*MasterModel
+	if err = cursor.All(ctx, &models); err != nil {
+		return nil, errGetMasterLogs(err, cId)
+	}
+	return models, nil
+}
+
+// MirrorVotes 指定したカテゴリの投票の座標を反転する(軸の両端を入れ替えた場合の移行用)
+func (r *FlavorMapRepository) MirrorVotes(ctx context.Context, cId int, mirrorX bool, mirrorY bool) *customError.Error {
+	set := bson.M{}
+	if mirrorX {
+		set[X] = bson.M{"$multiply": bson.A{"$" + X, -1}}
+	}
+	if mirrorY {
+		set[Y] = bson.M{"$multiply": bson.A{"$" + Y, -1}}
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	_, err := r.Collection.UpdateMany(ctx, bson.M{CategoryID: cId}, mongo.Pipeline{{{Key: "$set", Value: set}}})
+	if err != nil {
+		return errMirrorVotes(err, cId)
+	}
+	return nil
+}
+
+// ArchiveVotes 指定したカテゴリの投票を退避用コレクションに移し、集計対象から外す(マップを置き換えた場合の移行用)
+// memo:移動の途中で失敗すると票が失われるので、トランザクション内で呼ぶこと
+func (r *FlavorMapRepository) ArchiveVotes(ctx context.Context, cId int, masterVersionNo int) (int, *customError.Error) {
+	cursor, err := r.Collection.Find(ctx, bson.M{CategoryID: cId})
+	if err != nil {
+		return 0, errArchiveVotes(err, cId)
+	}
+	defer cursor.Close(ctx)
+
+	var votes []FlavorMapModel
+	if err = cursor.All(ctx, &votes); err != nil {
+		return 0, errArchiveVotes(err, cId)
+	}
+	if len(votes) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	archives := make([]interface{}, len(votes))
+	ids := make(bson.A, len(votes))
+	for i, vote := range votes {
+		archives[i] = ArchivedVoteModel{
+			FlavorMapModel:  vote,
+			MasterVersionNo: masterVersionNo,
+			ArchivedAt:      now,
+		}
+		ids[i] = vote.ID
+	}
+	if _, err = r.archiveCollection.InsertMany(ctx, archives); err != nil {
+		return 0, errArchiveVotes(err, cId)
+	}
+	if _, err = r.Collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+		return 0, errArchiveVotes(err, cId)
+	}
+	return len(votes), nil
+}
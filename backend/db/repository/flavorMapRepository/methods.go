@@ -2,12 +2,12 @@ package flavorMapRepository
 
 import (
 	"backend/middlewares/customError"
-	"backend/util/utilType"
 	"context"
 	"errors"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"time"
 )
 
@@ -26,26 +26,116 @@ func (r *FlavorMapMasterRepository) GetMasterData(ctx context.Context) ([]*Maste
 	return models, nil
 }
 
-func (r *FlavorMapRepository) PostFlavorMap(ctx context.Context, uId *primitive.ObjectID, liquorId primitive.ObjectID, categoryId int, coordinates utilType.Coordinates) *customError.Error {
-	d := FlavorMapModel{
-		LiquorId:   liquorId,
-		CategoryId: categoryId,
-		UserId:     uId,
-		X:          coordinates.X,
-		Y:          coordinates.Y,
-		UpdatedAt:  time.Now(),
-	}
-	var err error
-	if uId == nil {
-		_, err = r.Collection.InsertOne(ctx, d)
+// PostFlavorMap 投票データを登録する。同じ投票者(ログインユーザー、または同じ訪問者のゲスト)の再投票の場合は上書きし、上書き前の投票データを返す(新規投票の場合はnil)
+func (r *FlavorMapRepository) PostFlavorMap(ctx context.Context, d FlavorMapModel) (*FlavorMapModel, *customError.Error) {
+	d.ID = primitive.NilObjectID //_idは自動採番・既存のものを維持する
+	filter, ok := voterFilter(d)
+	if !ok {
+		//訪問者情報のないゲストは判定しようがないので、常に新規投票として扱う
+		_, err := r.Collection.InsertOne(ctx, d)
 		if err != nil {
-			return errInsert(err, d)
+			return nil, errInsert(err, d)
 		}
-	} else {
-		_, err = r.Upsert(ctx, bson.M{UserID: uId, CategoryID: categoryId, LiquorID: liquorId}, d)
-		if err != nil {
-			return errUpdate(err, d)
+		return nil, nil
+	}
+
+	//差分集計のため、上書き前のドキュメントをアトミックに取得する
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+	var prev FlavorMapModel
+	err := r.Collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": d}, opts).Decode(&prev)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			//新規投票
+			return nil, nil
 		}
+		return nil, errUpdate(err, d)
+	}
+	return &prev, nil
+}
+
+// voterFilter 同じ投票者の投票を探すフィルタ。ゲストは署名付きの訪問者IDが一致する場合のみ同一とみなす
+// memo:IPアドレスなどのフィンガープリントは同じNAT配下の別人と区別できないので、回数制限にだけ使う
+func voterFilter(d FlavorMapModel) (bson.M, bool) {
+	filter := bson.M{CategoryID: d.CategoryId, LiquorID: d.LiquorId}
+	if d.UserId != nil {
+		filter[UserID] = d.UserId
+		return filter, true
+	}
+	if d.GuestId == nil {
+		return nil, false
+	}
+	filter[UserID] = nil
+	filter[GuestID] = *d.GuestId
+	return filter, true
+}
+
+// VotedPairs 投票が存在するお酒とカテゴリの組み合わせを全件取得する(定期再集計用)
+func (r *FlavorMapRepository) VotedPairs(ctx context.Context) ([]VotedPair, *customError.Error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": bson.M{LiquorID: "$" + LiquorID, CategoryID: "$" + CategoryID}}}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$_id"}}},
+	}
+	cursor, err := r.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errVotedPairs(err)
+	}
+	defer cursor.Close(ctx)
+
+	var pairs []VotedPair
+	if err = cursor.All(ctx, &pairs); err != nil {
+		return nil, errVotedPairs(err)
+	}
+	return pairs, nil
+}
+
+// GetVotes 指定したお酒・カテゴリの投票データを全件取得する
+func (r *FlavorMapRepository) GetVotes(ctx context.Context, lId primitive.ObjectID, cId int) ([]*FlavorMapModel, *customError.Error) {
+	cursor, err := r.Collection.Find(ctx, bson.M{
+		LiquorID:   lId,
+		CategoryID: cId,
+	})
+	if err != nil {
+		return nil, errGetVotes(err, lId, cId)
+	}
+	defer cursor.Close(ctx)
+
+	var models []*FlavorMapModel
+	if err = cursor.All(ctx, &models); err != nil {
+		return nil, errGetVotes(err, lId, cId)
+	}
+	return models, nil
+}
+
+// GetVotesByUser 指定したユーザーの投票データを全件取得する
+func (r *FlavorMapRepository) GetVotesByUser(ctx context.Context, uId primitive.ObjectID) ([]*FlavorMapModel, *customError.Error) {
+	cursor, err := r.Collection.Find(ctx, bson.M{UserID: uId})
+	if err != nil {
+		return nil, errGetVotesByUser(err, uId)
+	}
+	defer cursor.Close(ctx)
+
+	var models []*FlavorMapModel
+	if err = cursor.All(ctx, &models); err != nil {
+		return nil, errGetVotesByUser(err, uId)
+	}
+	return models, nil
+}
+
+// UpdateWeights 再集計で計算し直した票の重みを書き戻す
+// 読み取り後に再投票された票は、updated_atが一致しないので上書きしない
+func (r *FlavorMapRepository) UpdateWeights(ctx context.Context, votes []*FlavorMapModel) *customError.Error {
+	if len(votes) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, len(votes))
+	for i, vote := range votes {
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": vote.ID, UpdatedAt: vote.UpdatedAt}).
+			SetUpdate(bson.M{"$set": bson.M{Weight: vote.Weight}})
+	}
+	_, err := r.Collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return errUpdateWeights(err, len(votes))
 	}
 	return nil
 }
@@ -66,16 +156,92 @@ func (r *FlavorMapRepository) GetVotedDataByLiquor(ctx context.Context, uId prim
 	return model, nil
 }
 
-func (r *FlavorToLiquorRepository) UpsertData(ctx context.Context, tying TyingModel) *customError.Error {
-	// 結果を TyingModel に反映
-	_, err := r.Upsert(ctx, bson.M{
-		LiquorID:   tying.LiquorID,
-		CategoryID: tying.CategoryID,
-	}, tying)
+// EnsureData 集計用ドキュメントが存在しない場合は、空のセルで作成する(既に存在する場合は何もしない)
+// 減衰の基準時刻は作成時刻にする
+func (r *FlavorToLiquorRepository) EnsureData(ctx context.Context, lId primitive.ObjectID, cId int) *customError.Error {
+	empty := NewEmptyTyingModel(lId, cId)
+	now := time.Now().Truncate(time.Millisecond)
+	empty.DecayLandmark = &now
+	_, err := r.Collection.UpdateOne(ctx, bson.M{
+		LiquorID:   lId,
+		CategoryID: cId,
+	}, bson.M{"$setOnInsert": empty}, options.Update().SetUpsert(true))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		//同時にupsertされた場合はユニーク制約で弾かれるが、どちらかで作成されているので問題ない
+		return errEnsureData(err, lId, cId)
+	}
+	return nil
+}
 
+// GetData 集計済のドキュメントを取得する。存在しない場合はnil
+func (r *FlavorToLiquorRepository) GetData(ctx context.Context, lId primitive.ObjectID, cId int) (*TyingModel, *customError.Error) {
+	var model TyingModel
+	err := r.Collection.FindOne(ctx, bson.M{LiquorID: lId, CategoryID: cId}).Decode(&model)
 	if err != nil {
-		return errUpsert(err, tying)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, errGetData(err, lId, cId)
 	}
+	return &model, nil
+}
 
-	return nil
+// ApplyVote 投票1件分の差分を$incで集計用ドキュメントに反映する
+// prevは上書き前の投票(新規投票の場合はnil)。1回のUpdateOneで反映するので、同時に投票されても更新が失われることはない
+// landmarkは重みの計算に使った減衰の基準時刻。全件再集計で置き直されていた場合は反映せず、falseを返す
+func (r *FlavorToLiquorRepository) ApplyVote(ctx context.Context, prev *FlavorMapModel, current FlavorMapModel, landmark *time.Time) (bool, *customError.Error) {
+	counts := map[string]int{}
+	scores := map[string]float64{}
+	if prev != nil {
+		counts[prev.cellAmountKey()]--
+		scores[prev.cellScoreKey()] -= prev.Weight
+		scores[ScoreTotal] -= prev.Weight
+	} else {
+		counts[current.fullAmountKey()]++
+	}
+	counts[current.cellAmountKey()]++
+	scores[current.cellScoreKey()] += current.Weight
+	scores[ScoreTotal] += current.Weight
+
+	inc := bson.M{Seq: 1}
+	for key, v := range counts {
+		if v != 0 {
+			inc[key] = v
+		}
+	}
+	for key, v := range scores {
+		if v != 0 {
+			inc[key] = v
+		}
+	}
+
+	result, err := r.Collection.UpdateOne(ctx, bson.M{
+		LiquorID:      current.LiquorId,
+		CategoryID:    current.CategoryId,
+		DecayLandmark: landmark, //基準時刻導入前のドキュメントは、フィールドがないのでnilで一致する
+	}, bson.M{"$inc": inc})
+	if err != nil {
+		return false, errApplyVote(err, current)
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// ReplaceData 全件集計した結果で上書きする
+// 集計開始時に読み取ったseqから変化していた(=集計中に投票があった)場合は上書きせず、falseを返す
+func (r *FlavorToLiquorRepository) ReplaceData(ctx context.Context, tying TyingModel, expectedSeq int64) (bool, *customError.Error) {
+	tying.Seq = expectedSeq
+	var seqFilter interface{} = expectedSeq
+	if expectedSeq == 0 {
+		//seq導入前のドキュメントにはフィールドが存在しない
+		seqFilter = bson.M{"$in": bson.A{0, nil}}
+	}
+	result, err := r.Collection.UpdateOne(ctx, bson.M{
+		LiquorID:   tying.LiquorID,
+		CategoryID: tying.CategoryID,
+		Seq:        seqFilter,
+	}, bson.M{"$set": tying})
+	if err != nil {
+		return false, errUpsert(err, tying)
+	}
+	return result.MatchedCount > 0, nil
 }
@@ -0,0 +1,82 @@
+package flavorMapRepository
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestVoterFilter_正常系_ゲストは訪問者IDだけで同一と判定すること(t *testing.T) {
+	lId := primitive.NewObjectID()
+	guestId := "guest"
+
+	filter, ok := voterFilter(FlavorMapModel{LiquorId: lId, CategoryId: 1, GuestId: &guestId})
+	assert.True(t, ok)
+	assert.Equal(t, bson.M{CategoryID: 1, LiquorID: lId, UserID: nil, GuestID: guestId}, filter)
+}
+
+func TestVoterFilter_異常系_訪問者IDのないゲストは判定しないこと(t *testing.T) {
+	_, ok := voterFilter(FlavorMapModel{LiquorId: primitive.NewObjectID(), CategoryId: 1})
+	assert.False(t, ok)
+}
+
+func TestCalcRates_正常系_重み付き得票が未集計のドキュメントは得票数で計算すること(t *testing.T) {
+	tying := NewEmptyTyingModel(primitive.NewObjectID(), 1)
+	tying.Weighted = false
+	tying.FlavorCellData[0].UserAmount = 3
+	tying.FlavorCellData[1].UserAmount = 1
+	// 重み付け導入後の1票だけがscoreに入っている
+	tying.FlavorCellData[1].Score = 5
+	tying.ScoreTotal = 5
+
+	tying.CalcRates(DefaultWeighting())
+	assert.InDelta(t, 75.0, tying.FlavorCellData[0].Rate, 1e-9)
+	assert.InDelta(t, 25.0, tying.FlavorCellData[1].Rate, 1e-9)
+}
+
+func TestCalcRates_正常系_集計済みのドキュメントは重み付き得票で計算すること(t *testing.T) {
+	tying := NewEmptyTyingModel(primitive.NewObjectID(), 1)
+	tying.FlavorCellData[0].UserAmount = 1
+	tying.FlavorCellData[0].Score = 3
+	tying.FlavorCellData[1].GuestAmount = 1
+	tying.FlavorCellData[1].Score = 1
+	tying.ScoreTotal = 4
+
+	tying.CalcRates(DefaultWeighting())
+	assert.InDelta(t, 75.0, tying.FlavorCellData[0].Rate, 1e-9)
+	assert.InDelta(t, 25.0, tying.FlavorCellData[1].Rate, 1e-9)
+}
+
+func TestVoteWeight_正常系_基準時刻から遠く離れた票でも溢れず0にもならないこと(t *testing.T) {
+	w := WeightingModel{UserWeight: 1, GuestWeight: 1, HalfLifeDays: MinHalfLifeDays}
+
+	for _, votedAt := range []time.Time{
+		time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2200, 1, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		weight := w.VoteWeight(true, 0, votedAt, decayLandmark)
+		assert.False(t, math.IsInf(weight, 0), votedAt)
+		assert.Greater(t, weight, 0.0, votedAt)
+	}
+}
+
+func TestLandmark_正常系_基準時刻を置き直せば上限を超える期間が経っても減衰が続くこと(t *testing.T) {
+	w := WeightingModel{UserWeight: 1, GuestWeight: 1, HalfLifeDays: MinHalfLifeDays}
+	// 既定の基準時刻から指数の上限を超えるだけ経った時点の、半減期1回分離れた2票
+	votedAt := decayLandmark.Add(time.Duration((maxDecayExponent+10)*MinHalfLifeDays*24) * time.Hour)
+	older := votedAt.Add(-time.Duration(MinHalfLifeDays*24) * time.Hour)
+
+	// 基準時刻が未設定のドキュメントは既定の基準時刻を使うので、どちらも上限で頭打ちになる
+	var tying TyingModel
+	assert.Equal(t, decayLandmark, tying.Landmark())
+	assert.Equal(t, w.VoteWeight(true, 0, votedAt, tying.Landmark()), w.VoteWeight(true, 0, older, tying.Landmark()))
+
+	// 再集計で基準時刻を置き直すと、半減期の分だけ重みが半分になる
+	tying.DecayLandmark = &votedAt
+	assert.Equal(t, votedAt, tying.Landmark())
+	assert.InDelta(t, 0.5, w.VoteWeight(true, 0, older, tying.Landmark())/w.VoteWeight(true, 0, votedAt, tying.Landmark()), 1e-9)
+}
@@ -4,15 +4,38 @@ import (
 	"backend/graph/graphModel"
 	"backend/graph/schema/customModel"
 	"backend/util/utilType"
+	"fmt"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math"
 	"time"
 )
 
 // MasterModel フレーバーマップのマスタモデル
 type MasterModel struct {
-	CategoryID int       `bson:"category_id"`
-	XName      [2]string `bson:"x"`
-	YName      [2]string `bson:"y"`
+	CategoryID   int                 `bson:"category_id"`
+	XName        [2]string           `bson:"x"`
+	YName        [2]string           `bson:"y"`
+	Weighting    *WeightingModel     `bson:"weighting,omitempty"` //未設定の場合はデフォルト値(重み付けなし)
+	VersionNo    int                 `bson:"version_no"`          //シードデータは0
+	Migration    *string             `bson:"migration,omitempty"` //このバージョンで既存の投票に適用した移行方法(Migration～)
+	UpdateUserId *primitive.ObjectID `bson:"update_user_id,omitempty"`
+	UpdatedAt    *time.Time          `bson:"updated_at,omitempty"`
+}
+
+// マスタ更新時の、既存の投票の移行方法
+const (
+	MigrationKeep     = "KEEP"      //軸名の変更のみ(投票はそのまま)
+	MigrationMirrorX  = "MIRROR_X"  //X軸の両端を入れ替えたので、投票を左右反転する
+	MigrationMirrorY  = "MIRROR_Y"  //Y軸の両端を入れ替えたので、投票を上下反転する
+	MigrationMirrorXY = "MIRROR_XY" //両軸とも反転する
+	MigrationReset    = "RESET"     //別のマップに置き換えたので、既存の投票を退避して集計から外す
+)
+
+// ArchivedVoteModel マスタの置き換えで退避した投票
+type ArchivedVoteModel struct {
+	FlavorMapModel  `bson:",inline"`
+	MasterVersionNo int       `bson:"master_version_no"` //退避した時点で有効だったマスタのバージョン
+	ArchivedAt      time.Time `bson:"archived_at"`
 }
 
 // TyingModel 特定のお酒とフレーバーマップを関連付けるコレクション(カテゴリが移動するとフレーバーマップが変わる可能性があるが、戻した時に復元できないといけない)
@@ -22,21 +45,158 @@ type TyingModel struct {
 	FlavorCellData  [21 * 21]FlavorCellData `bson:"flavor_cell_data"`
 	UserFullAmount  int                     `bson:"user_full_amount"`
 	GuestFullAmount int                     `bson:"guest_full_amount"`
+	ScoreTotal      float64                 `bson:"score_total"`    //重み付き得票の合計
+	Weighted        bool                    `bson:"weighted"`       //全ての票の重みがscoreに集計済みか(重み付け導入前のドキュメントは再集計されるまでfalse)
+	Seq             int64                   `bson:"seq"`            //投票を反映するたびにインクリメントする。全件再集計時の楽観ロックに使う
+	DecayLandmark   *time.Time              `bson:"decay_landmark"` //scoreの前方減衰の基準時刻。全件再集計のたびに集計時刻へ置き直す(未設定は既定の基準時刻)
+}
+
+// NeighbourModel フレーバーマップの分布が似ているお酒の一覧(CalcFlavorMap実行時に事前計算する)
+type NeighbourModel struct {
+	LiquorID   primitive.ObjectID `bson:"liquor_id"`
+	CategoryID int                `bson:"category_id"`
+	Neighbours []NeighbourEntry   `bson:"neighbours"` //類似度の降順
+	UpdatedAt  time.Time          `bson:"updated_at"`
 }
+
+type NeighbourEntry struct {
+	LiquorID   primitive.ObjectID `bson:"liquor_id"`
+	Similarity float64            `bson:"similarity"` //0～1(1が同一の分布)
+}
+
+// VotedPair 投票が存在するお酒とカテゴリの組み合わせ
+type VotedPair struct {
+	LiquorID   primitive.ObjectID `bson:"liquor_id"`
+	CategoryID int                `bson:"category_id"`
+}
+
 type FlavorCellData struct {
 	utilType.Coordinates
 	Rate        float64 `bson:"rate"`
 	UserAmount  int     `bson:"user_amount"`
 	GuestAmount int     `bson:"guest_amount"`
+	Score       float64 `bson:"score"` //重み付きの得票
 }
 
 type FlavorMapModel struct {
+	ID         primitive.ObjectID     `bson:"_id,omitempty"`
 	LiquorId   primitive.ObjectID     `bson:"liquor_id"`
 	CategoryId int                    `bson:"category_id"`
 	UserId     *primitive.ObjectID    `bson:"user_id"`
 	X          customModel.Coordinate `bson:"x"`
 	Y          customModel.Coordinate `bson:"y"`
 	UpdatedAt  time.Time              `bson:"updated_at"`
+	Weight     float64                `bson:"weight"` //投票時点の重み(WeightingModel.VoteWeight)。差分集計で票を差し引く際に使う
+	//ゲストの再投票を判定するための訪問者情報(ログインユーザーはnil)
+	GuestId *string `bson:"guest_id,omitempty"`
+}
+
+// NewEmptyTyingModel 投票が1件もない状態の集計データを作成する
+func NewEmptyTyingModel(lId primitive.ObjectID, cId int) TyingModel {
+	var cellData [21 * 21]FlavorCellData
+	for i := 0; i < 21*21; i++ {
+		x, y := utilType.UndoCoordinateIndex(i)
+		cellData[i] = FlavorCellData{
+			Coordinates: utilType.Coordinates{X: customModel.Coordinate(x), Y: customModel.Coordinate(y)},
+		}
+	}
+	return TyingModel{
+		LiquorID:       lId,
+		CategoryID:     cId,
+		FlavorCellData: cellData,
+		Weighted:       true, //票がないので、以降の差分集計だけで重み付き得票が揃う
+	}
+}
+
+// Landmark scoreの前方減衰の基準時刻。差分集計で加える票の重みも、この時刻を基準に計算する
+func (t *TyingModel) Landmark() time.Time {
+	if t.DecayLandmark == nil {
+		return decayLandmark
+	}
+	return *t.DecayLandmark
+}
+
+// CalcRates セルごとの得票から得票率を計算し直す
+// memo:差分集計では得票を$incで更新するので、得票率は読み出し時に計算する
+func (t *TyingModel) CalcRates(w WeightingModel) {
+	scores := make([]float64, len(t.FlavorCellData))
+	var total float64
+	for i := range t.FlavorCellData {
+		scores[i] = t.cellWeight(i, w)
+		total += scores[i]
+	}
+
+	rates := make([]float64, len(scores))
+	if total > 0 {
+		for i, score := range scores {
+			rates[i] = score / total * 100.0
+		}
+	}
+	if w.SmoothingSigma > 0 {
+		rates = smoothRates(rates, w.SmoothingSigma)
+	}
+	for i := range t.FlavorCellData {
+		t.FlavorCellData[i].Rate = rates[i]
+	}
+}
+
+// cellWeight セルの重み付き得票
+// 重み付き得票が未集計のドキュメント(再集計前)は、一部のセルにだけscoreがあると偏るので、全セルを得票数と票種別の重みだけで計算する
+func (t *TyingModel) cellWeight(i int, w WeightingModel) float64 {
+	cell := t.FlavorCellData[i]
+	if t.Weighted {
+		return cell.Score
+	}
+	return float64(cell.UserAmount)*w.UserWeight + float64(cell.GuestAmount)*w.GuestWeight
+}
+
+// Centroid 分布の重心(x,y)と、重心からの平均的な広がり(標準偏差)を返す。票がない場合はok=false
+func (t *TyingModel) Centroid() (x float64, y float64, spread float64, ok bool) {
+	counts := WeightingModel{UserWeight: 1, GuestWeight: 1}
+	var total float64
+	for i, cell := range t.FlavorCellData {
+		w := t.cellWeight(i, counts)
+		x += w * float64(cell.X)
+		y += w * float64(cell.Y)
+		total += w
+	}
+	if total <= 0 {
+		return 0, 0, 0, false
+	}
+	x /= total
+	y /= total
+
+	var variance float64
+	for i, cell := range t.FlavorCellData {
+		w := t.cellWeight(i, counts)
+		dx, dy := float64(cell.X)-x, float64(cell.Y)-y
+		variance += w * (dx*dx + dy*dy)
+	}
+	return x, y, math.Sqrt(variance / total), true
+}
+
+// cellAmountKey 投票位置のセルの得票数フィールド(flavor_cell_data.<index>.user_amount など)
+func (f *FlavorMapModel) cellAmountKey() string {
+	index := utilType.GetCoordinateIndex(f.X)*21 + utilType.GetCoordinateIndex(f.Y)
+	amount := GuestAmount
+	if f.UserId != nil {
+		amount = UserAmount
+	}
+	return fmt.Sprintf("%s.%d.%s", CellData, index, amount)
+}
+
+// cellScoreKey 投票位置のセルの重み付き得票フィールド
+func (f *FlavorMapModel) cellScoreKey() string {
+	index := utilType.GetCoordinateIndex(f.X)*21 + utilType.GetCoordinateIndex(f.Y)
+	return fmt.Sprintf("%s.%d.%s", CellData, index, Score)
+}
+
+// fullAmountKey 投票者種別ごとの総投票数フィールド
+func (f *FlavorMapModel) fullAmountKey() string {
+	if f.UserId != nil {
+		return UserFullAmount
+	}
+	return GuestFullAmount
 }
 
 // FlavorMapResult フロントに渡すためにマスタデータとTyingデータの両方が必要なのでまとめる
@@ -61,6 +221,7 @@ func (r *FlavorMapResult) ToGraphQL() *graphModel.FlavorMapData {
 		UserFullAmount:  r.Tying.UserFullAmount,
 		GuestFullAmount: r.Tying.GuestFullAmount,
 		MapData:         fMap,
+		Weighting:       r.Master.GetWeighting().ToGraphQL(),
 	}
 }
 
@@ -84,3 +245,22 @@ func (f *FlavorMapModel) ToGraphQL() *graphModel.VotedData {
 		UpdatedAt:  f.UpdatedAt,
 	}
 }
+
+// ToMasterGraphQL 管理画面用にマスタ単体を変換する(カテゴリ名はカテゴリのリポジトリから取得して渡す)
+func (m *MasterModel) ToMasterGraphQL(categoryName string) *graphModel.FlavorMapMaster {
+	var migration *graphModel.FlavorMapMigration
+	if m.Migration != nil {
+		mg := graphModel.FlavorMapMigration(*m.Migration)
+		migration = &mg
+	}
+	return &graphModel.FlavorMapMaster{
+		CategoryID:   m.CategoryID,
+		CategoryName: categoryName,
+		XNames:       m.XName[:],
+		YNames:       m.YName[:],
+		VersionNo:    m.VersionNo,
+		Migration:    migration,
+		UpdatedAt:    m.UpdatedAt,
+		Weighting:    m.GetWeighting().ToGraphQL(),
+	}
+}
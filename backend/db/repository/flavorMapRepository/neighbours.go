@@ -0,0 +1,73 @@
+package flavorMapRepository
+
+import (
+	"backend/middlewares/customError"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetDataByCategory 指定したカテゴリの集計データを全件取得する(類似度計算用)
+func (r *FlavorToLiquorRepository) GetDataByCategory(ctx context.Context, cId int) ([]*TyingModel, *customError.Error) {
+	cursor, err := r.Collection.Find(ctx, bson.M{CategoryID: cId})
+	if err != nil {
+		return nil, errGetDataByCategory(err, cId)
+	}
+	defer cursor.Close(ctx)
+
+	var models []*TyingModel
+	if err = cursor.All(ctx, &models); err != nil {
+		return nil, errGetDataByCategory(err, cId)
+	}
+	return models, nil
+}
+
+// GetNeighbours 指定したお酒の類似リストを取得する。未計算の場合はnil
+func (r *FlavorNeighbourRepository) GetNeighbours(ctx context.Context, lId primitive.ObjectID, cId int) (*NeighbourModel, *customError.Error) {
+	var model NeighbourModel
+	err := r.Collection.FindOne(ctx, bson.M{LiquorID: lId, CategoryID: cId}).Decode(&model)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, errGetNeighbours(err, lId, cId)
+	}
+	return &model, nil
+}
+
+// GetNeighboursByCategory 指定したカテゴリの類似リストを全件取得する
+func (r *FlavorNeighbourRepository) GetNeighboursByCategory(ctx context.Context, cId int) ([]*NeighbourModel, *customError.Error) {
+	cursor, err := r.Collection.Find(ctx, bson.M{CategoryID: cId})
+	if err != nil {
+		return nil, errGetNeighboursByCategory(err, cId)
+	}
+	defer cursor.Close(ctx)
+
+	var models []*NeighbourModel
+	if err = cursor.All(ctx, &models); err != nil {
+		return nil, errGetNeighboursByCategory(err, cId)
+	}
+	return models, nil
+}
+
+// SaveNeighbours 類似リストをまとめて上書きする
+func (r *FlavorNeighbourRepository) SaveNeighbours(ctx context.Context, neighbours []*NeighbourModel) *customError.Error {
+	if len(neighbours) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, len(neighbours))
+	for i, n := range neighbours {
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{LiquorID: n.LiquorID, CategoryID: n.CategoryID}).
+			SetUpdate(bson.M{"$set": n}).
+			SetUpsert(true)
+	}
+	_, err := r.Collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return errSaveNeighbours(err, len(neighbours))
+	}
+	return nil
+}
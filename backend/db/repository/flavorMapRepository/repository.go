@@ -2,23 +2,32 @@ package flavorMapRepository
 
 import (
 	"backend/db"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 const (
-	FlavorMapCollectionName          = "flavor_map"
-	FlavorMapMasterCollectionName    = "flavor_map_master"
-	FlavorMapToLiquorsCollectionNAme = "flavor_map_liquors"
+	FlavorMapCollectionName           = "flavor_map"
+	FlavorMapMasterCollectionName     = "flavor_map_master"
+	FlavorMapToLiquorsCollectionNAme  = "flavor_map_liquors"
+	FlavorMapNeighboursCollectionName = "flavor_map_neighbours"
+	FlavorMapMasterLogsCollectionName = "flavor_map_master_logs"
+	FlavorMapArchivesCollectionName   = "flavor_map_archives"
 )
 
 type FlavorMapRepository struct {
 	db.Base
+	archiveCollection *mongo.Collection //マスタの置き換えで無効になった投票の退避先
 }
 type FlavorMapMasterRepository struct {
 	db.Base
+	logsCollection *mongo.Collection
 }
 type FlavorToLiquorRepository struct {
 	db.Base
 }
+type FlavorNeighbourRepository struct {
+	db.Base
+}
 
 func NewFlavorMapMasterRepository(database *db.DB) FlavorMapMasterRepository {
 	return FlavorMapMasterRepository{
@@ -26,6 +35,7 @@ func NewFlavorMapMasterRepository(database *db.DB) FlavorMapMasterRepository {
 			Db:         database,
 			Collection: database.Collection(FlavorMapMasterCollectionName),
 		},
+		logsCollection: database.Collection(FlavorMapMasterLogsCollectionName),
 	}
 }
 
@@ -35,6 +45,7 @@ func NewFlavorMapRepository(database *db.DB) FlavorMapRepository {
 			Db:         database,
 			Collection: database.Collection(FlavorMapCollectionName),
 		},
+		archiveCollection: database.Collection(FlavorMapArchivesCollectionName),
 	}
 }
 
@@ -46,3 +57,12 @@ func NewFlavorToLiquorRepository(database *db.DB) FlavorToLiquorRepository {
 		},
 	}
 }
+
+func NewFlavorNeighbourRepository(database *db.DB) FlavorNeighbourRepository {
+	return FlavorNeighbourRepository{
+		Base: db.Base{
+			Db:         database,
+			Collection: database.Collection(FlavorMapNeighboursCollectionName),
+		},
+	}
+}
@@ -0,0 +1,135 @@
+package flavorMapRepository
+
+import (
+	"backend/graph/graphModel"
+	"math"
+	"time"
+)
+
+// WeightingModel 得票率計算の重み付けパラメータ(カテゴリごとにマスタで設定する)
+type WeightingModel struct {
+	UserWeight       float64 `bson:"user_weight"`       //登録済ユーザーの票の重み
+	GuestWeight      float64 `bson:"guest_weight"`      //ゲストの票の重み
+	ReputationWeight float64 `bson:"reputation_weight"` //レビュー投稿数による加重の係数(0で無効)
+	HalfLifeDays     float64 `bson:"half_life_days"`    //票の重みが半分になるまでの日数(0で減衰なし)
+	SmoothingSigma   float64 `bson:"smoothing_sigma"`   //隣接セルへのガウシアン平滑化の標準偏差(セル単位、0で無効)
+}
+
+const (
+	// MinHalfLifeDays 半減期の下限。前方減衰の重みは時間とともに指数的に大きくなるため、短すぎるとfloat64が溢れる
+	MinHalfLifeDays = 7.0
+	// maxDecayExponent 前方減衰の指数(2の何乗か)の上限と下限。2^±960なら2^64票を合計しても溢れず、古い票も0にはならない
+	// 半減期が下限の7日の場合、基準時刻から約18年で上限に達するので、全件再集計のたびに基準時刻を置き直す(TyingModel.DecayLandmark)
+	maxDecayExponent = 960.0
+)
+
+// decayLandmark 前方減衰(forward decay)の基準時刻の既定値。基準時刻からの経過時間に応じて新しい票ほど重くする
+// 得票率は合計値との比なので、「古い票ほど軽くする」のと結果は同じになり、かつ投票済の重みを再計算する必要がない
+var decayLandmark = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// DefaultWeighting マスタに重み付けが設定されていない場合の値(従来通り全票を同じ重みで扱う)
+func DefaultWeighting() WeightingModel {
+	return WeightingModel{
+		UserWeight:  1,
+		GuestWeight: 1,
+	}
+}
+
+// GetWeighting マスタの重み付けパラメータを取得する。不正な値は補正する
+func (m *MasterModel) GetWeighting() WeightingModel {
+	if m.Weighting == nil {
+		return DefaultWeighting()
+	}
+	w := *m.Weighting
+	if w.UserWeight < 0 {
+		w.UserWeight = 0
+	}
+	if w.GuestWeight < 0 {
+		w.GuestWeight = 0
+	}
+	if w.ReputationWeight < 0 {
+		w.ReputationWeight = 0
+	}
+	if w.HalfLifeDays < 0 {
+		w.HalfLifeDays = 0
+	}
+	if w.HalfLifeDays > 0 && w.HalfLifeDays < MinHalfLifeDays {
+		w.HalfLifeDays = MinHalfLifeDays
+	}
+	if w.SmoothingSigma < 0 {
+		w.SmoothingSigma = 0
+	}
+	return w
+}
+
+// VoteWeight 1票分の重みを計算する
+// reviewCountは投票者のレビュー投稿数(ゲストは0)、votedAtは投票日時、landmarkは集計データの減衰の基準時刻(TyingModel.Landmark)
+func (w WeightingModel) VoteWeight(isUser bool, reviewCount int, votedAt time.Time, landmark time.Time) float64 {
+	weight := w.GuestWeight
+	if isUser {
+		weight = w.UserWeight * (1 + w.ReputationWeight*math.Log1p(float64(reviewCount)))
+	}
+	if w.HalfLifeDays > 0 {
+		days := votedAt.Sub(landmark).Hours() / 24
+		exponent := math.Max(-maxDecayExponent, math.Min(days/w.HalfLifeDays, maxDecayExponent))
+		weight *= math.Exp2(exponent)
+	}
+	return weight
+}
+
+// smoothRates 得票率をガウシアンカーネルで隣接セルに広げる。合計は100のまま保つ
+func smoothRates(rates []float64, sigma float64) []float64 {
+	const size = 21
+	radius := int(math.Ceil(sigma * 3))
+	kernel := make([]float64, radius+1)
+	for d := 0; d <= radius; d++ {
+		kernel[d] = math.Exp(-float64(d*d) / (2 * sigma * sigma))
+	}
+
+	smoothed := make([]float64, len(rates))
+	var sum float64
+	for x := 0; x < size; x++ {
+		for y := 0; y < size; y++ {
+			var v float64
+			for dx := -radius; dx <= radius; dx++ {
+				nx := x + dx
+				if nx < 0 || nx >= size {
+					continue
+				}
+				for dy := -radius; dy <= radius; dy++ {
+					ny := y + dy
+					if ny < 0 || ny >= size {
+						continue
+					}
+					v += rates[nx*size+ny] * kernel[abs(dx)] * kernel[abs(dy)]
+				}
+			}
+			smoothed[x*size+y] = v
+			sum += v
+		}
+	}
+	if sum == 0 {
+		return smoothed
+	}
+	for i := range smoothed {
+		smoothed[i] = smoothed[i] / sum * 100.0
+	}
+	return smoothed
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+func (w WeightingModel) ToGraphQL() *graphModel.FlavorMapWeighting {
+	return &graphModel.FlavorMapWeighting{
+		UserWeight:       w.UserWeight,
+		GuestWeight:      w.GuestWeight,
+		ReputationWeight: w.ReputationWeight,
+		HalfLifeDays:     w.HalfLifeDays,
+		SmoothingSigma:   w.SmoothingSigma,
+	}
+}
@@ -0,0 +1,89 @@
+package identityRepository
+
+import (
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/errorMsg"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+)
+
+const (
+	GetByProviderSubject = "REPO-IDENTITY-001-GetByProviderSubject"
+	Insert               = "REPO-IDENTITY-002-Insert"
+	Touch                = "REPO-IDENTITY-003-Touch"
+	ListByUser           = "REPO-IDENTITY-004-ListByUser"
+	GetByUserProvider    = "REPO-IDENTITY-005-GetByUserProvider"
+	Delete               = "REPO-IDENTITY-006-Delete"
+	MoveToUser           = "REPO-IDENTITY-007-MoveToUser"
+)
+
+func errGetByProviderSubject(err error, provider string, subject string) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    GetByProviderSubject,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      map[string]interface{}{"provider": provider, "subject": subject},
+	})
+}
+
+func errInsert(err error, m *Model) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    Insert,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      map[string]interface{}{"provider": m.Provider, "subject": m.Subject, "userId": m.UserID},
+	})
+}
+
+func errTouch(err error, id primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    Touch,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
+
+func errListByUser(err error, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    ListByUser,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      uId,
+	})
+}
+
+func errGetByUserProvider(err error, uId primitive.ObjectID, provider string) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    GetByUserProvider,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      map[string]interface{}{"userId": uId, "provider": provider},
+	})
+}
+
+func errDelete(err error, id primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    Delete,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
+
+func errMoveToUser(err error, id primitive.ObjectID, from primitive.ObjectID, to primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    MoveToUser,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      map[string]interface{}{"id": id, "from": from, "to": to},
+	})
+}
@@ -0,0 +1,49 @@
+package identityRepository
+
+import (
+	"backend/graph/graphModel"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	CollectionName = "user_identities"
+	ID             = "_id"
+	UserID         = "user_id"
+	Provider       = "provider"
+	Subject        = "subject"
+	Name           = "name"
+	Email          = "email"
+	CreatedAt      = "created_at"
+	LastUsedAt     = "last_used_at"
+)
+
+// 連携できる外部サービス
+const (
+	ProviderX      = "x"
+	ProviderGoogle = "google"
+	ProviderLine   = "line"
+	ProviderGitHub = "github"
+)
+
+// Model 外部サービス(X・Google・LINE・GitHub)のアカウントとユーザーの紐付け
+type Model struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	UserID     primitive.ObjectID `bson:"user_id"`
+	Provider   string             `bson:"provider"`
+	Subject    string             `bson:"subject"` //プロバイダー内で一意なID(表示名などは変わりうるので使わない)
+	Name       string             `bson:"name"`
+	Email      *string            `bson:"email,omitempty"` //プロバイダーで確認済みのもののみ(ログインには使わない)
+	CreatedAt  time.Time          `bson:"created_at"`
+	LastUsedAt time.Time          `bson:"last_used_at"`
+}
+
+func (m *Model) ToGraphQL() *graphModel.LinkedIdentity {
+	return &graphModel.LinkedIdentity{
+		Provider:   m.Provider,
+		Name:       m.Name,
+		Email:      m.Email,
+		LinkedAt:   m.CreatedAt,
+		LastUsedAt: m.LastUsedAt,
+	}
+}
@@ -0,0 +1,107 @@
+package identityRepository
+
+import (
+	"backend/db"
+	"backend/middlewares/customError"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type IdentityRepository struct {
+	db         *db.DB
+	collection *mongo.Collection
+}
+
+func NewIdentityRepository(db *db.DB) IdentityRepository {
+	return IdentityRepository{
+		db:         db,
+		collection: db.Collection(CollectionName),
+	}
+}
+
+// GetByProviderSubject 存在しない場合はnilを返す
+func (r *IdentityRepository) GetByProviderSubject(ctx context.Context, provider string, subject string) (*Model, *customError.Error) {
+	var model Model
+	if err := r.collection.FindOne(ctx, bson.M{Provider: provider, Subject: subject}).Decode(&model); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, errGetByProviderSubject(err, provider, subject)
+	}
+	return &model, nil
+}
+
+// Insert 紐付けを登録し、採番したIDをモデルに設定する(同じプロバイダーのアカウントは一つのユーザーにしか紐付けられない)
+func (r *IdentityRepository) Insert(ctx context.Context, m *Model) *customError.Error {
+	m.ID = primitive.NewObjectID()
+	if _, err := r.collection.InsertOne(ctx, m); err != nil {
+		return errInsert(err, m)
+	}
+	return nil
+}
+
+// Touch ログインに使われた日時と、プロバイダー側で変わりうる情報を更新する
+func (r *IdentityRepository) Touch(ctx context.Context, id primitive.ObjectID, name string, email *string, now time.Time) *customError.Error {
+	set := bson.M{Name: name, LastUsedAt: now}
+	update := bson.M{"$set": set}
+	if email != nil {
+		set[Email] = *email
+	} else {
+		update["$unset"] = bson.M{Email: ""}
+	}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{ID: id}, update); err != nil {
+		return errTouch(err, id)
+	}
+	return nil
+}
+
+// ListByUser ユーザーに紐付いたものを、連携した順に取得する
+func (r *IdentityRepository) ListByUser(ctx context.Context, uId primitive.ObjectID) ([]*Model, *customError.Error) {
+	opts := options.Find().SetSort(bson.D{{Key: CreatedAt, Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{UserID: uId}, opts)
+	if err != nil {
+		return nil, errListByUser(err, uId)
+	}
+	defer cursor.Close(ctx)
+
+	var models []*Model
+	if err = cursor.All(ctx, &models); err != nil {
+		return nil, errListByUser(err, uId)
+	}
+	return models, nil
+}
+
+// GetByUserProvider 存在しない場合はnilを返す
+func (r *IdentityRepository) GetByUserProvider(ctx context.Context, uId primitive.ObjectID, provider string) (*Model, *customError.Error) {
+	var model Model
+	if err := r.collection.FindOne(ctx, bson.M{UserID: uId, Provider: provider}).Decode(&model); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, errGetByUserProvider(err, uId, provider)
+	}
+	return &model, nil
+}
+
+// Delete 本人の紐付けのみ削除する。削除できなかった場合はfalseを返す
+func (r *IdentityRepository) Delete(ctx context.Context, id primitive.ObjectID, uId primitive.ObjectID) (bool, *customError.Error) {
+	result, err := r.collection.DeleteOne(ctx, bson.M{ID: id, UserID: uId})
+	if err != nil {
+		return false, errDelete(err, id)
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// MoveToUser 紐付け先をfromからtoに付け替える。他のリクエストが先に変更していた場合はfalseを返す
+func (r *IdentityRepository) MoveToUser(ctx context.Context, id primitive.ObjectID, from primitive.ObjectID, to primitive.ObjectID, now time.Time) (bool, *customError.Error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{ID: id, UserID: from}, bson.M{"$set": bson.M{UserID: to, LastUsedAt: now}})
+	if err != nil {
+		return false, errMoveToUser(err, id, from, to)
+	}
+	return result.ModifiedCount > 0, nil
+}
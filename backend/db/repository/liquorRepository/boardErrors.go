@@ -7,6 +7,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"net/http"
+	"time"
 )
 
 const (
@@ -17,6 +18,12 @@ const (
 	BoardGetByUserAndLiquor = "REPO-LIQUOR-BOARD-005-BoardGetByUserAndLiquor"
 	BoardInsertGuest        = "REPO-LIQUOR-BOARD-006-BoardInsertGuest"
 	BoardUpsert             = "REPO-LIQUOR-BOARD-007-BoardUpsert"
+	BoardCountByUsers       = "REPO-LIQUOR-BOARD-008-BoardCountByUsers"
+	BoardRatesByUser        = "REPO-LIQUOR-BOARD-009-BoardRatesByUser"
+	BoardAllRates           = "REPO-LIQUOR-BOARD-010-BoardAllRates"
+	BoardGetById            = "REPO-LIQUOR-BOARD-011-BoardGetById"
+	BoardsSince             = "REPO-LIQUOR-BOARD-012-BoardsSince"
+	TrendingLiquors         = "REPO-LIQUOR-BOARD-013-TrendingLiquors"
 )
 
 func errGetList(err error, id primitive.ObjectID) *customError.Error {
@@ -87,3 +94,62 @@ func errBoardUpsert(err error, board *BoardModel) *customError.Error {
 		Input:      board,
 	})
 }
+
+func errBoardCountByUsers(err error, uIds []primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    BoardCountByUsers,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      uIds,
+	})
+}
+
+func errBoardRatesByUser(err error, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    BoardRatesByUser,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      uId,
+	})
+}
+
+func errBoardAllRates(err error) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    BoardAllRates,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+	})
+}
+
+func errBoardGetById(err error, id primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    BoardGetById,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
+
+func errBoardsSince(err error, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    BoardsSince,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      uId,
+	})
+}
+
+func errTrendingLiquors(err error, since time.Time) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    TrendingLiquors,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      since,
+	})
+}
@@ -13,6 +13,7 @@ const (
 	UserName            = "user_name"
 	Rate                = "rate"
 	Text                = "text"
+	GuestID             = "guest_id"
 )
 
 // BoardModel Collectionに挿入するデータ
@@ -23,6 +24,8 @@ type BoardModel struct {
 	Text      string              `bson:"text"`
 	Rate      *int                `bson:"rate"`
 	UpdatedAt time.Time           `bson:"updated_at"`
+	//ゲストの再投稿を判定するための訪問者情報(ログインユーザーはnil)
+	GuestId *string `bson:"guest_id,omitempty"`
 }
 
 // BoardModelWithRelation リレーション込みのモデル(実際に取得してくるデータ)
@@ -40,6 +43,12 @@ type BoardModelWithRelation struct {
 	UpdatedAt       time.Time           `bson:"updated_at"`
 }
 
+// TrendingModel 期間中に投稿が多かったお酒
+type TrendingModel struct {
+	LiquorID primitive.ObjectID `bson:"_id"`
+	Count    int                `bson:"count"`
+}
+
 // Post 各投稿の詳細
 type Post struct {
 	ID        primitive.ObjectID `bson:"_id"`        // 投稿内容
@@ -6,14 +6,107 @@ import (
 	"context"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
 )
 
 func (r *LiquorsRepository) BoardList(ctx context.Context, id primitive.ObjectID) ([]*BoardModelWithRelation, *customError.Error) {
-	// パイプラインを定義
+	pipeline := boardWithRelationPipeline(bson.M{"liquor_id": id})
+
+	// パイプラインを実行
+	cursor, err := r.boardCollection.Aggregate(ctx, pipeline)
+
+	if err != nil {
+		return nil, errGetList(err, id)
+	}
+	defer cursor.Close(ctx)
+
+	// 結果を格納するスライス
+	var boards []*BoardModelWithRelation
+
+	// 取得したドキュメントをスライスにデコード
+	if err = cursor.All(ctx, &boards); err != nil {
+		return nil, errGetListDecode(err, id)
+	}
+
+	return boards, nil
+}
+
+// BoardGetById 投稿を1件、ユーザー名とお酒の情報つきで取得する
+func (r *LiquorsRepository) BoardGetById(ctx context.Context, id primitive.ObjectID) (*BoardModelWithRelation, *customError.Error) {
+	cursor, err := r.boardCollection.Aggregate(ctx, boardWithRelationPipeline(bson.M{ID: id}))
+	if err != nil {
+		return nil, errBoardGetById(err, id)
+	}
+	defer cursor.Close(ctx)
+
+	var boards []*BoardModelWithRelation
+	if err = cursor.All(ctx, &boards); err != nil {
+		return nil, errBoardGetById(err, id)
+	}
+	if len(boards) == 0 {
+		return nil, nil
+	}
+	return boards[0], nil
+}
+
+// BoardsSince 指定したお酒への、since以降の投稿を新しい順に取得する(excludeUserの投稿は除く)
+func (r *LiquorsRepository) BoardsSince(ctx context.Context, lIds []primitive.ObjectID, since time.Time, excludeUser primitive.ObjectID, limit int) ([]*BoardModelWithRelation, *customError.Error) {
+	if len(lIds) == 0 {
+		return nil, nil
+	}
+	match := bson.M{
+		LiquorID:  bson.M{"$in": lIds},
+		UpdatedAt: bson.M{"$gte": since},
+		UserID:    bson.M{"$ne": excludeUser},
+	}
+	pipeline := boardWithRelationPipeline(match, bson.M{"$sort": bson.M{UpdatedAt: -1}}, bson.M{"$limit": limit})
+	cursor, err := r.boardCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errBoardsSince(err, excludeUser)
+	}
+	defer cursor.Close(ctx)
+
+	var boards []*BoardModelWithRelation
+	if err = cursor.All(ctx, &boards); err != nil {
+		return nil, errBoardsSince(err, excludeUser)
+	}
+	return boards, nil
+}
+
+// TrendingLiquors since以降の投稿が多いお酒を取得する
+func (r *LiquorsRepository) TrendingLiquors(ctx context.Context, since time.Time, limit int) ([]TrendingModel, *customError.Error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{UpdatedAt: bson.M{"$gte": since}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$" + LiquorID, "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	cursor, err := r.boardCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errTrendingLiquors(err, since)
+	}
+	defer cursor.Close(ctx)
+
+	var result []TrendingModel
+	if err = cursor.All(ctx, &result); err != nil {
+		return nil, errTrendingLiquors(err, since)
+	}
+	return result, nil
+}
+
+// boardWithRelationPipeline 条件に一致する投稿に、ユーザー名とお酒の情報を結合するパイプライン
+// 並び替えや件数の制限は、結合する前に行うようにafterMatchで渡す
+func boardWithRelationPipeline(match bson.M, afterMatch ...bson.M) bson.A {
 	pipeline := bson.A{
-		// 1. liquor_idに一致するドキュメントをフィルタリング
-		bson.M{"$match": bson.M{"liquor_id": id}},
+		// 1. 条件に一致するドキュメントをフィルタリング
+		bson.M{"$match": match},
+	}
+	for _, stage := range afterMatch {
+		pipeline = append(pipeline, stage)
+	}
+	return append(pipeline,
 
 		// 2. usersコレクションとuser_idで結合してuser_nameを取得
 		bson.M{"$lookup": bson.M{
@@ -51,25 +144,7 @@ func (r *LiquorsRepository) BoardList(ctx context.Context, id primitive.ObjectID
 			"text":               1,
 			"updated_at":         1,
 		}},
-	}
-
-	// パイプラインを実行
-	cursor, err := r.boardCollection.Aggregate(ctx, pipeline)
-
-	if err != nil {
-		return nil, errGetList(err, id)
-	}
-	defer cursor.Close(ctx)
-
-	// 結果を格納するスライス
-	var boards []*BoardModelWithRelation
-
-	// 取得したドキュメントをスライスにデコード
-	if err = cursor.All(ctx, &boards); err != nil {
-		return nil, errGetListDecode(err, id)
-	}
-
-	return boards, nil
+	)
 }
 
 // BoardListByUser ユーザーに紐づく掲示板投稿履歴を取得する。評価別および最近のものを取得
@@ -177,34 +252,112 @@ func (r *LiquorsRepository) BoardGetByUserAndLiquor(ctx context.Context, liquorI
 }
 
 func (r *LiquorsRepository) BoardInsert(ctx context.Context, board *BoardModel) *customError.Error {
-	// user_idが空かどうかを判定
-	if board.UserId == nil {
-		// user_idが空の場合はInsertOneを使用
-		_, err := r.boardCollection.InsertOne(ctx, board)
-		if err != nil {
-			return errBoardInsertGuest(err, board)
-		}
-		return nil
-	}
-	// フィルタ：liquorID,userIDが既に存在するか確認
+	// フィルタ：liquorID,userIDが既に存在するか確認(ゲストは署名付きの訪問者IDで確認。フィンガープリントは別人と区別できないので使わない)
 	filter := bson.M{
-		UserID:   board.UserId,
 		LiquorID: board.LiquorID,
 	}
+	if board.UserId != nil {
+		filter[UserID] = board.UserId
+	} else {
+		if board.GuestId == nil {
+			// 訪問者情報がない場合はInsertOneを使用
+			res, err := r.boardCollection.InsertOne(ctx, board)
+			if err != nil {
+				return errBoardInsertGuest(err, board)
+			}
+			board.ID = res.InsertedID.(primitive.ObjectID)
+			return nil
+		}
+		filter[UserID] = nil
+		filter[GuestID] = *board.GuestId
+	}
 
 	// 更新データ：board内のフィールドをそのまま更新
 	update := bson.M{
 		"$set": board,
 	}
 
-	// upsertオプション：ドキュメントが存在しない場合は新規挿入
-	opts := options.Update().SetUpsert(true)
+	// upsertオプション：ドキュメントが存在しない場合は新規挿入(購読者への配信用にIDを受け取る)
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After).SetProjection(bson.M{ID: 1})
 
 	// MongoDBにデータを更新または挿入（upsert）
-	_, err := r.boardCollection.UpdateOne(ctx, filter, update, opts)
+	var saved struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	err := r.boardCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&saved)
 	if err != nil {
 		return errBoardUpsert(err, board)
 	}
+	board.ID = saved.ID
 
 	return nil
 }
+
+// CountBoardsByUsers 指定したユーザーごとの掲示板投稿(レビュー)数を取得する。投稿がないユーザーはマップに含まれない
+func (r *LiquorsRepository) CountBoardsByUsers(ctx context.Context, uIds []primitive.ObjectID) (map[primitive.ObjectID]int, *customError.Error) {
+	result := make(map[primitive.ObjectID]int, len(uIds))
+	if len(uIds) == 0 {
+		return result, nil
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{UserID: bson.M{"$in": uIds}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$" + UserID, "count": bson.M{"$sum": 1}}}},
+	}
+	cursor, err := r.boardCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errBoardCountByUsers(err, uIds)
+	}
+	defer cursor.Close(ctx)
+
+	var counts []struct {
+		UserID primitive.ObjectID `bson:"_id"`
+		Count  int                `bson:"count"`
+	}
+	if err = cursor.All(ctx, &counts); err != nil {
+		return nil, errBoardCountByUsers(err, uIds)
+	}
+	for _, c := range counts {
+		result[c.UserID] = c.Count
+	}
+	return result, nil
+}
+
+// BoardRatesByUser 指定したユーザーが投稿したお酒ごとの評価を取得する(評価なしの投稿はnil)
+func (r *LiquorsRepository) BoardRatesByUser(ctx context.Context, uId primitive.ObjectID) (map[primitive.ObjectID]*int, *customError.Error) {
+	opts := options.Find().SetProjection(bson.M{LiquorID: 1, Rate: 1})
+	cursor, err := r.boardCollection.Find(ctx, bson.M{UserID: uId}, opts)
+	if err != nil {
+		return nil, errBoardRatesByUser(err, uId)
+	}
+	defer cursor.Close(ctx)
+
+	var boards []BoardModel
+	if err = cursor.All(ctx, &boards); err != nil {
+		return nil, errBoardRatesByUser(err, uId)
+	}
+	result := make(map[primitive.ObjectID]*int, len(boards))
+	for _, b := range boards {
+		result[b.LiquorID] = b.Rate
+	}
+	return result, nil
+}
+
+// AllBoardRates ログインユーザーによる評価つきの投稿を全件取得する(協調フィルタリングの集計用)
+func (r *LiquorsRepository) AllBoardRates(ctx context.Context) ([]BoardModel, *customError.Error) {
+	opts := options.Find().SetProjection(bson.M{UserID: 1, LiquorID: 1, Rate: 1})
+	cursor, err := r.boardCollection.Find(ctx, bson.M{
+		UserID: bson.M{"$ne": nil},
+		Rate:   bson.M{"$ne": nil},
+	}, opts)
+	if err != nil {
+		return nil, errBoardAllRates(err)
+	}
+	defer cursor.Close(ctx)
+
+	var boards []BoardModel
+	if err = cursor.All(ctx, &boards); err != nil {
+		return nil, errBoardAllRates(err)
+	}
+	return boards, nil
+}
@@ -0,0 +1,80 @@
+package listRepository
+
+import (
+	"backend/db"
+	"backend/middlewares/customError"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// AddBookmark リストをブックマークし、フォロワー数を加算する。既にブックマーク済の場合は何もしない
+func (r *ListRepository) AddBookmark(ctx context.Context, uId primitive.ObjectID, listId primitive.ObjectID) *customError.Error {
+	bookmark := BookmarkModel{
+		UserID:    uId,
+		ListID:    listId,
+		CreatedAt: time.Now(),
+	}
+	_, err := db.WithTransaction(ctx, r.db.Client, func(sc mongo.SessionContext) (struct{}, error) {
+		result, err := r.bookmarkCollection.UpdateOne(sc, bson.M{UserID: uId, ListID: listId}, bson.M{"$setOnInsert": bookmark}, options.Update().SetUpsert(true))
+		if err != nil || result.UpsertedCount == 0 {
+			return struct{}{}, err
+		}
+		_, err = r.collection.UpdateOne(sc, bson.M{ID: listId}, bson.M{"$inc": bson.M{FollowerCount: 1}})
+		return struct{}{}, err
+	})
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return errAddBookmark(err, uId, listId)
+	}
+	return nil
+}
+
+// RemoveBookmark ブックマークを外し、フォロワー数を減算する。ブックマークしていない場合は何もしない
+func (r *ListRepository) RemoveBookmark(ctx context.Context, uId primitive.ObjectID, listId primitive.ObjectID) *customError.Error {
+	_, err := db.WithTransaction(ctx, r.db.Client, func(sc mongo.SessionContext) (struct{}, error) {
+		result, err := r.bookmarkCollection.DeleteOne(sc, bson.M{UserID: uId, ListID: listId})
+		if err != nil || result.DeletedCount == 0 {
+			return struct{}{}, err
+		}
+		_, err = r.collection.UpdateOne(sc, bson.M{ID: listId}, bson.M{"$inc": bson.M{FollowerCount: -1}})
+		return struct{}{}, err
+	})
+	if err != nil {
+		return errRemoveBookmark(err, uId, listId)
+	}
+	return nil
+}
+
+func (r *ListRepository) IsBookmarked(ctx context.Context, uId primitive.ObjectID, listId primitive.ObjectID) (bool, *customError.Error) {
+	err := r.bookmarkCollection.FindOne(ctx, bson.M{UserID: uId, ListID: listId}).Err()
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return false, nil
+		}
+		return false, errIsBookmarked(err, uId, listId)
+	}
+	return true, nil
+}
+
+// BookmarkedListIds ブックマークしたリストのIDを新しい順に取得する
+func (r *ListRepository) BookmarkedListIds(ctx context.Context, uId primitive.ObjectID) ([]primitive.ObjectID, *customError.Error) {
+	cursor, err := r.bookmarkCollection.Find(ctx, bson.M{UserID: uId}, options.Find().SetSort(bson.D{{Key: CreatedAt, Value: -1}}))
+	if err != nil {
+		return nil, errBookmarkedListIds(err, uId)
+	}
+	defer cursor.Close(ctx)
+
+	var models []*BookmarkModel
+	if err = cursor.All(ctx, &models); err != nil {
+		return nil, errBookmarkedListIds(err, uId)
+	}
+	ids := make([]primitive.ObjectID, len(models))
+	for i, m := range models {
+		ids[i] = m.ListID
+	}
+	return ids, nil
+}
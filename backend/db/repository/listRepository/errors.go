@@ -0,0 +1,155 @@
+package listRepository
+
+import (
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/errorMsg"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+)
+
+const (
+	InsertList          = "REPO-LIST-001-InsertList"
+	UpdateList          = "REPO-LIST-002-UpdateList"
+	DeleteList          = "REPO-LIST-003-DeleteList"
+	GetList             = "REPO-LIST-004-GetList"
+	GetListsByIds       = "REPO-LIST-005-GetListsByIds"
+	ListsByUser         = "REPO-LIST-006-ListsByUser"
+	PublicLists         = "REPO-LIST-007-PublicLists"
+	CountPublicLists    = "REPO-LIST-008-CountPublicLists"
+	PublicListsByLiquor = "REPO-LIST-009-PublicListsByLiquor"
+	AddBookmark         = "REPO-LIST-010-AddBookmark"
+	RemoveBookmark      = "REPO-LIST-011-RemoveBookmark"
+	IsBookmarked        = "REPO-LIST-012-IsBookmarked"
+	BookmarkedListIds   = "REPO-LIST-013-BookmarkedListIds"
+)
+
+func errInsertList(err error, list *ListModel) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    InsertList,
+		UserMsg:    "リストの保存に失敗しました",
+		Level:      logrus.ErrorLevel,
+		Input:      list,
+	})
+}
+
+func errUpdateList(err error, list *ListModel) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    UpdateList,
+		UserMsg:    "リストの更新に失敗しました",
+		Level:      logrus.ErrorLevel,
+		Input:      list,
+	})
+}
+
+func errDeleteList(err error, id primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    DeleteList,
+		UserMsg:    "リストの削除に失敗しました",
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
+
+func errGetList(err error, id primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    GetList,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
+
+func errGetListsByIds(err error, ids []primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    GetListsByIds,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      ids,
+	})
+}
+
+func errListsByUser(err error, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    ListsByUser,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      uId,
+	})
+}
+
+func errPublicLists(err error, skip int64) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    PublicLists,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      skip,
+	})
+}
+
+func errCountPublicLists(err error) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    CountPublicLists,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+	})
+}
+
+func errPublicListsByLiquor(err error, lId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    PublicListsByLiquor,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      lId,
+	})
+}
+
+func errAddBookmark(err error, uId primitive.ObjectID, listId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    AddBookmark,
+		UserMsg:    "ブックマーク追加に失敗しました",
+		Level:      logrus.ErrorLevel,
+		Input:      fmt.Sprintf("{uid:%v,listId:%v}", uId, listId),
+	})
+}
+
+func errRemoveBookmark(err error, uId primitive.ObjectID, listId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    RemoveBookmark,
+		UserMsg:    "ブックマーク削除に失敗しました",
+		Level:      logrus.ErrorLevel,
+		Input:      fmt.Sprintf("{uid:%v,listId:%v}", uId, listId),
+	})
+}
+
+func errIsBookmarked(err error, uId primitive.ObjectID, listId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    IsBookmarked,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      fmt.Sprintf("{uid:%v,listId:%v}", uId, listId),
+	})
+}
+
+func errBookmarkedListIds(err error, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    BookmarkedListIds,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      uId,
+	})
+}
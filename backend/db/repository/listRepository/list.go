@@ -0,0 +1,157 @@
+package listRepository
+
+import (
+	"backend/db"
+	"backend/middlewares/customError"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// InsertList リストを登録し、採番したIDをモデルに設定する
+func (r *ListRepository) InsertList(ctx context.Context, list *ListModel) *customError.Error {
+	result, err := r.collection.InsertOne(ctx, list)
+	if err != nil {
+		return errInsertList(err, list)
+	}
+	list.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// UpdateList 作成者本人のリストを更新する。対象が存在しない場合はfalse
+func (r *ListRepository) UpdateList(ctx context.Context, list *ListModel) (bool, *customError.Error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{ID: list.ID, UserID: list.UserID}, bson.M{"$set": bson.M{
+		Title:       list.Title,
+		Description: list.Description,
+		IsPublic:    list.IsPublic,
+		Entries:     list.Entries,
+		UpdatedAt:   list.UpdatedAt,
+	}})
+	if err != nil {
+		return false, errUpdateList(err, list)
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// DeleteList 作成者本人のリストを、ブックマークごと削除する。対象が存在しない場合はfalse
+func (r *ListRepository) DeleteList(ctx context.Context, uId primitive.ObjectID, id primitive.ObjectID) (bool, *customError.Error) {
+	deleted, err := db.WithTransaction(ctx, r.db.Client, func(sc mongo.SessionContext) (bool, error) {
+		result, err := r.collection.DeleteOne(sc, bson.M{ID: id, UserID: uId})
+		if err != nil || result.DeletedCount == 0 {
+			return false, err
+		}
+		if _, err = r.bookmarkCollection.DeleteMany(sc, bson.M{ListID: id}); err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+	if err != nil {
+		return false, errDeleteList(err, id)
+	}
+	return deleted, nil
+}
+
+// GetList リストを1件取得する。存在しない場合はnil
+func (r *ListRepository) GetList(ctx context.Context, id primitive.ObjectID) (*ListWithOwnerModel, *customError.Error) {
+	lists, err := r.findWithOwner(ctx, bson.M{ID: id}, nil, 0, 1)
+	if err != nil {
+		return nil, errGetList(err, id)
+	}
+	if len(lists) == 0 {
+		return nil, nil
+	}
+	return lists[0], nil
+}
+
+// GetListsByIds 指定したIDのリストを取得する(並び順は保証しない)
+func (r *ListRepository) GetListsByIds(ctx context.Context, ids []primitive.ObjectID) ([]*ListWithOwnerModel, *customError.Error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	lists, err := r.findWithOwner(ctx, bson.M{ID: bson.M{"$in": ids}}, nil, 0, 0)
+	if err != nil {
+		return nil, errGetListsByIds(err, ids)
+	}
+	return lists, nil
+}
+
+// ListsByUser ユーザーが作成したリストを更新の新しい順に取得する。publicOnlyの場合は非公開のリストを含めない
+func (r *ListRepository) ListsByUser(ctx context.Context, uId primitive.ObjectID, publicOnly bool) ([]*ListWithOwnerModel, *customError.Error) {
+	match := bson.M{UserID: uId}
+	if publicOnly {
+		match[IsPublic] = true
+	}
+	lists, err := r.findWithOwner(ctx, match, bson.D{{Key: UpdatedAt, Value: -1}}, 0, 0)
+	if err != nil {
+		return nil, errListsByUser(err, uId)
+	}
+	return lists, nil
+}
+
+// PublicLists 公開されているリストをフォロワーの多い順に取得する
+func (r *ListRepository) PublicLists(ctx context.Context, skip int64, limit int64) ([]*ListWithOwnerModel, *customError.Error) {
+	lists, err := r.findWithOwner(ctx, bson.M{IsPublic: true}, popularSort(), skip, limit)
+	if err != nil {
+		return nil, errPublicLists(err, skip)
+	}
+	return lists, nil
+}
+
+func (r *ListRepository) CountPublicLists(ctx context.Context) (int, *customError.Error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{IsPublic: true})
+	if err != nil {
+		return 0, errCountPublicLists(err)
+	}
+	return int(count), nil
+}
+
+// PublicListsByLiquor 指定したお酒を含む公開リストをフォロワーの多い順に取得する
+func (r *ListRepository) PublicListsByLiquor(ctx context.Context, lId primitive.ObjectID, limit int64) ([]*ListWithOwnerModel, *customError.Error) {
+	lists, err := r.findWithOwner(ctx, bson.M{IsPublic: true, EntryLiquorID: lId}, popularSort(), 0, limit)
+	if err != nil {
+		return nil, errPublicListsByLiquor(err, lId)
+	}
+	return lists, nil
+}
+
+func popularSort() bson.D {
+	return bson.D{{Key: FollowerCount, Value: -1}, {Key: UpdatedAt, Value: -1}, {Key: ID, Value: -1}}
+}
+
+// findWithOwner usersコレクションと結合して作成者名をつけたリストを取得する。limitが0の場合は全件
+func (r *ListRepository) findWithOwner(ctx context.Context, match bson.M, sort bson.D, skip int64, limit int64) ([]*ListWithOwnerModel, error) {
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: match}}}
+	if sort != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$sort", Value: sort}})
+	}
+	if skip > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$skip", Value: skip}})
+	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: limit}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$lookup", Value: bson.M{
+			"from":         "users",
+			"localField":   UserID,
+			"foreignField": "_id",
+			"as":           "user_info",
+		}}},
+		bson.D{{Key: "$unwind", Value: bson.M{"path": "$user_info", "preserveNullAndEmptyArrays": true}}},
+		bson.D{{Key: "$set", Value: bson.M{"user_name": "$user_info.name"}}},
+		bson.D{{Key: "$unset", Value: "user_info"}},
+	)
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var lists []*ListWithOwnerModel
+	if err = cursor.All(ctx, &lists); err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
@@ -0,0 +1,57 @@
+package listRepository
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	CollectionName         = "liquor_lists"
+	BookmarkCollectionName = "liquor_list_bookmarks"
+	ID                     = "_id"
+	UserID                 = "user_id"
+	ListID                 = "list_id"
+	Title                  = "title"
+	Description            = "description"
+	IsPublic               = "is_public"
+	Entries                = "entries"
+	EntryLiquorID          = "entries.liquor_id"
+	ForkedFrom             = "forked_from"
+	FollowerCount          = "follower_count"
+	CreatedAt              = "created_at"
+	UpdatedAt              = "updated_at"
+)
+
+// ListModel ユーザーが作成したお酒のリスト
+type ListModel struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty"`
+	UserID        primitive.ObjectID  `bson:"user_id"`
+	Title         string              `bson:"title"`
+	Description   *string             `bson:"description"`
+	IsPublic      bool                `bson:"is_public"`
+	Entries       []EntryModel        `bson:"entries"`     //表示順
+	ForkedFrom    *primitive.ObjectID `bson:"forked_from"` //コピー元のリスト
+	FollowerCount int                 `bson:"follower_count"`
+	CreatedAt     time.Time           `bson:"created_at"`
+	UpdatedAt     time.Time           `bson:"updated_at"`
+}
+
+// EntryModel リストの1件
+type EntryModel struct {
+	LiquorID primitive.ObjectID `bson:"liquor_id"`
+	Comment  *string            `bson:"comment"`
+}
+
+// ListWithOwnerModel 作成者の情報込みのリスト(実際に取得してくるデータ)
+type ListWithOwnerModel struct {
+	ListModel `bson:",inline"`
+	UserName  string `bson:"user_name"`
+}
+
+// BookmarkModel 他のユーザーのリストのブックマーク
+type BookmarkModel struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	ListID    primitive.ObjectID `bson:"list_id"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
@@ -0,0 +1,20 @@
+package listRepository
+
+import (
+	"backend/db"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ListRepository struct {
+	db                 *db.DB
+	collection         *mongo.Collection
+	bookmarkCollection *mongo.Collection
+}
+
+func NewListRepository(db *db.DB) ListRepository {
+	return ListRepository{
+		db:                 db,
+		collection:         db.Collection(CollectionName),
+		bookmarkCollection: db.Collection(BookmarkCollectionName),
+	}
+}
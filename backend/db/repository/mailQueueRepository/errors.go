@@ -0,0 +1,55 @@
+package mailQueueRepository
+
+import (
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/errorMsg"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+)
+
+const (
+	Insert     = "REPO-MAIL-QUEUE-001-Insert"
+	ClaimDue   = "REPO-MAIL-QUEUE-002-ClaimDue"
+	Delete     = "REPO-MAIL-QUEUE-003-Delete"
+	Reschedule = "REPO-MAIL-QUEUE-004-Reschedule"
+)
+
+func errInsert(err error, m *Model) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    Insert,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      map[string]interface{}{"to": m.Message.To, "subject": m.Message.Subject},
+	})
+}
+
+func errClaimDue(err error) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    ClaimDue,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+	})
+}
+
+func errDelete(err error, id primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    Delete,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
+
+func errReschedule(err error, m *Model) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    Reschedule,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      map[string]interface{}{"id": m.ID, "attempts": m.Attempts, "status": m.Status},
+	})
+}
@@ -0,0 +1,33 @@
+package mailQueueRepository
+
+import (
+	"backend/util/mailer"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	CollectionName = "mail_queue"
+	ID             = "_id"
+	Status         = "status"
+	Attempts       = "attempts"
+	NextAttemptAt  = "next_attempt_at"
+	LastError      = "last_error"
+)
+
+// 再送の状態
+const (
+	StatusPending = "PENDING" //再送待ち
+	StatusFailed  = "FAILED"  //再送を諦めた(調査用に残しておく)
+)
+
+// Model 送信に失敗し、再送を待っているメール
+type Model struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"`
+	Message       mailer.Message     `bson:"message"`
+	Status        string             `bson:"status"`
+	Attempts      int                `bson:"attempts"`        //送信を試みた回数(最初の送信を含む)
+	NextAttemptAt time.Time          `bson:"next_attempt_at"` //再送中は他のインスタンスが取らないよう、少し先の日時にしておく
+	LastError     string             `bson:"last_error"`
+	CreatedAt     time.Time          `bson:"created_at"`
+}
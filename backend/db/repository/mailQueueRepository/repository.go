@@ -0,0 +1,76 @@
+package mailQueueRepository
+
+import (
+	"backend/db"
+	"backend/middlewares/customError"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type MailQueueRepository struct {
+	db         *db.DB
+	collection *mongo.Collection
+}
+
+func NewMailQueueRepository(db *db.DB) MailQueueRepository {
+	return MailQueueRepository{
+		db:         db,
+		collection: db.Collection(CollectionName),
+	}
+}
+
+// Insert 再送するメールを登録する
+func (r *MailQueueRepository) Insert(ctx context.Context, m *Model) *customError.Error {
+	m.ID = primitive.NewObjectID()
+	if _, err := r.collection.InsertOne(ctx, m); err != nil {
+		return errInsert(err, m)
+	}
+	return nil
+}
+
+// ClaimDue 再送日時を過ぎたメールを1件取り出す。取り出したメールは次の再送日時をleaseだけ先にずらし、他のインスタンスが同時に送らないようにする
+// 再送日時を過ぎたメールがない場合はnilを返す
+func (r *MailQueueRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*Model, *customError.Error) {
+	filter := bson.M{Status: StatusPending, NextAttemptAt: bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{NextAttemptAt: now.Add(lease)}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: NextAttemptAt, Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var model Model
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&model)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, errClaimDue(err)
+	}
+	return &model, nil
+}
+
+// Delete 送信できたメールを削除する
+func (r *MailQueueRepository) Delete(ctx context.Context, id primitive.ObjectID) *customError.Error {
+	if _, err := r.collection.DeleteOne(ctx, bson.M{ID: id}); err != nil {
+		return errDelete(err, id)
+	}
+	return nil
+}
+
+// Reschedule 再送に失敗したメールの試行回数・状態・次の再送日時を更新する
+func (r *MailQueueRepository) Reschedule(ctx context.Context, m *Model) *customError.Error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{ID: m.ID}, bson.M{"$set": bson.M{
+		Status:        m.Status,
+		Attempts:      m.Attempts,
+		NextAttemptAt: m.NextAttemptAt,
+		LastError:     m.LastError,
+	}})
+	if err != nil {
+		return errReschedule(err, m)
+	}
+	return nil
+}
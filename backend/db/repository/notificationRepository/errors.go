@@ -0,0 +1,89 @@
+package notificationRepository
+
+import (
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/errorMsg"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+)
+
+const (
+	Insert         = "REPO-NOTIFICATION-001-Insert"
+	List           = "REPO-NOTIFICATION-002-List"
+	CountUnread    = "REPO-NOTIFICATION-003-CountUnread"
+	MarkRead       = "REPO-NOTIFICATION-004-MarkRead"
+	GetPreference  = "REPO-NOTIFICATION-005-GetPreference"
+	SavePreference = "REPO-NOTIFICATION-006-SavePreference"
+	GetById        = "REPO-NOTIFICATION-007-GetById"
+)
+
+func errInsert(err error, m *Model) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    Insert,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      m,
+	})
+}
+
+func errList(err error, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    List,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      uId,
+	})
+}
+
+func errCountUnread(err error, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    CountUnread,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      uId,
+	})
+}
+
+func errMarkRead(err error, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    MarkRead,
+		UserMsg:    "既読にできませんでした",
+		Level:      logrus.ErrorLevel,
+		Input:      uId,
+	})
+}
+
+func errGetPreference(err error, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    GetPreference,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      uId,
+	})
+}
+
+func errSavePreference(err error, p *PreferenceModel) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    SavePreference,
+		UserMsg:    "通知設定の保存に失敗しました",
+		Level:      logrus.ErrorLevel,
+		Input:      p,
+	})
+}
+
+func errGetById(err error, id primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    GetById,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
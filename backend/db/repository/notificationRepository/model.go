@@ -0,0 +1,64 @@
+package notificationRepository
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	CollectionName           = "notifications"
+	PreferenceCollectionName = "notification_preferences"
+	ID                       = "_id"
+	UserID                   = "user_id"
+	Read                     = "read"
+	MutedTypes               = "muted_types"
+)
+
+// 通知の種類
+const (
+	TypeBookmarked   = "BOOKMARKED"    //ブックマークされた
+	TypeBoardPosted  = "BOARD_POSTED"  //自分が登録したお酒に掲示板の投稿・評価があった
+	TypeLiquorEdited = "LIQUOR_EDITED" //自分が登録したお酒が編集された
+	TypeTagAdded     = "TAG_ADDED"     //自分が登録したお酒にタグが追加された
+)
+
+// Types 通知の種類の一覧(設定画面の表示順)
+var Types = []string{TypeBookmarked, TypeBoardPosted, TypeLiquorEdited, TypeTagAdded}
+
+// Model 通知
+type Model struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty"` //生成順に並ぶので、ページングのカーソルに使う
+	UserID    primitive.ObjectID  `bson:"user_id"`       //通知を受け取るユーザー
+	Type      string              `bson:"type"`
+	ActorID   *primitive.ObjectID `bson:"actor_id"` //ゲストの場合はnil
+	LiquorID  *primitive.ObjectID `bson:"liquor_id,omitempty"`
+	Text      *string             `bson:"text,omitempty"` //掲示板の本文・タグ
+	Rate      *int                `bson:"rate,omitempty"`
+	Read      bool                `bson:"read"`
+	CreatedAt time.Time           `bson:"created_at"`
+}
+
+// WithActorModel 通知を発生させたユーザーの情報込みの通知(実際に取得してくるデータ)
+type WithActorModel struct {
+	Model     `bson:",inline"`
+	ActorName *string `bson:"actor_name"`
+}
+
+// PreferenceModel 通知の受け取り設定。受け取らない種類のみ保存する(未設定の場合はすべて受け取る)
+type PreferenceModel struct {
+	UserID     primitive.ObjectID `bson:"user_id"`
+	MutedTypes []string           `bson:"muted_types"`
+}
+
+// IsMuted 指定した種類の通知を受け取らない設定かどうか
+func (p *PreferenceModel) IsMuted(notificationType string) bool {
+	if p == nil {
+		return false
+	}
+	for _, t := range p.MutedTypes {
+		if t == notificationType {
+			return true
+		}
+	}
+	return false
+}
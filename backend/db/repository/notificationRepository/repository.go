@@ -0,0 +1,136 @@
+package notificationRepository
+
+import (
+	"backend/db"
+	"backend/middlewares/customError"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type NotificationRepository struct {
+	db                   *db.DB
+	collection           *mongo.Collection
+	preferenceCollection *mongo.Collection
+}
+
+func NewNotificationRepository(db *db.DB) NotificationRepository {
+	return NotificationRepository{
+		db:                   db,
+		collection:           db.Collection(CollectionName),
+		preferenceCollection: db.Collection(PreferenceCollectionName),
+	}
+}
+
+// Insert 通知を登録し、採番したIDをモデルに設定する
+func (r *NotificationRepository) Insert(ctx context.Context, m *Model) *customError.Error {
+	m.ID = primitive.NewObjectID()
+	if _, err := r.collection.InsertOne(ctx, m); err != nil {
+		return errInsert(err, m)
+	}
+	return nil
+}
+
+// List 通知を新しい順に取得する。afterを指定した場合はそれより古いものを取得する
+func (r *NotificationRepository) List(ctx context.Context, uId primitive.ObjectID, after *primitive.ObjectID, limit int64) ([]*WithActorModel, *customError.Error) {
+	match := bson.M{UserID: uId}
+	if after != nil {
+		match[ID] = bson.M{"$lt": *after}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$sort", Value: bson.D{{Key: ID, Value: -1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	pipeline = append(pipeline, actorLookup()...)
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errList(err, uId)
+	}
+	defer cursor.Close(ctx)
+
+	var models []*WithActorModel
+	if err = cursor.All(ctx, &models); err != nil {
+		return nil, errList(err, uId)
+	}
+	return models, nil
+}
+
+// GetById 通知を1件、行動したユーザーの名前つきで取得する。存在しない場合はnilを返す
+func (r *NotificationRepository) GetById(ctx context.Context, id primitive.ObjectID) (*WithActorModel, *customError.Error) {
+	pipeline := append(mongo.Pipeline{{{Key: "$match", Value: bson.M{ID: id}}}}, actorLookup()...)
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errGetById(err, id)
+	}
+	defer cursor.Close(ctx)
+
+	var models []*WithActorModel
+	if err = cursor.All(ctx, &models); err != nil {
+		return nil, errGetById(err, id)
+	}
+	if len(models) == 0 {
+		return nil, nil
+	}
+	return models[0], nil
+}
+
+// actorLookup 行動したユーザーの名前を結合するステージ
+func actorLookup() mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "users",
+			"localField":   "actor_id",
+			"foreignField": "_id",
+			"as":           "actor_info",
+		}}},
+		{{Key: "$unwind", Value: bson.M{"path": "$actor_info", "preserveNullAndEmptyArrays": true}}},
+		{{Key: "$set", Value: bson.M{"actor_name": "$actor_info.name"}}},
+		{{Key: "$unset", Value: "actor_info"}},
+	}
+}
+
+func (r *NotificationRepository) CountUnread(ctx context.Context, uId primitive.ObjectID) (int, *customError.Error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{UserID: uId, Read: false})
+	if err != nil {
+		return 0, errCountUnread(err, uId)
+	}
+	return int(count), nil
+}
+
+// MarkRead 通知を既読にし、既読にした件数を返す。idsがnilの場合は未読をすべて既読にする
+func (r *NotificationRepository) MarkRead(ctx context.Context, uId primitive.ObjectID, ids []primitive.ObjectID) (int, *customError.Error) {
+	filter := bson.M{UserID: uId, Read: false}
+	if ids != nil {
+		filter[ID] = bson.M{"$in": ids}
+	}
+	result, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{Read: true}})
+	if err != nil {
+		return 0, errMarkRead(err, uId)
+	}
+	return int(result.ModifiedCount), nil
+}
+
+// GetPreference 通知の受け取り設定を取得する。未設定の場合はnil
+func (r *NotificationRepository) GetPreference(ctx context.Context, uId primitive.ObjectID) (*PreferenceModel, *customError.Error) {
+	var model PreferenceModel
+	err := r.preferenceCollection.FindOne(ctx, bson.M{UserID: uId}).Decode(&model)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, errGetPreference(err, uId)
+	}
+	return &model, nil
+}
+
+func (r *NotificationRepository) SavePreference(ctx context.Context, p *PreferenceModel) *customError.Error {
+	_, err := r.preferenceCollection.UpdateOne(ctx, bson.M{UserID: p.UserID}, bson.M{"$set": bson.M{MutedTypes: p.MutedTypes}}, options.Update().SetUpsert(true))
+	if err != nil {
+		return errSavePreference(err, p)
+	}
+	return nil
+}
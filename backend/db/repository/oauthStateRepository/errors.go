@@ -0,0 +1,33 @@
+package oauthStateRepository
+
+import (
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/errorMsg"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+const (
+	Insert  = "REPO-OAUTH-STATE-001-Insert"
+	Consume = "REPO-OAUTH-STATE-002-Consume"
+)
+
+func errInsert(err error, m *Model) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    Insert,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      m.Provider,
+	})
+}
+
+func errConsume(err error, provider string) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    Consume,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      provider,
+	})
+}
@@ -0,0 +1,26 @@
+package oauthStateRepository
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	CollectionName = "oauth_states"
+	ID             = "_id"
+	Provider       = "provider"
+	Verifier       = "verifier"
+	UserID         = "user_id"
+	CreatedAt      = "created_at"
+	ExpiresAt      = "expires_at"
+)
+
+// Model 認可リクエストごとのstateとPKCEのcode_verifier(コールバックで一度だけ取り出す)
+type Model struct {
+	ID        string              `bson:"_id"` //state
+	Provider  string              `bson:"provider"`
+	Verifier  string              `bson:"verifier"`
+	UserID    *primitive.ObjectID `bson:"user_id,omitempty"` //ログイン中のユーザーが連携を始めた場合のみ
+	CreatedAt time.Time           `bson:"created_at"`
+	ExpiresAt time.Time           `bson:"expires_at"` //TTLインデックスで期限切れ後に削除される
+}
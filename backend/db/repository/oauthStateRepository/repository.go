@@ -0,0 +1,45 @@
+package oauthStateRepository
+
+import (
+	"backend/db"
+	"backend/middlewares/customError"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+type OAuthStateRepository struct {
+	db         *db.DB
+	collection *mongo.Collection
+}
+
+func NewOAuthStateRepository(db *db.DB) OAuthStateRepository {
+	return OAuthStateRepository{
+		db:         db,
+		collection: db.Collection(CollectionName),
+	}
+}
+
+// Insert stateを登録する
+func (r *OAuthStateRepository) Insert(ctx context.Context, m *Model) *customError.Error {
+	if _, err := r.collection.InsertOne(ctx, m); err != nil {
+		return errInsert(err, m)
+	}
+	return nil
+}
+
+// Consume stateを取り出して削除する(同じstateは二度使えない)
+// 存在しない場合や期限切れ、別のプロバイダーのものの場合はnilを返す
+func (r *OAuthStateRepository) Consume(ctx context.Context, state string, provider string, now time.Time) (*Model, *customError.Error) {
+	var model Model
+	filter := bson.M{ID: state, Provider: provider, ExpiresAt: bson.M{"$gt": now}}
+	if err := r.collection.FindOneAndDelete(ctx, filter).Decode(&model); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, errConsume(err, provider)
+	}
+	return &model, nil
+}
@@ -0,0 +1,42 @@
+package sessionRepository
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	CollectionName  = "sessions"
+	ID              = "_id"
+	UserID          = "user_id"
+	TokenID         = "token_id"
+	PreviousTokenID = "previous_token_id"
+	RotatedAt       = "rotated_at"
+	UserAgent       = "user_agent"
+	IP              = "ip"
+	LastUsedAt      = "last_used_at"
+	ExpiresAt       = "expires_at"
+	RevokedAt       = "revoked_at"
+	Provider        = "provider"
+)
+
+// Model ログイン中の端末ごとのセッション(リフレッシュトークン1系統に対応する)
+type Model struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty"`
+	UserID          primitive.ObjectID `bson:"user_id"`
+	TokenID         string             `bson:"token_id"`                    //現在有効なリフレッシュトークンのjti(ローテーションのたびに変わる)
+	PreviousTokenID string             `bson:"previous_token_id,omitempty"` //直前のjti(同時に届いたリフレッシュを再利用と誤判定しないために使う)
+	RotatedAt       *time.Time         `bson:"rotated_at,omitempty"`
+	UserAgent       string             `bson:"user_agent"`
+	IP              string             `bson:"ip"`
+	Provider        string             `bson:"provider,omitempty"` //ログインに使った外部サービス(パスワードの場合は空)
+	CreatedAt       time.Time          `bson:"created_at"`
+	LastUsedAt      time.Time          `bson:"last_used_at"`
+	ExpiresAt       time.Time          `bson:"expires_at"` //TTLインデックスで期限切れ後に削除される
+	RevokedAt       *time.Time         `bson:"revoked_at,omitempty"`
+}
+
+// IsActive 失効しておらず、期限内かどうか
+func (m *Model) IsActive(now time.Time) bool {
+	return m.RevokedAt == nil && now.Before(m.ExpiresAt)
+}
@@ -0,0 +1,126 @@
+package sessionRepository
+
+import (
+	"backend/db"
+	"backend/middlewares/customError"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type SessionRepository struct {
+	db         *db.DB
+	collection *mongo.Collection
+}
+
+func NewSessionRepository(db *db.DB) SessionRepository {
+	return SessionRepository{
+		db:         db,
+		collection: db.Collection(CollectionName),
+	}
+}
+
+// Insert セッションを登録し、採番したIDをモデルに設定する
+func (r *SessionRepository) Insert(ctx context.Context, m *Model) *customError.Error {
+	m.ID = primitive.NewObjectID()
+	if _, err := r.collection.InsertOne(ctx, m); err != nil {
+		return errInsert(err, m)
+	}
+	return nil
+}
+
+// GetById 存在しない場合はnilを返す
+func (r *SessionRepository) GetById(ctx context.Context, id primitive.ObjectID) (*Model, *customError.Error) {
+	var model Model
+	if err := r.collection.FindOne(ctx, bson.M{ID: id}).Decode(&model); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, errGetById(err, id)
+	}
+	return &model, nil
+}
+
+// Rotate 現在のjtiがtokenIdの場合のみ、新しいjtiに差し替えて最終利用日時などを更新する
+// 他のリクエストが先に差し替えていた場合や失効済みの場合はfalseを返す
+func (r *SessionRepository) Rotate(ctx context.Context, id primitive.ObjectID, tokenId string, newTokenId string, userAgent string, ip string, now time.Time) (bool, *customError.Error) {
+	filter := bson.M{ID: id, TokenID: tokenId, RevokedAt: bson.M{"$exists": false}, ExpiresAt: bson.M{"$gt": now}}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		TokenID:         newTokenId,
+		PreviousTokenID: tokenId,
+		RotatedAt:       now,
+		UserAgent:       userAgent,
+		IP:              ip,
+		LastUsedAt:      now,
+	}})
+	if err != nil {
+		return false, errRotate(err, id)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// ListActive ユーザーの有効なセッションを最近使った順に取得する
+func (r *SessionRepository) ListActive(ctx context.Context, uId primitive.ObjectID, now time.Time) ([]*Model, *customError.Error) {
+	filter := bson.M{UserID: uId, RevokedAt: bson.M{"$exists": false}, ExpiresAt: bson.M{"$gt": now}}
+	opts := options.Find().SetSort(bson.D{{Key: LastUsedAt, Value: -1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errListActive(err, uId)
+	}
+	defer cursor.Close(ctx)
+
+	var models []*Model
+	if err = cursor.All(ctx, &models); err != nil {
+		return nil, errListActive(err, uId)
+	}
+	return models, nil
+}
+
+// Revoke セッションを失効させる(調査用に、期限が来るまでは残しておく)
+func (r *SessionRepository) Revoke(ctx context.Context, id primitive.ObjectID, now time.Time) *customError.Error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{ID: id, RevokedAt: bson.M{"$exists": false}}, bson.M{"$set": bson.M{RevokedAt: now}})
+	if err != nil {
+		return errRevoke(err, id)
+	}
+	return nil
+}
+
+// RevokeByOwner ユーザー本人のセッションのみ失効させる。対象がない場合はfalseを返す
+func (r *SessionRepository) RevokeByOwner(ctx context.Context, id primitive.ObjectID, uId primitive.ObjectID, now time.Time) (bool, *customError.Error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{ID: id, UserID: uId, RevokedAt: bson.M{"$exists": false}}, bson.M{"$set": bson.M{RevokedAt: now}})
+	if err != nil {
+		return false, errRevokeByOwner(err, id, uId)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// RevokeAll ユーザーのセッションをすべて失効させ、失効させた件数を返す
+func (r *SessionRepository) RevokeAll(ctx context.Context, uId primitive.ObjectID, now time.Time) (int, *customError.Error) {
+	result, err := r.collection.UpdateMany(ctx, bson.M{UserID: uId, RevokedAt: bson.M{"$exists": false}}, bson.M{"$set": bson.M{RevokedAt: now}})
+	if err != nil {
+		return 0, errRevokeAll(err, uId)
+	}
+	return int(result.ModifiedCount), nil
+}
+
+// RevokeOthers 指定したセッション以外のユーザーのセッションをすべて失効させ、失効させた件数を返す
+func (r *SessionRepository) RevokeOthers(ctx context.Context, uId primitive.ObjectID, keepId primitive.ObjectID, now time.Time) (int, *customError.Error) {
+	result, err := r.collection.UpdateMany(ctx, bson.M{UserID: uId, ID: bson.M{"$ne": keepId}, RevokedAt: bson.M{"$exists": false}}, bson.M{"$set": bson.M{RevokedAt: now}})
+	if err != nil {
+		return 0, errRevokeOthers(err, uId, keepId)
+	}
+	return int(result.ModifiedCount), nil
+}
+
+// RevokeByProvider 指定した外部サービスでログインしたユーザーのセッションを失効させ、失効させた件数を返す
+func (r *SessionRepository) RevokeByProvider(ctx context.Context, uId primitive.ObjectID, provider string, now time.Time) (int, *customError.Error) {
+	result, err := r.collection.UpdateMany(ctx, bson.M{UserID: uId, Provider: provider, RevokedAt: bson.M{"$exists": false}}, bson.M{"$set": bson.M{RevokedAt: now}})
+	if err != nil {
+		return 0, errRevokeByProvider(err, uId, provider)
+	}
+	return int(result.ModifiedCount), nil
+}
@@ -0,0 +1,33 @@
+package settingRepository
+
+import (
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/errorMsg"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+)
+
+const (
+	GetAuth                   = "REPO-SETTING-001-GetAuth"
+	SetAdminTwoFactorRequired = "REPO-SETTING-002-SetAdminTwoFactorRequired"
+)
+
+func errGetAuth(err error) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    GetAuth,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+	})
+}
+
+func errSetAdminTwoFactorRequired(err error, required bool, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    SetAdminTwoFactorRequired,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      map[string]interface{}{"required": required, "userId": uId},
+	})
+}
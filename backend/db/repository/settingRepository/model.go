@@ -0,0 +1,31 @@
+package settingRepository
+
+import (
+	"backend/graph/graphModel"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	CollectionName         = "settings"
+	ID                     = "_id"
+	AdminTwoFactorRequired = "admin_two_factor_required"
+	UpdatedBy              = "updated_by"
+	UpdatedAt              = "updated_at"
+
+	AuthSettingID = "auth" //認証まわりの設定のドキュメント
+)
+
+// AuthModel 認証まわりのサイト全体の設定(ドキュメントがない場合はすべて既定値)
+type AuthModel struct {
+	ID                     string              `bson:"_id"`
+	AdminTwoFactorRequired bool                `bson:"admin_two_factor_required"` //管理者に2段階認証を必須にするか
+	UpdatedBy              *primitive.ObjectID `bson:"updated_by,omitempty"`
+	UpdatedAt              *time.Time          `bson:"updated_at,omitempty"`
+}
+
+func (m *AuthModel) ToGraphQL() *graphModel.AuthSettings {
+	return &graphModel.AuthSettings{
+		AdminTwoFactorRequired: m.AdminTwoFactorRequired,
+	}
+}
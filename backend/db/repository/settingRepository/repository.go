@@ -0,0 +1,50 @@
+package settingRepository
+
+import (
+	"backend/db"
+	"backend/middlewares/customError"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type SettingRepository struct {
+	db         *db.DB
+	collection *mongo.Collection
+}
+
+func NewSettingRepository(db *db.DB) SettingRepository {
+	return SettingRepository{
+		db:         db,
+		collection: db.Collection(CollectionName),
+	}
+}
+
+// GetAuth 認証まわりの設定を取得する。未設定の場合は既定値を返す
+func (r *SettingRepository) GetAuth(ctx context.Context) (*AuthModel, *customError.Error) {
+	var model AuthModel
+	if err := r.collection.FindOne(ctx, bson.M{ID: AuthSettingID}).Decode(&model); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return &AuthModel{ID: AuthSettingID}, nil
+		}
+		return nil, errGetAuth(err)
+	}
+	return &model, nil
+}
+
+// SetAdminTwoFactorRequired 管理者に2段階認証を必須にするかを設定する
+func (r *SettingRepository) SetAdminTwoFactorRequired(ctx context.Context, required bool, uId primitive.ObjectID, now time.Time) *customError.Error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{ID: AuthSettingID}, bson.M{"$set": bson.M{
+		AdminTwoFactorRequired: required,
+		UpdatedBy:              uId,
+		UpdatedAt:              now,
+	}}, options.Update().SetUpsert(true))
+	if err != nil {
+		return errSetAdminTwoFactorRequired(err, required, uId)
+	}
+	return nil
+}
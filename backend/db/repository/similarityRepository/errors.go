@@ -0,0 +1,34 @@
+package similarityRepository
+
+import (
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/errorMsg"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+)
+
+const (
+	ReplaceAll     = "REPO-SIMILARITY-001-ReplaceAll"
+	GetByLiquorIds = "REPO-SIMILARITY-002-GetByLiquorIds"
+)
+
+func errReplaceAll(err error, count int) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    ReplaceAll,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      count,
+	})
+}
+
+func errGetByLiquorIds(err error, ids []primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    GetByLiquorIds,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      ids,
+	})
+}
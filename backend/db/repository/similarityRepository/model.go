@@ -0,0 +1,25 @@
+package similarityRepository
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	CollectionName = "liquor_similarity"
+	LiquorID       = "liquor_id"
+	UpdatedAt      = "updated_at"
+)
+
+// Model 掲示板の評価から計算した、お酒ごとの類似アイテム(定期ジョブで作り直す)
+type Model struct {
+	LiquorID  primitive.ObjectID `bson:"liquor_id"`
+	Similars  []Entry            `bson:"similars"` //類似度の降順
+	UpdatedAt time.Time          `bson:"updated_at"`
+}
+
+type Entry struct {
+	LiquorID   primitive.ObjectID `bson:"liquor_id"`
+	Similarity float64            `bson:"similarity"` //調整コサイン類似度(-1～1)。正のもののみ保存する
+	CoRaters   int                `bson:"co_raters"`  //両方を評価したユーザー数
+}
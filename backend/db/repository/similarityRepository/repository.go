@@ -0,0 +1,62 @@
+package similarityRepository
+
+import (
+	"backend/db"
+	"backend/middlewares/customError"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type SimilarityRepository struct {
+	db         *db.DB
+	collection *mongo.Collection
+}
+
+func NewSimilarityRepository(db *db.DB) SimilarityRepository {
+	return SimilarityRepository{
+		db:         db,
+		collection: db.Collection(CollectionName),
+	}
+}
+
+// ReplaceAll 計算し直した類似アイテムで全件置き換える。今回の計算に含まれなかった(類似アイテムがなくなった)お酒は削除する
+func (r *SimilarityRepository) ReplaceAll(ctx context.Context, models []Model, startedAt time.Time) *customError.Error {
+	if len(models) > 0 {
+		writes := make([]mongo.WriteModel, len(models))
+		for i, m := range models {
+			writes[i] = mongo.NewReplaceOneModel().
+				SetFilter(bson.M{LiquorID: m.LiquorID}).
+				SetReplacement(m).
+				SetUpsert(true)
+		}
+		if _, err := r.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+			return errReplaceAll(err, len(models))
+		}
+	}
+	if _, err := r.collection.DeleteMany(ctx, bson.M{UpdatedAt: bson.M{"$lt": startedAt}}); err != nil {
+		return errReplaceAll(err, len(models))
+	}
+	return nil
+}
+
+// GetByLiquorIds 指定したお酒の類似アイテムを取得する(類似アイテムがないお酒は含まれない)
+func (r *SimilarityRepository) GetByLiquorIds(ctx context.Context, ids []primitive.ObjectID) ([]*Model, *customError.Error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	cursor, err := r.collection.Find(ctx, bson.M{LiquorID: bson.M{"$in": ids}})
+	if err != nil {
+		return nil, errGetByLiquorIds(err, ids)
+	}
+	defer cursor.Close(ctx)
+
+	var models []*Model
+	if err = cursor.All(ctx, &models); err != nil {
+		return nil, errGetByLiquorIds(err, ids)
+	}
+	return models, nil
+}
@@ -3,11 +3,10 @@ package userRepository
 import (
 	"backend/middlewares/customError"
 	"backend/middlewares/customError/errorMsg"
-	"errors"
-	"fmt"
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"net/http"
+	"time"
 )
 
 const (
@@ -16,10 +15,25 @@ const (
 	GetByEmail               = "REPO-USER-003-GetByEmail"
 	GetById                  = "REPO-USER-004-GetById"
 	GetByTwitterId           = "REPO-USER-005-GetByTwitterId"
-	SetPasswordTokenNotFound = "REPO-USER-006-SetPasswordTokenNotFound"
+	SetPasswordTokenNotFound = "REPO-USER-006-SetPasswordTokenNotFound" //memo:未登録のメールアドレスを判別されないよう、現在は使っていない
 	SetPasswordToken         = "REPO-USER-007-SetPasswordToken"
 	GetByPasswordToken       = "REPO-USER-008-GetByPasswordToken"
 	PasswordReset            = "REPO-USER-009-PasswordReset"
+	SaveEmailPreference      = "REPO-USER-010-SaveEmailPreference"
+	ListDigestTargets        = "REPO-USER-011-ListDigestTargets"
+	ClaimDigest              = "REPO-USER-012-ClaimDigest"
+	SetPendingEmail          = "REPO-USER-013-SetPendingEmail"
+	ConfirmEmail             = "REPO-USER-014-ConfirmEmail"
+	ClearPasswordToken       = "REPO-USER-015-ClearPasswordToken"
+	StartTwoFactor           = "REPO-USER-016-StartTwoFactor"
+	EnableTwoFactor          = "REPO-USER-017-EnableTwoFactor"
+	UseTwoFactorStep         = "REPO-USER-018-UseTwoFactorStep"
+	UseRecoveryCode          = "REPO-USER-019-UseRecoveryCode"
+	SetRecoveryCodes         = "REPO-USER-020-SetRecoveryCodes"
+	DeleteTwoFactor          = "REPO-USER-021-DeleteTwoFactor"
+	ClearTwitterId           = "REPO-USER-022-ClearTwitterId"
+	LockLoginMethods         = "REPO-USER-023-LockLoginMethods"
+	SetPassword              = "REPO-USER-024-SetPassword"
 )
 
 func errRegister(err error, user *Model) *customError.Error {
@@ -31,13 +45,13 @@ func errRegister(err error, user *Model) *customError.Error {
 		Input:      user,
 	})
 }
-func errUpdate(err error, user *Model) *customError.Error {
+func errUpdate(err error, id primitive.ObjectID) *customError.Error {
 	return customError.NewError(err, customError.Params{
 		StatusCode: http.StatusInternalServerError,
 		ErrCode:    Update,
 		UserMsg:    errorMsg.SERVER,
 		Level:      logrus.ErrorLevel,
-		Input:      user,
+		Input:      id,
 	})
 }
 
@@ -71,42 +85,182 @@ func errGetByTwitterId(err error, id string) *customError.Error {
 	})
 }
 
-func errSetPasswordTokenNotFound(email string, token string) *customError.Error {
-	return customError.NewError(errors.New("ユーザーが見つかりません"), customError.Params{
-		StatusCode: http.StatusBadRequest,
-		ErrCode:    SetPasswordTokenNotFound,
-		UserMsg:    errorMsg.DATA,
-		Level:      logrus.InfoLevel,
-		Input:      fmt.Sprintf("email: %s, token: %s", email, token),
-	})
-}
-
-func errSetPasswordToken(err error, email string, token string) *customError.Error {
+func errSetPasswordToken(err error, email string) *customError.Error {
 	return customError.NewError(err, customError.Params{
 		StatusCode: http.StatusInternalServerError,
 		ErrCode:    SetPasswordToken,
 		UserMsg:    errorMsg.DATA,
 		Level:      logrus.ErrorLevel,
-		Input:      fmt.Sprintf("email: %s, token: %s", email, token),
+		Input:      email,
 	})
 }
 
-func errGetByPasswordToken(err error, token string) *customError.Error {
+// memo:トークンはログに残さない
+func errGetByPasswordToken(err error) *customError.Error {
 	return customError.NewError(err, customError.Params{
 		StatusCode: http.StatusBadRequest,
 		ErrCode:    GetByPasswordToken,
-		UserMsg:    "有効期限切れです。パスワードリセットURLを再発行してください。",
+		UserMsg:    "URLが無効か、有効期限切れです。パスワードリセットURLを再発行してください。",
 		Level:      logrus.InfoLevel,
-		Input:      token,
 	})
 }
 
-func errPasswordReset(err error, user Model) *customError.Error {
+func errPasswordReset(err error, id primitive.ObjectID) *customError.Error {
 	return customError.NewError(err, customError.Params{
 		StatusCode: http.StatusInternalServerError,
-		ErrCode:    GetByPasswordToken,
+		ErrCode:    PasswordReset,
 		UserMsg:    "パスワードリセットに失敗しました",
-		Level:      logrus.InfoLevel,
-		Input:      user,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
+
+func errClearPasswordToken(err error, id primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    ClearPasswordToken,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
+
+func errSaveEmailPreference(err error, id primitive.ObjectID, pref EmailPreferenceModel) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    SaveEmailPreference,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      map[string]interface{}{"id": id, "pref": pref},
+	})
+}
+
+func errListDigestTargets(err error, before time.Time) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    ListDigestTargets,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      before,
+	})
+}
+
+func errClaimDigest(err error, id primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    ClaimDigest,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
+
+func errSetPendingEmail(err error, id primitive.ObjectID, email *string) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    SetPendingEmail,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      map[string]interface{}{"id": id, "email": email},
+	})
+}
+
+func errConfirmEmail(err error, id primitive.ObjectID, email string) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    ConfirmEmail,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      map[string]interface{}{"id": id, "email": email},
+	})
+}
+
+func errStartTwoFactor(err error, id primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    StartTwoFactor,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
+
+func errEnableTwoFactor(err error, id primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    EnableTwoFactor,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
+
+func errUseTwoFactorStep(err error, id primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    UseTwoFactorStep,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
+
+func errUseRecoveryCode(err error, id primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    UseRecoveryCode,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
+
+func errSetRecoveryCodes(err error, id primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    SetRecoveryCodes,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
+
+func errDeleteTwoFactor(err error, id primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    DeleteTwoFactor,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
+
+func errClearTwitterId(err error, id primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    ClearTwitterId,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
+
+func errLockLoginMethods(err error, id primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    LockLoginMethods,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
+	})
+}
+
+func errSetPassword(err error, id primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    SetPassword,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
 	})
 }
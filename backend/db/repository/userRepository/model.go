@@ -4,6 +4,7 @@ import (
 	"backend/graph/graphModel"
 	"backend/util/helper"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
 	"time"
 )
 
@@ -12,13 +13,34 @@ const (
 	Id                       = "_id"
 	Name                     = "name"
 	ImageBase64              = "image_base64"
+	Profile                  = "profile"
 	Email                    = "email"
 	TwitterId                = "twitter_id"
 	Password                 = "password"
 	PasswordResetToken       = "password_reset_token"
 	PasswordResetTokenExpire = "password_reset_expire"
+	EmailPreference          = "email_preference"
+	DigestSentAt             = "digest_sent_at"
+	EmailVerified            = "email_verified"
+	PendingEmail             = "pending_email"
+	TwoFactor                = "two_factor"
+	TwoFactorEnabled         = "two_factor.enabled"
+	TwoFactorRecoveryCodes   = "two_factor.recovery_codes"
+	TwoFactorLastUsedStep    = "two_factor.last_used_step"
+	TwoFactorEnabledAt       = "two_factor.enabled_at"
+	LoginMethodsVersion      = "login_methods_version"
+
+	RoleAdmin = "admin" //管理者ロール
+)
+
+// メールの種類(受け取り設定の対象。パスワードリセットなどの手続きのメールは常に送る)
+const (
+	EmailKindWeeklyDigest = "WEEKLY_DIGEST" //週間ダイジェスト
 )
 
+// EmailKinds 受け取り設定の対象になるメールの一覧(表示順)
+var EmailKinds = []string{EmailKindWeeklyDigest}
+
 type Model struct {
 	ID                  primitive.ObjectID `bson:"_id"`
 	Name                string             `bson:"name"`
@@ -28,17 +50,84 @@ type Model struct {
 	TwitterId           *string            `bson:"twitter_id"`
 	ImageBase64         *string            `bson:"image_base64"`
 	Profile             *string            `bson:"profile"`
-	PasswordResetToken  *[]byte            `bson:"password_reset_token"`
-	PasswordResetExpire *time.Time         `bson:"password_reset_expire"`
+	PasswordResetToken  *string            `bson:"password_reset_token,omitempty"` //SHA-256のハッシュだけを保存する(Updateで上書きされないようにomitemptyにしておく)
+	PasswordResetExpire *time.Time         `bson:"password_reset_expire,omitempty"`
+	//Updateで丸ごと上書きされないようにomitemptyにしておく
+	EmailPreference *EmailPreferenceModel `bson:"email_preference,omitempty"`
+	DigestSentAt    *time.Time            `bson:"digest_sent_at,omitempty"` //最後に週間ダイジェストを送った日時
+	EmailVerified   bool                  `bson:"email_verified"`           //確認メールのリンクからメールアドレスを確認済みかどうか
+	PendingEmail    *string               `bson:"pending_email,omitempty"`  //確認待ちの新しいメールアドレス(確認が済むまでemailは変えない)
+	TwoFactor       *TwoFactorModel       `bson:"two_factor,omitempty"`     //2段階認証(TOTP)の設定
+}
+
+// TwoFactorModel 2段階認証の設定。コードを確認するまではEnabledがfalseの登録途中の状態
+type TwoFactorModel struct {
+	Secret        string     `bson:"secret"`         //暗号化したTOTPの秘密鍵
+	Enabled       bool       `bson:"enabled"`        //コードの確認が済むと有効になる
+	RecoveryCodes []string   `bson:"recovery_codes"` //リカバリーコードのSHA-256ハッシュ(使ったものは削除する)
+	LastUsedStep  int64      `bson:"last_used_step"` //最後に使ったコードのステップ(同じコードの再利用を防ぐ)
+	EnabledAt     *time.Time `bson:"enabled_at,omitempty"`
+}
+
+// IsEnabled 2段階認証が有効かどうか
+func (t *TwoFactorModel) IsEnabled() bool {
+	return t != nil && t.Enabled
+}
+
+// EmailPreferenceModel メールの受け取り設定(未設定の場合は日本語ですべて受け取る)
+type EmailPreferenceModel struct {
+	Locale       string   `bson:"locale,omitempty"`
+	Unsubscribed []string `bson:"unsubscribed"` //受け取らないメールの種類
+}
+
+// IsSubscribed 指定した種類のメールを受け取るかどうか(設定がない場合は受け取る)
+func (p *EmailPreferenceModel) IsSubscribed(kind string) bool {
+	if p == nil {
+		return true
+	}
+	for _, k := range p.Unsubscribed {
+		if k == kind {
+			return false
+		}
+	}
+	return true
+}
+
+// GetLocale メールの言語(設定がない場合は空文字で、既定の言語になる)
+func (p *EmailPreferenceModel) GetLocale() string {
+	if p == nil {
+		return ""
+	}
+	return p.Locale
+}
+
+// HasPassword メールアドレスとパスワードでログインできるかどうか
+// memo:外部サービスで登録したユーザーのパスワードはハッシュ化していないランダム文字列なので、bcryptのハッシュかどうかで判別する
+func (m *Model) HasPassword() bool {
+	if m.Email == nil {
+		return false
+	}
+	_, err := bcrypt.Cost(m.Password)
+	return err == nil
 }
 
 func (m *Model) ToGraphQL() *graphModel.User {
 	return &graphModel.User{
-		ID:          m.ID.Hex(),
-		Name:        m.Name,
-		Email:       helper.NilToZero(m.Email),
-		ImageBase64: m.ImageBase64,
-		Profile:     m.Profile,
-		Roles:       m.Roles,
+		ID:            m.ID.Hex(),
+		Name:          m.Name,
+		Email:         helper.NilToZero(m.Email),
+		ImageBase64:   m.ImageBase64,
+		Profile:       m.Profile,
+		Roles:         m.Roles,
+		EmailVerified: m.EmailVerified,
+	}
+}
+
+// ToAccount 本人にだけ返すアカウントの状態
+func (m *Model) ToAccount() *graphModel.Account {
+	return &graphModel.Account{
+		PendingEmail:     m.PendingEmail,
+		TwoFactorEnabled: m.TwoFactor.IsEnabled(),
+		HasPassword:      m.HasPassword(),
 	}
 }
@@ -8,6 +8,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"time"
 )
 
@@ -36,15 +37,20 @@ func (r *UsersRepository) Register(ctx context.Context, user *Model) (*Model, *c
 	return user, nil
 }
 
-func (r *UsersRepository) Update(ctx context.Context, user *Model) *customError.Error {
-	// MongoDBにデータを挿入
-	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{
-		"$set": user, // userオブジェクト内のフィールドをセット
-	})
-	if err != nil {
-		return errUpdate(err, user)
+// UpdateProfile マイページで編集できる項目(名前・画像・自己紹介と、指定した場合はパスワード)だけを更新する
+// ロールや外部サービスの連携などは丸ごと上書きしないよう、対象の項目だけを$setする
+func (r *UsersRepository) UpdateProfile(ctx context.Context, id primitive.ObjectID, name string, imageBase64 *string, profile *string, password []byte) *customError.Error {
+	set := bson.M{
+		Name:        name,
+		ImageBase64: imageBase64,
+		Profile:     profile,
+	}
+	if password != nil {
+		set[Password] = password
+	}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{Id: id}, bson.M{"$set": set}); err != nil {
+		return errUpdate(err, id)
 	}
-
 	return nil
 }
 
@@ -72,6 +78,7 @@ func (r *UsersRepository) GetById(ctx context.Context, id primitive.ObjectID) (*
 	return &user, nil
 }
 
+// GetByTwitterId 旧方式(twitter_id)でXと連携したユーザーを取得する(現在の連携はuser_identitiesで管理し、SSOでログインした際に移行する)
 func (r *UsersRepository) GetByTwitterId(ctx context.Context, id string) (*Model, *customError.Error) {
 	// コレクションを取得
 	var user Model
@@ -85,45 +92,242 @@ func (r *UsersRepository) GetByTwitterId(ctx context.Context, id string) (*Model
 	return &user, nil
 }
 
-func (r *UsersRepository) SetPasswordToken(ctx context.Context, email string, token string) *customError.Error {
-	// ドキュメントの更新
-	result, err := r.collection.UpdateOne(ctx, bson.M{Email: email}, bson.M{
+// SetPasswordToken 確認済みのメールアドレスのユーザーにパスワードリセットのトークン(ハッシュ)を設定する
+// ユーザーが存在しない場合はnilを返す
+func (r *UsersRepository) SetPasswordToken(ctx context.Context, email string, tokenHash string, expire time.Time) (*Model, *customError.Error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var user Model
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{Email: email, EmailVerified: true}, bson.M{
 		"$set": bson.M{
-			PasswordResetToken:       token,
-			PasswordResetTokenExpire: time.Now().Add(1 * time.Hour),
+			PasswordResetToken:       tokenHash,
+			PasswordResetTokenExpire: expire,
 		},
+	}, opts).Decode(&user)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, errSetPasswordToken(err, email)
+	}
+	return &user, nil
+}
+
+// GetByPasswordToken 有効期限内のパスワードリセットのトークン(ハッシュ)からユーザーを取得する
+func (r *UsersRepository) GetByPasswordToken(ctx context.Context, tokenHash string) (*Model, *customError.Error) {
+	var user Model
+	if err := r.collection.FindOne(ctx, bson.M{PasswordResetToken: tokenHash, PasswordResetTokenExpire: bson.M{"$gt": time.Now()}}).Decode(&user); err != nil {
+		return nil, errGetByPasswordToken(err)
+	}
+
+	return &user, nil
+}
+
+// PasswordReset トークンを使ってパスワードを更新し、トークンを無効にする
+// 同じトークンで同時にリセットされた場合に備え、更新時にもトークンと有効期限を確認する(先に更新した方だけ成功する)
+func (r *UsersRepository) PasswordReset(ctx context.Context, user Model, tokenHash string, newPasswordHashed []byte) *customError.Error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id":                    user.ID,
+		PasswordResetToken:       tokenHash,
+		PasswordResetTokenExpire: bson.M{"$gt": time.Now()},
+	}, bson.M{
+		"$set":   bson.M{Password: newPasswordHashed},
+		"$unset": bson.M{PasswordResetToken: "", PasswordResetTokenExpire: ""},
 	})
+	if err != nil {
+		return errPasswordReset(err, user.ID)
+	}
 	if result.MatchedCount == 0 {
-		// ドキュメントが存在しなかった場合の処理
-		return errSetPasswordTokenNotFound(email, token)
+		return errGetByPasswordToken(mongo.ErrNoDocuments)
 	}
+	return nil
+}
+
+// ClearPasswordToken パスワードリセットのトークンを無効にする(パスワードを変更した場合など)
+func (r *UsersRepository) ClearPasswordToken(ctx context.Context, id primitive.ObjectID) *customError.Error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{Id: id}, bson.M{
+		"$unset": bson.M{PasswordResetToken: "", PasswordResetTokenExpire: ""},
+	})
 	if err != nil {
-		return errSetPasswordToken(err, email, token)
+		return errClearPasswordToken(err, id)
 	}
 	return nil
 }
 
-func (r *UsersRepository) GetByPasswordToken(ctx context.Context, token string) (*Model, *customError.Error) {
-	// コレクションを取得
-	var user Model
-	if err := r.collection.FindOne(ctx, bson.M{PasswordResetToken: token, PasswordResetTokenExpire: bson.M{"$gt": time.Now()}}).Decode(&user); err != nil {
-		return nil, errGetByPasswordToken(err, token)
+// SaveEmailPreference メールの受け取り設定を保存する
+func (r *UsersRepository) SaveEmailPreference(ctx context.Context, id primitive.ObjectID, pref EmailPreferenceModel) *customError.Error {
+	if pref.Unsubscribed == nil {
+		pref.Unsubscribed = []string{}
+	}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{Id: id}, bson.M{"$set": bson.M{EmailPreference: pref}}); err != nil {
+		return errSaveEmailPreference(err, id, pref)
 	}
+	return nil
+}
 
-	return &user, nil
+// ListDigestTargets 週間ダイジェストの送信対象(確認済みのメールアドレスがあり、受け取り設定が有効で、before以降に送っていないユーザー)をID順に取得する
+func (r *UsersRepository) ListDigestTargets(ctx context.Context, before time.Time, limit int64) ([]*Model, *customError.Error) {
+	filter := bson.M{
+		Email:                             bson.M{"$nin": bson.A{nil, ""}},
+		EmailVerified:                     true,
+		EmailPreference + ".unsubscribed": bson.M{"$ne": EmailKindWeeklyDigest},
+		"$or": bson.A{
+			bson.M{DigestSentAt: bson.M{"$exists": false}},
+			bson.M{DigestSentAt: bson.M{"$lt": before}},
+		},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: Id, Value: 1}}).SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errListDigestTargets(err, before)
+	}
+	defer cursor.Close(ctx)
+
+	var users []*Model
+	if err = cursor.All(ctx, &users); err != nil {
+		return nil, errListDigestTargets(err, before)
+	}
+	return users, nil
 }
 
-func (r *UsersRepository) PasswordReset(ctx context.Context, user Model, newPasswordHashed []byte) *customError.Error {
-	// パスワードを更新するクエリを実行
-	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{
-		"$set": bson.M{
-			Password:                 newPasswordHashed,
-			PasswordResetToken:       nil,
-			PasswordResetTokenExpire: nil,
+// ClaimDigest 週間ダイジェストの送信日時を記録する。他のインスタンスが先に記録していた場合はfalseを返す
+func (r *UsersRepository) ClaimDigest(ctx context.Context, id primitive.ObjectID, before time.Time, now time.Time) (bool, *customError.Error) {
+	filter := bson.M{
+		Id: id,
+		"$or": bson.A{
+			bson.M{DigestSentAt: bson.M{"$exists": false}},
+			bson.M{DigestSentAt: bson.M{"$lt": before}},
 		},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{DigestSentAt: now}})
+	if err != nil {
+		return false, errClaimDigest(err, id)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// SetPendingEmail 確認待ちの新しいメールアドレスを設定する。nilの場合は変更を取り消す
+func (r *UsersRepository) SetPendingEmail(ctx context.Context, id primitive.ObjectID, email *string) *customError.Error {
+	update := bson.M{"$unset": bson.M{PendingEmail: ""}}
+	if email != nil {
+		update = bson.M{"$set": bson.M{PendingEmail: *email}}
+	}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{Id: id}, update); err != nil {
+		return errSetPendingEmail(err, id, email)
+	}
+	return nil
+}
+
+// ConfirmEmail メールアドレスを確認済みにする。確認待ちのメールアドレスだった場合はemailに移す
+// 確認中に別のアドレスに変更された場合など、emailがどちらにも一致しない場合はfalseを返す
+// memo:メールアドレスが変わった場合、古いアドレスに送ったパスワードリセットのトークンは無効にする
+func (r *UsersRepository) ConfirmEmail(ctx context.Context, id primitive.ObjectID, email string) (bool, *customError.Error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{Id: id, PendingEmail: email}, bson.M{
+		"$set":   bson.M{Email: email, EmailVerified: true},
+		"$unset": bson.M{PendingEmail: "", PasswordResetToken: "", PasswordResetTokenExpire: ""},
+	})
+	if err != nil {
+		return false, errConfirmEmail(err, id, email)
+	}
+	if result.MatchedCount == 1 {
+		return true, nil
+	}
+
+	result, err = r.collection.UpdateOne(ctx, bson.M{Id: id, Email: email}, bson.M{"$set": bson.M{EmailVerified: true}})
+	if err != nil {
+		return false, errConfirmEmail(err, id, email)
+	}
+	return result.MatchedCount == 1, nil
+}
+
+// StartTwoFactor 2段階認証の登録を始める(コードを確認するまでは無効のまま)
+// 既に有効な場合は上書きせずにfalseを返す
+func (r *UsersRepository) StartTwoFactor(ctx context.Context, id primitive.ObjectID, secret string) (bool, *customError.Error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{Id: id, TwoFactorEnabled: bson.M{"$ne": true}}, bson.M{
+		"$set": bson.M{TwoFactor: TwoFactorModel{Secret: secret, RecoveryCodes: []string{}}},
 	})
 	if err != nil {
-		return errPasswordReset(err, user)
+		return false, errStartTwoFactor(err, id)
+	}
+	return result.MatchedCount == 1, nil
+}
+
+// EnableTwoFactor 登録途中の2段階認証を有効にする。確認に使ったコードのステップは使用済みにする
+func (r *UsersRepository) EnableTwoFactor(ctx context.Context, id primitive.ObjectID, recoveryCodes []string, step int64, now time.Time) (bool, *customError.Error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{Id: id, TwoFactorEnabled: false}, bson.M{"$set": bson.M{
+		TwoFactorEnabled:       true,
+		TwoFactorRecoveryCodes: recoveryCodes,
+		TwoFactorLastUsedStep:  step,
+		TwoFactorEnabledAt:     now,
+	}})
+	if err != nil {
+		return false, errEnableTwoFactor(err, id)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// UseTwoFactorStep コードのステップを使用済みにする。既に同じか新しいステップを使っていた場合はfalseを返す
+func (r *UsersRepository) UseTwoFactorStep(ctx context.Context, id primitive.ObjectID, step int64) (bool, *customError.Error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{Id: id, TwoFactorEnabled: true, TwoFactorLastUsedStep: bson.M{"$lt": step}}, bson.M{
+		"$set": bson.M{TwoFactorLastUsedStep: step},
+	})
+	if err != nil {
+		return false, errUseTwoFactorStep(err, id)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// UseRecoveryCode リカバリーコード(ハッシュ)を使用済みとして削除する。存在しない場合はfalseを返す
+func (r *UsersRepository) UseRecoveryCode(ctx context.Context, id primitive.ObjectID, codeHash string) (bool, *customError.Error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{Id: id, TwoFactorEnabled: true, TwoFactorRecoveryCodes: codeHash}, bson.M{
+		"$pull": bson.M{TwoFactorRecoveryCodes: codeHash},
+	})
+	if err != nil {
+		return false, errUseRecoveryCode(err, id)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// SetRecoveryCodes リカバリーコード(ハッシュ)を作り直す
+func (r *UsersRepository) SetRecoveryCodes(ctx context.Context, id primitive.ObjectID, recoveryCodes []string) *customError.Error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{Id: id, TwoFactorEnabled: true}, bson.M{
+		"$set": bson.M{TwoFactorRecoveryCodes: recoveryCodes},
+	})
+	if err != nil {
+		return errSetRecoveryCodes(err, id)
+	}
+	return nil
+}
+
+// DeleteTwoFactor 2段階認証を無効にする
+func (r *UsersRepository) DeleteTwoFactor(ctx context.Context, id primitive.ObjectID) *customError.Error {
+	if _, err := r.collection.UpdateOne(ctx, bson.M{Id: id}, bson.M{"$unset": bson.M{TwoFactor: ""}}); err != nil {
+		return errDeleteTwoFactor(err, id)
 	}
 	return nil
 }
+
+// ClearTwitterId 旧方式のX連携を削除する(user_identitiesに移した後に呼ぶ)
+func (r *UsersRepository) ClearTwitterId(ctx context.Context, id primitive.ObjectID) *customError.Error {
+	if _, err := r.collection.UpdateOne(ctx, bson.M{Id: id}, bson.M{"$unset": bson.M{TwitterId: ""}}); err != nil {
+		return errClearTwitterId(err, id)
+	}
+	return nil
+}
+
+// LockLoginMethods ログイン方法を減らす操作のトランザクション内で、確認より先に呼ぶ
+// 同じユーザーのドキュメントに書き込むので、同時に解除された場合は片方が書き込み競合で失敗する(確認だけでは両方通ってしまう)
+func (r *UsersRepository) LockLoginMethods(ctx context.Context, id primitive.ObjectID) *customError.Error {
+	if _, err := r.collection.UpdateOne(ctx, bson.M{Id: id}, bson.M{"$inc": bson.M{LoginMethodsVersion: 1}}); err != nil {
+		return errLockLoginMethods(err, id)
+	}
+	return nil
+}
+
+// SetPassword パスワードを設定する。確認済みのメールアドレスが変わっていた場合は設定せず、falseを返す
+func (r *UsersRepository) SetPassword(ctx context.Context, id primitive.ObjectID, email string, hashed []byte) (bool, *customError.Error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{Id: id, Email: email, EmailVerified: true}, bson.M{"$set": bson.M{Password: hashed}})
+	if err != nil {
+		return false, errSetPassword(err, id)
+	}
+	return result.MatchedCount > 0, nil
+}
@@ -32,21 +32,22 @@ func main() {
 
 	// Categoryモデルのシード
 	var categoryModel []map[string]interface{}
-	err = seedData(ctx, client, dbName, categoriesRepository.CollectionName, "./db/seeders/categories.json", &categoryModel, "id")
+	err = seedData(ctx, client, dbName, categoriesRepository.CollectionName, "./db/seeders/categories.json", &categoryModel, "id", true)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	// 他のモデルのシード
+	// フレーバーマップは管理画面から編集できるので、既存のものは上書きしない(上書きすると投票の移行をせずに軸が変わってしまう)
 	var flavorMapMstModel []map[string]interface{}
-	err = seedData(ctx, client, dbName, flavorMapRepository.FlavorMapMasterCollectionName, "./db/seeders/flavorMaps.json", &flavorMapMstModel, "category_id")
+	err = seedData(ctx, client, dbName, flavorMapRepository.FlavorMapMasterCollectionName, "./db/seeders/flavorMaps.json", &flavorMapMstModel, "category_id", false)
 	if err != nil {
 		log.Fatal(err)
 	}
 }
 
-// SeedData シード処理の共通関数
-func seedData(ctx context.Context, client *mongo.Client, dbName, collectionName, filePath string, model interface{}, idField string) error {
+// SeedData シード処理の共通関数(overwriteがfalseの場合は、存在しないドキュメントの挿入のみ行う)
+func seedData(ctx context.Context, client *mongo.Client, dbName, collectionName, filePath string, model interface{}, idField string, overwrite bool) error {
 	// データベースとコレクションの参照を取得
 	collection := client.Database(dbName).Collection(collectionName)
 
@@ -94,6 +95,10 @@ func seedData(ctx context.Context, client *mongo.Client, dbName, collectionName,
 			"$set":         itemWithoutID,
 			"$setOnInsert": bson.M{"_id": item["_id"]},
 		}
+		if !overwrite {
+			itemWithoutID["_id"] = item["_id"]
+			update = bson.M{"$setOnInsert": itemWithoutID}
+		}
 
 		// Upsertオプションを設定
 		options := options.Update().SetUpsert(true)
@@ -0,0 +1,22 @@
+package di
+
+import (
+	"backend/jobs"
+	"backend/util/pubsub"
+	"github.com/gin-gonic/gin"
+)
+
+// App 起動に必要なものをまとめた構造体(定期ジョブやカテゴリの更新の購読はmainで開始・停止する)
+type App struct {
+	Engine    *gin.Engine
+	Scheduler *jobs.Scheduler
+	PubSub    pubsub.PubSub
+}
+
+func NewApp(engine *gin.Engine, scheduler *jobs.Scheduler, ps pubsub.PubSub) *App {
+	return &App{
+		Engine:    engine,
+		Scheduler: scheduler,
+		PubSub:    ps,
+	}
+}
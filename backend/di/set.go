@@ -5,18 +5,25 @@ import (
 	"backend/di/handlers"
 	"backend/graph"
 	"backend/graph/resolver"
+	"backend/jobs"
 	"backend/router"
+	"backend/service/mailService"
 	"backend/util/amazon/s3"
+	"backend/util/pubsub"
 	"github.com/google/wire"
 )
 
 // BasicSet システム根幹部分
 var BasicSet = wire.NewSet(
 	s3.NewS3Client,
+	NewApp,
 	resolver.NewResolver,
 	handlers.NewHandlers,
 	router.Router,
 	graph.NewGraphQLServer,
+	jobs.NewScheduler,
+	pubsub.NewPubSub, //複数台構成にする場合はブローカーを使う実装に差し替える
+	mailService.NewMailer,
 	DatabaseSet,
 )
 
@@ -9,18 +9,28 @@ import (
 	"backend/api"
 	"backend/api/post/categoryPost"
 	"backend/api/post/liquorPost"
+	"backend/db/repository/activityRepository"
+	"backend/db/repository/attemptRepository"
 	"backend/db/repository/bookmarkRepository"
 	"backend/db/repository/categoriesRepository"
+	"backend/db/repository/drinkRepository"
 	"backend/db/repository/errorRepository"
 	"backend/db/repository/flavorMapRepository"
+	"backend/db/repository/identityRepository"
 	"backend/db/repository/liquorRepository"
+	"backend/db/repository/listRepository"
+	"backend/db/repository/mailQueueRepository"
+	"backend/db/repository/notificationRepository"
+	"backend/db/repository/oauthStateRepository"
+	"backend/db/repository/sessionRepository"
+	"backend/db/repository/settingRepository"
+	"backend/db/repository/similarityRepository"
 	"backend/db/repository/userRepository"
 	"backend/service/authService/tokenConfig"
-	"github.com/gin-gonic/gin"
 	"github.com/google/wire"
 )
 
-func InitializeHandler() (*gin.Engine, error) {
+func InitializeHandler() (*App, error) {
 	// それぞれのnewインスタンスの生成ロジックを並べる
 	wire.Build(
 		tokenConfig.NewTokenConfig,
@@ -37,7 +47,19 @@ func InitializeHandler() (*gin.Engine, error) {
 		flavorMapRepository.NewFlavorMapMasterRepository,
 		flavorMapRepository.NewFlavorMapRepository,
 		flavorMapRepository.NewFlavorToLiquorRepository,
+		flavorMapRepository.NewFlavorNeighbourRepository,
+		drinkRepository.NewDrinkRepository,
+		listRepository.NewListRepository,
+		activityRepository.NewActivityRepository,
+		similarityRepository.NewSimilarityRepository,
+		notificationRepository.NewNotificationRepository,
+		mailQueueRepository.NewMailQueueRepository,
+		sessionRepository.NewSessionRepository,
+		settingRepository.NewSettingRepository,
+		attemptRepository.NewAttemptRepository,
+		oauthStateRepository.NewOAuthStateRepository,
+		identityRepository.NewIdentityRepository,
 		errorRepository.New,
 	)
-	return &gin.Engine{}, nil
+	return &App{}, nil
 }
@@ -11,24 +11,37 @@ import (
 	"backend/api/post/categoryPost"
 	"backend/api/post/liquorPost"
 	"backend/db"
+	"backend/db/repository/activityRepository"
+	"backend/db/repository/attemptRepository"
 	"backend/db/repository/bookmarkRepository"
 	"backend/db/repository/categoriesRepository"
+	"backend/db/repository/drinkRepository"
 	"backend/db/repository/errorRepository"
 	"backend/db/repository/flavorMapRepository"
+	"backend/db/repository/identityRepository"
 	"backend/db/repository/liquorRepository"
+	"backend/db/repository/listRepository"
+	"backend/db/repository/mailQueueRepository"
+	"backend/db/repository/notificationRepository"
+	"backend/db/repository/oauthStateRepository"
+	"backend/db/repository/sessionRepository"
+	"backend/db/repository/settingRepository"
+	"backend/db/repository/similarityRepository"
 	"backend/db/repository/userRepository"
 	"backend/di/handlers"
 	"backend/graph"
 	"backend/graph/resolver"
+	"backend/jobs"
 	"backend/router"
 	"backend/service/authService/tokenConfig"
+	"backend/service/mailService"
 	"backend/util/amazon/s3"
-	"github.com/gin-gonic/gin"
+	"backend/util/pubsub"
 )
 
 // Injectors from wire.go:
 
-func InitializeHandler() (*gin.Engine, error) {
+func InitializeHandler() (*App, error) {
 	client, err := db.NewMongoClient()
 	if err != nil {
 		return nil, err
@@ -42,18 +55,43 @@ func InitializeHandler() (*gin.Engine, error) {
 	flavorMapRepositoryFlavorMapRepository := flavorMapRepository.NewFlavorMapRepository(dbDB)
 	flavorMapMasterRepository := flavorMapRepository.NewFlavorMapMasterRepository(dbDB)
 	flavorToLiquorRepository := flavorMapRepository.NewFlavorToLiquorRepository(dbDB)
-	tokenConfigTokenConfig := tokenConfig.NewTokenConfig()
-	resolverResolver := resolver.NewResolver(database, categoryRepository, liquorsRepository, usersRepository, bookMarkRepository, flavorMapRepositoryFlavorMapRepository, flavorMapMasterRepository, flavorToLiquorRepository, tokenConfigTokenConfig)
+	flavorNeighbourRepository := flavorMapRepository.NewFlavorNeighbourRepository(dbDB)
+	drinkRepositoryDrinkRepository := drinkRepository.NewDrinkRepository(dbDB)
+	listRepositoryListRepository := listRepository.NewListRepository(dbDB)
+	activityRepositoryActivityRepository := activityRepository.NewActivityRepository(dbDB)
+	similarityRepositorySimilarityRepository := similarityRepository.NewSimilarityRepository(dbDB)
+	notificationRepositoryNotificationRepository := notificationRepository.NewNotificationRepository(dbDB)
+	sessionRepositorySessionRepository := sessionRepository.NewSessionRepository(dbDB)
+	settingRepositorySettingRepository := settingRepository.NewSettingRepository(dbDB)
+	attemptRepositoryAttemptRepository := attemptRepository.NewAttemptRepository(dbDB)
+	oAuthStateRepository := oauthStateRepository.NewOAuthStateRepository(dbDB)
+	identityRepositoryIdentityRepository := identityRepository.NewIdentityRepository(dbDB)
+	pubSub := pubsub.NewPubSub()
+	mailQueueRepositoryMailQueueRepository := mailQueueRepository.NewMailQueueRepository(dbDB)
+	mailer, err := mailService.NewMailer(mailQueueRepositoryMailQueueRepository)
+	if err != nil {
+		return nil, err
+	}
+	tokenConfigTokenConfig, err := tokenConfig.NewTokenConfig()
+	if err != nil {
+		return nil, err
+	}
+	resolverResolver := resolver.NewResolver(database, categoryRepository, liquorsRepository, usersRepository, bookMarkRepository, flavorMapRepositoryFlavorMapRepository, flavorMapMasterRepository, flavorToLiquorRepository, flavorNeighbourRepository, drinkRepositoryDrinkRepository, listRepositoryListRepository, activityRepositoryActivityRepository, similarityRepositorySimilarityRepository, notificationRepositoryNotificationRepository, sessionRepositorySessionRepository, settingRepositorySettingRepository, attemptRepositoryAttemptRepository, oAuthStateRepository, identityRepositoryIdentityRepository, pubSub, mailer, tokenConfigTokenConfig)
 	server := graph.NewGraphQLServer(resolverResolver)
 	s3S3, err := s3.NewS3Client()
 	if err != nil {
 		return nil, err
 	}
-	handler := liquorPost.NewHandler(database, s3S3, categoryRepository, liquorsRepository, usersRepository)
+	handler := liquorPost.NewHandler(database, s3S3, categoryRepository, liquorsRepository, usersRepository, activityRepositoryActivityRepository, notificationRepositoryNotificationRepository, pubSub)
 	categoryPostHandler := categoryPost.NewHandler(database, s3S3, categoryRepository)
-	userHandler := api.NewUserHandler(database, usersRepository)
+	userHandler := api.NewUserHandler(database, usersRepository, sessionRepositorySessionRepository, settingRepositorySettingRepository, oAuthStateRepository, identityRepositoryIdentityRepository)
 	errorsRepository := errorRepository.New(dbDB)
 	handlersHandlers := handlers.NewHandlers(handler, categoryPostHandler, tokenConfigTokenConfig, userHandler, errorsRepository)
-	engine := router.Router(server, handlersHandlers)
-	return engine, nil
+	engine, err := router.Router(server, handlersHandlers)
+	if err != nil {
+		return nil, err
+	}
+	scheduler := jobs.NewScheduler(flavorMapMasterRepository, flavorMapRepositoryFlavorMapRepository, flavorToLiquorRepository, flavorNeighbourRepository, liquorsRepository, similarityRepositorySimilarityRepository, usersRepository, activityRepositoryActivityRepository, bookMarkRepository, mailer)
+	app := NewApp(engine, scheduler, pubSub)
+	return app, nil
 }
@@ -1,7 +1,7 @@
 package graph
 
 import (
-	"backend/db"
+	"backend/db/repository/settingRepository"
 	"backend/db/repository/userRepository"
 	"backend/di/handlers"
 	"backend/graph/resolver"
@@ -11,11 +11,20 @@ import (
 	"errors"
 	"fmt"
 	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
 	"net/http"
 )
 
 // 必須認証のディレクティブ
 func authDirective(ctx context.Context, _ interface{}, next graphql.Resolver) (interface{}, error) {
+	// websocketの購読は接続開始時に認証しているので、ユーザーIDがcontextにあるかだけ確認する
+	if isSubscription(ctx) {
+		if _, err := auth.GetId(ctx); err != nil {
+			return nil, err
+		}
+		return next(ctx)
+	}
+
 	// ヘッダーからトークンを取得
 	tokenString, err := auth.ExtractTokenFromHeader(ctx.Value("http.Request").(*http.Request))
 	if err != nil {
@@ -69,7 +78,7 @@ func adminDirective(ctx context.Context, _ interface{}, next graphql.Resolver, r
 	}
 
 	// トークンを検証し、ユーザーIDをcontextに保存
-	h := ctx.Value("handlers").(handlers.Handlers) //ハンドラをコンテキストに入れたので取得
+	h := ctx.Value("handlers").(*handlers.Handlers) //ハンドラをコンテキストに入れたので取得
 	tc := h.TokenConfig
 	ctx, err = auth.AuthenticateToken(ctx, tokenString, *tc)
 	if err != nil {
@@ -77,12 +86,7 @@ func adminDirective(ctx context.Context, _ interface{}, next graphql.Resolver, r
 	}
 
 	//追加で、権限を持っているか確認
-	client, err := db.NewMongoClient()
-	if err != nil {
-		return nil, err
-	}
-	r := userRepository.NewUsersRepository(db.NewDB(client))
-	err = checkRole(ctx, &r, role)
+	err = checkRole(ctx, &h.UserHandler.UserRepo, h.UserHandler.SettingRepo, role)
 	if err != nil {
 		return nil, err
 	}
@@ -92,18 +96,32 @@ func adminDirective(ctx context.Context, _ interface{}, next graphql.Resolver, r
 }
 
 // 認証したユーザーが権限を持っているか確認
-func checkRole(ctx context.Context, r *userRepository.UsersRepository, role *string) error {
+func checkRole(ctx context.Context, r *userRepository.UsersRepository, sr settingRepository.SettingRepository, role *string) error {
 	loginUser, err := userService.GetUserData(ctx, *r)
 	if err != nil {
 		return err
 	}
 
-	// 手動でデリファレンスして比較
-	for _, v := range loginUser.Roles {
-		if v == *role {
-			//指定されていたロールがあった場合
-			return nil
-		}
+	if loginUser == nil || role == nil || !userService.HasRole(loginUser, *role) {
+		return errors.New("権限エラー")
+	}
+
+	//ロールに2段階認証が必須の場合、有効にしていなければ権限なしとする(2段階認証の設定画面に誘導する)
+	ok, err := userService.SatisfiesTwoFactorRequirement(ctx, loginUser, *role, sr)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("2段階認証の設定が必要です")
+	}
+	return nil
+}
+
+// isSubscription websocketで購読中のオペレーションかどうか
+func isSubscription(ctx context.Context) bool {
+	if !graphql.HasOperationContext(ctx) {
+		return false
 	}
-	return errors.New("権限エラー")
+	op := graphql.GetOperationContext(ctx).Operation
+	return op != nil && op.Operation == ast.Subscription
 }
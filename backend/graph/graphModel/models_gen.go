@@ -4,9 +4,44 @@ package graphModel
 
 import (
 	"backend/graph/schema/customModel"
+	"fmt"
+	"io"
+	"strconv"
 	"time"
 )
 
+type LoginResult interface {
+	IsLoginResult()
+}
+
+type Account struct {
+	PendingEmail     *string `json:"pendingEmail,omitempty"`
+	TwoFactorEnabled bool    `json:"twoFactorEnabled"`
+	HasPassword      bool    `json:"hasPassword"`
+}
+
+type Activity struct {
+	ID              string       `json:"id"`
+	Type            ActivityType `json:"type"`
+	UserID          string       `json:"userId"`
+	UserName        string       `json:"userName"`
+	UserImageBase64 *string      `json:"userImageBase64,omitempty"`
+	Liquor          *Liquor      `json:"liquor,omitempty"`
+	Text            *string      `json:"text,omitempty"`
+	Rate            *int         `json:"rate,omitempty"`
+	CreatedAt       time.Time    `json:"createdAt"`
+}
+
+type ActivityConnection struct {
+	Edges    []*ActivityEdge `json:"edges"`
+	PageInfo *PageInfo       `json:"pageInfo"`
+}
+
+type ActivityEdge struct {
+	Cursor string    `json:"cursor"`
+	Node   *Activity `json:"node"`
+}
+
 type AffiliateData struct {
 	Items       []*AffiliateItem `json:"items,omitempty"`
 	LowestPrice *int             `json:"lowestPrice,omitempty"`
@@ -24,6 +59,12 @@ type AuthPayload struct {
 	User        *User  `json:"user"`
 }
 
+func (AuthPayload) IsLoginResult() {}
+
+type AuthSettings struct {
+	AdminTwoFactorRequired bool `json:"adminTwoFactorRequired"`
+}
+
 type BoardInput struct {
 	LiquorID string `json:"liquorID"`
 	Text     string `json:"text"`
@@ -79,6 +120,47 @@ type CategoryTrail struct {
 	Name string `json:"name"`
 }
 
+type CheckIn struct {
+	ID           string        `json:"id"`
+	UserID       string        `json:"userId"`
+	Liquor       *Liquor       `json:"liquor"`
+	DrankAt      time.Time     `json:"drankAt"`
+	Place        *string       `json:"place,omitempty"`
+	ServingStyle *ServingStyle `json:"servingStyle,omitempty"`
+	Price        *int          `json:"price,omitempty"`
+	Note         *string       `json:"note,omitempty"`
+	CreatedAt    time.Time     `json:"createdAt"`
+}
+
+type CheckInInput struct {
+	LiquorID     string        `json:"liquorId"`
+	DrankAt      *time.Time    `json:"drankAt,omitempty"`
+	Place        *string       `json:"place,omitempty"`
+	ServingStyle *ServingStyle `json:"servingStyle,omitempty"`
+	Price        *int          `json:"price,omitempty"`
+	Note         *string       `json:"note,omitempty"`
+}
+
+type EmailPreference struct {
+	Locale        EmailLocale          `json:"locale"`
+	Subscriptions []*EmailSubscription `json:"subscriptions"`
+}
+
+type EmailPreferenceInput struct {
+	Locale        *EmailLocale              `json:"locale,omitempty"`
+	Subscriptions []*EmailSubscriptionInput `json:"subscriptions,omitempty"`
+}
+
+type EmailSubscription struct {
+	Kind    EmailKind `json:"kind"`
+	Enabled bool      `json:"enabled"`
+}
+
+type EmailSubscriptionInput struct {
+	Kind    EmailKind `json:"kind"`
+	Enabled bool      `json:"enabled"`
+}
+
 type FlavorCellData struct {
 	X           customModel.Coordinate `json:"x"`
 	Y           customModel.Coordinate `json:"y"`
@@ -87,36 +169,104 @@ type FlavorCellData struct {
 	GuestAmount int                    `json:"guestAmount"`
 }
 
+type FlavorMapComparison struct {
+	CategoryID int                        `json:"categoryId"`
+	XNames     []string                   `json:"xNames"`
+	YNames     []string                   `json:"yNames"`
+	Items      []*FlavorMapComparisonItem `json:"items"`
+}
+
+type FlavorMapComparisonItem struct {
+	Liquor          *Liquor             `json:"liquor"`
+	UserFullAmount  int                 `json:"userFullAmount"`
+	GuestFullAmount int                 `json:"guestFullAmount"`
+	MapData         []*FlavorCellData   `json:"mapData"`
+	CentroidX       *float64            `json:"centroidX,omitempty"`
+	CentroidY       *float64            `json:"centroidY,omitempty"`
+	Spread          *float64            `json:"spread,omitempty"`
+	Overlaps        []*FlavorMapOverlap `json:"overlaps"`
+}
+
 type FlavorMapData struct {
-	CategoryID      int               `json:"categoryId"`
-	XNames          []string          `json:"xNames"`
-	YNames          []string          `json:"yNames"`
-	UserFullAmount  int               `json:"userFullAmount"`
-	GuestFullAmount int               `json:"guestFullAmount"`
-	MapData         []*FlavorCellData `json:"mapData"`
+	CategoryID      int                 `json:"categoryId"`
+	XNames          []string            `json:"xNames"`
+	YNames          []string            `json:"yNames"`
+	UserFullAmount  int                 `json:"userFullAmount"`
+	GuestFullAmount int                 `json:"guestFullAmount"`
+	MapData         []*FlavorCellData   `json:"mapData"`
+	Weighting       *FlavorMapWeighting `json:"weighting"`
+}
+
+type FlavorMapMaster struct {
+	CategoryID   int                 `json:"categoryId"`
+	CategoryName string              `json:"categoryName"`
+	XNames       []string            `json:"xNames"`
+	YNames       []string            `json:"yNames"`
+	VersionNo    int                 `json:"versionNo"`
+	Migration    *FlavorMapMigration `json:"migration,omitempty"`
+	UpdatedAt    *time.Time          `json:"updatedAt,omitempty"`
+	Weighting    *FlavorMapWeighting `json:"weighting"`
+}
+
+type FlavorMapMasterInput struct {
+	CategoryID int                      `json:"categoryId"`
+	XNames     []string                 `json:"xNames"`
+	YNames     []string                 `json:"yNames"`
+	Weighting  *FlavorMapWeightingInput `json:"weighting,omitempty"`
+}
+
+type FlavorMapOverlap struct {
+	LiquorID    string  `json:"liquorId"`
+	Coefficient float64 `json:"coefficient"`
+}
+
+type FlavorMapWeighting struct {
+	UserWeight       float64 `json:"userWeight"`
+	GuestWeight      float64 `json:"guestWeight"`
+	ReputationWeight float64 `json:"reputationWeight"`
+	HalfLifeDays     float64 `json:"halfLifeDays"`
+	SmoothingSigma   float64 `json:"smoothingSigma"`
+}
+
+type FlavorMapWeightingInput struct {
+	UserWeight       float64 `json:"userWeight"`
+	GuestWeight      float64 `json:"guestWeight"`
+	ReputationWeight float64 `json:"reputationWeight"`
+	HalfLifeDays     float64 `json:"halfLifeDays"`
+	SmoothingSigma   float64 `json:"smoothingSigma"`
+}
+
+type LinkedIdentity struct {
+	Provider   string    `json:"provider"`
+	Name       string    `json:"name"`
+	Email      *string   `json:"email,omitempty"`
+	LinkedAt   time.Time `json:"linkedAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
 }
 
 type Liquor struct {
-	ID             string           `json:"id"`
-	CategoryID     int              `json:"categoryId"`
-	CategoryName   string           `json:"categoryName"`
-	CategoryTrail  []*CategoryTrail `json:"categoryTrail,omitempty"`
-	Name           string           `json:"name"`
-	Description    *string          `json:"description,omitempty"`
-	ImageURL       *string          `json:"imageUrl,omitempty"`
-	ImageBase64    *string          `json:"imageBase64,omitempty"`
-	Youtube        *string          `json:"youtube,omitempty"`
-	UpdatedAt      time.Time        `json:"updatedAt"`
-	Rate5Users     []string         `json:"rate5Users"`
-	Rate4Users     []string         `json:"rate4Users"`
-	Rate3Users     []string         `json:"rate3Users"`
-	Rate2Users     []string         `json:"rate2Users"`
-	Rate1Users     []string         `json:"rate1Users"`
-	CreateUserID   *string          `json:"createUserId,omitempty"`
-	CreateUserName *string          `json:"createUserName,omitempty"`
-	UpdateUserID   *string          `json:"updateUserId,omitempty"`
-	UpdateUserName *string          `json:"updateUserName,omitempty"`
-	VersionNo      int              `json:"versionNo"`
+	ID             string               `json:"id"`
+	CategoryID     int                  `json:"categoryId"`
+	CategoryName   string               `json:"categoryName"`
+	CategoryTrail  []*CategoryTrail     `json:"categoryTrail,omitempty"`
+	Name           string               `json:"name"`
+	Description    *string              `json:"description,omitempty"`
+	ImageURL       *string              `json:"imageUrl,omitempty"`
+	ImageBase64    *string              `json:"imageBase64,omitempty"`
+	Youtube        *string              `json:"youtube,omitempty"`
+	UpdatedAt      time.Time            `json:"updatedAt"`
+	Rate5Users     []string             `json:"rate5Users"`
+	Rate4Users     []string             `json:"rate4Users"`
+	Rate3Users     []string             `json:"rate3Users"`
+	Rate2Users     []string             `json:"rate2Users"`
+	Rate1Users     []string             `json:"rate1Users"`
+	CreateUserID   *string              `json:"createUserId,omitempty"`
+	CreateUserName *string              `json:"createUserName,omitempty"`
+	UpdateUserID   *string              `json:"updateUserId,omitempty"`
+	UpdateUserName *string              `json:"updateUserName,omitempty"`
+	VersionNo      int                  `json:"versionNo"`
+	CheckInCount   int                  `json:"checkInCount"`
+	ListMentions   []*LiquorListMention `json:"listMentions"`
 }
 
 type LiquorHistory struct {
@@ -124,6 +274,52 @@ type LiquorHistory struct {
 	Histories []*Liquor `json:"histories,omitempty"`
 }
 
+type LiquorList struct {
+	ID            string             `json:"id"`
+	UserID        string             `json:"userId"`
+	UserName      string             `json:"userName"`
+	Title         string             `json:"title"`
+	Description   *string            `json:"description,omitempty"`
+	IsPublic      bool               `json:"isPublic"`
+	Entries       []*LiquorListEntry `json:"entries"`
+	ForkedFrom    *string            `json:"forkedFrom,omitempty"`
+	FollowerCount int                `json:"followerCount"`
+	IsBookmarked  bool               `json:"isBookmarked"`
+	CreatedAt     time.Time          `json:"createdAt"`
+	UpdatedAt     time.Time          `json:"updatedAt"`
+}
+
+type LiquorListEntry struct {
+	Liquor  *Liquor `json:"liquor"`
+	Comment *string `json:"comment,omitempty"`
+}
+
+type LiquorListEntryInput struct {
+	LiquorID string  `json:"liquorId"`
+	Comment  *string `json:"comment,omitempty"`
+}
+
+type LiquorListInput struct {
+	Title       string                  `json:"title"`
+	Description *string                 `json:"description,omitempty"`
+	IsPublic    bool                    `json:"isPublic"`
+	Entries     []*LiquorListEntryInput `json:"entries"`
+}
+
+type LiquorListMention struct {
+	ListID        string  `json:"listId"`
+	Title         string  `json:"title"`
+	UserID        string  `json:"userId"`
+	UserName      string  `json:"userName"`
+	FollowerCount int     `json:"followerCount"`
+	Comment       *string `json:"comment,omitempty"`
+}
+
+type LiquorListPage struct {
+	Lists      []*LiquorList `json:"lists"`
+	TotalCount int           `json:"totalCount"`
+}
+
 type ListFromCategory struct {
 	CategoryName        string    `json:"categoryName"`
 	CategoryDescription *string   `json:"categoryDescription,omitempty"`
@@ -135,9 +331,55 @@ type LoginInput struct {
 	Password string `json:"password"`
 }
 
+type LoginLock struct {
+	Action       string    `json:"action"`
+	Kind         string    `json:"kind"`
+	Value        string    `json:"value"`
+	Failures     int       `json:"failures"`
+	LastFailedAt time.Time `json:"lastFailedAt"`
+	LockedUntil  time.Time `json:"lockedUntil"`
+}
+
 type Mutation struct {
 }
 
+type Notification struct {
+	ID        string           `json:"id"`
+	Type      NotificationType `json:"type"`
+	ActorID   *string          `json:"actorId,omitempty"`
+	ActorName *string          `json:"actorName,omitempty"`
+	Liquor    *Liquor          `json:"liquor,omitempty"`
+	Text      *string          `json:"text,omitempty"`
+	Rate      *int             `json:"rate,omitempty"`
+	Read      bool             `json:"read"`
+	CreatedAt time.Time        `json:"createdAt"`
+}
+
+type NotificationConnection struct {
+	Edges    []*NotificationEdge `json:"edges"`
+	PageInfo *PageInfo           `json:"pageInfo"`
+}
+
+type NotificationEdge struct {
+	Cursor string        `json:"cursor"`
+	Node   *Notification `json:"node"`
+}
+
+type NotificationPreference struct {
+	Type    NotificationType `json:"type"`
+	Enabled bool             `json:"enabled"`
+}
+
+type NotificationPreferenceInput struct {
+	Type    NotificationType `json:"type"`
+	Enabled bool             `json:"enabled"`
+}
+
+type PageInfo struct {
+	EndCursor   *string `json:"endCursor,omitempty"`
+	HasNextPage bool    `json:"hasNextPage"`
+}
+
 type PostFlavorMap struct {
 	LiquorID string                 `json:"liquorId"`
 	X        customModel.Coordinate `json:"x"`
@@ -170,6 +412,14 @@ type RecommendUser struct {
 	ImageBase64 *string `json:"imageBase64,omitempty"`
 }
 
+type RecommendedLiquor struct {
+	Liquor          *Liquor              `json:"liquor"`
+	Score           float64              `json:"score"`
+	Reason          string               `json:"reason"`
+	BasedOnLiquorID *string              `json:"basedOnLiquorId,omitempty"`
+	Source          RecommendationSource `json:"source"`
+}
+
 type RegisterInput struct {
 	Name        string  `json:"name"`
 	Email       string  `json:"email"`
@@ -178,6 +428,23 @@ type RegisterInput struct {
 	ImageBase64 *string `json:"imageBase64,omitempty"`
 }
 
+type Session struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	Current    bool      `json:"current"`
+}
+
+type SimilarLiquor struct {
+	Liquor     *Liquor `json:"liquor"`
+	Similarity float64 `json:"similarity"`
+}
+
+type Subscription struct {
+}
+
 type Tag struct {
 	ID   string `json:"id"`
 	Text string `json:"text"`
@@ -188,13 +455,26 @@ type TagInput struct {
 	Text     string `json:"text"`
 }
 
+type TwoFactorChallenge struct {
+	ChallengeToken string    `json:"challengeToken"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+}
+
+func (TwoFactorChallenge) IsLoginResult() {}
+
+type TwoFactorSetup struct {
+	OtpauthURI string `json:"otpauthUri"`
+	Secret     string `json:"secret"`
+}
+
 type User struct {
-	ID          string   `json:"id"`
-	Name        string   `json:"name"`
-	Email       string   `json:"email"`
-	Profile     *string  `json:"profile,omitempty"`
-	ImageBase64 *string  `json:"imageBase64,omitempty"`
-	Roles       []string `json:"roles,omitempty"`
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Email         string   `json:"email"`
+	Profile       *string  `json:"profile,omitempty"`
+	ImageBase64   *string  `json:"imageBase64,omitempty"`
+	Roles         []string `json:"roles,omitempty"`
+	EmailVerified bool     `json:"emailVerified"`
 }
 
 type UserEvaluateList struct {
@@ -207,6 +487,19 @@ type UserEvaluateList struct {
 	NoRateLiquors  []*UserLiquor `json:"noRateLiquors,omitempty"`
 }
 
+type UserFlavorProfile struct {
+	CategoryID   int      `json:"categoryId"`
+	CategoryName string   `json:"categoryName"`
+	XNames       []string `json:"xNames"`
+	YNames       []string `json:"yNames"`
+	CentroidX    float64  `json:"centroidX"`
+	CentroidY    float64  `json:"centroidY"`
+	Spread       float64  `json:"spread"`
+	VoteCount    int      `json:"voteCount"`
+	XPreference  *string  `json:"xPreference,omitempty"`
+	YPreference  *string  `json:"yPreference,omitempty"`
+}
+
 type UserLiquor struct {
 	ID           string    `json:"id"`
 	LiquorID     string    `json:"liquorId"`
@@ -220,8 +513,9 @@ type UserLiquor struct {
 }
 
 type UserPageData struct {
-	EvaluateList *UserEvaluateList `json:"evaluateList"`
-	User         *User             `json:"user"`
+	EvaluateList   *UserEvaluateList    `json:"evaluateList"`
+	User           *User                `json:"user"`
+	FlavorProfiles []*UserFlavorProfile `json:"flavorProfiles"`
 }
 
 type VotedData struct {
@@ -232,3 +526,311 @@ type VotedData struct {
 	Y          customModel.Coordinate `json:"y"`
 	UpdatedAt  time.Time              `json:"updatedAt"`
 }
+
+type WishlistItem struct {
+	Liquor    *Liquor   `json:"liquor"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type ActivityType string
+
+const (
+	ActivityTypeBoardPost    ActivityType = "BOARD_POST"
+	ActivityTypeLiquorCreate ActivityType = "LIQUOR_CREATE"
+	ActivityTypeLiquorUpdate ActivityType = "LIQUOR_UPDATE"
+	ActivityTypeTag          ActivityType = "TAG"
+	ActivityTypeFlavorVote   ActivityType = "FLAVOR_VOTE"
+)
+
+var AllActivityType = []ActivityType{
+	ActivityTypeBoardPost,
+	ActivityTypeLiquorCreate,
+	ActivityTypeLiquorUpdate,
+	ActivityTypeTag,
+	ActivityTypeFlavorVote,
+}
+
+func (e ActivityType) IsValid() bool {
+	switch e {
+	case ActivityTypeBoardPost, ActivityTypeLiquorCreate, ActivityTypeLiquorUpdate, ActivityTypeTag, ActivityTypeFlavorVote:
+		return true
+	}
+	return false
+}
+
+func (e ActivityType) String() string {
+	return string(e)
+}
+
+func (e *ActivityType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ActivityType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ActivityType", str)
+	}
+	return nil
+}
+
+func (e ActivityType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type EmailKind string
+
+const (
+	EmailKindWeeklyDigest EmailKind = "WEEKLY_DIGEST"
+)
+
+var AllEmailKind = []EmailKind{
+	EmailKindWeeklyDigest,
+}
+
+func (e EmailKind) IsValid() bool {
+	switch e {
+	case EmailKindWeeklyDigest:
+		return true
+	}
+	return false
+}
+
+func (e EmailKind) String() string {
+	return string(e)
+}
+
+func (e *EmailKind) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = EmailKind(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid EmailKind", str)
+	}
+	return nil
+}
+
+func (e EmailKind) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type EmailLocale string
+
+const (
+	EmailLocaleJa EmailLocale = "JA"
+	EmailLocaleEn EmailLocale = "EN"
+)
+
+var AllEmailLocale = []EmailLocale{
+	EmailLocaleJa,
+	EmailLocaleEn,
+}
+
+func (e EmailLocale) IsValid() bool {
+	switch e {
+	case EmailLocaleJa, EmailLocaleEn:
+		return true
+	}
+	return false
+}
+
+func (e EmailLocale) String() string {
+	return string(e)
+}
+
+func (e *EmailLocale) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = EmailLocale(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid EmailLocale", str)
+	}
+	return nil
+}
+
+func (e EmailLocale) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type FlavorMapMigration string
+
+const (
+	FlavorMapMigrationKeep     FlavorMapMigration = "KEEP"
+	FlavorMapMigrationMirrorX  FlavorMapMigration = "MIRROR_X"
+	FlavorMapMigrationMirrorY  FlavorMapMigration = "MIRROR_Y"
+	FlavorMapMigrationMirrorXy FlavorMapMigration = "MIRROR_XY"
+	FlavorMapMigrationReset    FlavorMapMigration = "RESET"
+)
+
+var AllFlavorMapMigration = []FlavorMapMigration{
+	FlavorMapMigrationKeep,
+	FlavorMapMigrationMirrorX,
+	FlavorMapMigrationMirrorY,
+	FlavorMapMigrationMirrorXy,
+	FlavorMapMigrationReset,
+}
+
+func (e FlavorMapMigration) IsValid() bool {
+	switch e {
+	case FlavorMapMigrationKeep, FlavorMapMigrationMirrorX, FlavorMapMigrationMirrorY, FlavorMapMigrationMirrorXy, FlavorMapMigrationReset:
+		return true
+	}
+	return false
+}
+
+func (e FlavorMapMigration) String() string {
+	return string(e)
+}
+
+func (e *FlavorMapMigration) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = FlavorMapMigration(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid FlavorMapMigration", str)
+	}
+	return nil
+}
+
+func (e FlavorMapMigration) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type NotificationType string
+
+const (
+	NotificationTypeBookmarked   NotificationType = "BOOKMARKED"
+	NotificationTypeBoardPosted  NotificationType = "BOARD_POSTED"
+	NotificationTypeLiquorEdited NotificationType = "LIQUOR_EDITED"
+	NotificationTypeTagAdded     NotificationType = "TAG_ADDED"
+)
+
+var AllNotificationType = []NotificationType{
+	NotificationTypeBookmarked,
+	NotificationTypeBoardPosted,
+	NotificationTypeLiquorEdited,
+	NotificationTypeTagAdded,
+}
+
+func (e NotificationType) IsValid() bool {
+	switch e {
+	case NotificationTypeBookmarked, NotificationTypeBoardPosted, NotificationTypeLiquorEdited, NotificationTypeTagAdded:
+		return true
+	}
+	return false
+}
+
+func (e NotificationType) String() string {
+	return string(e)
+}
+
+func (e *NotificationType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = NotificationType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid NotificationType", str)
+	}
+	return nil
+}
+
+func (e NotificationType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type RecommendationSource string
+
+const (
+	RecommendationSourceSimilarity RecommendationSource = "SIMILARITY"
+	RecommendationSourceBookmark   RecommendationSource = "BOOKMARK"
+)
+
+var AllRecommendationSource = []RecommendationSource{
+	RecommendationSourceSimilarity,
+	RecommendationSourceBookmark,
+}
+
+func (e RecommendationSource) IsValid() bool {
+	switch e {
+	case RecommendationSourceSimilarity, RecommendationSourceBookmark:
+		return true
+	}
+	return false
+}
+
+func (e RecommendationSource) String() string {
+	return string(e)
+}
+
+func (e *RecommendationSource) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = RecommendationSource(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid RecommendationSource", str)
+	}
+	return nil
+}
+
+func (e RecommendationSource) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ServingStyle string
+
+const (
+	ServingStyleCold ServingStyle = "COLD"
+	ServingStyleRoom ServingStyle = "ROOM"
+	ServingStyleWarm ServingStyle = "WARM"
+)
+
+var AllServingStyle = []ServingStyle{
+	ServingStyleCold,
+	ServingStyleRoom,
+	ServingStyleWarm,
+}
+
+func (e ServingStyle) IsValid() bool {
+	switch e {
+	case ServingStyleCold, ServingStyleRoom, ServingStyleWarm:
+		return true
+	}
+	return false
+}
+
+func (e ServingStyle) String() string {
+	return string(e)
+}
+
+func (e *ServingStyle) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ServingStyle(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ServingStyle", str)
+	}
+	return nil
+}
+
+func (e ServingStyle) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
@@ -3,14 +3,32 @@ package graph
 import (
 	"backend/graph/generated"
 	"backend/graph/resolver"
+	"backend/middlewares/auth"
+	"backend/service/authService/tokenConfig"
+	"backend/service/drinkService"
+	"context"
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
 	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gorilla/websocket"
+	"github.com/vektah/gqlparser/v2/ast"
+	"net/http"
+	"strings"
+	"time"
 )
 
+// websocketExpiredReason トークンの有効期限が切れて接続を閉じる際に、クライアントへ返す理由
+const websocketExpiredReason = "トークンの有効期限が切れました"
+
+// websocketCancelKey 接続の期限のタイマーを、接続が閉じた時に解放するためのキー
+type websocketCancelKey struct{}
+
 func NewGraphQLServer(resolver *resolver.Resolver) *handler.Server {
 	// GraphQLサーバーをセットアップ
-	srv := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{
+	// memo:NewDefaultServerは認証なしのwebsocketトランスポートを先に登録してしまうので、トランスポートは個別に登録する
+	srv := handler.New(generated.NewExecutableSchema(generated.Config{
 		Resolvers: resolver,
 		Directives: generated.DirectiveRoot{
 			Auth:         authDirective,         // @authディレクティブを適用
@@ -19,15 +37,68 @@ func NewGraphQLServer(resolver *resolver.Resolver) *handler.Server {
 		},
 	}))
 
+	// Subscription用のwebsocketトランスポートを追加(接続開始時にJWTで認証する)
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		InitFunc:              websocketInit(resolver.UserTokenConfig),
+		CloseFunc:             websocketClose,
+		Upgrader: websocket.Upgrader{
+			// 認証はクッキーではなく接続開始時のpayloadで行うので、オリジンは制限しない
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	})
+
 	// POSTトランスポートを追加
 	srv.AddTransport(transport.POST{})
 
 	// 必要に応じてGETとOPTIONSもサポート
 	srv.AddTransport(transport.GET{})
 	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.MultipartForm{})
+
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+
+	// 一覧のお酒ごとに呼ばれるフィールドは、リクエスト単位のローダーでまとめて取得する
+	srv.AroundOperations(func(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+		return next(drinkService.WithCheckInCountLoader(ctx, &resolver.DrinkRepo))
+	})
 
 	// Introspectionを有効にする（GraphiQLからのクエリのため）
 	srv.Use(extension.Introspection{})
+	srv.Use(extension.AutomaticPersistedQuery{
+		Cache: lru.New[string](100),
+	})
 
 	return srv
 }
+
+// websocketInit 接続開始時のpayloadのAuthorizationを検証し、ユーザーIDをcontextに保存する
+// トークンがない場合はゲストとして接続を許可し、不正なトークンの場合は接続を拒否する
+// 接続中は再認証しないので、トークンの有効期限が切れたら接続を閉じる(クライアントは更新したトークンで接続し直す)
+func websocketInit(tc tokenConfig.TokenConfig) transport.WebsocketInitFunc {
+	return func(ctx context.Context, initPayload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+		tokenString := strings.TrimPrefix(initPayload.Authorization(), "Bearer ")
+		if tokenString == "" {
+			return ctx, &initPayload, nil
+		}
+		ctx, expiresAt, err := auth.AuthenticateTokenWithExpiry(ctx, tokenString, tc)
+		if err != nil {
+			return ctx, nil, err
+		}
+		if expiresAt != nil {
+			ctx = transport.AppendCloseReason(ctx, websocketExpiredReason)
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, *expiresAt)
+			// 期限より先に接続が閉じた場合は、websocketCloseでタイマーを解放する
+			ctx = context.WithValue(ctx, websocketCancelKey{}, cancel)
+		}
+		return ctx, &initPayload, nil
+	}
+}
+
+// websocketClose 接続が閉じたら、websocketInitで設定した期限のタイマーを解放する
+func websocketClose(ctx context.Context, _ int) {
+	if cancel, ok := ctx.Value(websocketCancelKey{}).(context.CancelFunc); ok {
+		cancel()
+	}
+}
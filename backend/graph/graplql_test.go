@@ -0,0 +1,78 @@
+package graph
+
+import (
+	"backend/middlewares/auth"
+	"backend/service/authService/tokenConfig"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func signedToken(t *testing.T, tc tokenConfig.TokenConfig, id primitive.ObjectID, expiresAt time.Time) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, auth.Claims{
+		Id:               id,
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(expiresAt)},
+	}).SignedString(tc.AccessSecretKey)
+	require.NoError(t, err)
+	return token
+}
+
+func TestWebsocketInit_正常系_トークンの有効期限で接続のcontextが終了すること(t *testing.T) {
+	tc := tokenConfig.TokenConfig{AccessSecretKey: []byte("access")}
+	id := primitive.NewObjectID()
+	expiresAt := time.Now().Add(time.Minute).Truncate(time.Second)
+	payload := transport.InitPayload{"Authorization": "Bearer " + signedToken(t, tc, id, expiresAt)}
+
+	ctx, _, err := websocketInit(tc)(context.Background(), payload)
+
+	require.NoError(t, err)
+	uId, cErr := auth.GetId(ctx)
+	assert.Nil(t, cErr)
+	assert.Equal(t, id, uId)
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.True(t, expiresAt.Equal(deadline))
+}
+
+func TestWebsocketClose_正常系_接続が閉じたら期限を待たずにcontextが終了すること(t *testing.T) {
+	tc := tokenConfig.TokenConfig{AccessSecretKey: []byte("access")}
+	payload := transport.InitPayload{"Authorization": "Bearer " + signedToken(t, tc, primitive.NewObjectID(), time.Now().Add(time.Hour))}
+	ctx, _, err := websocketInit(tc)(context.Background(), payload)
+	require.NoError(t, err)
+	require.NoError(t, ctx.Err())
+
+	websocketClose(ctx, 1000)
+
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+}
+
+func TestWebsocketClose_正常系_ゲストの接続でも閉じられること(t *testing.T) {
+	ctx, _, err := websocketInit(tokenConfig.TokenConfig{})(context.Background(), transport.InitPayload{})
+	require.NoError(t, err)
+
+	assert.NotPanics(t, func() { websocketClose(ctx, 1000) })
+}
+
+func TestWebsocketInit_正常系_トークンがなければ期限なしのゲストとして接続すること(t *testing.T) {
+	ctx, payload, err := websocketInit(tokenConfig.TokenConfig{})(context.Background(), transport.InitPayload{})
+
+	require.NoError(t, err)
+	assert.NotNil(t, payload)
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+}
+
+func TestWebsocketInit_異常系_期限切れのトークンは接続を拒否すること(t *testing.T) {
+	tc := tokenConfig.TokenConfig{AccessSecretKey: []byte("access")}
+	payload := transport.InitPayload{"Authorization": "Bearer " + signedToken(t, tc, primitive.NewObjectID(), time.Now().Add(-time.Minute))}
+
+	_, _, err := websocketInit(tc)(context.Background(), payload)
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,25 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.68
+
+import (
+	"backend/graph/graphModel"
+	"backend/middlewares/auth"
+	"backend/service/activityService"
+	"context"
+)
+
+// ActivityFeed is the resolver for the activityFeed field.
+func (r *queryResolver) ActivityFeed(ctx context.Context, first *int, after *string) (*graphModel.ActivityConnection, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	feed, err := activityService.GetFeed(ctx, &r.ActivityRepo, &r.BookmarkRepo, &r.LiquorRepo, uId, first, after)
+	if err != nil {
+		return nil, err
+	}
+	return feed.ToGraphQL(), nil
+}
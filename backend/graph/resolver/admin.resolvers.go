@@ -5,11 +5,59 @@ package resolver
 // Code generated by github.com/99designs/gqlgen version v0.17.68
 
 import (
+	"backend/graph/graphModel"
+	"backend/middlewares/auth"
+	"backend/service/authService"
 	"context"
 )
 
+// SetAdminTwoFactorRequired is the resolver for the setAdminTwoFactorRequired field.
+func (r *mutationResolver) SetAdminTwoFactorRequired(ctx context.Context, required bool) (*graphModel.AuthSettings, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	setting, err := authService.SetAdminTwoFactorRequired(ctx, &r.UserRepo, &r.SettingRepo, uId, required)
+	if err != nil {
+		return nil, err
+	}
+	return setting.ToGraphQL(), nil
+}
+
+// UnlockLogin is the resolver for the unlockLogin field.
+func (r *mutationResolver) UnlockLogin(ctx context.Context, email *string, ip *string) (int, error) {
+	deleted, err := authService.UnlockLogin(ctx, &r.AttemptRepo, email, ip)
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
 // CheckAdmin is the resolver for the checkAdmin field.
 func (r *queryResolver) CheckAdmin(ctx context.Context) (bool, error) {
 	// ディレクティブで認証が完了している
 	return true, nil
 }
+
+// AuthSettings is the resolver for the authSettings field.
+func (r *queryResolver) AuthSettings(ctx context.Context) (*graphModel.AuthSettings, error) {
+	setting, err := r.SettingRepo.GetAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return setting.ToGraphQL(), nil
+}
+
+// LoginLocks is the resolver for the loginLocks field.
+func (r *queryResolver) LoginLocks(ctx context.Context) ([]*graphModel.LoginLock, error) {
+	locks, err := authService.ListLoginLocks(ctx, &r.AttemptRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*graphModel.LoginLock, len(locks))
+	for i, l := range locks {
+		result[i] = l.ToGraphQL()
+	}
+	return result, nil
+}
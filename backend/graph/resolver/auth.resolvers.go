@@ -5,7 +5,9 @@ package resolver
 // Code generated by github.com/99designs/gqlgen version v0.17.68
 
 import (
+	"backend/api/sso"
 	"backend/graph/graphModel"
+	"backend/middlewares/auth"
 	"backend/service/authService"
 	"context"
 )
@@ -13,35 +15,32 @@ import (
 // RegisterUser is the resolver for the registerUser field.
 func (r *mutationResolver) RegisterUser(ctx context.Context, input graphModel.RegisterInput) (*graphModel.AuthPayload, error) {
 	//登録して、挿入したデータを受け取る
-	newUser, err := authService.RegisterUser(ctx, r.UserRepo, input)
+	newUser, err := authService.RegisterUser(ctx, r.UserRepo, &r.AttemptRepo, r.Mailer, input, getClientIP(ctx))
 	if err != nil {
 		return nil, err
 	}
 
-	//ログイン処理を流用する
-	u, instantErr := r.Login(ctx, graphModel.LoginInput{
-		Email:    *newUser.Email,
-		Password: *input.Password,
-	})
-	if instantErr != nil {
-		return nil, instantErr
+	//登録したユーザーでログインする(登録直後なので2段階認証は有効になっていない)
+	u, err := authService.LoginByUser(ctx, newUser, getResponseWriter(ctx), r.UserTokenConfig, &r.SessionRepo, getClient(ctx))
+	if err != nil {
+		return nil, err
 	}
-	return u, nil
+	return u.ToGraphQL(), nil
 }
 
 // Login is the resolver for the login field.
-func (r *mutationResolver) Login(ctx context.Context, input graphModel.LoginInput) (*graphModel.AuthPayload, error) {
-	user, err := authService.LoginWithInput(ctx, getResponseWriter(ctx), input, &r.UserRepo, r.UserTokenConfig)
+func (r *mutationResolver) Login(ctx context.Context, input graphModel.LoginInput) (graphModel.LoginResult, error) {
+	result, err := authService.LoginWithInput(ctx, getResponseWriter(ctx), input, &r.UserRepo, &r.AttemptRepo, r.UserTokenConfig, &r.SessionRepo, getClient(ctx))
 	if err != nil {
 		return nil, err
 	}
 
-	return user.ToGraphQL(), nil
+	return result.ToGraphQL(), nil
 }
 
 // RefreshToken 単にリフレッシュトークンの更新をするAPI(Vueストアにユーザーデータが存在しており、アクセストークンが切れた導線)
 func (r *mutationResolver) RefreshToken(ctx context.Context) (string, error) {
-	token, err := authService.RefreshTokens(getHttpRequest(ctx), getResponseWriter(ctx), r.UserTokenConfig)
+	token, err := authService.RefreshTokens(ctx, getHttpRequest(ctx), getResponseWriter(ctx), r.UserTokenConfig, &r.SessionRepo, getClient(ctx))
 	if err != nil {
 		return "", err
 	}
@@ -50,7 +49,7 @@ func (r *mutationResolver) RefreshToken(ctx context.Context) (string, error) {
 
 // LoginWithRefreshToken こちらはリフレッシュトークンを用いてログインするAPI(リロードなどでユーザーデータも同時取得する導線・実質再ログイン)
 func (r *mutationResolver) LoginWithRefreshToken(ctx context.Context) (*graphModel.AuthPayload, error) {
-	userWithToken, err := authService.LoginWithRefreshToken(ctx, getHttpRequest(ctx), getResponseWriter(ctx), r.UserTokenConfig, &r.UserRepo)
+	userWithToken, err := authService.LoginWithRefreshToken(ctx, getHttpRequest(ctx), getResponseWriter(ctx), r.UserTokenConfig, &r.UserRepo, &r.SessionRepo, getClient(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -59,7 +58,11 @@ func (r *mutationResolver) LoginWithRefreshToken(ctx context.Context) (*graphMod
 
 // Logout is the resolver for the logout field.
 func (r *mutationResolver) Logout(ctx context.Context) (bool, error) {
-	err := authService.DeleteRefreshToken(getResponseWriter(ctx))
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return false, err
+	}
+	err = authService.Logout(ctx, getHttpRequest(ctx), getResponseWriter(ctx), r.UserTokenConfig, &r.SessionRepo, uId)
 	if err != nil {
 		return false, err
 	}
@@ -68,7 +71,7 @@ func (r *mutationResolver) Logout(ctx context.Context) (bool, error) {
 
 // ResetEmail is the resolver for the resetEmail field.
 func (r *mutationResolver) ResetEmail(ctx context.Context, email string) (bool, error) {
-	_, err := authService.ResetEmail(ctx, r.UserRepo, email)
+	_, err := authService.ResetEmail(ctx, r.UserRepo, &r.AttemptRepo, r.Mailer, email, getClientIP(ctx))
 	if err != nil {
 		return false, err
 	}
@@ -76,8 +79,8 @@ func (r *mutationResolver) ResetEmail(ctx context.Context, email string) (bool,
 }
 
 // ResetExe is the resolver for the resetExe field.
-func (r *mutationResolver) ResetExe(ctx context.Context, token string, password string) (*graphModel.AuthPayload, error) {
-	user, err := authService.PasswordResetExe(ctx, r.UserRepo, token, password)
+func (r *mutationResolver) ResetExe(ctx context.Context, token string, password string) (graphModel.LoginResult, error) {
+	user, err := authService.PasswordResetExe(ctx, r.UserRepo, &r.SessionRepo, token, password)
 	if err != nil {
 		return nil, err
 	}
@@ -91,3 +94,197 @@ func (r *mutationResolver) ResetExe(ctx context.Context, token string, password
 	}
 	return lUser, nil
 }
+
+// VerifyEmail is the resolver for the verifyEmail field.
+func (r *mutationResolver) VerifyEmail(ctx context.Context, token string) (bool, error) {
+	uId, err := auth.GetIdNullable(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err = authService.VerifyEmail(ctx, r.UserRepo, token, uId); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ResendVerificationEmail is the resolver for the resendVerificationEmail field.
+func (r *mutationResolver) ResendVerificationEmail(ctx context.Context) (bool, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err = authService.ResendVerifyEmail(ctx, r.UserRepo, r.Mailer, uId); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RevokeSession is the resolver for the revokeSession field.
+func (r *mutationResolver) RevokeSession(ctx context.Context, id string) (bool, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return false, err
+	}
+	err = authService.RevokeSession(ctx, getHttpRequest(ctx), getResponseWriter(ctx), r.UserTokenConfig, &r.SessionRepo, uId, id)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// LogoutAllDevices is the resolver for the logoutAllDevices field.
+func (r *mutationResolver) LogoutAllDevices(ctx context.Context) (bool, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err = authService.LogoutAllDevices(ctx, getResponseWriter(ctx), &r.SessionRepo, uId); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// VerifyTwoFactor is the resolver for the verifyTwoFactor field.
+func (r *mutationResolver) VerifyTwoFactor(ctx context.Context, challengeToken string, code string) (*graphModel.AuthPayload, error) {
+	user, err := authService.VerifyTwoFactor(ctx, getResponseWriter(ctx), &r.UserRepo, r.UserTokenConfig, &r.SessionRepo, getClient(ctx), challengeToken, code)
+	if err != nil {
+		return nil, err
+	}
+	return user.ToGraphQL(), nil
+}
+
+// StartTwoFactorSetup is the resolver for the startTwoFactorSetup field.
+func (r *mutationResolver) StartTwoFactorSetup(ctx context.Context) (*graphModel.TwoFactorSetup, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	setup, err := authService.StartTwoFactorSetup(ctx, &r.UserRepo, uId)
+	if err != nil {
+		return nil, err
+	}
+	return setup.ToGraphQL(), nil
+}
+
+// ConfirmTwoFactorSetup is the resolver for the confirmTwoFactorSetup field.
+func (r *mutationResolver) ConfirmTwoFactorSetup(ctx context.Context, code string) ([]string, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := authService.ConfirmTwoFactorSetup(ctx, &r.UserRepo, uId, code)
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// RegenerateRecoveryCodes is the resolver for the regenerateRecoveryCodes field.
+func (r *mutationResolver) RegenerateRecoveryCodes(ctx context.Context, code string) ([]string, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := authService.RegenerateRecoveryCodes(ctx, &r.UserRepo, uId, code)
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor is the resolver for the disableTwoFactor field.
+func (r *mutationResolver) DisableTwoFactor(ctx context.Context, code string) (bool, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err = authService.DisableTwoFactor(ctx, &r.UserRepo, &r.SettingRepo, uId, code); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// LinkIdentity is the resolver for the linkIdentity field.
+func (r *mutationResolver) LinkIdentity(ctx context.Context, provider string) (string, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return "", err
+	}
+	url, err := sso.GenerateAuthURL(ctx, getResponseWriter(ctx), &r.OAuthStateRepo, provider, &uId)
+	if err != nil {
+		return "", err
+	}
+	return *url, nil
+}
+
+// UnlinkIdentity is the resolver for the unlinkIdentity field.
+func (r *mutationResolver) UnlinkIdentity(ctx context.Context, provider string) (bool, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err = authService.UnlinkIdentity(ctx, r.DB.Client(), &r.UserRepo, &r.IdentityRepo, uId, provider); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetPassword is the resolver for the setPassword field.
+func (r *mutationResolver) SetPassword(ctx context.Context, password string) (bool, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err = authService.SetPassword(ctx, &r.UserRepo, uId, password); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ResolveIdentityConflict is the resolver for the resolveIdentityConflict field.
+func (r *mutationResolver) ResolveIdentityConflict(ctx context.Context, conflictToken string) (bool, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err = authService.ResolveIdentityConflict(ctx, r.DB.Client(), &r.UserRepo, &r.IdentityRepo, &r.SessionRepo, r.Mailer, r.UserTokenConfig, uId, conflictToken); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// MySessions is the resolver for the mySessions field.
+func (r *queryResolver) MySessions(ctx context.Context) ([]*graphModel.Session, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := authService.ListSessions(ctx, getHttpRequest(ctx), r.UserTokenConfig, &r.SessionRepo, uId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*graphModel.Session, len(sessions))
+	for i, s := range sessions {
+		result[i] = s.ToGraphQL()
+	}
+	return result, nil
+}
+
+// LinkedIdentities is the resolver for the linkedIdentities field.
+func (r *queryResolver) LinkedIdentities(ctx context.Context) ([]*graphModel.LinkedIdentity, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	identities, err := authService.ListIdentities(ctx, &r.UserRepo, &r.IdentityRepo, uId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*graphModel.LinkedIdentity, len(identities))
+	for i, identity := range identities {
+		result[i] = identity.ToGraphQL()
+	}
+	return result, nil
+}
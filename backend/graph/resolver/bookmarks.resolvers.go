@@ -21,7 +21,7 @@ func (r *mutationResolver) AddBookMark(ctx context.Context, id string) (bool, er
 	if err != nil {
 		return false, err
 	}
-	err = r.BookmarkRepo.Add(ctx, uId, targetId)
+	err = bookmarkService.AddBookMark(ctx, &r.BookmarkRepo, &r.NotificationRepo, r.PubSub, uId, targetId)
 	if err != nil {
 		return false, err
 	}
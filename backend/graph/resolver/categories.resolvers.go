@@ -10,6 +10,24 @@ import (
 	"context"
 )
 
+// ReorderCategories is the resolver for the reorderCategories field.
+func (r *mutationResolver) ReorderCategories(ctx context.Context, parentID *int, ids []int) (bool, error) {
+	err := categoryService.ReorderCategories(ctx, &r.CategoryRepo, parentID, ids)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RollbackCategory is the resolver for the rollbackCategory field.
+func (r *mutationResolver) RollbackCategory(ctx context.Context, id int, versionNo int, expectedVersionNo int) (*graphModel.Category, error) {
+	category, err := categoryService.RollbackCategory(ctx, r.DB.Client(), &r.CategoryRepo, &r.UserRepo, id, versionNo, expectedVersionNo)
+	if err != nil {
+		return nil, err
+	}
+	return category.ToGraphQL(), nil
+}
+
 // Category is the resolver for the category field.
 func (r *queryResolver) Category(ctx context.Context, id int) (*graphModel.Category, error) {
 	//DB上に存在しないキーであり、gqlgenでしか書かない値なので一旦ハードコーディング。childrenを要求していた場合、子カテゴリを取得する。
@@ -0,0 +1,141 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.68
+
+import (
+	"backend/graph/graphModel"
+	"backend/middlewares/auth"
+	"backend/service/drinkService"
+	"context"
+)
+
+// AddWish is the resolver for the addWish field.
+func (r *mutationResolver) AddWish(ctx context.Context, liquorID string) (bool, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err = drinkService.AddWish(ctx, &r.DrinkRepo, &r.LiquorRepo, uId, liquorID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RemoveWish is the resolver for the removeWish field.
+func (r *mutationResolver) RemoveWish(ctx context.Context, liquorID string) (bool, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err = drinkService.RemoveWish(ctx, &r.DrinkRepo, uId, liquorID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CheckIn is the resolver for the checkIn field.
+func (r *mutationResolver) CheckIn(ctx context.Context, input graphModel.CheckInInput) (*graphModel.CheckIn, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	checkIn, err := drinkService.PostCheckIn(ctx, &r.DrinkRepo, &r.LiquorRepo, uId, input)
+	if err != nil {
+		return nil, err
+	}
+	return checkIn.ToGraphQL(), nil
+}
+
+// UpdateCheckIn is the resolver for the updateCheckIn field.
+func (r *mutationResolver) UpdateCheckIn(ctx context.Context, id string, input graphModel.CheckInInput) (*graphModel.CheckIn, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	checkIn, err := drinkService.UpdateCheckIn(ctx, &r.DrinkRepo, &r.LiquorRepo, uId, id, input)
+	if err != nil {
+		return nil, err
+	}
+	return checkIn.ToGraphQL(), nil
+}
+
+// DeleteCheckIn is the resolver for the deleteCheckIn field.
+func (r *mutationResolver) DeleteCheckIn(ctx context.Context, id string) (bool, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err = drinkService.DeleteCheckIn(ctx, &r.DrinkRepo, uId, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// MyWishlist is the resolver for the myWishlist field.
+func (r *queryResolver) MyWishlist(ctx context.Context) ([]*graphModel.WishlistItem, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items, err := drinkService.GetWishlist(ctx, &r.DrinkRepo, &r.LiquorRepo, uId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*graphModel.WishlistItem, len(items))
+	for i, item := range items {
+		result[i] = item.ToGraphQL()
+	}
+	return result, nil
+}
+
+// IsWished is the resolver for the isWished field.
+func (r *queryResolver) IsWished(ctx context.Context, liquorID string) (bool, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return false, err
+	}
+	wished, err := drinkService.IsWished(ctx, &r.DrinkRepo, uId, liquorID)
+	if err != nil {
+		return false, err
+	}
+	return wished, nil
+}
+
+// CheckInTimeline is the resolver for the checkInTimeline field.
+func (r *queryResolver) CheckInTimeline(ctx context.Context, userID string, page *int) ([]*graphModel.CheckIn, error) {
+	viewer, err := auth.GetIdNullable(ctx)
+	if err != nil {
+		return nil, err
+	}
+	checkIns, err := drinkService.GetCheckInTimeline(ctx, &r.DrinkRepo, &r.LiquorRepo, viewer, userID, page)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*graphModel.CheckIn, len(checkIns))
+	for i, c := range checkIns {
+		result[i] = c.ToGraphQL()
+	}
+	return result, nil
+}
+
+// MyCheckIns is the resolver for the myCheckIns field.
+func (r *queryResolver) MyCheckIns(ctx context.Context, liquorID string) ([]*graphModel.CheckIn, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	checkIns, err := drinkService.GetMyCheckIns(ctx, &r.DrinkRepo, &r.LiquorRepo, uId, liquorID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*graphModel.CheckIn, len(checkIns))
+	for i, c := range checkIns {
+		result[i] = c.ToGraphQL()
+	}
+	return result, nil
+}
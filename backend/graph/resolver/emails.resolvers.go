@@ -0,0 +1,38 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.68
+
+import (
+	"backend/graph/graphModel"
+	"backend/middlewares/auth"
+	"backend/service/mailService"
+	"context"
+)
+
+// UpdateEmailPreference is the resolver for the updateEmailPreference field.
+func (r *mutationResolver) UpdateEmailPreference(ctx context.Context, input graphModel.EmailPreferenceInput) (*graphModel.EmailPreference, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pref, err := mailService.UpdatePreference(ctx, &r.UserRepo, uId, input)
+	if err != nil {
+		return nil, err
+	}
+	return pref.ToGraphQL(), nil
+}
+
+// EmailPreference is the resolver for the emailPreference field.
+func (r *queryResolver) EmailPreference(ctx context.Context) (*graphModel.EmailPreference, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pref, err := mailService.GetPreference(ctx, &r.UserRepo, uId)
+	if err != nil {
+		return nil, err
+	}
+	return pref.ToGraphQL(), nil
+}
@@ -7,16 +7,20 @@ package resolver
 import (
 	"backend/graph/graphModel"
 	"backend/middlewares/auth"
+	"backend/middlewares/customError"
 	"backend/service/flavorMapService"
+	"backend/service/subscriptionService"
 	"backend/util/helper"
 	"backend/util/utilType"
 	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // PostFlavor is the resolver for the postFlavor field.
 func (r *mutationResolver) PostFlavor(ctx context.Context, input graphModel.PostFlavorMap) (bool, error) {
 	//マスタが存在するのを確認したので、フレーバーマップを更新する
-	err := flavorMapService.PostFlavorMap(ctx, &r.FlavorMapMstRepo, &r.FlavorLiqRepo, &r.FlavorMapRepo, &r.CategoryRepo, &r.LiquorRepo, input, utilType.Coordinates{
+	err := flavorMapService.PostFlavorMap(ctx, &r.FlavorMapMstRepo, &r.FlavorLiqRepo, &r.FlavorMapRepo, &r.CategoryRepo, &r.LiquorRepo, &r.ActivityRepo, r.PubSub, input, utilType.Coordinates{
 		X: input.X,
 		Y: input.Y,
 	})
@@ -26,6 +30,32 @@ func (r *mutationResolver) PostFlavor(ctx context.Context, input graphModel.Post
 	return true, nil
 }
 
+// CreateFlavorMapMaster is the resolver for the createFlavorMapMaster field.
+func (r *mutationResolver) CreateFlavorMapMaster(ctx context.Context, input graphModel.FlavorMapMasterInput) (*graphModel.FlavorMapMaster, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result, err := flavorMapService.CreateFlavorMaster(ctx, &r.FlavorMapMstRepo, &r.CategoryRepo, uId, input)
+	if err != nil {
+		return nil, err
+	}
+	return result.ToGraphQL(), nil
+}
+
+// UpdateFlavorMapMaster is the resolver for the updateFlavorMapMaster field.
+func (r *mutationResolver) UpdateFlavorMapMaster(ctx context.Context, input graphModel.FlavorMapMasterInput, expectedVersionNo int, migration graphModel.FlavorMapMigration) (*graphModel.FlavorMapMaster, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result, err := flavorMapService.UpdateFlavorMaster(ctx, &r.FlavorMapMstRepo, &r.FlavorLiqRepo, &r.FlavorMapRepo, &r.FlavorNbRepo, &r.LiquorRepo, &r.CategoryRepo, uId, input, expectedVersionNo, migration)
+	if err != nil {
+		return nil, err
+	}
+	return result.ToGraphQL(), nil
+}
+
 // GetFlavorMap is the resolver for the getFlavorMap field.
 func (r *queryResolver) GetFlavorMap(ctx context.Context, liquorID string) (*graphModel.FlavorMapData, error) {
 	lId, err := helper.ObjectIDFromHex(liquorID)
@@ -44,6 +74,33 @@ func (r *queryResolver) GetFlavorMap(ctx context.Context, liquorID string) (*gra
 	return result.ToGraphQL(), nil
 }
 
+// CompareFlavorMaps is the resolver for the compareFlavorMaps field.
+func (r *queryResolver) CompareFlavorMaps(ctx context.Context, liquorIds []string) (*graphModel.FlavorMapComparison, error) {
+	result, err := flavorMapService.CompareFlavorMaps(ctx, &r.FlavorMapMstRepo, &r.FlavorLiqRepo, &r.LiquorRepo, &r.CategoryRepo, liquorIds)
+	if err != nil {
+		return nil, err
+	}
+	return result.ToGraphQL(), nil
+}
+
+// SimilarByFlavor is the resolver for the similarByFlavor field.
+func (r *queryResolver) SimilarByFlavor(ctx context.Context, liquorID string, limit *int) ([]*graphModel.SimilarLiquor, error) {
+	lId, err := helper.ObjectIDFromHex(liquorID)
+	if err != nil {
+		return nil, err
+	}
+	similars, err := flavorMapService.GetSimilarByFlavor(ctx, &r.FlavorMapMstRepo, &r.FlavorLiqRepo, &r.FlavorNbRepo, &r.LiquorRepo, &r.CategoryRepo, lId, helper.NilToZero(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*graphModel.SimilarLiquor, len(similars))
+	for i, s := range similars {
+		result[i] = s.ToGraphQL()
+	}
+	return result, nil
+}
+
 // GetVoted is the resolver for the getVoted field.
 func (r *queryResolver) GetVoted(ctx context.Context, liquorID string) (*graphModel.VotedData, error) {
 	uId, err := auth.GetId(ctx)
@@ -71,3 +128,68 @@ func (r *queryResolver) GetVoted(ctx context.Context, liquorID string) (*graphMo
 	}
 	return result.ToGraphQL(), nil
 }
+
+// RecommendByFlavorProfile is the resolver for the recommendByFlavorProfile field.
+func (r *queryResolver) RecommendByFlavorProfile(ctx context.Context, limit *int) ([]*graphModel.SimilarLiquor, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	similars, err := flavorMapService.RecommendByFlavorProfile(ctx, &r.FlavorMapMstRepo, &r.FlavorMapRepo, &r.FlavorLiqRepo, &r.LiquorRepo, &r.CategoryRepo, uId, helper.NilToZero(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*graphModel.SimilarLiquor, len(similars))
+	for i, s := range similars {
+		result[i] = s.ToGraphQL()
+	}
+	return result, nil
+}
+
+// FlavorMapMasters is the resolver for the flavorMapMasters field.
+func (r *queryResolver) FlavorMapMasters(ctx context.Context) ([]*graphModel.FlavorMapMaster, error) {
+	masters, err := flavorMapService.GetFlavorMasters(ctx, &r.FlavorMapMstRepo, &r.CategoryRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*graphModel.FlavorMapMaster, len(masters))
+	for i, m := range masters {
+		result[i] = m.ToGraphQL()
+	}
+	return result, nil
+}
+
+// FlavorMapMasterLogs is the resolver for the flavorMapMasterLogs field.
+func (r *queryResolver) FlavorMapMasterLogs(ctx context.Context, categoryID int) ([]*graphModel.FlavorMapMaster, error) {
+	masters, err := flavorMapService.GetFlavorMasterLogs(ctx, &r.FlavorMapMstRepo, &r.CategoryRepo, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*graphModel.FlavorMapMaster, len(masters))
+	for i, m := range masters {
+		result[i] = m.ToGraphQL()
+	}
+	return result, nil
+}
+
+// FlavorMapUpdated is the resolver for the flavorMapUpdated field.
+func (r *subscriptionResolver) FlavorMapUpdated(ctx context.Context, liquorID string) (<-chan *graphModel.FlavorMapData, error) {
+	lId, err := helper.ObjectIDFromHex(liquorID)
+	if err != nil {
+		return nil, err
+	}
+	ch, err := subscriptionService.Subscribe(ctx, r.PubSub, subscriptionService.FlavorMapTopic(lId), func(ctx context.Context, id primitive.ObjectID) (*graphModel.FlavorMapData, *customError.Error) {
+		result, err := flavorMapService.GetFlavorMap(ctx, &r.FlavorMapMstRepo, &r.FlavorLiqRepo, &r.LiquorRepo, &r.CategoryRepo, id)
+		if err != nil || result == nil {
+			return nil, err
+		}
+		return result.ToGraphQL(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
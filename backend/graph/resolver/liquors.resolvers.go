@@ -5,17 +5,48 @@ package resolver
 // Code generated by github.com/99designs/gqlgen version v0.17.68
 
 import (
+	"backend/graph/generated"
 	"backend/graph/graphModel"
 	"backend/middlewares/auth"
+	"backend/middlewares/customError"
 	"backend/service/categoryService"
+	"backend/service/drinkService"
 	"backend/service/liquorService"
+	"backend/service/listService"
+	"backend/service/subscriptionService"
 	"backend/service/userService"
+	"backend/util/helper"
 	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// CheckInCount is the resolver for the checkInCount field.
+func (r *liquorResolver) CheckInCount(ctx context.Context, obj *graphModel.Liquor) (int, error) {
+	count, err := drinkService.CountCheckIns(ctx, &r.DrinkRepo, obj.ID)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// ListMentions is the resolver for the listMentions field.
+func (r *liquorResolver) ListMentions(ctx context.Context, obj *graphModel.Liquor) ([]*graphModel.LiquorListMention, error) {
+	mentions, err := listService.GetMentions(ctx, &r.ListRepo, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*graphModel.LiquorListMention, len(mentions))
+	for i, m := range mentions {
+		result[i] = m.ToGraphQL()
+	}
+	return result, nil
+}
+
 // PostBoard is the resolver for the postBoard field.
 func (r *mutationResolver) PostBoard(ctx context.Context, input graphModel.BoardInput) (bool, error) {
-	err := liquorService.PostBoard(ctx, r.LiquorRepo, r.UserRepo, input)
+	err := liquorService.PostBoard(ctx, r.LiquorRepo, r.UserRepo, &r.ActivityRepo, &r.NotificationRepo, r.PubSub, input)
 	if err != nil {
 		return false, err
 	}
@@ -125,3 +156,27 @@ func (r *queryResolver) SearchLiquors(ctx context.Context, keyword string, limit
 	}
 	return result, nil
 }
+
+// BoardPosted is the resolver for the boardPosted field.
+func (r *subscriptionResolver) BoardPosted(ctx context.Context, liquorID string) (<-chan *graphModel.BoardPost, error) {
+	lId, err := helper.ObjectIDFromHex(liquorID)
+	if err != nil {
+		return nil, err
+	}
+	ch, err := subscriptionService.Subscribe(ctx, r.PubSub, subscriptionService.BoardTopic(lId), func(ctx context.Context, id primitive.ObjectID) (*graphModel.BoardPost, *customError.Error) {
+		post, err := r.LiquorRepo.BoardGetById(ctx, id)
+		if err != nil || post == nil {
+			return nil, err
+		}
+		return post.ToGraphQL(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// Liquor returns generated.LiquorResolver implementation.
+func (r *Resolver) Liquor() generated.LiquorResolver { return &liquorResolver{r} }
+
+type liquorResolver struct{ *Resolver }
@@ -0,0 +1,157 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.68
+
+import (
+	"backend/graph/graphModel"
+	"backend/middlewares/auth"
+	"backend/service/listService"
+	"context"
+)
+
+// CreateLiquorList is the resolver for the createLiquorList field.
+func (r *mutationResolver) CreateLiquorList(ctx context.Context, input graphModel.LiquorListInput) (*graphModel.LiquorList, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list, err := listService.CreateList(ctx, &r.ListRepo, &r.LiquorRepo, uId, input)
+	if err != nil {
+		return nil, err
+	}
+	return list.ToGraphQL(), nil
+}
+
+// UpdateLiquorList is the resolver for the updateLiquorList field.
+func (r *mutationResolver) UpdateLiquorList(ctx context.Context, id string, input graphModel.LiquorListInput) (*graphModel.LiquorList, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list, err := listService.UpdateList(ctx, &r.ListRepo, &r.LiquorRepo, uId, id, input)
+	if err != nil {
+		return nil, err
+	}
+	return list.ToGraphQL(), nil
+}
+
+// DeleteLiquorList is the resolver for the deleteLiquorList field.
+func (r *mutationResolver) DeleteLiquorList(ctx context.Context, id string) (bool, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err = listService.DeleteList(ctx, &r.ListRepo, uId, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ForkLiquorList is the resolver for the forkLiquorList field.
+func (r *mutationResolver) ForkLiquorList(ctx context.Context, id string) (*graphModel.LiquorList, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list, err := listService.ForkList(ctx, &r.ListRepo, &r.LiquorRepo, uId, id)
+	if err != nil {
+		return nil, err
+	}
+	return list.ToGraphQL(), nil
+}
+
+// BookmarkLiquorList is the resolver for the bookmarkLiquorList field.
+func (r *mutationResolver) BookmarkLiquorList(ctx context.Context, id string) (bool, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err = listService.BookmarkList(ctx, &r.ListRepo, uId, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UnbookmarkLiquorList is the resolver for the unbookmarkLiquorList field.
+func (r *mutationResolver) UnbookmarkLiquorList(ctx context.Context, id string) (bool, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err = listService.UnbookmarkList(ctx, &r.ListRepo, uId, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// LiquorList is the resolver for the liquorList field.
+func (r *queryResolver) LiquorList(ctx context.Context, id string) (*graphModel.LiquorList, error) {
+	viewer, err := auth.GetIdNullable(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list, err := listService.GetList(ctx, &r.ListRepo, &r.LiquorRepo, viewer, id)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		return nil, nil
+	}
+	return list.ToGraphQL(), nil
+}
+
+// UserLiquorLists is the resolver for the userLiquorLists field.
+func (r *queryResolver) UserLiquorLists(ctx context.Context, userID string) ([]*graphModel.LiquorList, error) {
+	viewer, err := auth.GetIdNullable(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lists, err := listService.GetUserLists(ctx, &r.ListRepo, &r.LiquorRepo, viewer, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*graphModel.LiquorList, len(lists))
+	for i, l := range lists {
+		result[i] = l.ToGraphQL()
+	}
+	return result, nil
+}
+
+// PublicLists is the resolver for the publicLists field.
+func (r *queryResolver) PublicLists(ctx context.Context, page *int) (*graphModel.LiquorListPage, error) {
+	viewer, err := auth.GetIdNullable(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lists, total, err := listService.GetPublicLists(ctx, &r.ListRepo, &r.LiquorRepo, viewer, page)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*graphModel.LiquorList, len(lists))
+	for i, l := range lists {
+		result[i] = l.ToGraphQL()
+	}
+	return &graphModel.LiquorListPage{Lists: result, TotalCount: total}, nil
+}
+
+// MyBookmarkedLists is the resolver for the myBookmarkedLists field.
+func (r *queryResolver) MyBookmarkedLists(ctx context.Context) ([]*graphModel.LiquorList, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lists, err := listService.GetBookmarkedLists(ctx, &r.ListRepo, &r.LiquorRepo, uId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*graphModel.LiquorList, len(lists))
+	for i, l := range lists {
+		result[i] = l.ToGraphQL()
+	}
+	return result, nil
+}
@@ -13,7 +13,7 @@ import (
 
 // UpdateUser is the resolver for the updateUser field.
 func (r *mutationResolver) UpdateUser(ctx context.Context, input graphModel.RegisterInput) (bool, error) {
-	err := myPageService.UpdateUser(ctx, r.UserRepo, input)
+	err := myPageService.UpdateUser(ctx, getHttpRequest(ctx), r.UserTokenConfig, r.UserRepo, &r.SessionRepo, r.Mailer, input)
 	if err != nil {
 		return false, err
 	}
@@ -35,3 +35,18 @@ func (r *queryResolver) GetMyData(ctx context.Context) (*graphModel.User, error)
 
 	return user.ToGraphQL(), nil
 }
+
+// GetMyAccount is the resolver for the getMyAccount field.
+func (r *queryResolver) GetMyAccount(ctx context.Context) (*graphModel.Account, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := r.UserRepo.GetById(ctx, uId)
+	if err != nil {
+		return nil, err
+	}
+
+	return user.ToAccount(), nil
+}
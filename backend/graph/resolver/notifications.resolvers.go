@@ -0,0 +1,121 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.68
+
+import (
+	"backend/graph/graphModel"
+	"backend/middlewares/auth"
+	"backend/middlewares/customError"
+	"backend/service/notificationService"
+	"backend/service/subscriptionService"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MarkNotificationsRead is the resolver for the markNotificationsRead field.
+func (r *mutationResolver) MarkNotificationsRead(ctx context.Context, ids []string) (int, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return 0, err
+	}
+	count, err := notificationService.MarkRead(ctx, &r.NotificationRepo, uId, ids)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// UpdateNotificationPreferences is the resolver for the updateNotificationPreferences field.
+func (r *mutationResolver) UpdateNotificationPreferences(ctx context.Context, input []*graphModel.NotificationPreferenceInput) ([]*graphModel.NotificationPreference, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prefs, err := notificationService.UpdatePreferences(ctx, &r.NotificationRepo, uId, input)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*graphModel.NotificationPreference, len(prefs))
+	for i, p := range prefs {
+		result[i] = p.ToGraphQL()
+	}
+	return result, nil
+}
+
+// Notifications is the resolver for the notifications field.
+func (r *queryResolver) Notifications(ctx context.Context, first *int, after *string) (*graphModel.NotificationConnection, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	page, err := notificationService.GetNotifications(ctx, &r.NotificationRepo, &r.LiquorRepo, uId, first, after)
+	if err != nil {
+		return nil, err
+	}
+	return page.ToGraphQL(), nil
+}
+
+// UnreadNotificationCount is the resolver for the unreadNotificationCount field.
+func (r *queryResolver) UnreadNotificationCount(ctx context.Context) (int, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return 0, err
+	}
+	count, err := r.NotificationRepo.CountUnread(ctx, uId)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// NotificationPreferences is the resolver for the notificationPreferences field.
+func (r *queryResolver) NotificationPreferences(ctx context.Context) ([]*graphModel.NotificationPreference, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prefs, err := notificationService.GetPreferences(ctx, &r.NotificationRepo, uId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*graphModel.NotificationPreference, len(prefs))
+	for i, p := range prefs {
+		result[i] = p.ToGraphQL()
+	}
+	return result, nil
+}
+
+// NotificationReceived is the resolver for the notificationReceived field.
+func (r *subscriptionResolver) NotificationReceived(ctx context.Context) (<-chan *graphModel.Notification, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ch, err := subscriptionService.Subscribe(ctx, r.PubSub, subscriptionService.NotificationTopic(uId), func(ctx context.Context, id primitive.ObjectID) (*graphModel.Notification, *customError.Error) {
+		m, err := r.NotificationRepo.GetById(ctx, id)
+		if err != nil || m == nil {
+			return nil, err
+		}
+		item := &notificationService.Item{Model: m}
+		if m.LiquorID != nil {
+			//削除されたお酒の場合は空になる
+			liquors, err := r.LiquorRepo.GetLiquorsByIds(ctx, []primitive.ObjectID{*m.LiquorID})
+			if err != nil {
+				return nil, err
+			}
+			if len(liquors) > 0 {
+				item.Liquor = &liquors[0]
+			}
+		}
+		return item.ToGraphQL(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
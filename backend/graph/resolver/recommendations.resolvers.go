@@ -0,0 +1,30 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.68
+
+import (
+	"backend/graph/graphModel"
+	"backend/middlewares/auth"
+	"backend/service/recommendService"
+	"context"
+)
+
+// RecommendedLiquors is the resolver for the recommendedLiquors field.
+func (r *queryResolver) RecommendedLiquors(ctx context.Context, limit *int) ([]*graphModel.RecommendedLiquor, error) {
+	uId, err := auth.GetId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recommendations, err := recommendService.Recommend(ctx, &r.LiquorRepo, &r.SimilarityRepo, &r.BookmarkRepo, uId, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*graphModel.RecommendedLiquor, len(recommendations))
+	for i, rec := range recommendations {
+		result[i] = rec.ToGraphQL()
+	}
+	return result, nil
+}
@@ -1,12 +1,24 @@
 package resolver
 
 import (
+	"backend/db/repository/activityRepository"
+	"backend/db/repository/attemptRepository"
 	"backend/db/repository/bookmarkRepository"
 	"backend/db/repository/categoriesRepository"
+	"backend/db/repository/drinkRepository"
 	"backend/db/repository/flavorMapRepository"
+	"backend/db/repository/identityRepository"
 	"backend/db/repository/liquorRepository"
+	"backend/db/repository/listRepository"
+	"backend/db/repository/notificationRepository"
+	"backend/db/repository/oauthStateRepository"
+	"backend/db/repository/sessionRepository"
+	"backend/db/repository/settingRepository"
+	"backend/db/repository/similarityRepository"
 	"backend/db/repository/userRepository"
 	"backend/service/authService/tokenConfig"
+	"backend/service/mailService"
+	"backend/util/pubsub"
 	"context"
 	"github.com/99designs/gqlgen/graphql"
 	"github.com/vektah/gqlparser/v2/ast"
@@ -22,6 +34,19 @@ type Resolver struct {
 	FlavorMapRepo    flavorMapRepository.FlavorMapRepository
 	FlavorMapMstRepo flavorMapRepository.FlavorMapMasterRepository
 	FlavorLiqRepo    flavorMapRepository.FlavorToLiquorRepository
+	FlavorNbRepo     flavorMapRepository.FlavorNeighbourRepository
+	DrinkRepo        drinkRepository.DrinkRepository
+	ListRepo         listRepository.ListRepository
+	ActivityRepo     activityRepository.ActivityRepository
+	SimilarityRepo   similarityRepository.SimilarityRepository
+	NotificationRepo notificationRepository.NotificationRepository
+	SessionRepo      sessionRepository.SessionRepository
+	SettingRepo      settingRepository.SettingRepository
+	AttemptRepo      attemptRepository.AttemptRepository
+	OAuthStateRepo   oauthStateRepository.OAuthStateRepository
+	IdentityRepo     identityRepository.IdentityRepository
+	PubSub           pubsub.PubSub
+	Mailer           *mailService.Mailer
 	UserTokenConfig  tokenConfig.TokenConfig
 }
 
@@ -33,6 +58,19 @@ func NewResolver(db *mongo.Database,
 	flavorMapRepo flavorMapRepository.FlavorMapRepository,
 	flavorMapMstRepo flavorMapRepository.FlavorMapMasterRepository,
 	flavorLiqRepo flavorMapRepository.FlavorToLiquorRepository,
+	flavorNbRepo flavorMapRepository.FlavorNeighbourRepository,
+	drinkRepo drinkRepository.DrinkRepository,
+	listRepo listRepository.ListRepository,
+	activityRepo activityRepository.ActivityRepository,
+	similarityRepo similarityRepository.SimilarityRepository,
+	notificationRepo notificationRepository.NotificationRepository,
+	sessionRepo sessionRepository.SessionRepository,
+	settingRepo settingRepository.SettingRepository,
+	attemptRepo attemptRepository.AttemptRepository,
+	oauthStateRepo oauthStateRepository.OAuthStateRepository,
+	identityRepo identityRepository.IdentityRepository,
+	ps pubsub.PubSub,
+	mailer *mailService.Mailer,
 	userTokenConfig *tokenConfig.TokenConfig,
 ) *Resolver {
 	return &Resolver{
@@ -44,6 +82,19 @@ func NewResolver(db *mongo.Database,
 		FlavorMapRepo:    flavorMapRepo,
 		FlavorMapMstRepo: flavorMapMstRepo,
 		FlavorLiqRepo:    flavorLiqRepo,
+		FlavorNbRepo:     flavorNbRepo,
+		DrinkRepo:        drinkRepo,
+		ListRepo:         listRepo,
+		ActivityRepo:     activityRepo,
+		SimilarityRepo:   similarityRepo,
+		NotificationRepo: notificationRepo,
+		SessionRepo:      sessionRepo,
+		SettingRepo:      settingRepo,
+		AttemptRepo:      attemptRepo,
+		OAuthStateRepo:   oauthStateRepo,
+		IdentityRepo:     identityRepo,
+		PubSub:           ps,
+		Mailer:           mailer,
 		UserTokenConfig:  *userTokenConfig,
 	}
 }
@@ -5,10 +5,14 @@ package resolver
 // Code generated by github.com/99designs/gqlgen version v0.17.68
 
 import (
+	"backend/db/repository/activityRepository"
 	"backend/db/repository/liquorRepository"
+	"backend/db/repository/notificationRepository"
 	"backend/graph/graphModel"
 	"backend/middlewares/auth"
 	"backend/middlewares/customError"
+	"backend/service/activityService"
+	"backend/service/notificationService"
 	"backend/util/helper"
 	"context"
 	"errors"
@@ -49,6 +53,8 @@ func (r *mutationResolver) PostTag(ctx context.Context, input graphModel.TagInpu
 	if err != nil {
 		return nil, err
 	}
+	activityService.Record(ctx, &r.ActivityRepo, uId, activityRepository.TypeTag, lId, &input.Text, nil)
+	notificationService.NotifyLiquorCreatorById(ctx, &r.NotificationRepo, r.PubSub, &r.LiquorRepo, lId, notificationRepository.TypeTagAdded, &uId, &input.Text, nil)
 	return tag.ToGraphQL(), nil
 }
 
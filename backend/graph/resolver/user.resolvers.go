@@ -6,6 +6,7 @@ package resolver
 
 import (
 	"backend/graph/graphModel"
+	"backend/service/flavorMapService"
 	"backend/service/userService"
 	"backend/util/helper"
 	"context"
@@ -40,9 +41,22 @@ func (r *queryResolver) GetUserByIDDetail(ctx context.Context, id string) (*grap
 	if err != nil {
 		return nil, err
 	}
+	//フレーバーの好みは集計コストがかかるので、要求された場合のみ計算する
+	profiles := []*graphModel.UserFlavorProfile{}
+	if r.Resolver.isFieldRequested(ctx, "flavorProfiles") {
+		fp, err := flavorMapService.GetUserFlavorProfiles(ctx, &r.FlavorMapMstRepo, &r.FlavorMapRepo, &r.LiquorRepo, &r.CategoryRepo, uObjID)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range fp {
+			profiles = append(profiles, p.ToGraphQL())
+		}
+	}
+
 	result := &graphModel.UserPageData{
-		User:         user,
-		EvaluateList: eList.ToGraphQL(),
+		User:           user,
+		EvaluateList:   eList.ToGraphQL(),
+		FlavorProfiles: profiles,
 	}
 
 	return result, nil
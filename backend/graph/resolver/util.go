@@ -2,6 +2,7 @@ package resolver
 
 import (
 	"backend/di/handlers"
+	"backend/service/authService"
 	"backend/service/authService/tokenConfig"
 	"context"
 	"net/http"
@@ -25,6 +26,20 @@ func getResponseWriter(c context.Context) http.ResponseWriter {
 	return writer
 }
 
+// getClientIP 回数制限などに使う接続元のIPアドレス
+func getClientIP(c context.Context) string {
+	ip, ok := c.Value("clientIP").(string)
+	if !ok {
+		panic("clientIPがcontextに存在しません")
+	}
+	return ip
+}
+
+// getClient セッションに記録する端末の情報
+func getClient(c context.Context) authService.Client {
+	return authService.NewClient(getHttpRequest(c), getClientIP(c))
+}
+
 func getHandler(c context.Context) *handlers.Handlers {
 	h, ok := c.Value("handlers").(*handlers.Handlers)
 	if !ok {
@@ -0,0 +1,57 @@
+package jobs
+
+import (
+	"backend/db/repository/activityRepository"
+	"backend/db/repository/bookmarkRepository"
+	"backend/db/repository/flavorMapRepository"
+	"backend/db/repository/liquorRepository"
+	"backend/db/repository/similarityRepository"
+	"backend/db/repository/userRepository"
+	"backend/middlewares/customError"
+	"backend/service/flavorMapService"
+	"backend/service/mailService"
+	"backend/service/recommendService"
+	"context"
+	"time"
+)
+
+const (
+	flavorMapRecalcInterval  = 6 * time.Hour   //差分集計のズレの修復と、類似リストを作り直す間隔
+	similarityRecalcInterval = 3 * time.Hour   //おすすめに使う、評価ベースの類似度を作り直す間隔
+	digestCheckInterval      = 1 * time.Hour   //週間ダイジェストの送信対象を確認する間隔(送信自体はユーザーごとに1週間おき)
+	mailRetryInterval        = 1 * time.Minute //送信に失敗したメールの再送日時を確認する間隔
+)
+
+// NewScheduler 定期ジョブの一覧を組み立てる
+func NewScheduler(mstR flavorMapRepository.FlavorMapMasterRepository, fmR flavorMapRepository.FlavorMapRepository, flR flavorMapRepository.FlavorToLiquorRepository, nR flavorMapRepository.FlavorNeighbourRepository, lr liquorRepository.LiquorsRepository, sr similarityRepository.SimilarityRepository, ur userRepository.UsersRepository, ar activityRepository.ActivityRepository, br bookmarkRepository.BookMarkRepository, m *mailService.Mailer) *Scheduler {
+	return &Scheduler{
+		jobs: []Job{
+			{
+				Name:     "recalc-flavor-maps",
+				Interval: flavorMapRecalcInterval,
+				Run: func(ctx context.Context) *customError.Error {
+					return flavorMapService.RecalcAllFlavorMaps(ctx, &mstR, &flR, &fmR, &nR, &lr)
+				},
+			},
+			{
+				Name:     "recalc-liquor-similarity",
+				Interval: similarityRecalcInterval,
+				Run: func(ctx context.Context) *customError.Error {
+					return recommendService.RecalcSimilarities(ctx, &lr, &sr)
+				},
+			},
+			{
+				Name:     "send-weekly-digest",
+				Interval: digestCheckInterval,
+				Run: func(ctx context.Context) *customError.Error {
+					return mailService.SendWeeklyDigests(ctx, m, &ur, &lr, &ar, &br)
+				},
+			},
+			{
+				Name:     "retry-mail-queue",
+				Interval: mailRetryInterval,
+				Run:      m.RetryQueued,
+			},
+		},
+	}
+}
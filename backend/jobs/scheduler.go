@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/logger"
+	"context"
+	"sync"
+	"time"
+)
+
+// Job 定期実行する処理
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) *customError.Error
+}
+
+// Scheduler 定期ジョブをまとめて管理する
+// memo:複数インスタンスで動かす場合は各インスタンスで実行されるため、ジョブは冪等である必要がある
+type Scheduler struct {
+	jobs []Job
+	wg   sync.WaitGroup
+}
+
+// Start 全ジョブをバックグラウンドで開始する。ctxがキャンセルされると停止する
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.loop(ctx, job)
+		}()
+	}
+}
+
+// Wait Startに渡したctxがキャンセルされた後、実行中のジョブが終わるまで待つ
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.run(ctx, job)
+		}
+	}
+}
+
+// run ジョブを1回実行する。panicしてもスケジューラ全体は止めない
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.LogPanic(ctx, r, "job "+job.Name)
+		}
+	}()
+
+	if err := job.Run(ctx); err != nil {
+		logger.LogError(ctx, err)
+	}
+}
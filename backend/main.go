@@ -3,14 +3,24 @@ package main
 import (
 	"backend/db/indexes"
 	"backend/di"
+	"backend/service/categoryService"
 	"backend/util/helper"
 	"backend/util/validator"
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
+// shutdownTimeout 停止時に、処理中のリクエストの完了を待つ時間
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	helper.LoadEnv() //.envファイルを読み込み可能にする
-	server, err := di.InitializeHandler()
+	app, err := di.InitializeHandler()
 	if err != nil {
 		log.Fatal("Failed to initialize server:", err)
 	}
@@ -23,6 +33,32 @@ func main() {
 
 	validator.Init()
 
-	log.Println("connect to http://localhost:8080/query for GraphQL playground")
-	log.Println(server.Run(":8080"))
+	// 停止シグナルを受けたらキャンセルされるcontext(定期ジョブもこれで止める)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// 他のインスタンスでのカテゴリの更新を購読する
+	if err := categoryService.SyncChanges(ctx, app.PubSub); err != nil {
+		log.Fatal("Failed to subscribe category changes:", err)
+	}
+
+	// 定期ジョブの開始
+	app.Scheduler.Start(ctx)
+
+	server := &http.Server{Addr: ":8080", Handler: app.Engine}
+	go func() {
+		log.Println("connect to http://localhost:8080/query for GraphQL playground")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Println(err)
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Failed to shutdown server:", err)
+	}
+	app.Scheduler.Wait()
 }
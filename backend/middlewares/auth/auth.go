@@ -9,11 +9,13 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"net/http"
 	"strings"
+	"time"
 )
 
 // Claims represents the JWT claims
 type Claims struct {
-	Id primitive.ObjectID `json:"id"` //これがJWTトークンに含まれる
+	Id        primitive.ObjectID  `json:"id"`            //これがJWTトークンに含まれる
+	SessionID *primitive.ObjectID `json:"sid,omitempty"` //リフレッシュトークンのみ。どの端末のセッションか(jtiはRegisteredClaims.ID)
 	jwt.RegisteredClaims
 }
 
@@ -72,7 +74,30 @@ func ExtractTokenFromHeader(req *http.Request) (string, error) {
 }
 
 func AuthenticateToken(ctx context.Context, tokenString string, tokenConfig tokenConfig.TokenConfig) (context.Context, error) {
-	// トークンのパースと検証
+	claims, err := parseToken(tokenString, tokenConfig)
+	if err != nil {
+		return ctx, err
+	}
+	// 認証に成功した場合、ユーザーIDをcontextに保存
+	return setId(ctx, claims.Id), nil
+}
+
+// AuthenticateTokenWithExpiry AuthenticateTokenと同じく認証し、トークンの有効期限も返す(有効期限がないトークンはnil)
+// websocketのように、認証した後も接続が続く場合に期限を確認するために使う
+func AuthenticateTokenWithExpiry(ctx context.Context, tokenString string, tokenConfig tokenConfig.TokenConfig) (context.Context, *time.Time, error) {
+	claims, err := parseToken(tokenString, tokenConfig)
+	if err != nil {
+		return ctx, nil, err
+	}
+	var expiresAt *time.Time
+	if claims.ExpiresAt != nil {
+		expiresAt = &claims.ExpiresAt.Time
+	}
+	return setId(ctx, claims.Id), expiresAt, nil
+}
+
+// parseToken トークンのパースと検証
+func parseToken(tokenString string, tokenConfig tokenConfig.TokenConfig) (*Claims, error) {
 	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		return tokenConfig.AccessSecretKey, nil
@@ -80,11 +105,9 @@ func AuthenticateToken(ctx context.Context, tokenString string, tokenConfig toke
 
 	if err == nil {
 		if !token.Valid {
-			return ctx, errTokenInvalid(err)
+			return nil, errTokenInvalid(err)
 		}
-		// 認証に成功した場合、ユーザーIDをcontextに保存
-		newCtx := setId(ctx, claims.Id)
-		return newCtx, nil
+		return claims, nil
 	}
 
 	// 認証に失敗
@@ -92,8 +115,8 @@ func AuthenticateToken(ctx context.Context, tokenString string, tokenConfig toke
 	if errors.As(err, &validationErr) {
 		// トークンが期限切れの場合
 		if validationErr.Errors&jwt.ValidationErrorExpired != 0 {
-			return ctx, errTokenExpired(err)
+			return nil, errTokenExpired(err)
 		}
 	}
-	return ctx, errTokenSomething(err)
+	return nil, errTokenSomething(err)
 }
@@ -19,14 +19,14 @@ import (
 // ロガーを初期化
 var logger = logrus.New()
 
-// DB 接続インスタンス
-var repo errorRepository.ErrorsRepository
+// DB 接続インスタンス(Initを呼ぶまではnil。単体テストなどではDBに保存しない)
+var repo *errorRepository.ErrorsRepository
 
 func Init(r errorRepository.ErrorsRepository) {
 	if err := os.MkdirAll("logs", os.ModePerm); err != nil {
 		panic(fmt.Sprintf("ログディレクトリ作成に失敗: %v", err))
 	}
-	repo = r
+	repo = &r
 
 	// `lumberjack` を設定
 	logFile := &lumberjack.Logger{
@@ -45,6 +45,15 @@ func Init(r errorRepository.ErrorsRepository) {
 
 // LogError はエラーをログに記録する
 func LogError(ctx context.Context, err *customError.Error) {
+	write(ctx, err, err.Level <= logrus.ErrorLevel) // 深刻なエラーはDBにも保存
+}
+
+// LogAudit 不審なログインや管理者の操作など、後から追跡したい出来事を記録する(レベルに関わらずDBにも保存する)
+func LogAudit(ctx context.Context, err *customError.Error) {
+	write(ctx, err, true)
+}
+
+func write(ctx context.Context, err *customError.Error, persist bool) {
 	uid, _ := auth.GetIdNullable(ctx) //ID取得エラーは一旦握りつぶす。問題があればフィールドに追加して対応する。
 
 	l := logger.WithFields(logrus.Fields{
@@ -64,8 +73,10 @@ func LogError(ctx context.Context, err *customError.Error) {
 		ctxTO, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel() // タイムアウト後に `ctx` を解放
 
-		if err.Level <= logrus.ErrorLevel { // 深刻なエラーはDBにも保存
-			_ = writeDB(ctxTO, err)
+		if persist {
+			_ = writeDB(ctxTO, err, uid)
+		}
+		if err.Level <= logrus.ErrorLevel {
 			l.Error("Critical error occurred")
 		} else {
 			l.Warn("non-Critical error occurred")
@@ -100,8 +111,11 @@ func LogPanic(ctx context.Context, recovered interface{}, locationHint string) c
 	return customErr
 }
 
-func writeDB(ctx context.Context, err *customError.Error) error {
-	uid, _ := auth.GetIdNullable(ctx)
+// uidはリクエストのcontextから取得したもの(非同期で書き込むため、引数で受け取る)
+func writeDB(ctx context.Context, err *customError.Error, uid *primitive.ObjectID) error {
+	if repo == nil {
+		return nil
+	}
 	return repo.Write(ctx, &errorRepository.Model{
 		ID:        primitive.NewObjectID(),
 		Code:      err.ErrorCode,
@@ -0,0 +1,22 @@
+package guest
+
+import (
+	"backend/middlewares/customError"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+const (
+	TooManyRequests = "GUEST-001-TooManyRequests"
+)
+
+func errTooManyRequests(action string, identity *Identity) *customError.Error {
+	return customError.NewError(errors.New("ゲストの投稿回数が上限を超えました"), customError.Params{
+		StatusCode: http.StatusTooManyRequests,
+		ErrCode:    TooManyRequests,
+		UserMsg:    "短時間に投稿が集中しています。しばらく時間をおいてから再度お試しください。",
+		Level:      logrus.InfoLevel,
+		Input:      map[string]string{"action": action, "fingerprint": identity.Fingerprint},
+	})
+}
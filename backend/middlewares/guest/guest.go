@@ -0,0 +1,93 @@
+package guest
+
+import (
+	"backend/service/authService/tokenConfig"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	cookieName   = "guest_id"
+	cookieExpire = 365 * 24 * time.Hour
+)
+
+// Identity 未ログインの訪問者を識別するための情報
+type Identity struct {
+	ID          string //署名付きクッキーで払い出したID
+	Fingerprint string //IPアドレスとUser-Agentのハッシュ(生の値は保存しない)。同じNAT配下の別人と区別できないので、回数制限にだけ使う
+}
+
+type contextKey string
+
+const identityContextKey contextKey = "guestIdentity"
+
+// Identify 訪問者IDを検証し、なければ新しく払い出してコンテキストに保存する
+func Identify(tokenConfig *tokenConfig.TokenConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		secret := tokenConfig.GuestSecretKey
+		id, ok := verify(c.Request, secret)
+		if !ok {
+			id = newId()
+			http.SetCookie(c.Writer, &http.Cookie{
+				Name:     cookieName,
+				Value:    id + "." + sign(id, secret),
+				Expires:  time.Now().Add(cookieExpire),
+				Path:     "/",
+				Secure:   true,
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+		identity := &Identity{
+			ID:          id,
+			Fingerprint: fingerprint(c.ClientIP(), c.Request.UserAgent(), secret),
+		}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), identityContextKey, identity))
+		c.Next()
+	}
+}
+
+// GetIdentity コンテキストから訪問者情報を取得する。ミドルウェアを通っていない場合はnil
+func GetIdentity(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityContextKey).(*Identity)
+	return identity
+}
+
+// verify クッキーの署名を検証し、正しければIDを返す
+func verify(req *http.Request, secret []byte) (string, bool) {
+	cookie, err := req.Cookie(cookieName)
+	if err != nil {
+		return "", false
+	}
+	id, signature, found := strings.Cut(cookie.Value, ".")
+	if !found || id == "" {
+		return "", false
+	}
+	if !hmac.Equal([]byte(signature), []byte(sign(id, secret))) {
+		return "", false
+	}
+	return id, true
+}
+
+func sign(value string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func fingerprint(ip string, userAgent string, secret []byte) string {
+	return sign(ip+"\n"+userAgent, secret)
+}
+
+func newId() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b) //crypto/randは失敗しない
+	return hex.EncodeToString(b)
+}
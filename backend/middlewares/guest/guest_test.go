@@ -0,0 +1,32 @@
+package guest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newRequestWithCookie(value string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/query", nil)
+	req.AddCookie(&http.Cookie{Name: cookieName, Value: value})
+	return req
+}
+
+func TestVerify_正常系_署名が一致する訪問者IDのみ受け付けること(t *testing.T) {
+	secret := []byte("secret")
+	id := newId()
+
+	got, ok := verify(newRequestWithCookie(id+"."+sign(id, secret)), secret)
+	assert.True(t, ok)
+	assert.Equal(t, id, got)
+
+	// IDを書き換えた場合や、別の鍵で署名された場合は受け付けないこと
+	_, ok = verify(newRequestWithCookie("other."+sign(id, secret)), secret)
+	assert.False(t, ok)
+	_, ok = verify(newRequestWithCookie(id+"."+sign(id, []byte("other"))), secret)
+	assert.False(t, ok)
+	_, ok = verify(newRequestWithCookie(id), secret)
+	assert.False(t, ok)
+}
@@ -0,0 +1,31 @@
+package guest
+
+import (
+	"backend/middlewares/customError"
+	"backend/util/rateLimit"
+	"context"
+	"time"
+)
+
+// 同じ訪問者が連続して投稿できる回数(操作ごと)
+const (
+	postLimit  = 10
+	postWindow = time.Minute
+)
+
+var limiter = rateLimit.NewLimiter(postLimit, postWindow)
+
+// CheckRate 訪問者ごとの投稿回数を制限する
+// クッキーを消せばIDは変わるので、IDとフィンガープリントの両方で数える
+func CheckRate(ctx context.Context, action string) *customError.Error {
+	identity := GetIdentity(ctx)
+	if identity == nil {
+		return nil
+	}
+	idAllowed := limiter.Allow(action + ":id:" + identity.ID)
+	fpAllowed := limiter.Allow(action + ":fp:" + identity.Fingerprint)
+	if !idAllowed || !fpAllowed {
+		return errTooManyRequests(action, identity)
+	}
+	return nil
+}
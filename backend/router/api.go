@@ -3,9 +3,11 @@ package router
 import (
 	"backend/di/handlers"
 	"backend/middlewares/auth"
+	"backend/service/mailService"
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"os"
 )
 
 // ルートの設定
@@ -24,7 +26,7 @@ func apiRoutes(r *gin.Engine, srv *handler.Server, handlers *handlers.Handlers)
 
 	// カテゴリデータの投稿
 	r.POST("/category/post", auth.RESTOptionalAuthenticate(handlers.TokenConfig), func(c *gin.Context) {
-		id, err := handlers.CategoryHandler.Post(c, &handlers.UserHandler.UserRepo)
+		id, err := handlers.CategoryHandler.Post(c, &handlers.UserHandler.UserRepo, &handlers.UserHandler.SettingRepo)
 		if err != nil {
 			_ = c.Error(err)
 			return
@@ -32,4 +34,20 @@ func apiRoutes(r *gin.Engine, srv *handler.Server, handlers *handlers.Handlers)
 		// 正常なレスポンス
 		c.JSON(http.StatusOK, gin.H{"id": *id})
 	})
+
+	// メールの配信停止(ログイン不要。メール内のリンクはGET、メールソフトのワンクリック配信停止はPOSTで届く)
+	r.GET("/email/unsubscribe", func(c *gin.Context) {
+		if err := mailService.Unsubscribe(c, &handlers.UserHandler.UserRepo, c.Query("token")); err != nil {
+			_ = c.Error(err)
+			return
+		}
+		c.Redirect(http.StatusFound, os.Getenv("FRONT_URI"))
+	})
+	r.POST("/email/unsubscribe", func(c *gin.Context) {
+		if err := mailService.Unsubscribe(c, &handlers.UserHandler.UserRepo, c.Query("token")); err != nil {
+			_ = c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"unsubscribed": true})
+	})
 }
@@ -0,0 +1,23 @@
+package router
+
+import (
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/errorMsg"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+const (
+	UntrustedForwarding = "ROUTER-001-UntrustedForwarding"
+)
+
+func errUntrustedForwarding(remoteAddr string, forwardedFor string) *customError.Error {
+	return customError.NewError(errors.New("TRUSTED_PROXIESが未設定のまま、プロキシ経由のリクエストを受け付けています。すべてのクライアントがプロキシのIPアドレスとして扱われます"), customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    UntrustedForwarding,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      map[string]string{"remoteAddr": remoteAddr, "forwardedFor": forwardedFor},
+	})
+}
@@ -2,6 +2,7 @@ package router
 
 import (
 	"backend/di/handlers"
+	"backend/middlewares/guest"
 	"context"
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/playground"
@@ -10,16 +11,26 @@ import (
 
 // ルートの設定
 func graphRoutes(r *gin.Engine, srv *handler.Server, handlers *handlers.Handlers) {
-	r.POST("/query", func(c *gin.Context) {
-		// Ginのコンテキストからリクエストを取り出し、GraphQLの`context`にセット
+	r.POST("/query", guest.Identify(handlers.TokenConfig), serveGraphQL(srv, handlers))
+	r.GET("/query", func(c *gin.Context) {
+		// Subscriptionはwebsocketへのアップグレード要求として届く
+		if c.IsWebsocket() {
+			serveGraphQL(srv, handlers)(c)
+			return
+		}
+		playground.Handler("GraphQL", "/query").ServeHTTP(c.Writer, c.Request)
+	})
+}
+
+// serveGraphQL Ginのコンテキストからリクエストを取り出し、GraphQLの`context`にセットしてサーバーに渡す
+func serveGraphQL(srv *handler.Server, handlers *handlers.Handlers) gin.HandlerFunc {
+	return func(c *gin.Context) {
 		ctx := context.WithValue(c.Request.Context(), "http.Request", c.Request)
 		ctx = context.WithValue(ctx, "http.ResponseWriter", c.Writer) //クッキー用
 		ctx = context.WithValue(ctx, "handlers", handlers)
+		ctx = context.WithValue(ctx, "clientIP", c.ClientIP()) //回数制限用(プロキシ経由の場合も考慮したもの)
 
 		// GraphQLサーバーにリクエストを渡す
 		srv.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
-	})
-	r.GET("/query", func(c *gin.Context) {
-		playground.Handler("GraphQL", "/query").ServeHTTP(c.Writer, c.Request)
-	})
+	}
 }
@@ -1,44 +1,59 @@
 package router
 
 import (
-	"backend/api/sso/x"
+	"backend/api/sso"
 	"backend/di/handlers"
-	"context"
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"net/url"
 	"os"
 )
 
 // ルートの設定
 func oauthRoutes(r *gin.Engine, srv *handler.Server, handlers *handlers.Handlers) {
-	// Xログイン用のエンドポイント
+	// 外部サービスでのログイン用のエンドポイント(:providerはx/google/line/github)
+	r.GET("/sso/:provider/login", func(c *gin.Context) {
+		ssoLogin(c, handlers, c.Param("provider"))
+	})
+	// memo:フロントエンドの移行が済むまで、旧URLも残す
 	r.GET("/x/login", func(c *gin.Context) {
-		url, err := x.GenerateAuthURL()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return //TODO:エラーページに飛ばす
-		}
-		// JSONレスポンスでURLを返す
-		c.JSON(http.StatusOK, gin.H{
-			"redirectUrl": url,
-		})
+		ssoLogin(c, handlers, sso.ProviderX)
 	})
 
-	r.GET("/x/callback", func(c *gin.Context) {
-		// Ginのコンテキストからリクエストを取り出し、GraphQLの`context`にセット(GraphQL側の処理と共通化してるため、合わせる)
-		ctx := context.WithValue(c.Request.Context(), "http.Request", c.Request)
-		ctx = context.WithValue(ctx, "http.ResponseWriter", c.Writer) //クッキー用
-
-		user, err := x.Login(c, handlers, c.Writer)
+	r.GET("/sso/:provider/callback", func(c *gin.Context) {
+		provider := c.Param("provider")
+		result, err := sso.Callback(c, handlers, provider)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			_ = c.Error(err)
 			return //TODO:エラーページに飛ばす
 		}
 
-		// フロントエンドにリダイレクト
+		// フロントエンドにリダイレクト(サーバーのログに残らないよう、トークンはフラグメントで渡す)
 		frontURI := os.Getenv("FRONT_URI")
-		c.Redirect(http.StatusFound, frontURI)
-		c.JSON(http.StatusFound, gin.H{"user": user})
+		switch {
+		case result.Conflict != nil:
+			// 別のユーザーに連携済みだったため、付け替えるかどうかを確認してもらう
+			c.Redirect(http.StatusFound, frontURI+"/mypage/accounts?provider="+url.QueryEscape(provider)+"#conflict="+url.QueryEscape(result.Conflict.Token))
+		case result.Linked:
+			c.Redirect(http.StatusFound, frontURI+"/mypage/accounts?linked="+url.QueryEscape(provider))
+		case result.Login.Challenge != nil:
+			// 2段階認証のコードの入力画面へ
+			c.Redirect(http.StatusFound, frontURI+"/auth/two-factor#challenge="+url.QueryEscape(result.Login.Challenge.Token))
+		default:
+			c.Redirect(http.StatusFound, frontURI)
+		}
+	})
+}
+
+func ssoLogin(c *gin.Context, handlers *handlers.Handlers, provider string) {
+	url, err := sso.GenerateAuthURL(c.Request.Context(), c.Writer, &handlers.UserHandler.OAuthStateRepo, provider, nil)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	// JSONレスポンスでURLを返す
+	c.JSON(http.StatusOK, gin.H{
+		"redirectUrl": url,
 	})
 }
@@ -7,14 +7,23 @@ import (
 	"backend/util/helper"
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/gin-gonic/gin"
+	"os"
+	"strings"
+	"sync"
 )
 
-func Router(srv *handler.Server, handlers *handlers.Handlers) *gin.Engine {
+func Router(srv *handler.Server, handlers *handlers.Handlers) (*gin.Engine, error) {
 	// .envファイルを読み込みます
 	helper.LoadEnv()
 
 	r := gin.Default()
 
+	// ClientIPで X-Forwarded-For を信用するプロキシ(未設定なら信用しない)
+	proxies := getTrustedProxies()
+	if err := r.SetTrustedProxies(proxies); err != nil {
+		return nil, err
+	}
+
 	// CORS設定
 	//r.Use(corsMiddleware(getFrontURI()))
 
@@ -22,6 +31,11 @@ func Router(srv *handler.Server, handlers *handlers.Handlers) *gin.Engine {
 	logger.Init(*handlers.ErrorHandler)
 	r.Use(middlewares.ErrorHandler())
 	r.Use(middlewares.GinCustomRecovery())
+	if len(proxies) == 0 {
+		r.Use(warnUntrustedForwarding(func(c *gin.Context) {
+			logger.LogError(c, errUntrustedForwarding(c.Request.RemoteAddr, c.GetHeader("X-Forwarded-For")))
+		}))
+	}
 
 	// GraphQL のエラーハンドリング設定
 	srv.SetErrorPresenter(middlewares.GraphQLErrorPresenter) // GraphQL のエラーを適切にログ出力
@@ -30,7 +44,30 @@ func Router(srv *handler.Server, handlers *handlers.Handlers) *gin.Engine {
 	// ルート設定
 	configureRoutes(r, srv, handlers)
 
-	return r
+	return r, nil
+}
+
+// getTrustedProxies TRUSTED_PROXIES環境変数(カンマ区切りのIP/CIDR)の取得
+func getTrustedProxies() []string {
+	var proxies []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
+
+// warnUntrustedForwarding TRUSTED_PROXIESが未設定なのにX-Forwarded-Forつきのリクエストが届いたら、最初の1回だけreportを呼ぶ
+// プロキシを信用しないと、すべてのクライアントがプロキシのIPアドレスになり、IPアドレスごとの回数制限を全員で共有してしまう
+func warnUntrustedForwarding(report func(c *gin.Context)) gin.HandlerFunc {
+	var once sync.Once
+	return func(c *gin.Context) {
+		if c.GetHeader("X-Forwarded-For") != "" {
+			once.Do(func() { report(c) })
+		}
+		c.Next()
+	}
 }
 
 // FRONT_URI環境変数の取得
@@ -0,0 +1,63 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func clientIPWith(t *testing.T, proxies string) string {
+	t.Setenv("TRUSTED_PROXIES", proxies)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	assert.NoError(t, r.SetTrustedProxies(getTrustedProxies()))
+	r.GET("/ip", func(c *gin.Context) { c.String(http.StatusOK, c.ClientIP()) })
+
+	req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+	req.RemoteAddr = "203.0.113.5:12345"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	req.Header.Set("X-Real-IP", "198.51.100.1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Body.String()
+}
+
+func TestClientIP_正常系_未設定なら偽装ヘッダを無視(t *testing.T) {
+	assert.Equal(t, "203.0.113.5", clientIPWith(t, ""))
+}
+
+func TestClientIP_正常系_信用しないプロキシからのヘッダを無視(t *testing.T) {
+	assert.Equal(t, "203.0.113.5", clientIPWith(t, "10.0.0.0/8"))
+}
+
+func TestClientIP_正常系_信用するプロキシからのヘッダを採用(t *testing.T) {
+	assert.Equal(t, "198.51.100.1", clientIPWith(t, "10.0.0.1, 203.0.113.0/24"))
+}
+
+func TestGetTrustedProxies_正常系_空要素を除外(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", " 10.0.0.1 ,,172.16.0.0/12,")
+	assert.Equal(t, []string{"10.0.0.1", "172.16.0.0/12"}, getTrustedProxies())
+}
+
+func TestWarnUntrustedForwarding_正常系_プロキシ経由のリクエストが届いたら1回だけ報告すること(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reported := 0
+	r := gin.New()
+	r.Use(warnUntrustedForwarding(func(_ *gin.Context) { reported++ }))
+	r.GET("/ip", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	// 直接のリクエストは報告しない
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ip", nil))
+	assert.Equal(t, 0, reported)
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+		req.Header.Set("X-Forwarded-For", "198.51.100.1")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	assert.Equal(t, 1, reported)
+}
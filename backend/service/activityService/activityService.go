@@ -0,0 +1,144 @@
+package activityService
+
+import (
+	"backend/db/repository/activityRepository"
+	"backend/db/repository/bookmarkRepository"
+	"backend/db/repository/liquorRepository"
+	"backend/graph/graphModel"
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/logger"
+	"backend/util/helper"
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	feedDefaultSize = 20
+	feedMaxSize     = 50
+)
+
+// Item タイムラインの1件(行動したユーザーとお酒の情報つき)
+type Item struct {
+	Model  *activityRepository.Model
+	User   *bookmarkRepository.BookMarkListUser
+	Liquor *liquorRepository.Model //削除されたお酒の場合はnil
+}
+
+// Feed タイムラインの1ページ分
+type Feed struct {
+	Items       []*Item
+	HasNextPage bool
+}
+
+// Record 行動をタイムラインに記録する。記録に失敗しても元の処理は成功させたいので、エラーはログに残すだけにする
+func Record(ctx context.Context, ar *activityRepository.ActivityRepository, uId primitive.ObjectID, activityType string, lId primitive.ObjectID, text *string, rate *int) {
+	err := ar.Insert(ctx, &activityRepository.Model{
+		UserID:    uId,
+		Type:      activityType,
+		LiquorID:  lId,
+		Text:      text,
+		Rate:      rate,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		logger.LogError(ctx, err)
+	}
+}
+
+// GetFeed ブックマークしたユーザーの行動を新しい順に取得する(読み出し時にまとめる)
+func GetFeed(ctx context.Context, ar *activityRepository.ActivityRepository, br *bookmarkRepository.BookMarkRepository, lr *liquorRepository.LiquorsRepository, uId primitive.ObjectID, first *int, after *string) (*Feed, *customError.Error) {
+	var cursor *primitive.ObjectID
+	if after != nil && *after != "" {
+		id, err := helper.ObjectIDFromHex(*after)
+		if err != nil {
+			return nil, err
+		}
+		cursor = &id
+	}
+
+	followed, err := br.List(ctx, uId)
+	if err != nil {
+		return nil, err
+	}
+	users := make(map[primitive.ObjectID]*bookmarkRepository.BookMarkListUser, len(followed))
+	uIds := make([]primitive.ObjectID, 0, len(followed))
+	for _, u := range followed {
+		users[u.UserId] = u
+		uIds = append(uIds, u.UserId)
+	}
+
+	//次のページの有無を判定するため1件多く取得する
+	size := feedSize(first)
+	models, err := ar.ListByUsers(ctx, uIds, cursor, int64(size+1))
+	if err != nil {
+		return nil, err
+	}
+	feed := &Feed{HasNextPage: len(models) > size}
+	if feed.HasNextPage {
+		models = models[:size]
+	}
+
+	lIds := make([]primitive.ObjectID, len(models))
+	for i, m := range models {
+		lIds[i] = m.LiquorID
+	}
+	liquors := map[primitive.ObjectID]*liquorRepository.Model{}
+	if len(lIds) > 0 {
+		found, err := lr.GetLiquorsByIds(ctx, lIds)
+		if err != nil {
+			return nil, err
+		}
+		for i := range found {
+			liquors[found[i].ID] = &found[i]
+		}
+	}
+
+	feed.Items = make([]*Item, len(models))
+	for i, m := range models {
+		feed.Items[i] = &Item{Model: m, User: users[m.UserID], Liquor: liquors[m.LiquorID]}
+	}
+	return feed, nil
+}
+
+// feedSize 取得件数を既定値・上限に丸める
+func feedSize(first *int) int {
+	if first == nil || *first <= 0 {
+		return feedDefaultSize
+	}
+	if *first > feedMaxSize {
+		return feedMaxSize
+	}
+	return *first
+}
+
+func (f *Feed) ToGraphQL() *graphModel.ActivityConnection {
+	edges := make([]*graphModel.ActivityEdge, len(f.Items))
+	for i, item := range f.Items {
+		edges[i] = &graphModel.ActivityEdge{Cursor: item.Model.ID.Hex(), Node: item.ToGraphQL()}
+	}
+	pageInfo := &graphModel.PageInfo{HasNextPage: f.HasNextPage}
+	if len(edges) > 0 {
+		pageInfo.EndCursor = &edges[len(edges)-1].Cursor
+	}
+	return &graphModel.ActivityConnection{Edges: edges, PageInfo: pageInfo}
+}
+
+func (i *Item) ToGraphQL() *graphModel.Activity {
+	result := &graphModel.Activity{
+		ID:        i.Model.ID.Hex(),
+		Type:      graphModel.ActivityType(i.Model.Type),
+		UserID:    i.Model.UserID.Hex(),
+		Text:      i.Model.Text,
+		Rate:      i.Model.Rate,
+		CreatedAt: i.Model.CreatedAt,
+	}
+	if i.User != nil {
+		result.UserName = i.User.UserName
+		result.UserImageBase64 = i.User.ImageBase64
+	}
+	if i.Liquor != nil {
+		result.Liquor = i.Liquor.ToGraphQL()
+	}
+	return result
+}
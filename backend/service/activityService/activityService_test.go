@@ -0,0 +1,154 @@
+package activityService
+
+import (
+	"backend/db"
+	"backend/db/repository/activityRepository"
+	"backend/db/repository/bookmarkRepository"
+	"backend/db/repository/liquorRepository"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func mockDB(mt *mtest.T) *db.DB {
+	return &db.DB{Client: mt.Client, DBName: "test"}
+}
+
+func startedCommands(mt *mtest.T) []string {
+	var names []string
+	for _, e := range mt.GetAllStartedEvents() {
+		names = append(names, e.CommandName)
+	}
+	return names
+}
+
+func TestFeedSize_正常系_既定値と上限に丸められること(t *testing.T) {
+	zero, ten, tooMany := 0, 10, 1000
+
+	assert.Equal(t, feedDefaultSize, feedSize(nil))
+	assert.Equal(t, feedDefaultSize, feedSize(&zero))
+	assert.Equal(t, 10, feedSize(&ten))
+	assert.Equal(t, feedMaxSize, feedSize(&tooMany))
+}
+
+func TestRecord_正常系_上書き型の行動は同じお酒の以前の記録を消してから記録すること(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("replaceable", func(mt *mtest.T) {
+		ar := activityRepository.NewActivityRepository(mockDB(mt))
+		uId, lId := primitive.NewObjectID(), primitive.NewObjectID()
+		text, rate := "美味しい", 5
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+		)
+
+		Record(context.Background(), &ar, uId, activityRepository.TypeBoardPost, lId, &text, &rate)
+
+		events := mt.GetAllStartedEvents()
+		require.Len(mt, events, 2)
+		assert.Equal(mt, "delete", events[0].CommandName)
+		filter := events[0].Command.Lookup("deletes").Array().Index(0).Value().Document().Lookup("q").Document()
+		assert.Equal(mt, uId, filter.Lookup(activityRepository.UserID).ObjectID())
+		assert.Equal(mt, lId, filter.Lookup(activityRepository.LiquorID).ObjectID())
+		assert.Equal(mt, activityRepository.TypeBoardPost, filter.Lookup(activityRepository.Type).StringValue())
+
+		assert.Equal(mt, "insert", events[1].CommandName)
+		doc := events[1].Command.Lookup("documents").Array().Index(0).Value().Document()
+		assert.Equal(mt, text, doc.Lookup("text").StringValue())
+		assert.Equal(mt, int32(rate), doc.Lookup("rate").Int32())
+	})
+}
+
+func TestRecord_正常系_タグの追加は以前の記録を残したまま記録すること(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("append", func(mt *mtest.T) {
+		ar := activityRepository.NewActivityRepository(mockDB(mt))
+		text := "辛口"
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+
+		Record(context.Background(), &ar, primitive.NewObjectID(), activityRepository.TypeTag, primitive.NewObjectID(), &text, nil)
+
+		assert.Equal(mt, []string{"insert"}, startedCommands(mt))
+	})
+}
+
+func TestRecord_異常系_記録に失敗してもpanicせずに戻ること(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("fail", func(mt *mtest.T) {
+		ar := activityRepository.NewActivityRepository(mockDB(mt))
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 11600, Message: "interrupted"}))
+
+		assert.NotPanics(mt, func() {
+			Record(context.Background(), &ar, primitive.NewObjectID(), activityRepository.TypeFlavorVote, primitive.NewObjectID(), nil, nil)
+		})
+		// 以前の記録を消せなかった場合は、重複しないように新しい記録も追加しない
+		assert.Equal(mt, []string{"delete"}, startedCommands(mt))
+	})
+}
+
+func TestGetFeed_正常系_ブックマークしたユーザーの行動をお酒とユーザーの情報つきで返すこと(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("feed", func(mt *mtest.T) {
+		ar := activityRepository.NewActivityRepository(mockDB(mt))
+		br := bookmarkRepository.NewBookMarkRepository(mockDB(mt))
+		lr := liquorRepository.NewLiquorsRepository(mockDB(mt))
+		followed, lId, deletedLId := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+		newer, older, oldest := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.bookmarks", mtest.FirstBatch,
+				bson.D{{Key: "user_id", Value: followed}, {Key: "user_name", Value: "お酒好き"}}),
+			mtest.CreateCursorResponse(0, "test."+activityRepository.CollectionName, mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: newer}, {Key: "user_id", Value: followed}, {Key: "type", Value: activityRepository.TypeLiquorUpdate}, {Key: "liquor_id", Value: lId}},
+				bson.D{{Key: "_id", Value: older}, {Key: "user_id", Value: followed}, {Key: "type", Value: activityRepository.TypeTag}, {Key: "liquor_id", Value: deletedLId}},
+				bson.D{{Key: "_id", Value: oldest}, {Key: "user_id", Value: followed}, {Key: "type", Value: activityRepository.TypeTag}, {Key: "liquor_id", Value: lId}}),
+			mtest.CreateCursorResponse(0, "test."+liquorRepository.CollectionName, mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: lId}, {Key: "name", Value: "獺祭"}, {Key: "version_no", Value: 1}}),
+		)
+		first, after := 2, primitive.NewObjectID().Hex()
+
+		feed, err := GetFeed(context.Background(), &ar, &br, &lr, primitive.NewObjectID(), &first, &after)
+
+		require.Nil(mt, err)
+		// 次のページの有無を判定するための1件は返さない
+		assert.True(mt, feed.HasNextPage)
+		require.Len(mt, feed.Items, 2)
+		assert.Equal(mt, newer, feed.Items[0].Model.ID)
+		assert.Equal(mt, "お酒好き", feed.Items[0].User.UserName)
+		assert.Equal(mt, "獺祭", feed.Items[0].Liquor.Name)
+		// 削除されたお酒の行動も、お酒の情報なしで返す
+		assert.Nil(mt, feed.Items[1].Liquor)
+
+		result := feed.ToGraphQL()
+		assert.Equal(mt, older.Hex(), *result.PageInfo.EndCursor)
+		assert.Nil(mt, result.Edges[1].Node.Liquor)
+
+		// カーソルより古い行動を、1件多く取得する
+		events := mt.GetAllStartedEvents()
+		find := events[1].Command
+		filter := find.Lookup("filter").Document()
+		assert.Equal(mt, after, filter.Lookup(activityRepository.ID, "$lt").ObjectID().Hex())
+		assert.Equal(mt, int64(first+1), find.Lookup("limit").AsInt64())
+	})
+}
+
+func TestGetFeed_正常系_誰もブックマークしていない場合は行動を検索しないこと(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("empty", func(mt *mtest.T) {
+		ar := activityRepository.NewActivityRepository(mockDB(mt))
+		br := bookmarkRepository.NewBookMarkRepository(mockDB(mt))
+		lr := liquorRepository.NewLiquorsRepository(mockDB(mt))
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "test.bookmarks", mtest.FirstBatch))
+
+		feed, err := GetFeed(context.Background(), &ar, &br, &lr, primitive.NewObjectID(), nil, nil)
+
+		require.Nil(mt, err)
+		assert.Empty(mt, feed.Items)
+		assert.False(mt, feed.HasNextPage)
+		assert.Equal(mt, []string{"aggregate"}, startedCommands(mt))
+	})
+}
@@ -0,0 +1,158 @@
+package authService
+
+import (
+	"backend/db/repository/attemptRepository"
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/logger"
+	"context"
+	"strings"
+	"time"
+)
+
+const (
+	attemptWindow     = 24 * time.Hour   //最後の失敗からこの期間が過ぎたら数え直す
+	lockBase          = 30 * time.Second //最初のロックの長さ(以降は失敗するたびに倍にする)
+	lockMax           = 1 * time.Hour    //ロックの長さの上限
+	stuffingThreshold = 10               //同じIPアドレスからこの数のメールアドレスで失敗したら、リスト型攻撃とみなして記録する
+	maxTrackedEmails  = 20
+)
+
+// attemptPolicies 操作・対象ごとに、ロックせずに許容する回数
+// memo:登録とパスワードリセットは失敗かどうかを判別できない(判別させない)ので、すべての試行を数える
+var attemptPolicies = map[string]map[string]int{
+	attemptRepository.ActionLogin:    {attemptRepository.KindEmail: 5, attemptRepository.KindIP: 20},
+	attemptRepository.ActionReset:    {attemptRepository.KindEmail: 3, attemptRepository.KindIP: 10},
+	attemptRepository.ActionRegister: {attemptRepository.KindIP: 10},
+}
+
+// attemptTarget 回数を数える対象
+type attemptTarget struct {
+	Kind  string
+	Value string
+	Free  int
+}
+
+// attemptTargets 操作の対象になるメールアドレス(小文字)とIPアドレス。空のものは数えない
+func attemptTargets(action string, email string, ip string) []attemptTarget {
+	values := map[string]string{
+		attemptRepository.KindEmail: strings.ToLower(strings.TrimSpace(email)),
+		attemptRepository.KindIP:    ip,
+	}
+	var targets []attemptTarget
+	for _, kind := range []string{attemptRepository.KindEmail, attemptRepository.KindIP} {
+		free, ok := attemptPolicies[action][kind]
+		if !ok || values[kind] == "" {
+			continue
+		}
+		targets = append(targets, attemptTarget{Kind: kind, Value: values[kind], Free: free})
+	}
+	return targets
+}
+
+// lockDuration 許容する回数に達したらロックし、それを超えた分だけロックの長さを倍にしていく
+func lockDuration(failures int, free int) time.Duration {
+	over := failures - free
+	if over < 0 {
+		return 0
+	}
+	d := lockBase
+	for i := 0; i < over && d < lockMax; i++ {
+		d *= 2
+	}
+	return min(d, lockMax)
+}
+
+// reserveAttempt 試行の前に回数を加算し、ロック中の場合はエラーを返す
+// 許容する回数に達した試行は、次の試行をロックしてから通す(ロックが切れた後の最初の試行も、パスワードなどの確認まで進める)
+// ロックを掛けられるのは1つの試行だけなので、同時に届いたリクエストが揃って判定をすり抜けることはない
+// ロックが始まった時と、リスト型攻撃が疑われる時は監査ログに残す
+func reserveAttempt(ctx context.Context, ar *attemptRepository.AttemptRepository, action string, email string, ip string, now time.Time) *customError.Error {
+	for _, t := range attemptTargets(action, email, ip) {
+		// ロック中は加算されずにそのまま返る
+		m, err := ar.Increment(ctx, action, t.Kind, t.Value, trackedEmail(t, email), now, attemptWindow, maxTrackedEmails)
+		if err != nil {
+			return err
+		}
+		if m.IsLocked(now) {
+			return errTooManyAttempts(action, email, ip, m.LockedUntil.Sub(now))
+		}
+		if action == attemptRepository.ActionLogin && len(m.Emails) == stuffingThreshold {
+			logger.LogAudit(ctx, errCredentialStuffing(m))
+		}
+
+		d := lockDuration(m.Failures, t.Free)
+		if d == 0 {
+			continue
+		}
+		until := now.Add(d)
+		locked, err := ar.Lock(ctx, m.ID, until, now)
+		if err != nil {
+			return err
+		}
+		if !locked {
+			// 同時に届いた他の試行が先にロックした
+			return errTooManyAttempts(action, email, ip, d)
+		}
+		if m.Failures == t.Free {
+			logger.LogAudit(ctx, errAttemptsLocked(m, ip, until))
+		}
+	}
+	return nil
+}
+
+// trackedEmail IPアドレスごとの回数に記録するメールアドレス(リスト型攻撃の検知用)
+func trackedEmail(t attemptTarget, email string) *string {
+	if t.Kind != attemptRepository.KindIP || email == "" {
+		return nil
+	}
+	lower := strings.ToLower(strings.TrimSpace(email))
+	return &lower
+}
+
+// releaseAttempt ログインに成功したら、メールアドレスの失敗回数をリセットし、IPアドレスの回数からは今回の分を取り消す
+// memo:IPアドレスはリセットしない(攻撃者が自分のアカウントへのログインを挟んで回数を戻せないように)
+func releaseAttempt(ctx context.Context, ar *attemptRepository.AttemptRepository, email string, ip string) *customError.Error {
+	for _, t := range attemptTargets(attemptRepository.ActionLogin, email, ip) {
+		id := attemptRepository.Key(attemptRepository.ActionLogin, t.Kind, t.Value)
+		if t.Kind == attemptRepository.KindEmail {
+			if err := ar.Delete(ctx, id); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := ar.Release(ctx, id, trackedEmail(t, email), t.Free); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnlockLogin 管理者がメールアドレスやIPアドレスのロックを解除する(すべての操作の回数をリセットする)
+func UnlockLogin(ctx context.Context, ar *attemptRepository.AttemptRepository, email *string, ip *string) (int, *customError.Error) {
+	if (email == nil || *email == "") && (ip == nil || *ip == "") {
+		return 0, errUnlockTargetRequired()
+	}
+
+	var deleted int64
+	if email != nil && *email != "" {
+		n, err := ar.DeleteByValue(ctx, attemptRepository.KindEmail, strings.ToLower(strings.TrimSpace(*email)))
+		if err != nil {
+			return 0, err
+		}
+		deleted += n
+	}
+	if ip != nil && *ip != "" {
+		n, err := ar.DeleteByValue(ctx, attemptRepository.KindIP, *ip)
+		if err != nil {
+			return 0, err
+		}
+		deleted += n
+	}
+	logger.LogAudit(ctx, errLoginUnlocked(email, ip, deleted))
+	return int(deleted), nil
+}
+
+// ListLoginLocks ロック中のメールアドレスやIPアドレスの一覧
+func ListLoginLocks(ctx context.Context, ar *attemptRepository.AttemptRepository) ([]*attemptRepository.Model, *customError.Error) {
+	return ar.ListLocked(ctx, time.Now())
+}
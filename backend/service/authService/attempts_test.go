@@ -0,0 +1,171 @@
+package authService
+
+import (
+	"backend/db/repository/attemptRepository"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestLockDuration_正常系_許容回数を超えるたびに倍になり上限で止まること(t *testing.T) {
+	assert.Equal(t, time.Duration(0), lockDuration(4, 5))
+	assert.Equal(t, lockBase, lockDuration(5, 5))
+	assert.Equal(t, 2*lockBase, lockDuration(6, 5))
+	assert.Equal(t, 4*lockBase, lockDuration(7, 5))
+	assert.Equal(t, lockMax, lockDuration(100, 5))
+}
+
+func TestAttemptTargets_正常系_操作ごとの対象を返すこと(t *testing.T) {
+	targets := attemptTargets(attemptRepository.ActionLogin, " User@Example.com ", "192.0.2.1")
+	assert.Equal(t, []attemptTarget{
+		{Kind: attemptRepository.KindEmail, Value: "user@example.com", Free: 5},
+		{Kind: attemptRepository.KindIP, Value: "192.0.2.1", Free: 20},
+	}, targets)
+
+	// 登録はメールアドレスごとには数えない
+	targets = attemptTargets(attemptRepository.ActionRegister, "user@example.com", "192.0.2.1")
+	assert.Len(t, targets, 1)
+	assert.Equal(t, attemptRepository.KindIP, targets[0].Kind)
+}
+
+func TestAttemptTargets_異常系_空の値は数えないこと(t *testing.T) {
+	assert.Empty(t, attemptTargets(attemptRepository.ActionLogin, " ", ""))
+	assert.Empty(t, attemptTargets("UNKNOWN", "user@example.com", "192.0.2.1"))
+}
+
+// attemptResponse FindOneAndUpdateで返す、加算後の回数
+func attemptResponse(m bson.D) bson.D {
+	return bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: m}}
+}
+
+// startedCommands 発行したコマンドの名前
+func startedCommands(mt *mtest.T) []string {
+	var names []string
+	for _, e := range mt.GetAllStartedEvents() {
+		names = append(names, e.CommandName)
+	}
+	return names
+}
+
+// lockResponse Lockで返す、ロックできたかどうか
+func lockResponse(n int) bson.D {
+	return bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: n}, {Key: "nModified", Value: n}}
+}
+
+// lockedUntilOf 直前のLockで設定したロックの期限
+func lockedUntilOf(mt *mtest.T) time.Time {
+	for _, e := range mt.GetAllStartedEvents() {
+		if e.CommandName == "update" {
+			return e.Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u", "$set", "locked_until").Time()
+		}
+	}
+	mt.Fatal("ロックされていません")
+	return time.Time{}
+}
+
+func TestReserveAttempt_正常系_許容する回数以内なら通すこと(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("free", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			attemptResponse(bson.D{{Key: "_id", Value: "email"}, {Key: "failures", Value: 4}}),
+			attemptResponse(bson.D{{Key: "_id", Value: "ip"}, {Key: "failures", Value: 19}}),
+		)
+		ar := attemptRepository.NewAttemptRepository(mockDB(mt))
+
+		err := reserveAttempt(context.Background(), &ar, attemptRepository.ActionLogin, "user@example.com", "192.0.2.1", time.Now())
+		assert.Nil(mt, err)
+		assert.Equal(mt, []string{"findAndModify", "findAndModify"}, startedCommands(mt))
+	})
+}
+
+func TestReserveAttempt_正常系_許容する回数に達した試行は次の試行をロックしてから通すこと(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("reach", func(mt *mtest.T) {
+		now := time.Now().Truncate(time.Millisecond)
+		mt.AddMockResponses(
+			attemptResponse(bson.D{{Key: "_id", Value: "email"}, {Key: "failures", Value: 5}}),
+			lockResponse(1),
+			attemptResponse(bson.D{{Key: "_id", Value: "ip"}, {Key: "failures", Value: 1}}),
+		)
+		ar := attemptRepository.NewAttemptRepository(mockDB(mt))
+
+		err := reserveAttempt(context.Background(), &ar, attemptRepository.ActionLogin, "user@example.com", "192.0.2.1", now)
+		assert.Nil(mt, err)
+		assert.Equal(mt, []string{"findAndModify", "update", "findAndModify"}, startedCommands(mt))
+		assert.Equal(mt, now.Add(lockBase), lockedUntilOf(mt))
+	})
+}
+
+func TestReserveAttempt_正常系_ロックが切れた後の最初の試行は確認まで進めてロックを延ばすこと(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("expired", func(mt *mtest.T) {
+		now := time.Now().Truncate(time.Millisecond)
+		mt.AddMockResponses(
+			// 前回のロックは期限切れ
+			attemptResponse(bson.D{{Key: "_id", Value: "email"}, {Key: "failures", Value: 6}, {Key: "locked_until", Value: now.Add(-time.Second)}}),
+			lockResponse(1),
+			attemptResponse(bson.D{{Key: "_id", Value: "ip"}, {Key: "failures", Value: 1}}),
+		)
+		ar := attemptRepository.NewAttemptRepository(mockDB(mt))
+
+		err := reserveAttempt(context.Background(), &ar, attemptRepository.ActionLogin, "user@example.com", "192.0.2.1", now)
+		assert.Nil(mt, err)
+		// この試行が失敗した場合に備えて、次の試行は倍の期間ロックする
+		assert.Equal(mt, now.Add(2*lockBase), lockedUntilOf(mt))
+	})
+}
+
+func TestReserveAttempt_異常系_同時に届いた他の試行が先にロックした場合は拒否すること(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("race", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			attemptResponse(bson.D{{Key: "_id", Value: "email"}, {Key: "failures", Value: 7}}),
+			lockResponse(0),
+		)
+		ar := attemptRepository.NewAttemptRepository(mockDB(mt))
+
+		err := reserveAttempt(context.Background(), &ar, attemptRepository.ActionLogin, "user@example.com", "192.0.2.1", time.Now())
+		assert.Equal(mt, TooManyAttempts, err.ErrorCode)
+		// IPアドレスの回数は加算しない
+		assert.Equal(mt, []string{"findAndModify", "update"}, startedCommands(mt))
+	})
+}
+
+func TestReserveAttempt_異常系_ロック中なら拒否すること(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("locked", func(mt *mtest.T) {
+		now := time.Now()
+		mt.AddMockResponses(attemptResponse(bson.D{{Key: "_id", Value: "email"}, {Key: "failures", Value: 6}, {Key: "locked_until", Value: now.Add(time.Minute)}}))
+		ar := attemptRepository.NewAttemptRepository(mockDB(mt))
+
+		err := reserveAttempt(context.Background(), &ar, attemptRepository.ActionLogin, "user@example.com", "192.0.2.1", now)
+		assert.Equal(mt, TooManyAttempts, err.ErrorCode)
+		assert.Equal(mt, []string{"findAndModify"}, startedCommands(mt))
+	})
+}
+
+func TestReleaseAttempt_正常系_メールアドレスはリセットしIPアドレスは今回の分だけ戻すこと(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("release", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+		)
+		ar := attemptRepository.NewAttemptRepository(mockDB(mt))
+
+		assert.Nil(mt, releaseAttempt(context.Background(), &ar, "User@example.com", "192.0.2.1"))
+		assert.Equal(mt, attemptRepository.Key(attemptRepository.ActionLogin, attemptRepository.KindEmail, "user@example.com"),
+			mt.GetStartedEvent().Command.Lookup("deletes").Array().Index(0).Value().Document().Lookup("q", "_id").StringValue())
+		update := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(mt, attemptRepository.Key(attemptRepository.ActionLogin, attemptRepository.KindIP, "192.0.2.1"), update.Lookup("q", "_id").StringValue())
+		set := update.Lookup("u").Array().Index(0).Value().Document().Lookup("$set").Document()
+		assert.NotNil(mt, set.Lookup("failures", "$subtract").Array())
+		// 取り消した後に許容する回数を下回れば、ロックも外す
+		assert.Equal(mt, int32(20), set.Lookup("locked_until", "$cond").Array().Index(0).Value().Document().Lookup("$lt").Array().Index(1).Value().Int32())
+		assert.Equal(mt, "user@example.com", set.Lookup("emails", "$filter", "cond", "$ne").Array().Index(1).Value().StringValue())
+	})
+}
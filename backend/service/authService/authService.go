@@ -1,14 +1,17 @@
 package authService
 
 import (
+	"backend/db/repository/attemptRepository"
+	"backend/db/repository/sessionRepository"
 	"backend/db/repository/userRepository"
 	"backend/graph/graphModel"
-	"backend/middlewares/auth"
 	"backend/middlewares/customError"
+	"backend/middlewares/customError/logger"
 	"backend/service/authService/tokenConfig"
+	"backend/service/mailService"
+	"backend/util/mailer"
 	"context"
 	"errors"
-	"github.com/golang-jwt/jwt/v4"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/crypto/bcrypt"
@@ -16,44 +19,51 @@ import (
 	"time"
 )
 
-// RefreshTokens アクセストークンが切れたため、リフレッシュトークンを使いトークンを再生成
-func RefreshTokens(req *http.Request, writer http.ResponseWriter, tokenConfig tokenConfig.TokenConfig) (*string, *customError.Error) {
-	return refreshHandler(req, writer, tokenConfig)
+// RefreshTokens アクセストークンが切れたため、リフレッシュトークンを使いトークンを再生成(リフレッシュトークンもローテーションする)
+func RefreshTokens(ctx context.Context, req *http.Request, writer http.ResponseWriter, tokenConfig tokenConfig.TokenConfig, sr *sessionRepository.SessionRepository, client Client) (*string, *customError.Error) {
+	claims, err := rotateSession(ctx, req, writer, tokenConfig, sr, client)
+	if err != nil {
+		return nil, err
+	}
+	return generateAccessToken(claims.Id, tokenConfig)
 }
 
-// LoginWithRefreshToken リフレッシュトークンを用いてログインする
-func LoginWithRefreshToken(ctx context.Context, req *http.Request, writer http.ResponseWriter, tokenConfig tokenConfig.TokenConfig, r *userRepository.UsersRepository) (*UserWithToken, *customError.Error) {
-	claims, err := parseRefreshToken(req, tokenConfig)
+// LoginWithRefreshToken リフレッシュトークンを用いてログインする(リフレッシュトークンもローテーションする)
+func LoginWithRefreshToken(ctx context.Context, req *http.Request, writer http.ResponseWriter, tokenConfig tokenConfig.TokenConfig, r *userRepository.UsersRepository, sr *sessionRepository.SessionRepository, client Client) (*UserWithToken, *customError.Error) {
+	claims, err := rotateSession(ctx, req, writer, tokenConfig, sr, client)
 	if err != nil {
 		return nil, err
 	}
 
 	// ユーザーインスタンスを取得
-	return loginById(ctx, claims.Id, writer, tokenConfig, r)
+	user, err := r.GetById(ctx, claims.Id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errSessionRevoked(*claims.SessionID)
+	}
+	accessToken, err := generateAccessToken(user.ID, tokenConfig)
+	if err != nil {
+		return nil, err
+	}
+	return generateUserWithToken(user, *accessToken), nil
 }
 
-// GenerateTokens トークンを生成
-func GenerateTokens(writer http.ResponseWriter, id primitive.ObjectID, tokenConfig tokenConfig.TokenConfig) (*string, *customError.Error) {
+// GenerateTokens ログイン時にトークンを生成(端末ごとのセッションを新しく作る)
+func GenerateTokens(ctx context.Context, writer http.ResponseWriter, id primitive.ObjectID, tokenConfig tokenConfig.TokenConfig, sr *sessionRepository.SessionRepository, client Client) (*string, *customError.Error) {
 	// アクセストークン
-	accessClaims := auth.Claims{
-		Id: id,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(tokenConfig.AccessExpire)),
-		},
-	}
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessString, err := accessToken.SignedString(tokenConfig.AccessSecretKey)
+	accessToken, err := generateAccessToken(id, tokenConfig)
 	if err != nil {
-		return nil, errGenerateAccessToken(err, id)
+		return nil, err
 	}
 
 	// リフレッシュトークン
-	cErr := resetRefreshToken(writer, id, tokenConfig)
-	if cErr != nil {
-		return nil, cErr
+	if err = startSession(ctx, writer, id, tokenConfig, sr, client); err != nil {
+		return nil, err
 	}
 
-	return &accessString, nil
+	return accessToken, nil
 }
 
 func DeleteRefreshToken(writer http.ResponseWriter) *customError.Error {
@@ -71,10 +81,14 @@ func DeleteRefreshToken(writer http.ResponseWriter) *customError.Error {
 	return nil
 }
 
-func RegisterUser(ctx context.Context, r userRepository.UsersRepository, input graphModel.RegisterInput) (*userRepository.Model, *customError.Error) {
+func RegisterUser(ctx context.Context, r userRepository.UsersRepository, ar *attemptRepository.AttemptRepository, m mailer.Mailer, input graphModel.RegisterInput, ip string) (*userRepository.Model, *customError.Error) {
 	if input.Password == nil {
 		return nil, errNeedPassword()
 	}
+	//登録済みのメールアドレスを探られないよう、成否に関わらずIPアドレスごとに数える
+	if cErr := reserveAttempt(ctx, ar, attemptRepository.ActionRegister, input.Email, ip, time.Now()); cErr != nil {
+		return nil, cErr
+	}
 	//パスワードをハッシュする
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*input.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -103,5 +117,10 @@ func RegisterUser(ctx context.Context, r userRepository.UsersRepository, input g
 		}
 		return nil, errFailRegister(cErr, user)
 	}
+
+	//確認メールの送信に失敗しても登録は完了させる(確認メールは後から再送できる)
+	if err = mailService.SendVerifyEmail(ctx, m, newUser, *newUser.Email); err != nil {
+		logger.LogError(ctx, errSendVerifyEmail(err, newUser.ID))
+	}
 	return newUser, nil
 }
@@ -0,0 +1,82 @@
+package authService
+
+import (
+	"backend/db/repository/userRepository"
+	"backend/middlewares/customError"
+	"backend/service/mailService"
+	"backend/util/mailer"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+// VerifyEmail 確認メールのリンクのトークンを検証し、メールアドレスを確認済みにする
+// メールアドレスの変更を確定する場合は、入力ミスのアドレスの持ち主に乗っ取られないよう、本人のログインを必要とする
+func VerifyEmail(ctx context.Context, r userRepository.UsersRepository, token string, loginId *primitive.ObjectID) *customError.Error {
+	claims, rawErr := mailer.ParseVerifyEmailToken(token, time.Now())
+	if rawErr != nil {
+		if errors.Is(rawErr, mailer.ErrExpiredToken) {
+			return errVerifyEmailExpired()
+		}
+		return errVerifyEmailInvalid(rawErr)
+	}
+
+	user, err := r.GetById(ctx, claims.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errVerifyEmailInvalid(errors.New("user not found"))
+	}
+	isChange := user.PendingEmail != nil && *user.PendingEmail == claims.Email
+	if isChange && (loginId == nil || *loginId != user.ID) {
+		return errVerifyEmailNeedLogin(user.ID)
+	}
+
+	ok, err := r.ConfirmEmail(ctx, user.ID, claims.Email)
+	if err != nil {
+		var mongoErr mongo.WriteException
+		if errors.As(err.RawErr, &mongoErr) && len(mongoErr.WriteErrors) > 0 && mongoErr.WriteErrors[0].Code == 11000 {
+			return errVerifyEmailTaken(claims.Email)
+		}
+		return err
+	}
+	if !ok {
+		//確認中に別のアドレスに変更された
+		return errVerifyEmailOutdated(user.ID, claims.Email)
+	}
+	return nil
+}
+
+// ResendVerifyEmail 確認待ちのメールアドレス(なければ登録済みのメールアドレス)に確認メールを送り直す
+func ResendVerifyEmail(ctx context.Context, r userRepository.UsersRepository, m mailer.Mailer, uId primitive.ObjectID) *customError.Error {
+	user, err := r.GetById(ctx, uId)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errVerifyEmailInvalid(errors.New("user not found"))
+	}
+
+	email := verifyTarget(user)
+	if email == "" {
+		return errNothingToVerify(uId)
+	}
+	if rawErr := mailService.SendVerifyEmail(ctx, m, user, email); rawErr != nil {
+		return errSendVerifyEmail(rawErr, uId)
+	}
+	return nil
+}
+
+// verifyTarget 確認メールを送るメールアドレス。確認が必要なものがなければ空文字
+func verifyTarget(user *userRepository.Model) string {
+	if user.PendingEmail != nil && *user.PendingEmail != "" {
+		return *user.PendingEmail
+	}
+	if user.Email != nil && !user.EmailVerified {
+		return *user.Email
+	}
+	return ""
+}
@@ -0,0 +1,18 @@
+package authService
+
+import (
+	"backend/db/repository/userRepository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyTarget_正常系_確認待ちのメールアドレスを優先すること(t *testing.T) {
+	email := "old@example.com"
+	pending := "new@example.com"
+
+	assert.Equal(t, pending, verifyTarget(&userRepository.Model{Email: &email, EmailVerified: true, PendingEmail: &pending}))
+	assert.Equal(t, email, verifyTarget(&userRepository.Model{Email: &email}))
+	assert.Equal(t, "", verifyTarget(&userRepository.Model{Email: &email, EmailVerified: true}))
+	assert.Equal(t, "", verifyTarget(&userRepository.Model{}))
+}
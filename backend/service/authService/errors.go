@@ -1,6 +1,8 @@
 package authService
 
 import (
+	"backend/db/repository/attemptRepository"
+	"backend/db/repository/identityRepository"
 	"backend/db/repository/userRepository"
 	"backend/middlewares/customError"
 	"backend/middlewares/customError/errorMsg"
@@ -8,7 +10,9 @@ import (
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math"
 	"net/http"
+	"time"
 )
 
 const (
@@ -82,6 +86,8 @@ const (
 	SendPasswordReset    = "AUTH-PASSWORD-RESET-002-SendPasswordReset"
 	GenerateAccessToken  = "AUTH-PASSWORD-RESET-003-GenerateAccessToken"
 	GenerateRefreshToken = "AUTH-PASSWORD-RESET-004-GenerateRefreshToken"
+	GenerateResetToken   = "AUTH-PASSWORD-RESET-005-GenerateResetToken"
+	TooManyResetRequests = "AUTH-PASSWORD-RESET-006-TooManyResetRequests" //memo:AUTH-ATTEMPT-001に統合したため、現在は使っていない
 )
 
 func errGenerateFromPassword(err error) *customError.Error {
@@ -93,13 +99,23 @@ func errGenerateFromPassword(err error) *customError.Error {
 	})
 }
 
-func errSendPasswordReset(err error, email string, token string) *customError.Error {
+// memo:トークンはログに残さない
+func errSendPasswordReset(err error, uId primitive.ObjectID) *customError.Error {
 	return customError.NewError(err, customError.Params{
 		StatusCode: http.StatusInternalServerError,
 		ErrCode:    SendPasswordReset,
 		UserMsg:    errorMsg.SERVER,
 		Level:      logrus.ErrorLevel,
-		Input:      fmt.Sprintf("email: %s, token: %s", email, token),
+		Input:      uId,
+	})
+}
+
+func errGenerateResetToken(err error) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    GenerateResetToken,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
 	})
 }
 
@@ -166,3 +182,493 @@ func errFailRegister(cErr *customError.Error, u userRepository.Model) *customErr
 		Input:      u,
 	})
 }
+
+const (
+	VerifyEmailInvalid   = "AUTH-VERIFY-EMAIL-001-Invalid"
+	VerifyEmailExpired   = "AUTH-VERIFY-EMAIL-002-Expired"
+	VerifyEmailNeedLogin = "AUTH-VERIFY-EMAIL-003-NeedLogin"
+	VerifyEmailTaken     = "AUTH-VERIFY-EMAIL-004-Taken"
+	VerifyEmailOutdated  = "AUTH-VERIFY-EMAIL-005-Outdated"
+	NothingToVerify      = "AUTH-VERIFY-EMAIL-006-NothingToVerify"
+	SendVerifyEmail      = "AUTH-VERIFY-EMAIL-007-SendVerifyEmail"
+)
+
+func errVerifyEmailInvalid(err error) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    VerifyEmailInvalid,
+		UserMsg:    "リンクが正しくありません。",
+		Level:      logrus.InfoLevel,
+	})
+}
+
+func errVerifyEmailExpired() *customError.Error {
+	return customError.NewError(errors.New("確認メールの有効期限切れ"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    VerifyEmailExpired,
+		UserMsg:    "リンクの有効期限が切れています。確認メールを再送してください。",
+		Level:      logrus.InfoLevel,
+	})
+}
+
+func errVerifyEmailNeedLogin(uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(errors.New("メールアドレスの変更にはログインが必要"), customError.Params{
+		StatusCode: http.StatusUnauthorized,
+		ErrCode:    VerifyEmailNeedLogin,
+		UserMsg:    "メールアドレスの変更を確定するには、ログインしてからもう一度リンクを開いてください。",
+		Level:      logrus.InfoLevel,
+		Input:      uId,
+	})
+}
+
+func errVerifyEmailTaken(email string) *customError.Error {
+	return customError.NewError(errors.New("確認中に他のユーザーが登録したメールアドレス"), customError.Params{
+		StatusCode: http.StatusConflict,
+		ErrCode:    VerifyEmailTaken,
+		UserMsg:    "このメールアドレスは既に登録されています。",
+		Level:      logrus.InfoLevel,
+		Input:      email,
+	})
+}
+
+func errVerifyEmailOutdated(uId primitive.ObjectID, email string) *customError.Error {
+	return customError.NewError(errors.New("確認中に変更されたメールアドレス"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    VerifyEmailOutdated,
+		UserMsg:    "このリンクは使用できません。最新の確認メールのリンクを開いてください。",
+		Level:      logrus.InfoLevel,
+		Input:      map[string]interface{}{"userId": uId, "email": email},
+	})
+}
+
+func errNothingToVerify(uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(errors.New("確認が必要なメールアドレスがない"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    NothingToVerify,
+		UserMsg:    "メールアドレスは確認済みです。",
+		Level:      logrus.InfoLevel,
+		Input:      uId,
+	})
+}
+
+func errSendVerifyEmail(err error, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    SendVerifyEmail,
+		UserMsg:    "確認メールの送信に失敗しました。",
+		Level:      logrus.ErrorLevel,
+		Input:      uId,
+	})
+}
+
+const (
+	GenerateTokenId    = "AUTH-SESSION-001-GenerateTokenId"
+	SessionRevoked     = "AUTH-SESSION-002-SessionRevoked"
+	RefreshTokenReused = "AUTH-SESSION-003-RefreshTokenReused"
+	SessionNotFound    = "AUTH-SESSION-004-SessionNotFound"
+)
+
+func errGenerateTokenId(err error) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    GenerateTokenId,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+	})
+}
+
+func errSessionRevoked(sId primitive.ObjectID) *customError.Error {
+	return customError.NewError(errors.New("失効済みのセッション"), customError.Params{
+		StatusCode: http.StatusUnauthorized,
+		ErrCode:    SessionRevoked,
+		UserMsg:    "ログインの有効期限が切れました。再度ログインしてください。",
+		Level:      logrus.InfoLevel,
+		Input:      sId,
+	})
+}
+
+func errRefreshTokenReused(sId primitive.ObjectID, uId primitive.ObjectID, client Client) *customError.Error {
+	return customError.NewError(errors.New("ローテーション済みのリフレッシュトークンの再利用"), customError.Params{
+		StatusCode: http.StatusUnauthorized,
+		ErrCode:    RefreshTokenReused,
+		UserMsg:    "ログインの有効期限が切れました。再度ログインしてください。",
+		Level:      logrus.WarnLevel,
+		Input:      map[string]interface{}{"sessionId": sId, "userId": uId, "ip": client.IP, "userAgent": client.UserAgent},
+	})
+}
+
+func errSessionNotFound(err error, id string) *customError.Error {
+	if err == nil {
+		err = errors.New("セッションが見つからない")
+	}
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusNotFound,
+		ErrCode:    SessionNotFound,
+		UserMsg:    "ログイン中の端末が見つかりません。",
+		Level:      logrus.InfoLevel,
+		Input:      id,
+	})
+}
+
+const (
+	InvalidChallenge         = "AUTH-TWO-FACTOR-001-InvalidChallenge"
+	InvalidTwoFactorCode     = "AUTH-TWO-FACTOR-002-InvalidTwoFactorCode"
+	TooManyTwoFactorAttempts = "AUTH-TWO-FACTOR-003-TooManyTwoFactorAttempts"
+	TwoFactorAlreadyEnabled  = "AUTH-TWO-FACTOR-004-TwoFactorAlreadyEnabled"
+	TwoFactorNotStarted      = "AUTH-TWO-FACTOR-005-TwoFactorNotStarted"
+	TwoFactorNotEnabled      = "AUTH-TWO-FACTOR-006-TwoFactorNotEnabled"
+	TwoFactorSecret          = "AUTH-TWO-FACTOR-007-TwoFactorSecret"
+	GenerateTwoFactor        = "AUTH-TWO-FACTOR-008-GenerateTwoFactor"
+	TwoFactorRequired        = "AUTH-TWO-FACTOR-009-TwoFactorRequired"
+	EnableTwoFactorFirst     = "AUTH-TWO-FACTOR-010-EnableTwoFactorFirst"
+	GenerateChallenge        = "AUTH-TWO-FACTOR-011-GenerateChallenge"
+	LoginUserNotFound        = "AUTH-TWO-FACTOR-012-LoginUserNotFound"
+)
+
+func errInvalidChallenge(err error) *customError.Error {
+	if err == nil {
+		err = errors.New("不正なログイン途中のトークン")
+	}
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusUnauthorized,
+		ErrCode:    InvalidChallenge,
+		UserMsg:    "ログインの有効期限が切れました。再度ログインしてください。",
+		Level:      logrus.InfoLevel,
+	})
+}
+
+func errInvalidTwoFactorCode(uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(errors.New("2段階認証のコードが一致しない"), customError.Params{
+		StatusCode: http.StatusUnauthorized,
+		ErrCode:    InvalidTwoFactorCode,
+		UserMsg:    "確認コードが正しくありません。",
+		Level:      logrus.InfoLevel,
+		Input:      uId,
+	})
+}
+
+func errTooManyTwoFactorAttempts(uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(errors.New("2段階認証のコードの試行回数の上限"), customError.Params{
+		StatusCode: http.StatusTooManyRequests,
+		ErrCode:    TooManyTwoFactorAttempts,
+		UserMsg:    "しばらく時間をおいてから再度お試しください。",
+		Level:      logrus.WarnLevel,
+		Input:      uId,
+	})
+}
+
+func errTwoFactorAlreadyEnabled(uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(errors.New("2段階認証は有効にされている"), customError.Params{
+		StatusCode: http.StatusConflict,
+		ErrCode:    TwoFactorAlreadyEnabled,
+		UserMsg:    "2段階認証は既に有効です。",
+		Level:      logrus.InfoLevel,
+		Input:      uId,
+	})
+}
+
+func errTwoFactorNotStarted(uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(errors.New("2段階認証の登録が始まっていない"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    TwoFactorNotStarted,
+		UserMsg:    "2段階認証の設定を最初からやり直してください。",
+		Level:      logrus.InfoLevel,
+		Input:      uId,
+	})
+}
+
+func errTwoFactorNotEnabled(uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(errors.New("2段階認証が有効でない"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    TwoFactorNotEnabled,
+		UserMsg:    "2段階認証が有効になっていません。",
+		Level:      logrus.InfoLevel,
+		Input:      uId,
+	})
+}
+
+// memo:秘密鍵はログに残さない
+func errTwoFactorSecret(err error, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    TwoFactorSecret,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      uId,
+	})
+}
+
+func errGenerateTwoFactor(err error, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    GenerateTwoFactor,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      uId,
+	})
+}
+
+func errTwoFactorRequired(uId primitive.ObjectID, role string) *customError.Error {
+	return customError.NewError(errors.New("2段階認証が必須のロール"), customError.Params{
+		StatusCode: http.StatusForbidden,
+		ErrCode:    TwoFactorRequired,
+		UserMsg:    "このアカウントでは2段階認証を無効にできません。",
+		Level:      logrus.InfoLevel,
+		Input:      map[string]interface{}{"userId": uId, "role": role},
+	})
+}
+
+func errEnableTwoFactorFirst(uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(errors.New("2段階認証を有効にしていない管理者による必須化"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    EnableTwoFactorFirst,
+		UserMsg:    "必須にする前に、ご自身の2段階認証を有効にしてください。",
+		Level:      logrus.InfoLevel,
+		Input:      uId,
+	})
+}
+
+func errGenerateChallenge(err error, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    GenerateChallenge,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      uId,
+	})
+}
+
+func errLoginUserNotFound(uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(errors.New("ログイン中のユーザーが存在しない"), customError.Params{
+		StatusCode: http.StatusNotFound,
+		ErrCode:    LoginUserNotFound,
+		UserMsg:    "ユーザーが見つかりません。",
+		Level:      logrus.WarnLevel,
+		Input:      uId,
+	})
+}
+
+const (
+	TooManyAttempts      = "AUTH-ATTEMPT-001-TooManyAttempts"
+	AttemptsLocked       = "AUTH-ATTEMPT-002-AttemptsLocked"
+	CredentialStuffing   = "AUTH-ATTEMPT-003-CredentialStuffing"
+	LoginUnlocked        = "AUTH-ATTEMPT-004-LoginUnlocked"
+	UnlockTargetRequired = "AUTH-ATTEMPT-005-UnlockTargetRequired"
+)
+
+func errTooManyAttempts(action string, email string, ip string, retryAfter time.Duration) *customError.Error {
+	minutes := int(math.Ceil(retryAfter.Minutes()))
+	return customError.NewError(errors.New("試行回数の上限によるロック中"), customError.Params{
+		StatusCode: http.StatusTooManyRequests,
+		ErrCode:    TooManyAttempts,
+		UserMsg:    fmt.Sprintf("試行回数が多すぎます。%d分ほど時間をおいてから再度お試しください。", minutes),
+		Level:      logrus.InfoLevel,
+		Input:      map[string]interface{}{"action": action, "email": email, "ip": ip},
+	})
+}
+
+// memo:監査ログ用(ユーザーには返さない)
+func errAttemptsLocked(m *attemptRepository.Model, ip string, until time.Time) *customError.Error {
+	return customError.NewError(errors.New("試行回数の上限を超えたためロック"), customError.Params{
+		StatusCode: http.StatusTooManyRequests,
+		ErrCode:    AttemptsLocked,
+		UserMsg:    "試行回数が多すぎます。",
+		Level:      logrus.WarnLevel,
+		Input:      map[string]interface{}{"action": m.Action, "kind": m.Kind, "value": m.Value, "failures": m.Failures, "ip": ip, "lockedUntil": until},
+	})
+}
+
+// memo:監査ログ用(ユーザーには返さない)
+func errCredentialStuffing(m *attemptRepository.Model) *customError.Error {
+	return customError.NewError(errors.New("同じIPアドレスから多数のメールアドレスで失敗"), customError.Params{
+		StatusCode: http.StatusTooManyRequests,
+		ErrCode:    CredentialStuffing,
+		UserMsg:    "試行回数が多すぎます。",
+		Level:      logrus.WarnLevel,
+		Input:      map[string]interface{}{"action": m.Action, "ip": m.Value, "failures": m.Failures, "emails": m.Emails},
+	})
+}
+
+// memo:監査ログ用(解除した管理者はcontextのユーザーIDで記録される)
+func errLoginUnlocked(email *string, ip *string, deleted int64) *customError.Error {
+	return customError.NewError(errors.New("管理者によるロック解除"), customError.Params{
+		StatusCode: http.StatusOK,
+		ErrCode:    LoginUnlocked,
+		UserMsg:    "ロックを解除しました。",
+		Level:      logrus.InfoLevel,
+		Input:      map[string]interface{}{"email": email, "ip": ip, "deleted": deleted},
+	})
+}
+
+func errUnlockTargetRequired() *customError.Error {
+	return customError.NewError(errors.New("ロック解除の対象が未指定"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    UnlockTargetRequired,
+		UserMsg:    "メールアドレスかIPアドレスを指定してください。",
+		Level:      logrus.InfoLevel,
+	})
+}
+
+const (
+	IdentityNotLinked            = "AUTH-IDENTITY-001-IdentityNotLinked"
+	LastLoginMethod              = "AUTH-IDENTITY-002-LastLoginMethod"
+	ProviderAlreadyLinked        = "AUTH-IDENTITY-003-ProviderAlreadyLinked"
+	IdentityConflictFound        = "AUTH-IDENTITY-004-IdentityConflict"
+	InvalidConflictToken         = "AUTH-IDENTITY-005-InvalidConflictToken"
+	GenerateConflictToken        = "AUTH-IDENTITY-006-GenerateConflictToken"
+	ConflictOwnerLastLoginMethod = "AUTH-IDENTITY-007-ConflictOwnerLastLoginMethod"
+	IdentityConflictResolved     = "AUTH-IDENTITY-008-IdentityConflictResolved"
+	IdentityMoved                = "AUTH-IDENTITY-009-IdentityMoved"
+	UnlinkIdentityTx             = "AUTH-IDENTITY-010-UnlinkIdentity"
+	ResolveIdentityConflictTx    = "AUTH-IDENTITY-011-ResolveIdentityConflict"
+)
+
+func errIdentityNotLinked(provider string, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(errors.New("連携していない外部サービス"), customError.Params{
+		StatusCode: http.StatusNotFound,
+		ErrCode:    IdentityNotLinked,
+		UserMsg:    "このサービスとは連携していません",
+		Level:      logrus.InfoLevel,
+		Input:      map[string]interface{}{"provider": provider, "userId": uId},
+	})
+}
+
+func errLastLoginMethod(provider string, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(errors.New("最後のログイン方法の解除"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    LastLoginMethod,
+		UserMsg:    "ログインできなくなるため解除できません。パスワードを設定するか、他のサービスと連携してから解除してください",
+		Level:      logrus.InfoLevel,
+		Input:      map[string]interface{}{"provider": provider, "userId": uId},
+	})
+}
+
+func errProviderAlreadyLinked(provider string, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(errors.New("同じ外部サービスの別のアカウントと連携済み"), customError.Params{
+		StatusCode: http.StatusConflict,
+		ErrCode:    ProviderAlreadyLinked,
+		UserMsg:    "このサービスの別のアカウントと連携済みです。解除してから連携してください",
+		Level:      logrus.InfoLevel,
+		Input:      map[string]interface{}{"provider": provider, "userId": uId},
+	})
+}
+
+// memo:監査ログ用(ユーザーには付け替えの確認用のトークンを返す)
+func errIdentityConflict(owner *identityRepository.Model, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(errors.New("別のユーザーに連携済みのアカウントで連携しようとした"), customError.Params{
+		StatusCode: http.StatusConflict,
+		ErrCode:    IdentityConflictFound,
+		UserMsg:    "このアカウントは別のユーザーと連携済みです",
+		Level:      logrus.InfoLevel,
+		Input:      map[string]interface{}{"provider": owner.Provider, "subject": owner.Subject, "ownerId": owner.UserID, "userId": uId},
+	})
+}
+
+func errInvalidConflictToken(err error, uId primitive.ObjectID) *customError.Error {
+	if err == nil {
+		err = errors.New("付け替えの確認用のトークンが不正")
+	}
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    InvalidConflictToken,
+		UserMsg:    "有効期限が切れました。もう一度連携をやり直してください",
+		Level:      logrus.InfoLevel,
+		Input:      uId,
+	})
+}
+
+func errGenerateConflictToken(err error, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    GenerateConflictToken,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      uId,
+	})
+}
+
+func errConflictOwnerLastLoginMethod(owner *identityRepository.Model, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(errors.New("付け替えると元のユーザーがログインできなくなる"), customError.Params{
+		StatusCode: http.StatusConflict,
+		ErrCode:    ConflictOwnerLastLoginMethod,
+		UserMsg:    "連携先のユーザーはこのサービスでしかログインできないため、付け替えられません。そのユーザーでログインしてパスワードを設定してから、もう一度お試しください",
+		Level:      logrus.InfoLevel,
+		Input:      map[string]interface{}{"provider": owner.Provider, "ownerId": owner.UserID, "userId": uId},
+	})
+}
+
+func errIdentityConflictResolved(provider string, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(errors.New("付け替えの対象が変わっている"), customError.Params{
+		StatusCode: http.StatusConflict,
+		ErrCode:    IdentityConflictResolved,
+		UserMsg:    "連携の状態が変わりました。もう一度連携をやり直してください",
+		Level:      logrus.InfoLevel,
+		Input:      map[string]interface{}{"provider": provider, "userId": uId},
+	})
+}
+
+// memo:監査ログ用(付け替えたユーザーはcontextのユーザーIDで記録される)
+func errIdentityMoved(owner *identityRepository.Model, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(errors.New("外部サービスのアカウントを別のユーザーに付け替えた"), customError.Params{
+		StatusCode: http.StatusOK,
+		ErrCode:    IdentityMoved,
+		UserMsg:    "連携を付け替えました",
+		Level:      logrus.InfoLevel,
+		Input:      map[string]interface{}{"provider": owner.Provider, "subject": owner.Subject, "from": owner.UserID, "to": uId},
+	})
+}
+
+func errUnlinkIdentity(err error, provider string, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    UnlinkIdentityTx,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      map[string]interface{}{"provider": provider, "userId": uId},
+	})
+}
+
+func errResolveIdentityConflict(err error, owner *identityRepository.Model, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    ResolveIdentityConflictTx,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      map[string]interface{}{"provider": owner.Provider, "ownerId": owner.UserID, "userId": uId},
+	})
+}
+
+const (
+	PasswordAlreadySet       = "AUTH-SET-PASSWORD-001-PasswordAlreadySet"
+	PasswordNeedVerifiedMail = "AUTH-SET-PASSWORD-002-NeedVerifiedEmail"
+	PasswordTooShort         = "AUTH-SET-PASSWORD-003-TooShort"
+)
+
+func errPasswordAlreadySet(uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(errors.New("パスワードを設定済み"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    PasswordAlreadySet,
+		UserMsg:    "パスワードは設定済みです。変更はマイページから行ってください",
+		Level:      logrus.InfoLevel,
+		Input:      uId,
+	})
+}
+
+func errPasswordNeedVerifiedEmail(uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(errors.New("確認済みのメールアドレスがない"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    PasswordNeedVerifiedMail,
+		UserMsg:    "パスワードでログインするには、先にメールアドレスを登録して確認を済ませてください",
+		Level:      logrus.InfoLevel,
+		Input:      uId,
+	})
+}
+
+func errPasswordTooShort() *customError.Error {
+	return customError.NewError(errors.New("パスワードが短い"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    PasswordTooShort,
+		UserMsg:    "パスワードが短いです",
+		Level:      logrus.InfoLevel,
+	})
+}
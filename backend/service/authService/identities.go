@@ -0,0 +1,349 @@
+package authService
+
+import (
+	"backend/db"
+	"backend/db/repository/identityRepository"
+	"backend/db/repository/sessionRepository"
+	"backend/db/repository/userRepository"
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/logger"
+	"backend/service/authService/tokenConfig"
+	"backend/service/mailService"
+	"backend/util/mailer"
+	"context"
+	"errors"
+	"github.com/golang-jwt/jwt/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+	"time"
+)
+
+const (
+	conflictAudience  = "identity_conflict"
+	passwordMinLength = 8
+)
+
+// IdentityConflict 連携しようとした外部サービスのアカウントが、別のユーザーに連携済みだったことを表すトークン
+// ログイン中のユーザーが付け替えを確認したら、ResolveIdentityConflictに渡す
+type IdentityConflict struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// conflictClaims 認可画面で本人確認が済んだ外部サービスのアカウント
+type conflictClaims struct {
+	UserId   primitive.ObjectID `json:"user_id"` //連携しようとしたユーザー
+	Provider string             `json:"provider"`
+	Subject  string             `json:"subject"`
+	jwt.RegisteredClaims
+}
+
+// newIdentityConflict 付け替えの確認用のトークンを発行する(2段階認証のチャレンジと同じ鍵で、用途(aud)を分けて署名する)
+func newIdentityConflict(uId primitive.ObjectID, provider string, subject string, tokenConfig tokenConfig.TokenConfig, now time.Time) (*IdentityConflict, *customError.Error) {
+	expiresAt := now.Add(tokenConfig.ChallengeExpire)
+	claims := conflictClaims{
+		UserId:   uId,
+		Provider: provider,
+		Subject:  subject,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{conflictAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(tokenConfig.ChallengeSecretKey)
+	if err != nil {
+		return nil, errGenerateConflictToken(err, uId)
+	}
+	return &IdentityConflict{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// parseIdentityConflict 付け替えの確認用のトークンを検証する(発行した時と別のユーザーでは使えない)
+func parseIdentityConflict(token string, uId primitive.ObjectID, tokenConfig tokenConfig.TokenConfig) (*conflictClaims, *customError.Error) {
+	claims := &conflictClaims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return tokenConfig.ChallengeSecretKey, nil
+	})
+	if err != nil || !parsed.Valid || !claims.VerifyAudience(conflictAudience, true) || claims.UserId != uId {
+		return nil, errInvalidConflictToken(err, uId)
+	}
+	return claims, nil
+}
+
+// loginMethods パスワードと、連携中の外部サービスの数
+func loginMethods(user *userRepository.Model, identities []*identityRepository.Model) int {
+	n := len(identities)
+	if user.HasPassword() {
+		n++
+	}
+	return n
+}
+
+// MigrateTwitterId 旧方式(usersのtwitter_id)のX連携をuser_identitiesに移し、移した紐付けを返す(旧方式の連携がなければnil)
+func MigrateTwitterId(ctx context.Context, ur *userRepository.UsersRepository, ir *identityRepository.IdentityRepository, user *userRepository.Model, now time.Time) (*identityRepository.Model, *customError.Error) {
+	if user.TwitterId == nil {
+		return nil, nil
+	}
+	identity, err := ir.GetByUserProvider(ctx, user.ID, identityRepository.ProviderX)
+	if err != nil {
+		return nil, err
+	}
+	if identity == nil {
+		identity = &identityRepository.Model{
+			UserID:     user.ID,
+			Provider:   identityRepository.ProviderX,
+			Subject:    *user.TwitterId,
+			Name:       user.Name, //Xでの表示名は次にログインした時に更新される
+			CreatedAt:  now,
+			LastUsedAt: now,
+		}
+		if err = ir.Insert(ctx, identity); err != nil {
+			return nil, err
+		}
+	}
+	if err = ur.ClearTwitterId(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	user.TwitterId = nil
+	return identity, nil
+}
+
+// getIdentityOwner 外部サービスのアカウントの紐付けを取得する(旧方式で連携していたユーザーは移行してから返す)
+func getIdentityOwner(ctx context.Context, ur *userRepository.UsersRepository, ir *identityRepository.IdentityRepository, provider string, subject string, now time.Time) (*identityRepository.Model, *customError.Error) {
+	identity, err := ir.GetByProviderSubject(ctx, provider, subject)
+	if err != nil || identity != nil || provider != identityRepository.ProviderX {
+		return identity, err
+	}
+	legacy, err := ur.GetByTwitterId(ctx, subject)
+	if err != nil || legacy == nil {
+		return nil, err
+	}
+	return MigrateTwitterId(ctx, ur, ir, legacy, now)
+}
+
+// getLinkUser 連携を操作するログイン中のユーザーと、連携中のアカウントを取得する
+func getLinkUser(ctx context.Context, ur *userRepository.UsersRepository, ir *identityRepository.IdentityRepository, uId primitive.ObjectID, now time.Time) (*userRepository.Model, []*identityRepository.Model, *customError.Error) {
+	user, err := getLoginUser(ctx, ur, uId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err = MigrateTwitterId(ctx, ur, ir, user, now); err != nil {
+		return nil, nil, err
+	}
+	identities, err := ir.ListByUser(ctx, uId)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, identities, nil
+}
+
+// ListIdentities 連携中の外部サービスのアカウント
+func ListIdentities(ctx context.Context, ur *userRepository.UsersRepository, ir *identityRepository.IdentityRepository, uId primitive.ObjectID) ([]*identityRepository.Model, *customError.Error) {
+	_, identities, err := getLinkUser(ctx, ur, ir, uId, time.Now())
+	return identities, err
+}
+
+// LinkIdentity 認可画面で本人確認が済んだ外部サービスのアカウントを、ログイン中のユーザーに連携する
+// 別のユーザーに連携済みの場合は付け替えずに、付け替えの確認用のトークンを返す
+func LinkIdentity(ctx context.Context, ur *userRepository.UsersRepository, ir *identityRepository.IdentityRepository, tokenConfig tokenConfig.TokenConfig, uId primitive.ObjectID, identity *identityRepository.Model) (*IdentityConflict, *customError.Error) {
+	now := time.Now()
+	if _, _, err := getLinkUser(ctx, ur, ir, uId, now); err != nil {
+		return nil, err
+	}
+
+	owner, err := getIdentityOwner(ctx, ur, ir, identity.Provider, identity.Subject, now)
+	if err != nil {
+		return nil, err
+	}
+	if owner != nil {
+		if owner.UserID == uId {
+			//連携済み(表示名などだけ更新する)
+			return nil, ir.Touch(ctx, owner.ID, identity.Name, identity.Email, now)
+		}
+		logger.LogAudit(ctx, errIdentityConflict(owner, uId))
+		return newIdentityConflict(uId, identity.Provider, identity.Subject, tokenConfig, now)
+	}
+
+	if err = checkProviderNotLinked(ctx, ir, uId, identity.Provider); err != nil {
+		return nil, err
+	}
+	identity.UserID = uId
+	identity.CreatedAt = now
+	identity.LastUsedAt = now
+	return nil, ir.Insert(ctx, identity)
+}
+
+// ResolveIdentityConflict 別のユーザーに連携済みだったアカウントを、ログイン中のユーザーに付け替える
+// 付け替えると元のユーザーがログインできなくなる場合は付け替えない
+// 付け替えたら、元のユーザーのそのサービスでログインしたセッションを失効させ、メールで知らせる
+func ResolveIdentityConflict(ctx context.Context, client *mongo.Client, ur *userRepository.UsersRepository, ir *identityRepository.IdentityRepository, sr *sessionRepository.SessionRepository, m mailer.Mailer, tokenConfig tokenConfig.TokenConfig, uId primitive.ObjectID, conflictToken string) *customError.Error {
+	claims, err := parseIdentityConflict(conflictToken, uId, tokenConfig)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if _, _, err = getLinkUser(ctx, ur, ir, uId, now); err != nil {
+		return err
+	}
+	owner, err := getIdentityOwner(ctx, ur, ir, claims.Provider, claims.Subject, now)
+	if err != nil {
+		return err
+	}
+	if owner == nil || owner.UserID == uId {
+		//トークンの発行後に元のユーザーが解除した場合や、付け替え済みの場合
+		return errIdentityConflictResolved(claims.Provider, uId)
+	}
+	if err = checkProviderNotLinked(ctx, ir, uId, claims.Provider); err != nil {
+		return err
+	}
+	//元のユーザーの旧方式の連携の移行はトランザクションの外で済ませておく
+	if _, _, err = getLinkUser(ctx, ur, ir, owner.UserID, now); err != nil {
+		return err
+	}
+
+	//元のユーザーのログイン方法の確認と付け替えは同じトランザクションで行う(UnlinkIdentityと同じ)
+	ownerUser, e := db.WithTransaction(ctx, client, func(sc mongo.SessionContext) (*userRepository.Model, error) {
+		if err := ur.LockLoginMethods(sc, owner.UserID); err != nil {
+			return nil, err
+		}
+		ownerUser, err := getLoginUser(sc, ur, owner.UserID)
+		if err != nil {
+			return nil, err
+		}
+		ownerIdentities, err := ir.ListByUser(sc, owner.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if loginMethods(ownerUser, ownerIdentities) <= 1 {
+			return nil, errConflictOwnerLastLoginMethod(owner, uId)
+		}
+
+		ok, err := ir.MoveToUser(sc, owner.ID, owner.UserID, uId, now)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errIdentityConflictResolved(claims.Provider, uId)
+		}
+		//付け替えたアカウントでログインした端末は、元のユーザーのままでいられないようにする
+		if _, err = sr.RevokeByProvider(sc, owner.UserID, owner.Provider, now); err != nil {
+			return nil, err
+		}
+		return ownerUser, nil
+	})
+	if e != nil {
+		var cErr *customError.Error
+		if errors.As(e, &cErr) {
+			return cErr
+		}
+		return errResolveIdentityConflict(e, owner, uId)
+	}
+	logger.LogAudit(ctx, errIdentityMoved(owner, uId))
+
+	//付け替えは済んでいるので、メールの送信に失敗してもエラーにはしない
+	if err = mailService.SendIdentityMoved(ctx, m, ownerUser, owner.Provider); err != nil {
+		logger.LogError(ctx, err)
+	}
+	return nil
+}
+
+// UnlinkIdentity 外部サービスとの連携を解除する(最後のログイン方法は解除できない)
+// 残りのログイン方法の確認と解除は同じトランザクションで行い、同時に別の連携を解除されてもログイン方法がなくならないようにする
+func UnlinkIdentity(ctx context.Context, client *mongo.Client, ur *userRepository.UsersRepository, ir *identityRepository.IdentityRepository, uId primitive.ObjectID, provider string) *customError.Error {
+	//旧方式の連携の移行はトランザクションの外で済ませておく
+	if _, _, err := getLinkUser(ctx, ur, ir, uId, time.Now()); err != nil {
+		return err
+	}
+
+	_, e := db.WithTransaction(ctx, client, func(sc mongo.SessionContext) (struct{}, error) {
+		zero := struct{}{}
+		if err := ur.LockLoginMethods(sc, uId); err != nil {
+			return zero, err
+		}
+		user, err := getLoginUser(sc, ur, uId)
+		if err != nil {
+			return zero, err
+		}
+		identities, err := ir.ListByUser(sc, uId)
+		if err != nil {
+			return zero, err
+		}
+
+		var target *identityRepository.Model
+		for _, identity := range identities {
+			if identity.Provider == provider {
+				target = identity
+			}
+		}
+		if target == nil {
+			return zero, errIdentityNotLinked(provider, uId)
+		}
+		if loginMethods(user, identities) <= 1 {
+			return zero, errLastLoginMethod(provider, uId)
+		}
+
+		ok, err := ir.Delete(sc, target.ID, uId)
+		if err != nil {
+			return zero, err
+		}
+		if !ok {
+			return zero, errIdentityNotLinked(provider, uId)
+		}
+		return zero, nil
+	})
+	if e != nil {
+		var cErr *customError.Error
+		if errors.As(e, &cErr) {
+			return cErr
+		}
+		return errUnlinkIdentity(e, provider, uId)
+	}
+	return nil
+}
+
+// SetPassword 外部サービスだけで登録したユーザーが、ログイン方法としてパスワードを設定する
+// パスワードでログインするにはメールアドレスが必要なので、確認済みのメールアドレスがあるユーザーに限る(設定済みの場合の変更はマイページのupdateUserで行う)
+func SetPassword(ctx context.Context, ur *userRepository.UsersRepository, uId primitive.ObjectID, password string) *customError.Error {
+	user, err := getLoginUser(ctx, ur, uId)
+	if err != nil {
+		return err
+	}
+	if user.HasPassword() {
+		return errPasswordAlreadySet(uId)
+	}
+	if user.Email == nil || !user.EmailVerified {
+		return errPasswordNeedVerifiedEmail(uId)
+	}
+	if len(password) < passwordMinLength {
+		return errPasswordTooShort()
+	}
+
+	hashed, rawErr := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if rawErr != nil {
+		return errGenerateFromPassword(rawErr)
+	}
+	ok, err := ur.SetPassword(ctx, uId, *user.Email, hashed)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		//確認してから設定するまでの間にメールアドレスが変わった
+		return errPasswordNeedVerifiedEmail(uId)
+	}
+	return nil
+}
+
+// checkProviderNotLinked 同じ外部サービスの別のアカウントを連携済みの場合はエラー(先に解除してもらう)
+func checkProviderNotLinked(ctx context.Context, ir *identityRepository.IdentityRepository, uId primitive.ObjectID, provider string) *customError.Error {
+	linked, err := ir.GetByUserProvider(ctx, uId, provider)
+	if err != nil {
+		return err
+	}
+	if linked != nil {
+		return errProviderAlreadyLinked(provider, uId)
+	}
+	return nil
+}
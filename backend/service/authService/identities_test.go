@@ -0,0 +1,270 @@
+package authService
+
+import (
+	"backend/db/repository/identityRepository"
+	"backend/db/repository/sessionRepository"
+	"backend/db/repository/userRepository"
+	"backend/util/mailer"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLoginMethods_正常系_パスワードと連携中のサービスを数えること(t *testing.T) {
+	email := "user@example.com"
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	identities := []*identityRepository.Model{{Provider: identityRepository.ProviderX}}
+
+	withPassword := &userRepository.Model{Email: &email, Password: hashed}
+	assert.Equal(t, 2, loginMethods(withPassword, identities))
+
+	// 外部サービスで登録したユーザーのパスワードはハッシュではないので数えない
+	socialOnly := &userRepository.Model{Password: []byte("randomst")}
+	assert.Equal(t, 1, loginMethods(socialOnly, identities))
+
+	// メールアドレスがなければパスワードでログインできない
+	noEmail := &userRepository.Model{Password: hashed}
+	assert.Equal(t, 0, loginMethods(noEmail, nil))
+}
+
+func TestParseIdentityConflict_正常系_発行したユーザーで連携先を取り出せること(t *testing.T) {
+	tc := testTokenConfig()
+	uId := primitive.NewObjectID()
+
+	conflict, err := newIdentityConflict(uId, identityRepository.ProviderGitHub, "12345", tc, time.Now())
+	assert.Nil(t, err)
+	claims, err := parseIdentityConflict(conflict.Token, uId, tc)
+	assert.Nil(t, err)
+	assert.Equal(t, identityRepository.ProviderGitHub, claims.Provider)
+	assert.Equal(t, "12345", claims.Subject)
+}
+
+func TestParseIdentityConflict_異常系_別のユーザーや別の用途のトークンは拒否されること(t *testing.T) {
+	tc := testTokenConfig()
+	uId := primitive.NewObjectID()
+
+	conflict, _ := newIdentityConflict(uId, identityRepository.ProviderX, "12345", tc, time.Now())
+	_, err := parseIdentityConflict(conflict.Token, primitive.NewObjectID(), tc)
+	assert.Equal(t, InvalidConflictToken, err.ErrorCode)
+
+	// 同じ鍵で署名した2段階認証のチャレンジは使えない
+	challenge, _ := newTwoFactorChallenge(uId, "", tc, time.Now())
+	_, err = parseIdentityConflict(challenge.Token, uId, tc)
+	assert.Equal(t, InvalidConflictToken, err.ErrorCode)
+
+	expired, _ := newIdentityConflict(uId, identityRepository.ProviderX, "12345", tc, time.Now().Add(-tc.ChallengeExpire))
+	_, err = parseIdentityConflict(expired.Token, uId, tc)
+	assert.Equal(t, InvalidConflictToken, err.ErrorCode)
+}
+
+// unlinkMockResponses UnlinkIdentityの確認で返すユーザーと連携中のアカウント(トランザクションの外と中で2回ずつ読む)
+func unlinkMockResponses(mt *mtest.T, uId primitive.ObjectID, user bson.D) {
+	identity := bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "user_id", Value: uId}, {Key: "provider", Value: identityRepository.ProviderX}}
+	for i := 0; i < 2; i++ {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test."+userRepository.CollectionName, mtest.FirstBatch, user),
+			mtest.CreateCursorResponse(0, "test."+identityRepository.CollectionName, mtest.FirstBatch, identity),
+		)
+		if i == 0 {
+			// ログイン方法の確認より先にユーザーのドキュメントに書き込む
+			mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}})
+		}
+	}
+}
+
+func TestUnlinkIdentity_正常系_ユーザーに書き込んでから確認と解除を同じトランザクションで行うこと(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("unlink", func(mt *mtest.T) {
+		uId := primitive.NewObjectID()
+		hashed, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+		unlinkMockResponses(mt, uId, bson.D{{Key: "_id", Value: uId}, {Key: "email", Value: "user@example.com"}, {Key: "password", Value: hashed}})
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}},
+			bson.D{{Key: "ok", Value: 1}},
+		)
+		ur := userRepository.NewUsersRepository(mockDB(mt))
+		ir := identityRepository.NewIdentityRepository(mockDB(mt))
+
+		err := UnlinkIdentity(context.Background(), mt.Client, &ur, &ir, uId, identityRepository.ProviderX)
+
+		assert.Nil(mt, err)
+		assert.Equal(mt, []string{"find", "find", "update", "find", "find", "delete", "commitTransaction"}, startedCommands(mt))
+	})
+}
+
+func TestUnlinkIdentity_異常系_最後のログイン方法は解除せずにトランザクションを中止すること(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("last", func(mt *mtest.T) {
+		uId := primitive.NewObjectID()
+		unlinkMockResponses(mt, uId, bson.D{{Key: "_id", Value: uId}, {Key: "password", Value: []byte("randomst")}})
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		ur := userRepository.NewUsersRepository(mockDB(mt))
+		ir := identityRepository.NewIdentityRepository(mockDB(mt))
+
+		err := UnlinkIdentity(context.Background(), mt.Client, &ur, &ir, uId, identityRepository.ProviderX)
+
+		assert.NotNil(mt, err)
+		assert.Equal(mt, LastLoginMethod, err.ErrorCode)
+		assert.Equal(mt, []string{"find", "find", "update", "find", "find", "abortTransaction"}, startedCommands(mt))
+	})
+}
+
+// recordingMailer 送ったメールを記録する
+type recordingMailer struct{ msgs []*mailer.Message }
+
+func (r *recordingMailer) Send(_ context.Context, msg *mailer.Message) error {
+	r.msgs = append(r.msgs, msg)
+	return nil
+}
+
+func TestResolveIdentityConflict_正常系_元のユーザーのそのサービスのセッションを失効させてメールで知らせること(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("resolve", func(mt *mtest.T) {
+		tc := testTokenConfig()
+		uId, ownerId := primitive.NewObjectID(), primitive.NewObjectID()
+		hashed, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+		conflict, _ := newIdentityConflict(uId, identityRepository.ProviderX, "12345", tc, time.Now())
+
+		user := bson.D{{Key: "_id", Value: uId}}
+		owner := bson.D{{Key: "_id", Value: ownerId}, {Key: "email", Value: "owner@example.com"}, {Key: "password", Value: hashed}}
+		identity := bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "user_id", Value: ownerId}, {Key: "provider", Value: identityRepository.ProviderX}, {Key: "subject", Value: "12345"}}
+		ack := bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test."+userRepository.CollectionName, mtest.FirstBatch, user),
+			mtest.CreateCursorResponse(0, "test."+identityRepository.CollectionName, mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "test."+identityRepository.CollectionName, mtest.FirstBatch, identity),
+			mtest.CreateCursorResponse(0, "test."+identityRepository.CollectionName, mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "test."+userRepository.CollectionName, mtest.FirstBatch, owner),
+			mtest.CreateCursorResponse(0, "test."+identityRepository.CollectionName, mtest.FirstBatch, identity),
+			// トランザクション
+			ack,
+			mtest.CreateCursorResponse(0, "test."+userRepository.CollectionName, mtest.FirstBatch, owner),
+			mtest.CreateCursorResponse(0, "test."+identityRepository.CollectionName, mtest.FirstBatch, identity),
+			ack,
+			ack,
+			bson.D{{Key: "ok", Value: 1}},
+		)
+		ur := userRepository.NewUsersRepository(mockDB(mt))
+		ir := identityRepository.NewIdentityRepository(mockDB(mt))
+		sr := sessionRepository.NewSessionRepository(mockDB(mt))
+		m := &recordingMailer{}
+
+		err := ResolveIdentityConflict(context.Background(), mt.Client, &ur, &ir, &sr, m, tc, uId, conflict.Token)
+
+		assert.Nil(mt, err)
+		revoke := updateFilterOf(mt, sessionRepository.CollectionName)
+		assert.Equal(mt, ownerId, revoke.Lookup(sessionRepository.UserID).ObjectID())
+		assert.Equal(mt, identityRepository.ProviderX, revoke.Lookup(sessionRepository.Provider).StringValue())
+		if assert.Len(mt, m.msgs, 1) {
+			assert.Equal(mt, "owner@example.com", m.msgs[0].To)
+			assert.Contains(mt, m.msgs[0].Subject, "X")
+		}
+	})
+}
+
+func TestResolveIdentityConflict_異常系_元のユーザーの最後のログイン方法は付け替えずに知らせないこと(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("last", func(mt *mtest.T) {
+		tc := testTokenConfig()
+		uId, ownerId := primitive.NewObjectID(), primitive.NewObjectID()
+		conflict, _ := newIdentityConflict(uId, identityRepository.ProviderX, "12345", tc, time.Now())
+
+		user := bson.D{{Key: "_id", Value: uId}}
+		owner := bson.D{{Key: "_id", Value: ownerId}, {Key: "password", Value: []byte("randomst")}}
+		identity := bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "user_id", Value: ownerId}, {Key: "provider", Value: identityRepository.ProviderX}, {Key: "subject", Value: "12345"}}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test."+userRepository.CollectionName, mtest.FirstBatch, user),
+			mtest.CreateCursorResponse(0, "test."+identityRepository.CollectionName, mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "test."+identityRepository.CollectionName, mtest.FirstBatch, identity),
+			mtest.CreateCursorResponse(0, "test."+identityRepository.CollectionName, mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "test."+userRepository.CollectionName, mtest.FirstBatch, owner),
+			mtest.CreateCursorResponse(0, "test."+identityRepository.CollectionName, mtest.FirstBatch, identity),
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+			mtest.CreateCursorResponse(0, "test."+userRepository.CollectionName, mtest.FirstBatch, owner),
+			mtest.CreateCursorResponse(0, "test."+identityRepository.CollectionName, mtest.FirstBatch, identity),
+			bson.D{{Key: "ok", Value: 1}},
+		)
+		ur := userRepository.NewUsersRepository(mockDB(mt))
+		ir := identityRepository.NewIdentityRepository(mockDB(mt))
+		sr := sessionRepository.NewSessionRepository(mockDB(mt))
+		m := &recordingMailer{}
+
+		err := ResolveIdentityConflict(context.Background(), mt.Client, &ur, &ir, &sr, m, tc, uId, conflict.Token)
+
+		assert.NotNil(mt, err)
+		assert.Equal(mt, ConflictOwnerLastLoginMethod, err.ErrorCode)
+		assert.Equal(mt, "abortTransaction", startedCommands(mt)[len(startedCommands(mt))-1])
+		assert.Empty(mt, m.msgs)
+	})
+}
+
+func TestSetPassword_正常系_確認済みのメールアドレスがあればパスワードを設定できること(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("set", func(mt *mtest.T) {
+		uId := primitive.NewObjectID()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test."+userRepository.CollectionName, mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: uId}, {Key: "email", Value: "user@example.com"}, {Key: "email_verified", Value: true}, {Key: "password", Value: []byte("randomst")},
+			}),
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+		)
+		ur := userRepository.NewUsersRepository(mockDB(mt))
+
+		err := SetPassword(context.Background(), &ur, uId, "password")
+
+		assert.Nil(mt, err)
+		filter := updateFilterOf(mt, userRepository.CollectionName)
+		// 確認してから設定するまでにメールアドレスが変わっていないことも条件にする
+		assert.Equal(mt, "user@example.com", filter.Lookup(userRepository.Email).StringValue())
+		assert.True(mt, filter.Lookup(userRepository.EmailVerified).Boolean())
+	})
+}
+
+func TestSetPassword_異常系_設定済みや確認済みのメールアドレスがない場合は設定しないこと(t *testing.T) {
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	tests := []struct {
+		name     string
+		user     bson.D
+		password string
+		code     string
+	}{
+		{"設定済み", bson.D{{Key: "email", Value: "user@example.com"}, {Key: "email_verified", Value: true}, {Key: "password", Value: hashed}}, "password", PasswordAlreadySet},
+		{"メールアドレスなし", bson.D{{Key: "password", Value: []byte("randomst")}}, "password", PasswordNeedVerifiedMail},
+		{"未確認のメールアドレス", bson.D{{Key: "email", Value: "user@example.com"}, {Key: "password", Value: []byte("randomst")}}, "password", PasswordNeedVerifiedMail},
+		{"短いパスワード", bson.D{{Key: "email", Value: "user@example.com"}, {Key: "email_verified", Value: true}, {Key: "password", Value: []byte("randomst")}}, "short", PasswordTooShort},
+	}
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			uId := primitive.NewObjectID()
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "test."+userRepository.CollectionName, mtest.FirstBatch, append(bson.D{{Key: "_id", Value: uId}}, tt.user...)))
+			ur := userRepository.NewUsersRepository(mockDB(mt))
+
+			err := SetPassword(context.Background(), &ur, uId, tt.password)
+
+			assert.NotNil(mt, err)
+			assert.Equal(mt, tt.code, err.ErrorCode)
+			assert.Equal(mt, []string{"find"}, startedCommands(mt))
+		})
+	}
+}
+
+// updateFilterOf 指定したコレクションに最後に発行したupdateコマンドの検索条件
+func updateFilterOf(mt *mtest.T, collection string) bson.Raw {
+	var filter bson.Raw
+	for _, e := range mt.GetAllStartedEvents() {
+		if e.CommandName == "update" && e.Command.Lookup("update").StringValue() == collection {
+			filter = e.Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("q").Document()
+		}
+	}
+	if filter == nil {
+		mt.Fatalf("%sへのupdateコマンドが発行されていません", collection)
+	}
+	return filter
+}
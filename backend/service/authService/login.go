@@ -1,14 +1,17 @@
 package authService
 
 import (
+	"backend/db/repository/attemptRepository"
+	"backend/db/repository/sessionRepository"
 	"backend/db/repository/userRepository"
 	"backend/graph/graphModel"
 	"backend/middlewares/customError"
+	"backend/middlewares/customError/logger"
 	"backend/service/authService/tokenConfig"
 	"context"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/crypto/bcrypt"
 	"net/http"
+	"time"
 )
 
 type UserWithToken struct {
@@ -30,14 +33,53 @@ func (u *UserWithToken) ToGraphQL() *graphModel.AuthPayload {
 	}
 }
 
-func LoginWithInput(ctx context.Context, writer http.ResponseWriter, input graphModel.LoginInput, r *userRepository.UsersRepository, tokenConfig tokenConfig.TokenConfig) (*UserWithToken, *customError.Error) {
-	// ユーザーインスタンスを取得
+// LoginResult ログインの結果。2段階認証が有効なユーザーはトークンの代わりにChallengeが入る
+type LoginResult struct {
+	User      *UserWithToken
+	Challenge *TwoFactorChallenge
+}
+
+func (l *LoginResult) ToGraphQL() graphModel.LoginResult {
+	if l.Challenge != nil {
+		return l.Challenge.ToGraphQL()
+	}
+	return l.User.ToGraphQL()
+}
+
+func LoginWithInput(ctx context.Context, writer http.ResponseWriter, input graphModel.LoginInput, r *userRepository.UsersRepository, ar *attemptRepository.AttemptRepository, tokenConfig tokenConfig.TokenConfig, sr *sessionRepository.SessionRepository, client Client) (*LoginResult, *customError.Error) {
+	// パスワードを確認する前に回数を加算し、ロック中や上限を超えた場合はパスワードを確認しない
+	if err := reserveAttempt(ctx, ar, attemptRepository.ActionLogin, input.Email, client.IP, time.Now()); err != nil {
+		return nil, err
+	}
+
+	// ユーザーインスタンスを取得(失敗した場合は、加算した回数がそのまま失敗として残る)
 	user, err := getUserByInput(ctx, input, r)
 	if err != nil {
 		return nil, err
 	}
+	if cErr := releaseAttempt(ctx, ar, input.Email, client.IP); cErr != nil {
+		logger.LogError(ctx, cErr)
+	}
+
+	return StartLogin(ctx, user, writer, tokenConfig, sr, client)
+}
+
+// StartLogin パスワードなどの確認が済んだユーザーでログインする
+// 2段階認証が有効な場合はトークンを発行せず、コードの確認(VerifyTwoFactor)に必要なチャレンジを返す
+func StartLogin(ctx context.Context, user *userRepository.Model, writer http.ResponseWriter, tokenConfig tokenConfig.TokenConfig, sr *sessionRepository.SessionRepository, client Client) (*LoginResult, *customError.Error) {
+	if user.TwoFactor.IsEnabled() {
+		challenge, err := newTwoFactorChallenge(user.ID, client.Provider, tokenConfig, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{Challenge: challenge}, nil
+	}
 
-	return LoginByUser(user, writer, tokenConfig)
+	userWithToken, err := LoginByUser(ctx, user, writer, tokenConfig, sr, client)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{User: userWithToken}, nil
 }
 
 func getUserByInput(ctx context.Context, input graphModel.LoginInput, r *userRepository.UsersRepository) (*userRepository.Model, *customError.Error) {
@@ -55,19 +97,10 @@ func getUserByInput(ctx context.Context, input graphModel.LoginInput, r *userRep
 	return user, nil
 }
 
-func loginById(ctx context.Context, id primitive.ObjectID, writer http.ResponseWriter, tokenConfig tokenConfig.TokenConfig, r *userRepository.UsersRepository) (*UserWithToken, *customError.Error) {
-	// ユーザーインスタンスを取得
-	user, err := r.GetById(ctx, id)
-	if err != nil {
-		return nil, err
-	}
-	//UserWithTokenを生成し返す
-	return LoginByUser(user, writer, tokenConfig)
-}
-
-func LoginByUser(user *userRepository.Model, writer http.ResponseWriter, tokenConfig tokenConfig.TokenConfig) (*UserWithToken, *customError.Error) {
+// LoginByUser トークンを発行してログインする(2段階認証の確認は呼び出し側で済ませておくこと)
+func LoginByUser(ctx context.Context, user *userRepository.Model, writer http.ResponseWriter, tokenConfig tokenConfig.TokenConfig, sr *sessionRepository.SessionRepository, client Client) (*UserWithToken, *customError.Error) {
 	// JWTトークン生成
-	accessToken, err := GenerateTokens(writer, user.ID, tokenConfig)
+	accessToken, err := GenerateTokens(ctx, writer, user.ID, tokenConfig, sr, client)
 	if err != nil {
 		return nil, err
 	}
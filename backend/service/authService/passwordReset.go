@@ -1,42 +1,46 @@
 package authService
 
 import (
+	"backend/db/repository/attemptRepository"
+	"backend/db/repository/sessionRepository"
 	"backend/db/repository/userRepository"
 	"backend/middlewares/customError"
-	"backend/util/amazon/ses"
+	"backend/middlewares/customError/logger"
+	"backend/service/mailService"
+	"backend/util/mailer"
 	"context"
-	"fmt"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"golang.org/x/crypto/bcrypt"
-	"math/rand"
 	"time"
 )
 
-// GeneratePasswordResetToken トークンを生成し、DBに格納する
-func GeneratePasswordResetToken(ctx context.Context, r userRepository.UsersRepository, email string) (string, *customError.Error) {
-	ran := rand.New(rand.NewSource(time.Now().UnixNano())) // 生成器を生成
+const passwordResetExpire = 1 * time.Hour //パスワードリセットのURLの有効期限
+
+// GeneratePasswordResetToken トークンを生成し、ハッシュをDBに格納する(トークン自体はメールで送るだけで保存しない)
+// ユーザーが存在しない場合はnilを返す
+func GeneratePasswordResetToken(ctx context.Context, r userRepository.UsersRepository, email string) (*userRepository.Model, string, *customError.Error) {
 	// ランダムな32バイトのスライスを作成
 	tokenBytes := make([]byte, 32)
-
-	// 生成器からバイトをランダムに埋める
-	for i := range tokenBytes {
-		tokenBytes[i] = byte(ran.Intn(256)) // 0~255の範囲でバイトを生成
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, "", errGenerateResetToken(err)
 	}
+	token := hex.EncodeToString(tokenBytes)
 
-	//stringに変換する
-	token := fmt.Sprintf("%x", tokenBytes)
-
-	//DBにトークンを格納する
-	err := r.SetPasswordToken(ctx, email, token)
-	if err != nil {
-		return "", err
+	//DBにトークンのハッシュを格納する
+	user, err := r.SetPasswordToken(ctx, email, hashResetToken(token), time.Now().Add(passwordResetExpire))
+	if err != nil || user == nil {
+		return nil, "", err
 	}
-
-	// base64でエンコードしてトークンを文字列に変換
-	return token, nil
+	return user, token, nil
 }
 
-func PasswordResetExe(ctx context.Context, r userRepository.UsersRepository, token string, password string) (*userRepository.Model, *customError.Error) {
-	user, err := r.GetByPasswordToken(ctx, token)
+// PasswordResetExe トークンを使ってパスワードを変更する
+// パスワードを知られた可能性があるので、ログイン中のセッションはすべて失効させる
+func PasswordResetExe(ctx context.Context, r userRepository.UsersRepository, sr *sessionRepository.SessionRepository, token string, password string) (*userRepository.Model, *customError.Error) {
+	tokenHash := hashResetToken(token)
+	user, err := r.GetByPasswordToken(ctx, tokenHash)
 	if err != nil {
 		return nil, err
 	}
@@ -46,22 +50,56 @@ func PasswordResetExe(ctx context.Context, r userRepository.UsersRepository, tok
 	if rawErr != nil {
 		return nil, errGenerateFromPassword(rawErr)
 	}
-	//パスワードリセットを実行する
-	err = r.PasswordReset(ctx, *user, newPassword)
-	return user, err
+	//パスワードリセットを実行する(トークンは使い捨て)
+	if err = r.PasswordReset(ctx, *user, tokenHash, newPassword); err != nil {
+		return nil, err
+	}
+	if _, err = sr.RevokeAll(ctx, user.ID, time.Now()); err != nil {
+		return nil, err
+	}
+	return user, nil
 }
 
-func ResetEmail(ctx context.Context, r userRepository.UsersRepository, email string) (bool, *customError.Error) {
+// ResetEmail パスワードリセットのメールを送る
+// 登録済みのメールアドレスかどうかを判別されないよう、未登録の場合やメールアドレスごとの回数制限に達した場合も成功として扱う
+func ResetEmail(ctx context.Context, r userRepository.UsersRepository, ar *attemptRepository.AttemptRepository, m mailer.Mailer, email string, ip string) (bool, *customError.Error) {
+	//回数はログインと同じ仕組み(attempts.go)で数える
+	//IPアドレスごとの制限はメールアドレスの登録有無に関係しないので、エラーとして返す
+	now := time.Now()
+	if cErr := reserveAttempt(ctx, ar, attemptRepository.ActionReset, "", ip, now); cErr != nil {
+		return false, cErr
+	}
+	if cErr := reserveAttempt(ctx, ar, attemptRepository.ActionReset, email, "", now); cErr != nil {
+		if cErr.ErrorCode == TooManyAttempts {
+			return true, nil
+		}
+		return false, cErr
+	}
+
 	//トークンを生成しDBに格納する
-	token, cErr := GeneratePasswordResetToken(ctx, r, email)
+	user, token, cErr := GeneratePasswordResetToken(ctx, r, email)
 	if cErr != nil {
 		return false, cErr
 	}
+	if user == nil {
+		return true, nil
+	}
+
+	//送信にかかる時間で登録有無を判別されないよう、送信の完了は待たない(トークンを含むので、失敗しても再送キューには積まずログに残すだけ)
+	go sendPasswordReset(context.WithoutCancel(ctx), m, user, token)
+	return true, nil
+}
 
-	//生成したトークンからメールを作り送信する
-	err := ses.SendPasswordReset(ctx, email, token)
+// sendPasswordReset ユーザーの言語設定に合わせてメールを作り送信する
+func sendPasswordReset(ctx context.Context, m mailer.Mailer, user *userRepository.Model, token string) {
+	err := mailService.SendPasswordReset(ctx, m, *user.Email, user.EmailPreference.GetLocale(), token)
 	if err != nil {
-		return false, errSendPasswordReset(err, email, token)
+		logger.LogError(ctx, errSendPasswordReset(err, user.ID))
 	}
-	return true, nil
+}
+
+// hashResetToken DBにはトークンのハッシュだけを保存する(DBが漏れてもリセットできないようにする)
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,122 @@
+package authService
+
+import (
+	"backend/db/repository/attemptRepository"
+	"backend/db/repository/sessionRepository"
+	"backend/db/repository/userRepository"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestHashResetToken_正常系_トークンそのものは保存されないこと(t *testing.T) {
+	hash := hashResetToken("token")
+
+	assert.Equal(t, hash, hashResetToken("token"))
+	assert.NotEqual(t, hash, hashResetToken("token2"))
+	assert.NotContains(t, hash, "token")
+	assert.Len(t, hash, 64)
+}
+
+func TestResetEmail_正常系_メールアドレスごとの上限に達しても成功として扱うこと(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("email", func(mt *mtest.T) {
+		ip := "192.0.2.1"
+		email := "limit@example.com"
+		free := attemptPolicies[attemptRepository.ActionReset][attemptRepository.KindEmail]
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: bson.D{
+				{Key: "_id", Value: attemptRepository.Key(attemptRepository.ActionReset, attemptRepository.KindIP, ip)},
+				{Key: "failures", Value: 1},
+			}}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: bson.D{
+				{Key: "_id", Value: attemptRepository.Key(attemptRepository.ActionReset, attemptRepository.KindEmail, email)},
+				{Key: "failures", Value: free},
+				{Key: "locked_until", Value: time.Now().Add(time.Minute)},
+			}}},
+		)
+		ar := attemptRepository.NewAttemptRepository(mockDB(mt))
+
+		// 上限に達した後はトークンを発行せずに成功を返す(大文字小文字は区別しない)
+		ok, err := ResetEmail(context.Background(), userRepository.UsersRepository{}, &ar, nil, "Limit@example.com", ip)
+		assert.Nil(mt, err)
+		assert.True(mt, ok)
+		assert.Equal(mt, []string{"findAndModify", "findAndModify"}, startedCommands(mt))
+		query := mt.GetAllStartedEvents()[1].Command.Lookup("query").Document()
+		assert.Equal(mt, attemptRepository.Key(attemptRepository.ActionReset, attemptRepository.KindEmail, email), query.Lookup(attemptRepository.ID).StringValue())
+	})
+}
+
+func TestPasswordResetExe_正常系_パスワードを変更したらすべてのセッションを失効させること(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("reset", func(mt *mtest.T) {
+		uId := primitive.NewObjectID()
+		email := "reset@example.com"
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test."+userRepository.CollectionName, mtest.FirstBatch, bson.D{{Key: "_id", Value: uId}, {Key: "email", Value: email}}),
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 3}, {Key: "nModified", Value: 3}},
+		)
+		r := userRepository.NewUsersRepository(mockDB(mt))
+		sr := sessionRepository.NewSessionRepository(mockDB(mt))
+
+		user, err := PasswordResetExe(context.Background(), r, &sr, "token", "newpassword")
+		assert.Nil(mt, err)
+		assert.Equal(mt, uId, user.ID)
+
+		// ユーザーの更新の後に、そのユーザーのセッションをすべて失効させる
+		mt.GetStartedEvent()
+		mt.GetStartedEvent()
+		filter := updateFilter(mt)
+		assert.Equal(mt, uId, filter.Lookup(sessionRepository.UserID).ObjectID())
+		_, lookupErr := filter.LookupErr(sessionRepository.ID)
+		assert.Error(mt, lookupErr)
+	})
+}
+
+func TestResetEmail_異常系_IPアドレスごとの上限に達した場合はエラーになること(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("ip", func(mt *mtest.T) {
+		ip := "192.0.2.2"
+		free := attemptPolicies[attemptRepository.ActionReset][attemptRepository.KindIP]
+		// 上限に達してロック中なので、トークンは発行しない
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: bson.D{
+				{Key: "_id", Value: attemptRepository.Key(attemptRepository.ActionReset, attemptRepository.KindIP, ip)},
+				{Key: "failures", Value: free},
+				{Key: "locked_until", Value: time.Now().Add(time.Minute)},
+			}}},
+		)
+		ar := attemptRepository.NewAttemptRepository(mockDB(mt))
+
+		ok, err := ResetEmail(context.Background(), userRepository.UsersRepository{}, &ar, nil, "someone@example.com", ip)
+		assert.False(mt, ok)
+		if assert.NotNil(mt, err) {
+			assert.Equal(mt, TooManyAttempts, err.ErrorCode)
+		}
+		assert.Equal(mt, []string{"findAndModify"}, startedCommands(mt))
+	})
+}
+
+func TestGeneratePasswordResetToken_正常系_確認済みのメールアドレスのユーザーにだけトークンを設定すること(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("unverified", func(mt *mtest.T) {
+		// 未確認のメールアドレスは条件に一致しない
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: nil}})
+		ur := userRepository.NewUsersRepository(mockDB(mt))
+
+		user, token, err := GeneratePasswordResetToken(context.Background(), ur, "typo@example.com")
+
+		assert.Nil(mt, err)
+		assert.Nil(mt, user)
+		assert.Empty(mt, token)
+		query := mt.GetStartedEvent().Command.Lookup("query").Document()
+		assert.Equal(mt, "typo@example.com", query.Lookup(userRepository.Email).StringValue())
+		assert.True(mt, query.Lookup(userRepository.EmailVerified).Boolean())
+	})
+}
@@ -0,0 +1,221 @@
+package authService
+
+import (
+	"backend/db/repository/sessionRepository"
+	"backend/graph/graphModel"
+	"backend/middlewares/auth"
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/logger"
+	"backend/service/authService/tokenConfig"
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	rotationGrace      = 30 * time.Second //同時に届いたリフレッシュ(複数タブなど)を、トークンの再利用と誤判定しない猶予
+	userAgentMaxLength = 256
+)
+
+// Client ログインした端末の情報(セッションの一覧に表示する)
+type Client struct {
+	UserAgent string
+	IP        string
+	Provider  string //ログインに使った外部サービス(パスワードの場合は空)。連携を付け替えた時に、そのサービスでのセッションだけ失効させるために記録する
+}
+
+// NewClient ipはプロキシ経由の場合も考慮したもの(gin.Context.ClientIP)を渡す
+func NewClient(req *http.Request, ip string) Client {
+	ua := req.UserAgent()
+	if utf8.RuneCountInString(ua) > userAgentMaxLength {
+		ua = string([]rune(ua)[:userAgentMaxLength])
+	}
+	return Client{UserAgent: ua, IP: ip}
+}
+
+// Session ログイン中の端末
+type Session struct {
+	Model   *sessionRepository.Model
+	Current bool //このリクエストを送った端末のセッションかどうか
+}
+
+func (s *Session) ToGraphQL() *graphModel.Session {
+	return &graphModel.Session{
+		ID:         s.Model.ID.Hex(),
+		UserAgent:  s.Model.UserAgent,
+		IP:         s.Model.IP,
+		CreatedAt:  s.Model.CreatedAt,
+		LastUsedAt: s.Model.LastUsedAt,
+		Current:    s.Current,
+	}
+}
+
+// startSession 新しいセッションを作り、リフレッシュトークンをクッキーに設定する
+func startSession(ctx context.Context, writer http.ResponseWriter, id primitive.ObjectID, tokenConfig tokenConfig.TokenConfig, sr *sessionRepository.SessionRepository, client Client) *customError.Error {
+	tokenId, err := newTokenId()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	session := &sessionRepository.Model{
+		UserID:     id,
+		TokenID:    tokenId,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		Provider:   client.Provider,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(tokenConfig.RefreshExpire),
+	}
+	if err = sr.Insert(ctx, session); err != nil {
+		return err
+	}
+	return setRefreshToken(writer, id, session.ID, tokenId, session.ExpiresAt, tokenConfig)
+}
+
+// rotateSession リフレッシュトークンを検証し、新しいjtiのトークンに差し替える
+// ローテーション済みのトークンが再び使われた場合は盗まれたものとみなし、セッションごと失効させる
+func rotateSession(ctx context.Context, req *http.Request, writer http.ResponseWriter, tokenConfig tokenConfig.TokenConfig, sr *sessionRepository.SessionRepository, client Client) (*auth.Claims, *customError.Error) {
+	claims, err := parseRefreshToken(req, tokenConfig)
+	if err != nil {
+		return nil, err
+	}
+	if claims.SessionID == nil {
+		//セッション管理を導入する前に発行されたトークンは使えない(ログインし直してもらう)
+		return nil, errTokenInvalid()
+	}
+
+	now := time.Now()
+	session, err := sr.GetById(ctx, *claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || !session.IsActive(now) || session.UserID != claims.Id {
+		_ = DeleteRefreshToken(writer)
+		return nil, errSessionRevoked(*claims.SessionID)
+	}
+
+	switch checkTokenId(session, claims.ID, now) {
+	case tokenCurrent:
+		newId, err := newTokenId()
+		if err != nil {
+			return nil, err
+		}
+		ok, err := sr.Rotate(ctx, session.ID, claims.ID, newId, client.UserAgent, client.IP, now)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			//同時に届いた別のリクエストが先に差し替えた。そちらのクッキーが使われるので、ここでは設定しない
+			return claims, nil
+		}
+		if err = setRefreshToken(writer, claims.Id, session.ID, newId, session.ExpiresAt, tokenConfig); err != nil {
+			return nil, err
+		}
+		return claims, nil
+	case tokenJustRotated:
+		return claims, nil
+	default:
+		if err = sr.Revoke(ctx, session.ID, now); err != nil {
+			return nil, err
+		}
+		_ = DeleteRefreshToken(writer)
+		reused := errRefreshTokenReused(session.ID, claims.Id, client)
+		logger.LogError(ctx, reused)
+		return nil, reused
+	}
+}
+
+type tokenState int
+
+const (
+	tokenCurrent     tokenState = iota //現在有効なトークン
+	tokenJustRotated                   //直前に差し替えられたばかりのトークン(同時リクエスト)
+	tokenReused                        //差し替え済みのトークンの再利用
+)
+
+// checkTokenId 提示されたjtiがセッションの現在のトークンかどうかを判定する
+func checkTokenId(session *sessionRepository.Model, tokenId string, now time.Time) tokenState {
+	if tokenId != "" && tokenId == session.TokenID {
+		return tokenCurrent
+	}
+	if tokenId != "" && tokenId == session.PreviousTokenID && session.RotatedAt != nil && now.Sub(*session.RotatedAt) < rotationGrace {
+		return tokenJustRotated
+	}
+	return tokenReused
+}
+
+// currentSessionId リクエストのリフレッシュトークンのセッションID。ない場合はnil
+func currentSessionId(req *http.Request, tokenConfig tokenConfig.TokenConfig) *primitive.ObjectID {
+	claims, err := parseRefreshToken(req, tokenConfig)
+	if err != nil {
+		return nil
+	}
+	return claims.SessionID
+}
+
+// Logout このリクエストを送った端末のセッションを失効させ、クッキーを削除する
+func Logout(ctx context.Context, req *http.Request, writer http.ResponseWriter, tokenConfig tokenConfig.TokenConfig, sr *sessionRepository.SessionRepository, uId primitive.ObjectID) *customError.Error {
+	if sId := currentSessionId(req, tokenConfig); sId != nil {
+		if _, err := sr.RevokeByOwner(ctx, *sId, uId, time.Now()); err != nil {
+			return err
+		}
+	}
+	return DeleteRefreshToken(writer)
+}
+
+// LogoutAllDevices ユーザーのすべてのセッションを失効させる
+func LogoutAllDevices(ctx context.Context, writer http.ResponseWriter, sr *sessionRepository.SessionRepository, uId primitive.ObjectID) *customError.Error {
+	if _, err := sr.RevokeAll(ctx, uId, time.Now()); err != nil {
+		return err
+	}
+	return DeleteRefreshToken(writer)
+}
+
+// LogoutOtherDevices このリクエストを送った端末以外のセッションを失効させる(パスワードの変更時など)
+// このリクエストのセッションが分からない場合はすべて失効させる
+func LogoutOtherDevices(ctx context.Context, req *http.Request, tokenConfig tokenConfig.TokenConfig, sr *sessionRepository.SessionRepository, uId primitive.ObjectID) *customError.Error {
+	now := time.Now()
+	if sId := currentSessionId(req, tokenConfig); sId != nil {
+		_, err := sr.RevokeOthers(ctx, uId, *sId, now)
+		return err
+	}
+	_, err := sr.RevokeAll(ctx, uId, now)
+	return err
+}
+
+// ListSessions ユーザーのログイン中の端末を最近使った順に取得する
+func ListSessions(ctx context.Context, req *http.Request, tokenConfig tokenConfig.TokenConfig, sr *sessionRepository.SessionRepository, uId primitive.ObjectID) ([]*Session, *customError.Error) {
+	models, err := sr.ListActive(ctx, uId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	current := currentSessionId(req, tokenConfig)
+
+	result := make([]*Session, len(models))
+	for i, m := range models {
+		result[i] = &Session{Model: m, Current: current != nil && *current == m.ID}
+	}
+	return result, nil
+}
+
+// RevokeSession 指定した端末のセッションを失効させる。このリクエストを送った端末の場合はクッキーも削除する
+func RevokeSession(ctx context.Context, req *http.Request, writer http.ResponseWriter, tokenConfig tokenConfig.TokenConfig, sr *sessionRepository.SessionRepository, uId primitive.ObjectID, id string) *customError.Error {
+	sId, rawErr := primitive.ObjectIDFromHex(id)
+	if rawErr != nil {
+		return errSessionNotFound(rawErr, id)
+	}
+	ok, err := sr.RevokeByOwner(ctx, sId, uId, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errSessionNotFound(nil, id)
+	}
+	if current := currentSessionId(req, tokenConfig); current != nil && *current == sId {
+		return DeleteRefreshToken(writer)
+	}
+	return nil
+}
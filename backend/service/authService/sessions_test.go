@@ -0,0 +1,99 @@
+package authService
+
+import (
+	"backend/db"
+	"backend/db/repository/sessionRepository"
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestCheckTokenId_正常系_現在のトークンと直前に差し替えたトークンを判定できること(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	rotatedAt := now.Add(-10 * time.Second)
+	session := &sessionRepository.Model{TokenID: "new", PreviousTokenID: "old", RotatedAt: &rotatedAt}
+
+	assert.Equal(t, tokenCurrent, checkTokenId(session, "new", now))
+	// 同時に届いたリクエストは猶予の間だけ許可する
+	assert.Equal(t, tokenJustRotated, checkTokenId(session, "old", now))
+}
+
+func TestCheckTokenId_異常系_猶予を過ぎた古いトークンは再利用とみなすこと(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	rotatedAt := now.Add(-rotationGrace)
+	session := &sessionRepository.Model{TokenID: "new", PreviousTokenID: "old", RotatedAt: &rotatedAt}
+
+	assert.Equal(t, tokenReused, checkTokenId(session, "old", now))
+	assert.Equal(t, tokenReused, checkTokenId(session, "older", now))
+	// jtiのないトークンは現在のトークンとして扱わない
+	assert.Equal(t, tokenReused, checkTokenId(&sessionRepository.Model{}, "", now))
+}
+
+func TestNewClient_正常系_長すぎるUserAgentは切り詰めること(t *testing.T) {
+	req := httptest.NewRequest("POST", "/graphql", nil)
+	req.Header.Set("User-Agent", strings.Repeat("あ", userAgentMaxLength+10))
+
+	client := NewClient(req, "192.0.2.1")
+	assert.Equal(t, strings.Repeat("あ", userAgentMaxLength), client.UserAgent)
+	assert.Equal(t, "192.0.2.1", client.IP)
+}
+
+// mockDB mtestのモックに接続するDB(Dockerを使わずに、発行したクエリを確かめる)
+func mockDB(mt *mtest.T) *db.DB {
+	return &db.DB{Client: mt.Client, DBName: "test"}
+}
+
+// updateFilter 直前に発行したupdateコマンドの検索条件
+func updateFilter(mt *mtest.T) bson.Raw {
+	event := mt.GetStartedEvent()
+	for event != nil && event.CommandName != "update" {
+		event = mt.GetStartedEvent()
+	}
+	if event == nil {
+		mt.Fatal("updateコマンドが発行されていません")
+	}
+	assert.Equal(mt, sessionRepository.CollectionName, event.Command.Lookup("update").StringValue())
+	return event.Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("q").Document()
+}
+
+func TestLogoutOtherDevices_正常系_このリクエストの端末のセッションは残すこと(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("current", func(mt *mtest.T) {
+		tc := testTokenConfig()
+		uId, sId := primitive.NewObjectID(), primitive.NewObjectID()
+		rec := httptest.NewRecorder()
+		assert.Nil(mt, setRefreshToken(rec, uId, sId, "jti", time.Now().Add(time.Hour), tc))
+		req := httptest.NewRequest("POST", "/query", nil)
+		req.AddCookie(rec.Result().Cookies()[0])
+
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 2}, {Key: "nModified", Value: 2}})
+		sr := sessionRepository.NewSessionRepository(mockDB(mt))
+		assert.Nil(mt, LogoutOtherDevices(context.Background(), req, tc, &sr, uId))
+
+		filter := updateFilter(mt)
+		assert.Equal(mt, uId, filter.Lookup(sessionRepository.UserID).ObjectID())
+		assert.Equal(mt, sId, filter.Lookup(sessionRepository.ID, "$ne").ObjectID())
+	})
+}
+
+func TestLogoutOtherDevices_正常系_端末が分からない場合はすべて失効させること(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("unknown", func(mt *mtest.T) {
+		uId := primitive.NewObjectID()
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}})
+		sr := sessionRepository.NewSessionRepository(mockDB(mt))
+		assert.Nil(mt, LogoutOtherDevices(context.Background(), httptest.NewRequest("POST", "/query", nil), testTokenConfig(), &sr, uId))
+
+		filter := updateFilter(mt)
+		assert.Equal(mt, uId, filter.Lookup(sessionRepository.UserID).ObjectID())
+		_, err := filter.LookupErr(sessionRepository.ID)
+		assert.Error(mt, err)
+	})
+}
@@ -1,24 +1,41 @@
 package tokenConfig
 
 import (
+	"errors"
 	"os"
 	"time"
 )
 
+const minGuestSecretLength = 32 //ゲストの訪問者IDの署名鍵の最小長(バイト)
+
+var ErrMissingGuestSecret = errors.New("GUEST_SECRET_KEY is not set or too short")
+
 type TokenConfig struct {
-	AccessSecretKey  []byte
-	RefreshSecretKey []byte
-	AccessExpire     time.Duration
-	RefreshExpire    time.Duration
-	FrontDomain      string
+	AccessSecretKey    []byte
+	RefreshSecretKey   []byte
+	AccessExpire       time.Duration
+	RefreshExpire      time.Duration
+	GuestSecretKey     []byte //ゲストの訪問者IDの署名用
+	ChallengeSecretKey []byte //2段階認証のコードを確認するまでの、ログイン途中のトークンの署名用
+	ChallengeExpire    time.Duration
+	FrontDomain        string
 }
 
-func NewTokenConfig() *TokenConfig {
-	return &TokenConfig{
-		AccessSecretKey:  []byte(os.Getenv("JWT_SECRET_KEY")),
-		RefreshSecretKey: []byte(os.Getenv("JWT_REFRESH_KEY")),
-		AccessExpire:     15 * time.Minute,
-		RefreshExpire:    7 * 24 * time.Hour,
-		FrontDomain:      os.Getenv("FRONT_URI"),
+// NewTokenConfig 環境変数から読み込む
+// memo:ゲストの署名鍵が空だと誰でも他の訪問者のクッキーを偽造できるため、起動時にエラーにする
+func NewTokenConfig() (*TokenConfig, error) {
+	config := &TokenConfig{
+		AccessSecretKey:    []byte(os.Getenv("JWT_SECRET_KEY")),
+		RefreshSecretKey:   []byte(os.Getenv("JWT_REFRESH_KEY")),
+		AccessExpire:       15 * time.Minute,
+		RefreshExpire:      7 * 24 * time.Hour,
+		GuestSecretKey:     []byte(os.Getenv("GUEST_SECRET_KEY")),
+		ChallengeSecretKey: []byte(os.Getenv("JWT_CHALLENGE_KEY")),
+		ChallengeExpire:    5 * time.Minute,
+		FrontDomain:        os.Getenv("FRONT_URI"),
+	}
+	if len(config.GuestSecretKey) < minGuestSecretLength {
+		return nil, ErrMissingGuestSecret
 	}
+	return config, nil
 }
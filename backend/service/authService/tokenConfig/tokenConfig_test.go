@@ -0,0 +1,26 @@
+package tokenConfig
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTokenConfig_正常系_十分な長さのゲスト署名鍵で読み込めること(t *testing.T) {
+	t.Setenv("GUEST_SECRET_KEY", strings.Repeat("k", minGuestSecretLength))
+
+	config, err := NewTokenConfig()
+	assert.NoError(t, err)
+	assert.Len(t, config.GuestSecretKey, minGuestSecretLength)
+}
+
+func TestNewTokenConfig_異常系_ゲスト署名鍵が空や短い場合は起動できないこと(t *testing.T) {
+	t.Setenv("GUEST_SECRET_KEY", "")
+	_, err := NewTokenConfig()
+	assert.ErrorIs(t, err, ErrMissingGuestSecret)
+
+	t.Setenv("GUEST_SECRET_KEY", "short")
+	_, err = NewTokenConfig()
+	assert.ErrorIs(t, err, ErrMissingGuestSecret)
+}
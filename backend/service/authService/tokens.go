@@ -4,6 +4,8 @@ import (
 	"backend/middlewares/auth"
 	"backend/middlewares/customError"
 	"backend/service/authService/tokenConfig"
+	"crypto/rand"
+	"encoding/hex"
 	"github.com/golang-jwt/jwt/v4"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"net/http"
@@ -37,29 +39,31 @@ func parseRefreshToken(req *http.Request, tokenConfig tokenConfig.TokenConfig) (
 	return claims, nil
 }
 
-// リフレッシュトークンを使用してアクセストークンを再生成
-func refreshHandler(req *http.Request, writer http.ResponseWriter, tokenConfig tokenConfig.TokenConfig) (*string, *customError.Error) {
-	claims, err := parseRefreshToken(req, tokenConfig)
-	if err != nil {
-		return nil, err
+// generateAccessToken アクセストークンを生成する
+// memo:アクセストークンはDBを見ずに検証するので、セッションを失効させても期限(15分)までは使える
+func generateAccessToken(id primitive.ObjectID, tokenConfig tokenConfig.TokenConfig) (*string, *customError.Error) {
+	accessClaims := auth.Claims{
+		Id: id,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(tokenConfig.AccessExpire)),
+		},
 	}
-
-	// 新しいトークンを発行・リフレッシュトークンの再生成
-	accessToken, err := GenerateTokens(writer, claims.Id, tokenConfig)
+	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
+	accessString, err := accessToken.SignedString(tokenConfig.AccessSecretKey)
 	if err != nil {
-		return nil, err
+		return nil, errGenerateAccessToken(err, id)
 	}
-
-	return accessToken, nil
+	return &accessString, nil
 }
 
-// リフレッシュトークンを再発行する
-func resetRefreshToken(writer http.ResponseWriter, id primitive.ObjectID, tokenConfig tokenConfig.TokenConfig) *customError.Error {
-	// リフレッシュトークン
+// setRefreshToken セッションとjtiを含むリフレッシュトークンをクッキーに設定する
+func setRefreshToken(writer http.ResponseWriter, id primitive.ObjectID, sessionId primitive.ObjectID, tokenId string, expiresAt time.Time, tokenConfig tokenConfig.TokenConfig) *customError.Error {
 	refreshClaims := auth.Claims{
-		Id: id,
+		Id:        id,
+		SessionID: &sessionId,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(tokenConfig.RefreshExpire)),
+			ID:        tokenId,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 		},
 	}
 	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
@@ -71,7 +75,7 @@ func resetRefreshToken(writer http.ResponseWriter, id primitive.ObjectID, tokenC
 	http.SetCookie(writer, &http.Cookie{
 		Name:     refreshTokenName,
 		Value:    refreshString,
-		Expires:  time.Now().Add(tokenConfig.RefreshExpire),
+		Expires:  expiresAt,
 		Path:     "/",
 		Secure:   true,
 		HttpOnly: true,
@@ -80,3 +84,12 @@ func resetRefreshToken(writer http.ResponseWriter, id primitive.ObjectID, tokenC
 
 	return nil
 }
+
+// newTokenId リフレッシュトークンのjtiを生成する
+func newTokenId() (string, *customError.Error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errGenerateTokenId(err)
+	}
+	return hex.EncodeToString(b), nil
+}
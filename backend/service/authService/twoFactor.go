@@ -0,0 +1,392 @@
+package authService
+
+import (
+	"backend/db/repository/sessionRepository"
+	"backend/db/repository/settingRepository"
+	"backend/db/repository/userRepository"
+	"backend/graph/graphModel"
+	"backend/middlewares/customError"
+	"backend/service/authService/tokenConfig"
+	"backend/service/userService"
+	"backend/util/rateLimit"
+	"backend/util/totp"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/golang-jwt/jwt/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	twoFactorIssuer       = "Sake DB" //認証アプリに表示されるサービス名
+	challengeAudience     = "two_factor"
+	recoveryCodeCount     = 10
+	recoveryCodeBytes     = 6 //16進数12桁
+	twoFactorAttemptLimit = 5 //同じユーザーがコードを試せる回数(twoFactorWindowあたり)
+	twoFactorWindow       = 5 * time.Minute
+)
+
+// コードの総当たりを防ぐ(ログイン時・設定変更時の確認で共有する)
+var twoFactorLimiter = rateLimit.NewLimiter(twoFactorAttemptLimit, twoFactorWindow)
+
+var errMissingTwoFactorKey = errors.New("TWO_FACTOR_SECRET_KEY is not set")
+
+// TwoFactorChallenge パスワードの確認が済み、2段階認証のコードを待っている状態を表すトークン
+type TwoFactorChallenge struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+func (c *TwoFactorChallenge) ToGraphQL() *graphModel.TwoFactorChallenge {
+	return &graphModel.TwoFactorChallenge{
+		ChallengeToken: c.Token,
+		ExpiresAt:      c.ExpiresAt,
+	}
+}
+
+// TwoFactorSetup 登録を始めた2段階認証の秘密鍵(コードを確認するまでは有効にならない)
+type TwoFactorSetup struct {
+	Secret string
+	URI    string
+}
+
+func (s *TwoFactorSetup) ToGraphQL() *graphModel.TwoFactorSetup {
+	return &graphModel.TwoFactorSetup{
+		OtpauthURI: s.URI,
+		Secret:     s.Secret,
+	}
+}
+
+// challengeClaims ログイン途中のユーザーと、ログインに使った外部サービス(パスワードの場合は空)
+type challengeClaims struct {
+	Id       primitive.ObjectID `json:"id"`
+	Provider string             `json:"provider,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// newTwoFactorChallenge ログイン途中のトークンを発行する(アクセストークンとは別の鍵で署名する)
+func newTwoFactorChallenge(id primitive.ObjectID, provider string, tokenConfig tokenConfig.TokenConfig, now time.Time) (*TwoFactorChallenge, *customError.Error) {
+	expiresAt := now.Add(tokenConfig.ChallengeExpire)
+	claims := challengeClaims{
+		Id:       id,
+		Provider: provider,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{challengeAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(tokenConfig.ChallengeSecretKey)
+	if err != nil {
+		return nil, errGenerateChallenge(err, id)
+	}
+	return &TwoFactorChallenge{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// parseTwoFactorChallenge ログイン途中のトークンからユーザーIDとログインに使った外部サービスを取り出す
+func parseTwoFactorChallenge(token string, tokenConfig tokenConfig.TokenConfig) (*challengeClaims, *customError.Error) {
+	claims := &challengeClaims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return tokenConfig.ChallengeSecretKey, nil
+	})
+	if err != nil || !parsed.Valid || !claims.VerifyAudience(challengeAudience, true) {
+		return nil, errInvalidChallenge(err)
+	}
+	return claims, nil
+}
+
+// VerifyTwoFactor ログイン途中のトークンと2段階認証のコード(またはリカバリーコード)を確認してログインする
+func VerifyTwoFactor(ctx context.Context, writer http.ResponseWriter, r *userRepository.UsersRepository, tokenConfig tokenConfig.TokenConfig, sr *sessionRepository.SessionRepository, client Client, challengeToken string, code string) (*UserWithToken, *customError.Error) {
+	claims, err := parseTwoFactorChallenge(challengeToken, tokenConfig)
+	if err != nil {
+		return nil, err
+	}
+	user, err := r.GetById(ctx, claims.Id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.TwoFactor.IsEnabled() {
+		return nil, errInvalidChallenge(nil)
+	}
+	if err = verifySecondFactor(ctx, r, user, code, time.Now()); err != nil {
+		return nil, err
+	}
+	client.Provider = claims.Provider
+	return LoginByUser(ctx, user, writer, tokenConfig, sr, client)
+}
+
+// StartTwoFactorSetup 2段階認証の秘密鍵を生成する。確認のコードを送るまでは無効のまま(やり直す場合は作り直す)
+func StartTwoFactorSetup(ctx context.Context, r *userRepository.UsersRepository, uId primitive.ObjectID) (*TwoFactorSetup, *customError.Error) {
+	user, err := getLoginUser(ctx, r, uId)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactor.IsEnabled() {
+		return nil, errTwoFactorAlreadyEnabled(uId)
+	}
+
+	secret, rawErr := totp.GenerateSecret()
+	if rawErr != nil {
+		return nil, errGenerateTwoFactor(rawErr, uId)
+	}
+	sealed, rawErr := sealTwoFactorSecret(secret)
+	if rawErr != nil {
+		return nil, errTwoFactorSecret(rawErr, uId)
+	}
+	ok, err := r.StartTwoFactor(ctx, uId, sealed)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errTwoFactorAlreadyEnabled(uId)
+	}
+
+	account := user.Name
+	if user.Email != nil && *user.Email != "" {
+		account = *user.Email
+	}
+	return &TwoFactorSetup{Secret: secret, URI: totp.URI(twoFactorIssuer, account, secret)}, nil
+}
+
+// ConfirmTwoFactorSetup 認証アプリのコードを確認して2段階認証を有効にし、リカバリーコードを返す
+// リカバリーコードはハッシュだけを保存するので、表示できるのはこの時だけ
+func ConfirmTwoFactorSetup(ctx context.Context, r *userRepository.UsersRepository, uId primitive.ObjectID, code string) ([]string, *customError.Error) {
+	user, err := getLoginUser(ctx, r, uId)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactor == nil {
+		return nil, errTwoFactorNotStarted(uId)
+	}
+	if user.TwoFactor.IsEnabled() {
+		return nil, errTwoFactorAlreadyEnabled(uId)
+	}
+	if !twoFactorLimiter.Allow(uId.Hex()) {
+		return nil, errTooManyTwoFactorAttempts(uId)
+	}
+
+	secret, rawErr := openTwoFactorSecret(user.TwoFactor.Secret)
+	if rawErr != nil {
+		return nil, errTwoFactorSecret(rawErr, uId)
+	}
+	now := time.Now()
+	step, valid := totp.Validate(secret, code, now)
+	if !valid {
+		return nil, errInvalidTwoFactorCode(uId)
+	}
+
+	codes, hashes, err := generateRecoveryCodes(uId)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := r.EnableTwoFactor(ctx, uId, hashes, step, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errTwoFactorAlreadyEnabled(uId)
+	}
+	return codes, nil
+}
+
+// RegenerateRecoveryCodes リカバリーコードを作り直す(以前のコードは使えなくなる)
+func RegenerateRecoveryCodes(ctx context.Context, r *userRepository.UsersRepository, uId primitive.ObjectID, code string) ([]string, *customError.Error) {
+	user, err := getTwoFactorUser(ctx, r, uId)
+	if err != nil {
+		return nil, err
+	}
+	if err = verifySecondFactor(ctx, r, user, code, time.Now()); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes(uId)
+	if err != nil {
+		return nil, err
+	}
+	if err = r.SetRecoveryCodes(ctx, uId, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor 2段階認証を無効にする。必須になっているロールのユーザーは無効にできない
+func DisableTwoFactor(ctx context.Context, r *userRepository.UsersRepository, st *settingRepository.SettingRepository, uId primitive.ObjectID, code string) *customError.Error {
+	user, err := getTwoFactorUser(ctx, r, uId)
+	if err != nil {
+		return err
+	}
+	for _, role := range user.Roles {
+		required, err := userService.IsTwoFactorRequired(ctx, role, *st)
+		if err != nil {
+			return err
+		}
+		if required {
+			return errTwoFactorRequired(uId, role)
+		}
+	}
+	if err = verifySecondFactor(ctx, r, user, code, time.Now()); err != nil {
+		return err
+	}
+	return r.DeleteTwoFactor(ctx, uId)
+}
+
+// SetAdminTwoFactorRequired 管理者に2段階認証を必須にするかを設定する
+// 必須にした途端に自分が管理者の操作をできなくならないよう、自分が有効にしている場合のみ必須にできる
+func SetAdminTwoFactorRequired(ctx context.Context, r *userRepository.UsersRepository, st *settingRepository.SettingRepository, uId primitive.ObjectID, required bool) (*settingRepository.AuthModel, *customError.Error) {
+	user, err := getLoginUser(ctx, r, uId)
+	if err != nil {
+		return nil, err
+	}
+	if required && !user.TwoFactor.IsEnabled() {
+		return nil, errEnableTwoFactorFirst(uId)
+	}
+	if err = st.SetAdminTwoFactorRequired(ctx, required, uId, time.Now()); err != nil {
+		return nil, err
+	}
+	return st.GetAuth(ctx)
+}
+
+// getLoginUser ログイン中のユーザーを取得する
+func getLoginUser(ctx context.Context, r *userRepository.UsersRepository, uId primitive.ObjectID) (*userRepository.Model, *customError.Error) {
+	user, err := r.GetById(ctx, uId)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errLoginUserNotFound(uId)
+	}
+	return user, nil
+}
+
+// getTwoFactorUser 2段階認証が有効なユーザーを取得する
+func getTwoFactorUser(ctx context.Context, r *userRepository.UsersRepository, uId primitive.ObjectID) (*userRepository.Model, *customError.Error) {
+	user, err := getLoginUser(ctx, r, uId)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactor.IsEnabled() {
+		return nil, errTwoFactorNotEnabled(uId)
+	}
+	return user, nil
+}
+
+// verifySecondFactor 認証アプリのコードかリカバリーコードを確認し、使用済みにする
+func verifySecondFactor(ctx context.Context, r *userRepository.UsersRepository, user *userRepository.Model, code string, now time.Time) *customError.Error {
+	if !twoFactorLimiter.Allow(user.ID.Hex()) {
+		return errTooManyTwoFactorAttempts(user.ID)
+	}
+
+	code = normalizeCode(code)
+	if len(code) == totp.Digits {
+		secret, rawErr := openTwoFactorSecret(user.TwoFactor.Secret)
+		if rawErr != nil {
+			return errTwoFactorSecret(rawErr, user.ID)
+		}
+		step, valid := totp.Validate(secret, code, now)
+		if !valid {
+			return errInvalidTwoFactorCode(user.ID)
+		}
+		//一度使ったコードは、有効期間内でも使えないようにする
+		ok, err := r.UseTwoFactorStep(ctx, user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errInvalidTwoFactorCode(user.ID)
+		}
+		return nil
+	}
+
+	ok, err := r.UseRecoveryCode(ctx, user.ID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errInvalidTwoFactorCode(user.ID)
+	}
+	return nil
+}
+
+// generateRecoveryCodes 表示用のリカバリーコードと、保存用のハッシュを生成する
+func generateRecoveryCodes(uId primitive.ObjectID) ([]string, []string, *customError.Error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, errGenerateTwoFactor(err, uId)
+		}
+		code := hex.EncodeToString(b)
+		codes[i] = code[:4] + "-" + code[4:8] + "-" + code[8:]
+		hashes[i] = hashRecoveryCode(code)
+	}
+	return codes, hashes, nil
+}
+
+// normalizeCode 入力されたコードの区切りや空白を取り除く
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// sealTwoFactorSecret TOTPの秘密鍵を暗号化する(DBが漏れてもコードを生成できないようにする)
+func sealTwoFactorSecret(secret string) (string, error) {
+	gcm, err := twoFactorCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// openTwoFactorSecret 暗号化したTOTPの秘密鍵を復号する
+func openTwoFactorSecret(sealed string) (string, error) {
+	gcm, err := twoFactorCipher()
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.RawStdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("invalid sealed secret")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func twoFactorCipher() (cipher.AEAD, error) {
+	key := os.Getenv("TWO_FACTOR_SECRET_KEY")
+	if key == "" {
+		return nil, errMissingTwoFactorKey
+	}
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
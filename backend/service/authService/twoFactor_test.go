@@ -0,0 +1,105 @@
+package authService
+
+import (
+	"backend/db/repository/identityRepository"
+	"backend/graph/graphModel"
+	"backend/service/authService/tokenConfig"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func testTokenConfig() tokenConfig.TokenConfig {
+	return tokenConfig.TokenConfig{
+		AccessSecretKey:    []byte("access"),
+		RefreshSecretKey:   []byte("refresh"),
+		ChallengeSecretKey: []byte("challenge"),
+		AccessExpire:       15 * time.Minute,
+		ChallengeExpire:    5 * time.Minute,
+	}
+}
+
+func TestSealTwoFactorSecret_正常系_暗号化した秘密鍵を復号できること(t *testing.T) {
+	t.Setenv("TWO_FACTOR_SECRET_KEY", "secret")
+
+	sealed, err := sealTwoFactorSecret("JBSWY3DPEHPK3PXP")
+	assert.NoError(t, err)
+	assert.NotContains(t, sealed, "JBSWY3DPEHPK3PXP")
+
+	opened, err := openTwoFactorSecret(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", opened)
+}
+
+func TestSealTwoFactorSecret_異常系_鍵が未設定や別の鍵の場合は失敗すること(t *testing.T) {
+	t.Setenv("TWO_FACTOR_SECRET_KEY", "")
+	_, err := sealTwoFactorSecret("JBSWY3DPEHPK3PXP")
+	assert.ErrorIs(t, err, errMissingTwoFactorKey)
+
+	t.Setenv("TWO_FACTOR_SECRET_KEY", "secret")
+	sealed, _ := sealTwoFactorSecret("JBSWY3DPEHPK3PXP")
+	t.Setenv("TWO_FACTOR_SECRET_KEY", "rotated")
+	_, err = openTwoFactorSecret(sealed)
+	assert.Error(t, err)
+}
+
+func TestGenerateRecoveryCodes_正常系_表示用のコードとハッシュが対応すること(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes(primitive.NewObjectID())
+	assert.Nil(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+	assert.Len(t, hashes, recoveryCodeCount)
+
+	format := regexp.MustCompile(`^[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}$`)
+	for i, code := range codes {
+		assert.Regexp(t, format, code)
+		// 区切りや大文字で入力されても同じハッシュになること
+		assert.Equal(t, hashes[i], hashRecoveryCode(normalizeCode(" "+code+" ")))
+	}
+	assert.NotEqual(t, codes[0], codes[1])
+}
+
+func TestParseTwoFactorChallenge_正常系_発行したトークンからユーザーIDとログインに使ったサービスを取り出せること(t *testing.T) {
+	tc := testTokenConfig()
+	id := primitive.NewObjectID()
+
+	challenge, err := newTwoFactorChallenge(id, identityRepository.ProviderGoogle, tc, time.Now())
+	assert.Nil(t, err)
+	parsed, err := parseTwoFactorChallenge(challenge.Token, tc)
+	assert.Nil(t, err)
+	assert.Equal(t, id, parsed.Id)
+	// コードを確認した後のセッションに、どのサービスでログインしたかを引き継ぐ
+	assert.Equal(t, identityRepository.ProviderGoogle, parsed.Provider)
+}
+
+func TestParseTwoFactorChallenge_異常系_アクセストークンや期限切れのトークンは拒否されること(t *testing.T) {
+	tc := testTokenConfig()
+	id := primitive.NewObjectID()
+
+	// ログイン途中のトークンの代わりに、アクセストークンは使えない
+	accessToken, _ := generateAccessToken(id, tc)
+	_, err := parseTwoFactorChallenge(*accessToken, tc)
+	assert.Equal(t, InvalidChallenge, err.ErrorCode)
+
+	// 同じ鍵でも用途(aud)が違うトークンは使えない
+	tc.AccessSecretKey = tc.ChallengeSecretKey
+	accessToken, _ = generateAccessToken(id, tc)
+	_, err = parseTwoFactorChallenge(*accessToken, tc)
+	assert.Equal(t, InvalidChallenge, err.ErrorCode)
+
+	expired, _ := newTwoFactorChallenge(id, "", tc, time.Now().Add(-tc.ChallengeExpire))
+	_, err = parseTwoFactorChallenge(expired.Token, tc)
+	assert.Equal(t, InvalidChallenge, err.ErrorCode)
+}
+
+func TestLoginResultToGraphQL_正常系_2段階認証が有効な場合はチャレンジを返すこと(t *testing.T) {
+	expiresAt := time.Unix(1700000000, 0)
+	result := &LoginResult{Challenge: &TwoFactorChallenge{Token: "token", ExpiresAt: expiresAt}}
+
+	challenge, ok := result.ToGraphQL().(*graphModel.TwoFactorChallenge)
+	assert.True(t, ok)
+	assert.Equal(t, "token", challenge.ChallengeToken)
+	assert.Equal(t, expiresAt, challenge.ExpiresAt)
+}
@@ -2,8 +2,11 @@ package bookmarkService
 
 import (
 	"backend/db/repository/bookmarkRepository"
+	"backend/db/repository/notificationRepository"
 	"backend/middlewares/auth"
 	"backend/middlewares/customError"
+	"backend/service/notificationService"
+	"backend/util/pubsub"
 	"context"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -24,6 +27,19 @@ func GetPrimitiveIds(ctx context.Context, targetIdStr string) (primitive.ObjectI
 	return uId, targetId, nil
 }
 
+// AddBookMark ブックマークを追加し、ブックマークされたユーザーに通知する
+func AddBookMark(ctx context.Context, br *bookmarkRepository.BookMarkRepository, nr *notificationRepository.NotificationRepository, ps pubsub.PubSub, uId primitive.ObjectID, targetId primitive.ObjectID) *customError.Error {
+	if err := br.Add(ctx, uId, targetId); err != nil {
+		return err
+	}
+	notificationService.Notify(ctx, nr, ps, notificationRepository.Model{
+		UserID:  targetId,
+		Type:    notificationRepository.TypeBookmarked,
+		ActorID: &uId,
+	})
+	return nil
+}
+
 func GetBookMarkedList(ctx context.Context, r bookmarkRepository.BookMarkRepository, id string) ([]*bookmarkRepository.BookMarkListUser, *customError.Error) {
 	idObj, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
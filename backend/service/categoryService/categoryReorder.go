@@ -0,0 +1,44 @@
+package categoryService
+
+import (
+	"backend/db/repository/categoriesRepository"
+	"backend/middlewares/customError"
+	"context"
+)
+
+// ReorderCategories 兄弟カテゴリの並び順を、idsの順番で振り直す
+// idsはparentIdの直下にある全カテゴリを過不足なく含んでいる必要がある(parentIdがnilの場合はルートカテゴリ)
+func ReorderCategories(ctx context.Context, r *categoriesRepository.CategoryRepository, parentId *int, ids []int) *customError.Error {
+	tree, err := loadTree(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	var siblings []*categoriesRepository.Model
+	if parentId == nil {
+		siblings = tree.roots
+	} else {
+		parent := tree.node(*parentId)
+		if parent == nil {
+			return errReorderInvalidIds(parentId, ids)
+		}
+		siblings = parent.Children
+	}
+
+	//重複や漏れがないかチェック
+	if len(siblings) != len(ids) {
+		return errReorderInvalidIds(parentId, ids)
+	}
+	siblingIds := make(map[int]struct{}, len(siblings))
+	for _, s := range siblings {
+		siblingIds[s.ID] = struct{}{}
+	}
+	for _, id := range ids {
+		if _, ok := siblingIds[id]; !ok {
+			return errReorderInvalidIds(parentId, ids)
+		}
+		delete(siblingIds, id)
+	}
+
+	return r.UpdateOrders(ctx, ids)
+}
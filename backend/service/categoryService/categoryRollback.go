@@ -0,0 +1,106 @@
+package categoryService
+
+import (
+	"backend/db"
+	"backend/db/repository/categoriesRepository"
+	"backend/db/repository/userRepository"
+	"backend/middlewares/auth"
+	"backend/middlewares/customError"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+// RollbackCategory 指定したバージョンの内容(名前・説明・親・画像)にカテゴリを戻す
+// ロールバック自体も新しいバージョンとして記録される(移動不可のカテゴリも戻せるよう、管理者のみ実行できる)
+func RollbackCategory(ctx context.Context, client *mongo.Client, r *categoriesRepository.CategoryRepository, ur *userRepository.UsersRepository, id int, versionNo int, expectedVersionNo int) (*categoriesRepository.Model, *customError.Error) {
+	uId, uName, err := auth.GetIdAndNameNullable(ctx, ur)
+	if err != nil {
+		return nil, err
+	}
+
+	//logsに代入する現在のドキュメントを取得する
+	old, err := r.GetCategoryByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	//versionNoがスキーマ上後付なので、nilは0扱いとする
+	currentVersionNo := 0
+	if old.VersionNo != nil {
+		currentVersionNo = *old.VersionNo
+	}
+	if currentVersionNo != expectedVersionNo {
+		return nil, errRollbackInvalidVersion(id, expectedVersionNo)
+	}
+
+	//戻し先のスナップショットを取得
+	target, err := r.GetLogsByVersionNo(ctx, id, versionNo)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errRollbackVersionNotFound(id, versionNo)
+		}
+		return nil, err
+	}
+
+	//親カテゴリが自身または子カテゴリになっていないかチェック(スナップショット作成後にツリーが変わっている可能性がある)
+	if target.Parent != nil {
+		tree, err := loadTree(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		if tree.node(*target.Parent) == nil {
+			return nil, errRollbackInvalidParent(id, versionNo)
+		}
+		hasIdInTrail, err := HasIdInTrail(ctx, r, id, *target.Parent)
+		if err != nil {
+			return nil, err
+		}
+		if hasIdInTrail {
+			return nil, errRollbackInvalidParent(id, versionNo)
+		}
+	}
+
+	newVersionNo := currentVersionNo + 1
+	record := &categoriesRepository.Model{
+		ID:             id,
+		Name:           target.Name,
+		Parent:         target.Parent,
+		Description:    target.Description,
+		ImageURL:       target.ImageURL,
+		ImageBase64:    target.ImageBase64,
+		VersionNo:      &newVersionNo,
+		Order:          old.Order,
+		CreateUserId:   old.CreateUserId,
+		CreateUserName: old.CreateUserName,
+		UpdateUserId:   uId,
+		UpdateUserName: uName,
+		UpdatedAt:      time.Now(),
+		Readonly:       old.Readonly,
+	}
+	if old.VersionNo == nil {
+		//logsのバージョン番号がnilのままだと戻し先として指定できないため、0を入れておく
+		old.VersionNo = &currentVersionNo
+	}
+
+	//トランザクション(ログを優先的に更新する)
+	_, e := db.WithTransaction(ctx, client, func(sc mongo.SessionContext) (struct{}, error) {
+		zero := struct{}{}
+		if err := r.InsertOneToLog(sc, old); err != nil {
+			return zero, err
+		}
+		if err := r.UpdateOne(sc, record); err != nil {
+			return zero, err
+		}
+		return zero, nil
+	})
+	if e != nil {
+		var cErr *customError.Error
+		if errors.As(e, &cErr) {
+			return nil, cErr
+		}
+		return nil, errRollback(e, id, versionNo)
+	}
+
+	return record, nil
+}
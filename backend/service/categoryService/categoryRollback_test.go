@@ -0,0 +1,61 @@
+package categoryService
+
+import (
+	"backend/db"
+	"backend/db/repository/categoriesRepository"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func startedCommands(mt *mtest.T) []string {
+	var names []string
+	for _, e := range mt.GetAllStartedEvents() {
+		names = append(names, e.CommandName)
+	}
+	return names
+}
+
+func TestRollbackCategory_正常系_指定したバージョンの内容を新しいバージョンとして記録すること(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("rollback", func(mt *mtest.T) {
+		r := categoriesRepository.NewCategoryRepository(&db.DB{Client: mt.Client, DBName: "test"})
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test."+categoriesRepository.CollectionName, mtest.FirstBatch,
+				bson.D{{Key: "id", Value: 5}, {Key: "name", Value: "焼酎"}, {Key: "version_no", Value: 3}}),
+			mtest.CreateCursorResponse(0, "test."+categoriesRepository.LogsCollectionName, mtest.FirstBatch,
+				bson.D{{Key: "id", Value: 5}, {Key: "name", Value: "本格焼酎"}, {Key: "version_no", Value: 1}}),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+			mtest.CreateSuccessResponse(),
+		)
+
+		record, err := RollbackCategory(context.Background(), mt.Client, &r, nil, 5, 1, 3)
+
+		assert.Nil(mt, err)
+		assert.Equal(mt, "本格焼酎", record.Name)
+		assert.Equal(mt, 4, *record.VersionNo)
+		// 現在の内容をログに残してから、同じトランザクションで更新する
+		assert.Equal(mt, []string{"find", "find", "insert", "update", "commitTransaction"}, startedCommands(mt))
+	})
+}
+
+func TestRollbackCategory_異常系_存在しないバージョンには戻せないこと(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("unknown", func(mt *mtest.T) {
+		r := categoriesRepository.NewCategoryRepository(&db.DB{Client: mt.Client, DBName: "test"})
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test."+categoriesRepository.CollectionName, mtest.FirstBatch,
+				bson.D{{Key: "id", Value: 5}, {Key: "name", Value: "焼酎"}, {Key: "version_no", Value: 3}}),
+			mtest.CreateCursorResponse(0, "test."+categoriesRepository.LogsCollectionName, mtest.FirstBatch),
+		)
+
+		_, err := RollbackCategory(context.Background(), mt.Client, &r, nil, 5, 9, 3)
+
+		assert.Equal(mt, RollbackVersionNotFound, err.ErrorCode)
+		assert.Equal(mt, []string{"find", "find"}, startedCommands(mt))
+	})
+}
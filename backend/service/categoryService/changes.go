@@ -0,0 +1,41 @@
+package categoryService
+
+import (
+	"backend/db/repository/categoriesRepository"
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/logger"
+	"backend/util/pubsub"
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// changesTopic カテゴリの書き込みを他のインスタンスに伝えるトピック(ペイロードは書き込んだインスタンスのID)
+const changesTopic = "categories:changed"
+
+// instanceId 自分が送った通知を読み飛ばすための、このプロセスのID
+var instanceId = primitive.NewObjectID().Hex()
+
+// SyncChanges カテゴリの書き込みをPubSubで他のインスタンスに伝え、他のインスタンスからの通知でツリーのキャッシュを無効にする
+// ctxが終了するまで購読を続ける。通知が届かなかった場合もtreeCacheTTLが経てば作り直される
+func SyncChanges(ctx context.Context, ps pubsub.PubSub) *customError.Error {
+	ch, err := ps.Subscribe(ctx, changesTopic)
+	if err != nil {
+		return errSubscribeChanges(err)
+	}
+	categoriesRepository.SetChangePublisher(func(ctx context.Context) {
+		if err := ps.Publish(ctx, changesTopic, []byte(instanceId)); err != nil {
+			logger.LogError(ctx, errPublishChanges(err))
+		}
+	})
+
+	go func() {
+		for payload := range ch {
+			//自分の書き込みは書き込み時に世代を進めているので、無効にし直さない
+			if string(payload) != instanceId {
+				categoriesRepository.Invalidate()
+			}
+		}
+		categoriesRepository.SetChangePublisher(nil)
+	}()
+	return nil
+}
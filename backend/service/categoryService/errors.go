@@ -1,2 +1,88 @@
 package categoryService
 
+import (
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/errorMsg"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+const (
+	ReorderInvalidIds       = "CATEGORY-SERVICE-001-ReorderInvalidIds"
+	RollbackInvalidVersion  = "CATEGORY-SERVICE-002-RollbackInvalidVersion"
+	RollbackInvalidParent   = "CATEGORY-SERVICE-003-RollbackInvalidParent"
+	RollbackTransaction     = "CATEGORY-SERVICE-005-RollbackTransaction"
+	SubscribeChanges        = "CATEGORY-SERVICE-006-SubscribeChanges"
+	PublishChanges          = "CATEGORY-SERVICE-007-PublishChanges"
+	RollbackVersionNotFound = "CATEGORY-SERVICE-008-RollbackVersionNotFound"
+)
+
+func errReorderInvalidIds(parentId *int, ids []int) *customError.Error {
+	return customError.NewError(errors.New("並び替え対象が兄弟カテゴリと一致しません"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    ReorderInvalidIds,
+		UserMsg:    "並び替え対象のカテゴリが不正です",
+		Level:      logrus.InfoLevel,
+		Input:      map[string]interface{}{"parentId": parentId, "ids": ids},
+	})
+}
+
+func errRollbackInvalidVersion(id int, expectedVersionNo int) *customError.Error {
+	return customError.NewError(errors.New("ロールバック時のバージョンが一致しません"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    RollbackInvalidVersion,
+		UserMsg:    errorMsg.VERSION,
+		Level:      logrus.InfoLevel,
+		Input:      fmt.Sprintf("id: %d, expectedVersionNo: %d", id, expectedVersionNo),
+	})
+}
+
+func errRollbackInvalidParent(id int, versionNo int) *customError.Error {
+	return customError.NewError(errors.New("ロールバック先の親カテゴリが不正です"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    RollbackInvalidParent,
+		UserMsg:    "親カテゴリが存在しないか、自身または子カテゴリになるため戻せません",
+		Level:      logrus.InfoLevel,
+		Input:      fmt.Sprintf("id: %d, versionNo: %d", id, versionNo),
+	})
+}
+
+func errRollbackVersionNotFound(id int, versionNo int) *customError.Error {
+	return customError.NewError(errors.New("ロールバック先のバージョンが存在しません"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    RollbackVersionNotFound,
+		UserMsg:    "指定したバージョンが存在しません",
+		Level:      logrus.InfoLevel,
+		Input:      fmt.Sprintf("id: %d, versionNo: %d", id, versionNo),
+	})
+}
+
+func errRollback(err error, id int, versionNo int) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    RollbackTransaction,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      fmt.Sprintf("id: %d, versionNo: %d", id, versionNo),
+	})
+}
+
+func errSubscribeChanges(err error) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    SubscribeChanges,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+	})
+}
+
+func errPublishChanges(err error) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    PublishChanges,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+	})
+}
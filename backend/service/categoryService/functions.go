@@ -6,9 +6,17 @@ import (
 	"sort"
 )
 
-// 再帰的にカテゴリをID順にソートする関数
+// 再帰的にカテゴリを並び順(order)→ID順にソートする関数
+// orderが未設定のカテゴリは、設定済のカテゴリの後ろに並べる
 func sortCategories(categories []*categoriesRepository.Model) {
-	sort.Slice(categories, func(i, j int) bool {
+	sort.SliceStable(categories, func(i, j int) bool {
+		oi, oj := categories[i].Order, categories[j].Order
+		if oi != nil && oj != nil && *oi != *oj {
+			return *oi < *oj
+		}
+		if (oi == nil) != (oj == nil) {
+			return oi != nil
+		}
 		return categories[i].ID < categories[j].ID
 	})
 	for _, cat := range categories {
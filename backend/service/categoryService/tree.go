@@ -0,0 +1,152 @@
+package categoryService
+
+import (
+	"backend/db/repository/categoriesRepository"
+	"backend/middlewares/customError"
+	"context"
+	"sync"
+	"time"
+)
+
+// treeCacheTTL 他インスタンスからの無効化通知が届かなかった場合でも、この時間が経てば作り直す(保険)
+const treeCacheTTL = 5 * time.Minute
+
+// categoryTree 階層化済みのカテゴリと、O(1)で引くための索引をまとめたもの
+// memo:キャッシュはプロセス内で共有されるので、取り出したModelは書き換えないこと
+type categoryTree struct {
+	generation  uint64
+	builtAt     time.Time
+	roots       []*categoriesRepository.Model
+	nodes       map[int]*categoriesRepository.Model
+	trails      map[int][]*categoriesRepository.Model //ルートから自身までのパンくずリスト
+	descendants map[int][]int                         //自身を先頭にした、配下の全カテゴリID
+}
+
+var treeCache struct {
+	mu   sync.RWMutex
+	tree *categoryTree
+}
+
+func (t *categoryTree) isFresh(generation uint64) bool {
+	return t != nil && t.generation == generation && time.Since(t.builtAt) < treeCacheTTL
+}
+
+// loadTree キャッシュ済のツリーを返す。書き込み世代が進んでいる場合はDBから作り直す
+func loadTree(ctx context.Context, r *categoriesRepository.CategoryRepository) (*categoryTree, *customError.Error) {
+	// 取得前の世代を控えておく(取得中に書き込みがあっても、次回アクセス時に作り直される)
+	generation := categoriesRepository.Generation()
+
+	treeCache.mu.RLock()
+	tree := treeCache.tree
+	treeCache.mu.RUnlock()
+	if tree.isFresh(generation) {
+		return tree, nil
+	}
+
+	treeCache.mu.Lock()
+	defer treeCache.mu.Unlock()
+	// ロック待ちの間に他のリクエストが作り直している可能性がある
+	if treeCache.tree.isFresh(generation) {
+		return treeCache.tree, nil
+	}
+
+	//DBからデータを取得
+	categories, err := r.GetCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tree = buildTree(categories)
+	tree.generation = generation
+	tree.builtAt = time.Now()
+	treeCache.tree = tree
+
+	return tree, nil
+}
+
+// buildTree フラットなカテゴリ一覧から親子関係と索引を構築する
+func buildTree(categories []*categoriesRepository.Model) *categoryTree {
+	//並び順にソートする(兄弟間の並びはこの順序で子に追加される)
+	sortCategories(categories)
+
+	tree := &categoryTree{
+		nodes:       make(map[int]*categoriesRepository.Model, len(categories)),
+		trails:      make(map[int][]*categoriesRepository.Model, len(categories)),
+		descendants: make(map[int][]int, len(categories)),
+	}
+
+	// カテゴリをIDをキーとするマップに格納
+	for _, cat := range categories {
+		cat.Children = nil
+		tree.nodes[cat.ID] = cat
+	}
+
+	// 親子関係を構築
+	var detached []*categoriesRepository.Model //親が存在しないカテゴリ(データ不整合)。ツリーには含めないが索引は作る
+	for _, cat := range categories {
+		if cat.Parent == nil {
+			// Parent が存在しない場合、ルートカテゴリとして扱う
+			tree.roots = append(tree.roots, cat)
+			continue
+		}
+		parentCategory, exists := tree.nodes[*cat.Parent]
+		if !exists {
+			detached = append(detached, cat)
+			continue
+		}
+		// Parent が存在する場合、親カテゴリの Children に追加
+		parentCategory.Children = append(parentCategory.Children, cat)
+	}
+
+	// 索引の構築(ルートから深さ優先で辿り、パンくずと子孫IDを記録する)
+	var walk func(category *categoriesRepository.Model, trail []*categoriesRepository.Model) []int
+	walk = func(category *categoriesRepository.Model, trail []*categoriesRepository.Model) []int {
+		if _, visited := tree.descendants[category.ID]; visited {
+			// 循環している場合は打ち切る
+			return nil
+		}
+		tree.descendants[category.ID] = []int{}
+
+		current := make([]*categoriesRepository.Model, len(trail)+1)
+		copy(current, trail)
+		current[len(trail)] = category
+		tree.trails[category.ID] = current
+
+		ids := []int{category.ID}
+		for _, child := range category.Children {
+			ids = append(ids, walk(child, current)...)
+		}
+		tree.descendants[category.ID] = ids
+		return ids
+	}
+	for _, cat := range tree.roots {
+		walk(cat, nil)
+	}
+	for _, cat := range detached {
+		walk(cat, nil)
+	}
+
+	return tree
+}
+
+// node 指定したIDのカテゴリを返す。存在しない場合はnil
+func (t *categoryTree) node(id int) *categoriesRepository.Model {
+	return t.nodes[id]
+}
+
+// trail ルートから指定IDまでのパンくずリストを値のコピーとして返す
+func (t *categoryTree) trail(id int) []categoriesRepository.Model {
+	trail := t.trails[id]
+	result := make([]categoriesRepository.Model, len(trail))
+	for i, category := range trail {
+		result[i] = *category
+	}
+	return result
+}
+
+// descendantIds 指定IDと、その配下の全カテゴリIDを返す
+func (t *categoryTree) descendantIds(id int) []int {
+	ids := t.descendants[id]
+	result := make([]int, len(ids))
+	copy(result, ids)
+	return result
+}
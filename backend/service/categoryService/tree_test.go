@@ -0,0 +1,135 @@
+package categoryService
+
+import (
+	"backend/db/repository/categoriesRepository"
+	"backend/util/pubsub"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCategory(id int, parent *int) *categoriesRepository.Model {
+	return &categoriesRepository.Model{ID: id, Name: "カテゴリ", Parent: parent}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+// testCategories 1 ─┬─ 2 ── 4
+//
+//	└─ 3
+//
+// 5(ルート) / 6(親が存在しない)
+func testCategories() []*categoriesRepository.Model {
+	return []*categoriesRepository.Model{
+		newTestCategory(4, intPtr(2)),
+		newTestCategory(3, intPtr(1)),
+		newTestCategory(1, nil),
+		newTestCategory(6, intPtr(99)),
+		newTestCategory(2, intPtr(1)),
+		newTestCategory(5, nil),
+	}
+}
+
+func TestBuildTree_正常系_親子関係と索引が構築されること(t *testing.T) {
+	tree := buildTree(testCategories())
+
+	// ルートはID順に並ぶこと
+	require.Len(t, tree.roots, 2)
+	assert.Equal(t, 1, tree.roots[0].ID)
+	assert.Equal(t, 5, tree.roots[1].ID)
+
+	// 子カテゴリが紐づいていること
+	require.Len(t, tree.node(1).Children, 2)
+	assert.Equal(t, 2, tree.node(1).Children[0].ID)
+	assert.Equal(t, 3, tree.node(1).Children[1].ID)
+
+	// パンくずリストがルートから順に並ぶこと
+	var trailIds []int
+	for _, c := range tree.trail(4) {
+		trailIds = append(trailIds, c.ID)
+	}
+	assert.Equal(t, []int{1, 2, 4}, trailIds)
+
+	// 子孫IDに自身と配下が含まれること
+	assert.ElementsMatch(t, []int{1, 2, 3, 4}, tree.descendantIds(1))
+	assert.Equal(t, []int{4}, tree.descendantIds(4))
+}
+
+func TestBuildTree_異常系_存在しないIDや親のないカテゴリでもpanicしないこと(t *testing.T) {
+	tree := buildTree(testCategories())
+
+	assert.Nil(t, tree.node(100))
+	assert.Empty(t, tree.trail(100))
+	assert.Empty(t, tree.descendantIds(100))
+
+	// 親が存在しないカテゴリはツリーには含まれないが、索引からは引けること
+	assert.Equal(t, []int{6}, tree.descendantIds(6))
+	require.Len(t, tree.trail(6), 1)
+}
+
+func TestBuildTree_異常系_循環参照があっても停止すること(t *testing.T) {
+	categories := []*categoriesRepository.Model{
+		newTestCategory(1, nil),
+		newTestCategory(2, intPtr(3)),
+		newTestCategory(3, intPtr(2)),
+	}
+	tree := buildTree(categories)
+
+	assert.Equal(t, []int{1}, tree.descendantIds(1))
+	assert.Empty(t, tree.trail(2))
+}
+
+func TestCategoryTree_正常系_取得結果を書き換えてもキャッシュに影響しないこと(t *testing.T) {
+	tree := buildTree(testCategories())
+
+	ids := tree.descendantIds(1)
+	ids[0] = 999
+	trail := tree.trail(4)
+	trail[0].Name = "変更"
+
+	assert.Equal(t, 1, tree.descendantIds(1)[0])
+	assert.Equal(t, "カテゴリ", tree.node(1).Name)
+}
+
+func TestBuildTree_正常系_兄弟カテゴリが並び順に従って並ぶこと(t *testing.T) {
+	first, second := intPtr(1), intPtr(2)
+	categories := []*categoriesRepository.Model{
+		newTestCategory(1, nil),
+		newTestCategory(2, intPtr(1)),
+		newTestCategory(3, intPtr(1)),
+		newTestCategory(4, intPtr(1)),
+	}
+	categories[3].Order = first
+	categories[1].Order = second
+	tree := buildTree(categories)
+
+	// orderが設定されたものが先、未設定のものはID順で後ろに並ぶこと
+	var childIds []int
+	for _, c := range tree.node(1).Children {
+		childIds = append(childIds, c.ID)
+	}
+	assert.Equal(t, []int{4, 2, 3}, childIds)
+}
+
+func TestSyncChanges_正常系_他のインスタンスの通知でだけキャッシュの世代が進むこと(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ps := pubsub.NewMemory()
+	assert.Nil(t, SyncChanges(ctx, ps))
+
+	before := categoriesRepository.Generation()
+	// 自分の書き込みの通知は読み飛ばし、その後に届いた他のインスタンスの通知で1回だけ進む
+	assert.NoError(t, ps.Publish(ctx, changesTopic, []byte(instanceId)))
+	assert.NoError(t, ps.Publish(ctx, changesTopic, []byte("other")))
+	assert.Eventually(t, func() bool {
+		return categoriesRepository.Generation() == before+1
+	}, time.Second, 10*time.Millisecond)
+	assert.Never(t, func() bool {
+		return categoriesRepository.Generation() > before+1
+	}, 100*time.Millisecond, 10*time.Millisecond)
+}
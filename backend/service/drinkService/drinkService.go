@@ -0,0 +1,347 @@
+package drinkService
+
+import (
+	"backend/db/repository/drinkRepository"
+	"backend/db/repository/liquorRepository"
+	"backend/graph/graphModel"
+	"backend/middlewares/customError"
+	"backend/util/helper"
+	"backend/util/loader"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	timelinePageSize = 20
+	placeMaxLength   = 100
+	noteMaxLength    = 1000
+	priceMax         = 10000000
+	drankAtTolerance = time.Hour //端末の時計のずれを許容する
+	countLoaderWait  = 2 * time.Millisecond
+)
+
+type checkInCountLoaderKey struct{}
+
+// WishlistItem 飲みたいリストの1件(お酒の情報つき)
+type WishlistItem struct {
+	Liquor    *liquorRepository.Model
+	CreatedAt time.Time
+}
+
+// CheckIn 飲んだ記録(お酒の情報つき)
+type CheckIn struct {
+	Model   *drinkRepository.CheckInModel
+	Liquor  *liquorRepository.Model
+	IsOwner bool //場所・金額・メモは本人にのみ返す
+}
+
+func AddWish(ctx context.Context, dr *drinkRepository.DrinkRepository, lr *liquorRepository.LiquorsRepository, uId primitive.ObjectID, liquorId string) *customError.Error {
+	lId, err := helper.ObjectIDFromHex(liquorId)
+	if err != nil {
+		return err
+	}
+	//存在しないお酒は追加できない
+	if _, err = lr.GetLiquorById(ctx, lId); err != nil {
+		return err
+	}
+	return dr.AddWish(ctx, uId, lId)
+}
+
+func RemoveWish(ctx context.Context, dr *drinkRepository.DrinkRepository, uId primitive.ObjectID, liquorId string) *customError.Error {
+	lId, err := helper.ObjectIDFromHex(liquorId)
+	if err != nil {
+		return err
+	}
+	return dr.RemoveWish(ctx, uId, lId)
+}
+
+func IsWished(ctx context.Context, dr *drinkRepository.DrinkRepository, uId primitive.ObjectID, liquorId string) (bool, *customError.Error) {
+	lId, err := helper.ObjectIDFromHex(liquorId)
+	if err != nil {
+		return false, err
+	}
+	return dr.IsWished(ctx, uId, lId)
+}
+
+// GetWishlist 飲みたいリストを取得する(削除されたお酒は含めない)
+func GetWishlist(ctx context.Context, dr *drinkRepository.DrinkRepository, lr *liquorRepository.LiquorsRepository, uId primitive.ObjectID) ([]*WishlistItem, *customError.Error) {
+	wishes, err := dr.ListWishes(ctx, uId)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, len(wishes))
+	for i, wish := range wishes {
+		ids[i] = wish.LiquorID
+	}
+	liquors, err := liquorsById(ctx, lr, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*WishlistItem, 0, len(wishes))
+	for _, wish := range wishes {
+		if liquor, ok := liquors[wish.LiquorID]; ok {
+			result = append(result, &WishlistItem{Liquor: liquor, CreatedAt: wish.CreatedAt})
+		}
+	}
+	return result, nil
+}
+
+// PostCheckIn 飲んだ記録を追加する
+func PostCheckIn(ctx context.Context, dr *drinkRepository.DrinkRepository, lr *liquorRepository.LiquorsRepository, uId primitive.ObjectID, input graphModel.CheckInInput) (*CheckIn, *customError.Error) {
+	model, err := buildCheckIn(uId, input, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	liquor, err := lr.GetLiquorById(ctx, model.LiquorID)
+	if err != nil {
+		return nil, err
+	}
+	if err = dr.InsertCheckIn(ctx, model); err != nil {
+		return nil, err
+	}
+	return &CheckIn{Model: model, Liquor: liquor, IsOwner: true}, nil
+}
+
+// UpdateCheckIn 飲んだ記録を更新する(お酒は変更できない)
+func UpdateCheckIn(ctx context.Context, dr *drinkRepository.DrinkRepository, lr *liquorRepository.LiquorsRepository, uId primitive.ObjectID, id string, input graphModel.CheckInInput) (*CheckIn, *customError.Error) {
+	cId, err := helper.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	current, err := dr.GetCheckIn(ctx, cId)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil || current.UserID != uId {
+		return nil, errCheckInNotFound(id)
+	}
+	model, err := buildCheckIn(uId, input, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if model.LiquorID != current.LiquorID {
+		return nil, errCheckInLiquorChanged(id)
+	}
+	model.ID = current.ID
+	model.CreatedAt = current.CreatedAt
+
+	updated, err := dr.UpdateCheckIn(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		//取得後に削除された
+		return nil, errCheckInNotFound(id)
+	}
+	liquor, err := lr.GetLiquorById(ctx, model.LiquorID)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckIn{Model: model, Liquor: liquor, IsOwner: true}, nil
+}
+
+func DeleteCheckIn(ctx context.Context, dr *drinkRepository.DrinkRepository, uId primitive.ObjectID, id string) *customError.Error {
+	cId, err := helper.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	deleted, err := dr.DeleteCheckIn(ctx, uId, cId)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errCheckInNotFound(id)
+	}
+	return nil
+}
+
+// GetCheckInTimeline ユーザーの飲んだ記録を新しい順に取得する。閲覧者が本人でない場合は場所・金額・メモを返さない
+func GetCheckInTimeline(ctx context.Context, dr *drinkRepository.DrinkRepository, lr *liquorRepository.LiquorsRepository, viewer *primitive.ObjectID, userId string, page *int) ([]*CheckIn, *customError.Error) {
+	uId, err := helper.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, err
+	}
+	p := helper.NilToZero(page)
+	if p < 1 {
+		p = 1
+	}
+	models, err := dr.ListCheckIns(ctx, uId, nil, int64((p-1)*timelinePageSize), timelinePageSize)
+	if err != nil {
+		return nil, err
+	}
+	isOwner := viewer != nil && *viewer == uId
+	return withLiquors(ctx, lr, models, isOwner)
+}
+
+// GetMyCheckIns 指定したお酒についての自分の記録を取得する
+func GetMyCheckIns(ctx context.Context, dr *drinkRepository.DrinkRepository, lr *liquorRepository.LiquorsRepository, uId primitive.ObjectID, liquorId string) ([]*CheckIn, *customError.Error) {
+	lId, err := helper.ObjectIDFromHex(liquorId)
+	if err != nil {
+		return nil, err
+	}
+	models, err := dr.ListCheckIns(ctx, uId, &lId, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return withLiquors(ctx, lr, models, true)
+}
+
+// WithCheckInCountLoader リクエスト内のお酒の記録件数をまとめて数えるローダーをcontextに入れる
+func WithCheckInCountLoader(ctx context.Context, dr *drinkRepository.DrinkRepository) context.Context {
+	l := loader.New(countLoaderWait, func(ctx context.Context, lIds []primitive.ObjectID) (map[primitive.ObjectID]int, error) {
+		counts, err := dr.CountCheckIns(ctx, lIds)
+		if err != nil {
+			return nil, err
+		}
+		return counts, nil
+	})
+	return context.WithValue(ctx, checkInCountLoaderKey{}, l)
+}
+
+// CountCheckIns お酒の記録件数。contextにローダーがあれば、同じリクエスト内の他のお酒とまとめて数える
+func CountCheckIns(ctx context.Context, dr *drinkRepository.DrinkRepository, liquorId string) (int, *customError.Error) {
+	lId, err := helper.ObjectIDFromHex(liquorId)
+	if err != nil {
+		return 0, err
+	}
+	l, ok := ctx.Value(checkInCountLoaderKey{}).(*loader.Loader[primitive.ObjectID, int])
+	if !ok {
+		counts, err := dr.CountCheckIns(ctx, []primitive.ObjectID{lId})
+		if err != nil {
+			return 0, err
+		}
+		return counts[lId], nil
+	}
+
+	count, e := l.Load(ctx, lId)
+	if e != nil {
+		var cErr *customError.Error
+		if errors.As(e, &cErr) {
+			return 0, cErr
+		}
+		return 0, errCountCheckIns(e, liquorId)
+	}
+	return count, nil
+}
+
+// buildCheckIn 入力値を検証して保存用のモデルを作る
+func buildCheckIn(uId primitive.ObjectID, input graphModel.CheckInInput, now time.Time) (*drinkRepository.CheckInModel, *customError.Error) {
+	lId, err := helper.ObjectIDFromHex(input.LiquorID)
+	if err != nil {
+		return nil, err
+	}
+	model := &drinkRepository.CheckInModel{
+		UserID:    uId,
+		LiquorID:  lId,
+		DrankAt:   now,
+		CreatedAt: now,
+	}
+	if input.DrankAt != nil {
+		if input.DrankAt.After(now.Add(drankAtTolerance)) {
+			return nil, errCheckInInvalid("飲んだ日時に未来の日時は指定できません", input)
+		}
+		model.DrankAt = *input.DrankAt
+	}
+	if input.ServingStyle != nil {
+		if !input.ServingStyle.IsValid() {
+			return nil, errCheckInInvalid("飲み方が不正です", input)
+		}
+		style := string(*input.ServingStyle)
+		model.ServingStyle = &style
+	}
+	if input.Price != nil {
+		if *input.Price < 0 || *input.Price > priceMax {
+			return nil, errCheckInInvalid("金額が不正です", input)
+		}
+		model.Price = input.Price
+	}
+	var ok bool
+	if model.Place, ok = optionalText(input.Place, placeMaxLength); !ok {
+		return nil, errCheckInInvalid("場所が長すぎます", input)
+	}
+	if model.Note, ok = optionalText(input.Note, noteMaxLength); !ok {
+		return nil, errCheckInInvalid("メモが長すぎます", input)
+	}
+	return model, nil
+}
+
+// optionalText 前後の空白を除き、空文字はnilにする。長さが上限を超える場合はfalse
+func optionalText(text *string, maxLength int) (*string, bool) {
+	if text == nil {
+		return nil, true
+	}
+	trimmed := strings.TrimSpace(*text)
+	if trimmed == "" {
+		return nil, true
+	}
+	if utf8.RuneCountInString(trimmed) > maxLength {
+		return nil, false
+	}
+	return &trimmed, true
+}
+
+func liquorsById(ctx context.Context, lr *liquorRepository.LiquorsRepository, ids []primitive.ObjectID) (map[primitive.ObjectID]*liquorRepository.Model, *customError.Error) {
+	liquors, err := lr.GetLiquorsByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[primitive.ObjectID]*liquorRepository.Model, len(liquors))
+	for i := range liquors {
+		result[liquors[i].ID] = &liquors[i]
+	}
+	return result, nil
+}
+
+// withLiquors 記録にお酒の情報をつける(削除されたお酒の記録は含めない)
+func withLiquors(ctx context.Context, lr *liquorRepository.LiquorsRepository, models []*drinkRepository.CheckInModel, isOwner bool) ([]*CheckIn, *customError.Error) {
+	ids := make([]primitive.ObjectID, len(models))
+	for i, model := range models {
+		ids[i] = model.LiquorID
+	}
+	liquors, err := liquorsById(ctx, lr, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*CheckIn, 0, len(models))
+	for _, model := range models {
+		if liquor, ok := liquors[model.LiquorID]; ok {
+			result = append(result, &CheckIn{Model: model, Liquor: liquor, IsOwner: isOwner})
+		}
+	}
+	return result, nil
+}
+
+func (w *WishlistItem) ToGraphQL() *graphModel.WishlistItem {
+	return &graphModel.WishlistItem{
+		Liquor:    w.Liquor.ToGraphQL(),
+		CreatedAt: w.CreatedAt,
+	}
+}
+
+func (c *CheckIn) ToGraphQL() *graphModel.CheckIn {
+	var style *graphModel.ServingStyle
+	if c.Model.ServingStyle != nil {
+		s := graphModel.ServingStyle(*c.Model.ServingStyle)
+		style = &s
+	}
+	result := &graphModel.CheckIn{
+		ID:           c.Model.ID.Hex(),
+		UserID:       c.Model.UserID.Hex(),
+		Liquor:       c.Liquor.ToGraphQL(),
+		DrankAt:      c.Model.DrankAt,
+		ServingStyle: style,
+		CreatedAt:    c.Model.CreatedAt,
+	}
+	if c.IsOwner {
+		result.Place = c.Model.Place
+		result.Price = c.Model.Price
+		result.Note = c.Model.Note
+	}
+	return result
+}
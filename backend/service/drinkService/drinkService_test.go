@@ -0,0 +1,102 @@
+package drinkService
+
+import (
+	"backend/db"
+	"backend/db/repository/drinkRepository"
+	"backend/db/repository/liquorRepository"
+	"backend/graph/graphModel"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestBuildCheckIn_正常系_未指定の項目は既定値になること(t *testing.T) {
+	now := time.Date(2024, 4, 1, 20, 0, 0, 0, time.UTC)
+	lId := primitive.NewObjectID()
+	model, err := buildCheckIn(primitive.NewObjectID(), graphModel.CheckInInput{
+		LiquorID: lId.Hex(),
+		Place:    strPtr("  "),
+		Note:     strPtr(" 美味しい "),
+	}, now)
+	require.Nil(t, err)
+
+	assert.Equal(t, lId, model.LiquorID)
+	assert.Equal(t, now, model.DrankAt)
+	assert.Nil(t, model.Place)
+	assert.Equal(t, "美味しい", *model.Note)
+}
+
+func TestBuildCheckIn_異常系_不正な入力を弾くこと(t *testing.T) {
+	now := time.Date(2024, 4, 1, 20, 0, 0, 0, time.UTC)
+	future := now.Add(2 * time.Hour)
+	price := -1
+	style := graphModel.ServingStyle("ON_THE_ROCKS")
+	lId := primitive.NewObjectID().Hex()
+
+	inputs := map[string]graphModel.CheckInInput{
+		"未来の日時":  {LiquorID: lId, DrankAt: &future},
+		"負の金額":   {LiquorID: lId, Price: &price},
+		"長すぎる場所": {LiquorID: lId, Place: strPtr(strings.Repeat("あ", placeMaxLength+1))},
+		"不正な飲み方": {LiquorID: lId, ServingStyle: &style},
+	}
+	for name, input := range inputs {
+		_, err := buildCheckIn(primitive.NewObjectID(), input, now)
+		require.NotNil(t, err, name)
+		assert.Equal(t, CheckInInvalid, err.ErrorCode, name)
+	}
+}
+
+func TestCheckInToGraphQL_正常系_場所と金額とメモは本人にのみ返すこと(t *testing.T) {
+	price := 800
+	model := &drinkRepository.CheckInModel{Place: strPtr("自宅"), Price: &price, Note: strPtr("美味しい")}
+	versionNo := 1
+	liquor := &liquorRepository.Model{VersionNo: &versionNo}
+
+	owner := (&CheckIn{Model: model, Liquor: liquor, IsOwner: true}).ToGraphQL()
+	assert.Equal(t, "自宅", *owner.Place)
+	assert.Equal(t, 800, *owner.Price)
+	assert.Equal(t, "美味しい", *owner.Note)
+
+	other := (&CheckIn{Model: model, Liquor: liquor}).ToGraphQL()
+	assert.Nil(t, other.Place)
+	assert.Nil(t, other.Price)
+	assert.Nil(t, other.Note)
+}
+
+func TestCountCheckIns_正常系_同じリクエスト内のお酒は1回の集計でまとめて数えること(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("batch", func(mt *mtest.T) {
+		dr := drinkRepository.NewDrinkRepository(&db.DB{Client: mt.Client, DBName: "test"})
+		a, b := primitive.NewObjectID(), primitive.NewObjectID()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "test."+drinkRepository.CheckInCollectionName, mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: a}, {Key: "count", Value: 3}}))
+		ctx := WithCheckInCountLoader(context.Background(), &dr)
+
+		counts := make([]int, 2)
+		var wg sync.WaitGroup
+		for i, id := range []primitive.ObjectID{a, b} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				counts[i], _ = CountCheckIns(ctx, &dr, id.Hex())
+			}()
+		}
+		wg.Wait()
+
+		// 記録がないお酒は0件
+		assert.Equal(mt, []int{3, 0}, counts)
+		assert.Len(mt, mt.GetAllStartedEvents(), 1)
+	})
+}
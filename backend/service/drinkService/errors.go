@@ -0,0 +1,57 @@
+package drinkService
+
+import (
+	"backend/graph/graphModel"
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/errorMsg"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+const (
+	CheckInInvalid       = "DRINK-SERVICE-001-CheckInInvalid"
+	CheckInNotFound      = "DRINK-SERVICE-002-CheckInNotFound"
+	CheckInLiquorChanged = "DRINK-SERVICE-003-CheckInLiquorChanged"
+	CountCheckInsLoader  = "DRINK-SERVICE-004-CountCheckInsLoader"
+)
+
+func errCheckInInvalid(msg string, input graphModel.CheckInInput) *customError.Error {
+	return customError.NewError(errors.New(msg), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    CheckInInvalid,
+		UserMsg:    msg,
+		Level:      logrus.InfoLevel,
+		Input:      input,
+	})
+}
+
+func errCheckInNotFound(id string) *customError.Error {
+	return customError.NewError(errors.New("記録が見つかりません"), customError.Params{
+		StatusCode: http.StatusNotFound,
+		ErrCode:    CheckInNotFound,
+		UserMsg:    "記録が見つかりません",
+		Level:      logrus.InfoLevel,
+		Input:      id,
+	})
+}
+
+func errCheckInLiquorChanged(id string) *customError.Error {
+	return customError.NewError(errors.New("記録のお酒は変更できません"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    CheckInLiquorChanged,
+		UserMsg:    "記録のお酒は変更できません",
+		Level:      logrus.InfoLevel,
+		Input:      id,
+	})
+}
+
+func errCountCheckIns(err error, liquorId string) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    CountCheckInsLoader,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.WarnLevel,
+		Input:      liquorId,
+	})
+}
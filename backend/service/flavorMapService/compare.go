@@ -0,0 +1,154 @@
+package flavorMapService
+
+import (
+	"backend/db/repository/categoriesRepository"
+	"backend/db/repository/flavorMapRepository"
+	"backend/db/repository/liquorRepository"
+	"backend/graph/graphModel"
+	"backend/middlewares/customError"
+	"backend/util/helper"
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math"
+)
+
+const compareMaxLiquors = 5 //一度に比較できるお酒の数
+
+// Comparison 同じフレーバーマップ上で比較したお酒の一覧
+type Comparison struct {
+	Master flavorMapRepository.MasterModel
+	Items  []*ComparedMap
+}
+
+// ComparedMap 比較対象のお酒1件分のフレーバーマップと統計値
+type ComparedMap struct {
+	Liquor    *liquorRepository.Model
+	Tying     flavorMapRepository.TyingModel
+	CentroidX *float64 //票がない場合はnil
+	CentroidY *float64
+	Spread    *float64
+	Overlaps  []Overlap //他のお酒との重なり(リクエストの順)
+}
+
+type Overlap struct {
+	LiquorID    primitive.ObjectID
+	Coefficient float64 //0～1(1が同一の分布)
+}
+
+// CompareFlavorMaps 同じフレーバーマップのカテゴリに属するお酒(最大5件)の分布を並べて比較する
+func CompareFlavorMaps(ctx context.Context, mstR *flavorMapRepository.FlavorMapMasterRepository, flR *flavorMapRepository.FlavorToLiquorRepository, lr *liquorRepository.LiquorsRepository, cr *categoriesRepository.CategoryRepository, liquorIds []string) (*Comparison, *customError.Error) {
+	ids, err := compareTargetIds(liquorIds)
+	if err != nil {
+		return nil, err
+	}
+
+	var mst *flavorMapRepository.MasterModel
+	items := make([]*ComparedMap, len(ids))
+	for i, id := range ids {
+		liquor, err := lr.GetLiquorById(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		found, err := findMaster(ctx, mstR, cr, liquor.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		if found == nil {
+			return nil, errNotFoundMstData(id)
+		}
+		//軸が違うマップ同士は比較できない
+		if mst != nil && mst.CategoryID != found.CategoryID {
+			return nil, errCompareCategoryMismatch(liquorIds)
+		}
+		mst = found
+
+		tying, err := flR.GetData(ctx, id, mst.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		if tying == nil {
+			empty := flavorMapRepository.NewEmptyTyingModel(id, mst.CategoryID)
+			tying = &empty
+		}
+		tying.CalcRates(mst.GetWeighting())
+		items[i] = &ComparedMap{Liquor: liquor, Tying: *tying}
+		if x, y, spread, ok := tying.Centroid(); ok {
+			items[i].CentroidX, items[i].CentroidY, items[i].Spread = &x, &y, &spread
+		}
+	}
+
+	for i, item := range items {
+		for j, other := range items {
+			if i == j {
+				continue
+			}
+			item.Overlaps = append(item.Overlaps, Overlap{
+				LiquorID:    other.Liquor.ID,
+				Coefficient: overlapCoefficient(&item.Tying, &other.Tying),
+			})
+		}
+	}
+	return &Comparison{Master: *mst, Items: items}, nil
+}
+
+// compareTargetIds 比較対象のIDを変換する(重複は除き、リクエストの順を保つ)
+func compareTargetIds(liquorIds []string) ([]primitive.ObjectID, *customError.Error) {
+	seen := make(map[primitive.ObjectID]bool, len(liquorIds))
+	ids := make([]primitive.ObjectID, 0, len(liquorIds))
+	for _, hex := range liquorIds {
+		id, err := helper.ObjectIDFromHex(hex)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 || len(ids) > compareMaxLiquors {
+		return nil, errCompareInvalidIds(liquorIds)
+	}
+	return ids, nil
+}
+
+// overlapCoefficient 2つの分布の重なり(セルごとの得票率の小さい方の合計)。どちらかに票がない場合は0
+func overlapCoefficient(a *flavorMapRepository.TyingModel, b *flavorMapRepository.TyingModel) float64 {
+	var overlap float64
+	for i := range a.FlavorCellData {
+		overlap += math.Min(a.FlavorCellData[i].Rate, b.FlavorCellData[i].Rate)
+	}
+	return math.Min(overlap/100.0, 1)
+}
+
+func (c *Comparison) ToGraphQL() *graphModel.FlavorMapComparison {
+	items := make([]*graphModel.FlavorMapComparisonItem, len(c.Items))
+	for i, item := range c.Items {
+		mapData := make([]*graphModel.FlavorCellData, len(item.Tying.FlavorCellData))
+		for j := range item.Tying.FlavorCellData {
+			mapData[j] = item.Tying.FlavorCellData[j].ToGraphQL()
+		}
+		overlaps := make([]*graphModel.FlavorMapOverlap, len(item.Overlaps))
+		for j, o := range item.Overlaps {
+			overlaps[j] = &graphModel.FlavorMapOverlap{
+				LiquorID:    o.LiquorID.Hex(),
+				Coefficient: o.Coefficient,
+			}
+		}
+		items[i] = &graphModel.FlavorMapComparisonItem{
+			Liquor:          item.Liquor.ToGraphQL(),
+			UserFullAmount:  item.Tying.UserFullAmount,
+			GuestFullAmount: item.Tying.GuestFullAmount,
+			MapData:         mapData,
+			CentroidX:       item.CentroidX,
+			CentroidY:       item.CentroidY,
+			Spread:          item.Spread,
+			Overlaps:        overlaps,
+		}
+	}
+	return &graphModel.FlavorMapComparison{
+		CategoryID: c.Master.CategoryID,
+		XNames:     c.Master.XName[:],
+		YNames:     c.Master.YName[:],
+		Items:      items,
+	}
+}
@@ -1,6 +1,8 @@
 package flavorMapService
 
 import (
+	"backend/db/repository/flavorMapRepository"
+	"backend/graph/graphModel"
 	"backend/middlewares/customError"
 	"backend/middlewares/customError/errorMsg"
 	"errors"
@@ -11,11 +13,22 @@ import (
 )
 
 const (
-	NotFoundMstData        = "FLAVOR-SERVICE-001-GetFlavorMasterData"
-	NotFound               = "FLAVOR-SERVICE-002-NotFound"
-	Cursor                 = "FLAVOR-SERVICE-003-Cursor"
-	InsertOne              = "FLAVOR-SERVICE-004-InsertOne"
-	PostFlavorMapIdFromHex = "FLAVOR-SERVICE-005-PostFlavorMapIdFromHex"
+	NotFoundMstData         = "FLAVOR-SERVICE-001-GetFlavorMasterData"
+	PostFlavorMapIdFromHex  = "FLAVOR-SERVICE-005-PostFlavorMapIdFromHex"
+	PostFlavorMapErr        = "FLAVOR-SERVICE-006-PostFlavorMap"
+	RecalcConflict          = "FLAVOR-SERVICE-007-RecalcConflict"
+	InvalidAxisNames        = "FLAVOR-SERVICE-008-InvalidAxisNames"
+	InvalidMigration        = "FLAVOR-SERVICE-009-InvalidMigration"
+	MasterCategoryNotFound  = "FLAVOR-SERVICE-010-MasterCategoryNotFound"
+	MasterExists            = "FLAVOR-SERVICE-011-MasterExists"
+	MasterNotFound          = "FLAVOR-SERVICE-012-MasterNotFound"
+	MasterVersion           = "FLAVOR-SERVICE-013-MasterVersion"
+	SaveMaster              = "FLAVOR-SERVICE-014-SaveMaster"
+	CompareInvalidIds       = "FLAVOR-SERVICE-015-CompareInvalidIds"
+	CompareCategoryMismatch = "FLAVOR-SERVICE-016-CompareCategoryMismatch"
+	InvalidWeighting        = "FLAVOR-SERVICE-017-InvalidWeighting"
+	VoteConflict            = "FLAVOR-SERVICE-018-VoteConflict"
+	ReplaceFlavorMap        = "FLAVOR-SERVICE-019-ReplaceFlavorMap"
 )
 
 func errNotFoundMstData(id primitive.ObjectID) *customError.Error {
@@ -28,39 +41,152 @@ func errNotFoundMstData(id primitive.ObjectID) *customError.Error {
 	})
 }
 
-func errNotFound(err error, lId primitive.ObjectID, cId int) *customError.Error {
+func errPostFlavorMapIdFromHex(err error, id string) *customError.Error {
 	return customError.NewError(err, customError.Params{
-		StatusCode: http.StatusBadRequest,
-		ErrCode:    NotFound,
-		UserMsg:    errorMsg.DATA,
-		Level:      logrus.InfoLevel,
-		Input:      fmt.Sprintf("lId: %s, cId: %d", lId.Hex(), cId),
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    PostFlavorMapIdFromHex,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      id,
 	})
 }
-func errCursor(err error) *customError.Error {
+
+func errPostFlavorMap(err error, d flavorMapRepository.FlavorMapModel) *customError.Error {
 	return customError.NewError(err, customError.Params{
 		StatusCode: http.StatusInternalServerError,
-		ErrCode:    Cursor,
+		ErrCode:    PostFlavorMapErr,
 		UserMsg:    errorMsg.SERVER,
 		Level:      logrus.ErrorLevel,
+		Input:      d,
 	})
 }
 
-func errInsertOne(err error) *customError.Error {
+func errRecalcConflict(lId primitive.ObjectID, cId int) *customError.Error {
+	return customError.NewError(errors.New("再集計中に投票が続いたため、集計結果を反映できませんでした"), customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    RecalcConflict,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.WarnLevel,
+		Input:      fmt.Sprintf("lId: %s, cId: %d", lId.Hex(), cId),
+	})
+}
+
+func errVoteConflict(d flavorMapRepository.FlavorMapModel) *customError.Error {
+	return customError.NewError(errors.New("投票中に全件再集計で減衰の基準時刻が置き直されたため、投票を反映できませんでした"), customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    VoteConflict,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.WarnLevel,
+		Input:      d,
+	})
+}
+
+func errReplaceFlavorMap(err error, lId primitive.ObjectID, cId int) *customError.Error {
 	return customError.NewError(err, customError.Params{
 		StatusCode: http.StatusInternalServerError,
-		ErrCode:    InsertOne,
+		ErrCode:    ReplaceFlavorMap,
 		UserMsg:    errorMsg.SERVER,
 		Level:      logrus.ErrorLevel,
+		Input:      fmt.Sprintf("lId: %s, cId: %d", lId.Hex(), cId),
 	})
 }
 
-func errPostFlavorMapIdFromHex(err error, id string) *customError.Error {
+func errInvalidAxisNames(input graphModel.FlavorMapMasterInput) *customError.Error {
+	return customError.NewError(errors.New("軸名が不正です"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    InvalidAxisNames,
+		UserMsg:    fmt.Sprintf("軸名はそれぞれ両端を1～%d文字で指定してください", axisNameMaxLength),
+		Level:      logrus.InfoLevel,
+		Input:      input,
+	})
+}
+
+func errInvalidWeighting(input graphModel.FlavorMapMasterInput) *customError.Error {
+	return customError.NewError(errors.New("重み付けが不正です"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    InvalidWeighting,
+		UserMsg:    fmt.Sprintf("重み付けは0以上で、ユーザーとゲストの票のどちらかに重みを付けてください(半減期は0か%.0f日以上)", flavorMapRepository.MinHalfLifeDays),
+		Level:      logrus.InfoLevel,
+		Input:      input,
+	})
+}
+
+func errInvalidMigration(migration graphModel.FlavorMapMigration) *customError.Error {
+	return customError.NewError(errors.New("投票の移行方法が不正です"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    InvalidMigration,
+		UserMsg:    "投票の移行方法が不正です",
+		Level:      logrus.InfoLevel,
+		Input:      migration,
+	})
+}
+
+func errMasterCategoryNotFound(cId int) *customError.Error {
+	return customError.NewError(errors.New("フレーバーマップを設定するカテゴリが存在しません"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    MasterCategoryNotFound,
+		UserMsg:    "カテゴリが存在しません",
+		Level:      logrus.InfoLevel,
+		Input:      cId,
+	})
+}
+
+func errMasterExists(cId int) *customError.Error {
+	return customError.NewError(errors.New("フレーバーマップが既に存在します"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    MasterExists,
+		UserMsg:    "このカテゴリには既にフレーバーマップが設定されています",
+		Level:      logrus.InfoLevel,
+		Input:      cId,
+	})
+}
+
+func errMasterNotFound(cId int) *customError.Error {
+	return customError.NewError(errors.New("フレーバーマップが存在しません"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    MasterNotFound,
+		UserMsg:    errorMsg.DATA,
+		Level:      logrus.InfoLevel,
+		Input:      cId,
+	})
+}
+
+func errMasterVersion(cId int, expectedVersionNo int) *customError.Error {
+	return customError.NewError(errors.New("フレーバーマップのバージョンが一致しません"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    MasterVersion,
+		UserMsg:    errorMsg.VERSION,
+		Level:      logrus.InfoLevel,
+		Input:      fmt.Sprintf("cId: %d, expectedVersionNo: %d", cId, expectedVersionNo),
+	})
+}
+
+func errSaveMaster(err error, mst *flavorMapRepository.MasterModel) *customError.Error {
 	return customError.NewError(err, customError.Params{
 		StatusCode: http.StatusInternalServerError,
-		ErrCode:    PostFlavorMapIdFromHex,
+		ErrCode:    SaveMaster,
 		UserMsg:    errorMsg.SERVER,
 		Level:      logrus.ErrorLevel,
-		Input:      id,
+		Input:      mst,
+	})
+}
+
+func errCompareInvalidIds(liquorIds []string) *customError.Error {
+	return customError.NewError(errors.New("比較対象のお酒の数が不正です"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    CompareInvalidIds,
+		UserMsg:    fmt.Sprintf("比較できるお酒は1～%d件です", compareMaxLiquors),
+		Level:      logrus.InfoLevel,
+		Input:      liquorIds,
+	})
+}
+
+func errCompareCategoryMismatch(liquorIds []string) *customError.Error {
+	return customError.NewError(errors.New("比較対象のフレーバーマップが異なります"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    CompareCategoryMismatch,
+		UserMsg:    "同じフレーバーマップのお酒同士でのみ比較できます",
+		Level:      logrus.InfoLevel,
+		Input:      liquorIds,
 	})
 }
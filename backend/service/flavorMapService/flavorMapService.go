@@ -1,24 +1,32 @@
 package flavorMapService
 
 import (
+	"backend/db"
+	"backend/db/repository/activityRepository"
 	"backend/db/repository/categoriesRepository"
 	"backend/db/repository/flavorMapRepository"
 	"backend/db/repository/liquorRepository"
 	"backend/graph/graphModel"
-	"backend/graph/schema/customModel"
 	"backend/middlewares/auth"
 	"backend/middlewares/customError"
+	"backend/middlewares/guest"
+	"backend/service/activityService"
 	"backend/service/categoryService"
+	"backend/service/subscriptionService"
+	"backend/util/pubsub"
 	"backend/util/utilType"
 	"context"
 	"errors"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"time"
 )
 
+// recalcMaxAttempts 全件再集計中に投票があった場合のリトライ回数
+const recalcMaxAttempts = 3
+
 // PostFlavorMap 実際にポストする関数
-func PostFlavorMap(ctx context.Context, mstR *flavorMapRepository.FlavorMapMasterRepository, flR *flavorMapRepository.FlavorToLiquorRepository, fmR *flavorMapRepository.FlavorMapRepository, cr *categoriesRepository.CategoryRepository, lr *liquorRepository.LiquorsRepository, input graphModel.PostFlavorMap, coordinates utilType.Coordinates) *customError.Error {
+func PostFlavorMap(ctx context.Context, mstR *flavorMapRepository.FlavorMapMasterRepository, flR *flavorMapRepository.FlavorToLiquorRepository, fmR *flavorMapRepository.FlavorMapRepository, cr *categoriesRepository.CategoryRepository, lr *liquorRepository.LiquorsRepository, ar *activityRepository.ActivityRepository, ps pubsub.PubSub, input graphModel.PostFlavorMap, coordinates utilType.Coordinates) *customError.Error {
 	lId, rawErr := primitive.ObjectIDFromHex(input.LiquorID)
 	if rawErr != nil {
 		return errPostFlavorMapIdFromHex(rawErr, input.LiquorID)
@@ -27,6 +35,14 @@ func PostFlavorMap(ctx context.Context, mstR *flavorMapRepository.FlavorMapMaste
 	if err != nil {
 		return err
 	}
+	var visitor *guest.Identity
+	if uId == nil {
+		//ゲストは訪問者ごとに投票回数を制限する
+		if err = guest.CheckRate(ctx, "postFlavor"); err != nil {
+			return err
+		}
+		visitor = guest.GetIdentity(ctx)
+	}
 
 	//マスタデータを取得する
 	mst, err := GetFlavorMasterData(ctx, mstR, lr, cr, lId)
@@ -37,85 +53,226 @@ func PostFlavorMap(ctx context.Context, mstR *flavorMapRepository.FlavorMapMaste
 		return errNotFoundMstData(lId)
 	}
 
-	//フレーバーマップデータを投入する
-	err = fmR.PostFlavorMap(ctx, uId, lId, mst.CategoryID, coordinates)
+	//集計用ドキュメントがなければ作成しておく($incの対象が必要なため)
+	err = flR.EnsureData(ctx, lId, mst.CategoryID)
 	if err != nil {
 		return err
 	}
 
-	//統計データをリフレッシュする
-	err = CalcFlavorMap(ctx, mst, flR, fmR, lId)
-
-	return err
+	//票の重みを計算する(レビュー投稿数による加重は、重みを使う設定の場合のみ取得する)
+	weighting := mst.GetWeighting()
+	reviewCount := 0
+	if uId != nil && weighting.ReputationWeight > 0 {
+		counts, err := lr.CountBoardsByUsers(ctx, []primitive.ObjectID{*uId})
+		if err != nil {
+			return err
+		}
+		reviewCount = counts[*uId]
+	}
+	now := time.Now()
+	current := flavorMapRepository.FlavorMapModel{
+		LiquorId:   lId,
+		CategoryId: mst.CategoryID,
+		UserId:     uId,
+		X:          coordinates.X,
+		Y:          coordinates.Y,
+		UpdatedAt:  now,
+	}
+	if visitor != nil {
+		current.GuestId = &visitor.ID
+	}
+	//重みは集計データの減衰の基準時刻で計算する。その間に全件再集計で置き直された場合は、計算し直して投票する
+	applied := false
+	for attempt := 0; attempt < recalcMaxAttempts && !applied; attempt++ {
+		if applied, err = postVote(ctx, flR, fmR, weighting, reviewCount, &current); err != nil {
+			return err
+		}
+	}
+	if !applied {
+		return errVoteConflict(current)
+	}
+	if uId != nil {
+		activityService.Record(ctx, ar, *uId, activityRepository.TypeFlavorVote, lId, nil, nil)
+	}
+	subscriptionService.Publish(ctx, ps, subscriptionService.FlavorMapTopic(lId), lId)
+	return nil
 }
 
-// CalcFlavorMap 統計データを更新する(マスタデータは取ってきてる前提にする)
-func CalcFlavorMap(ctx context.Context, mst *flavorMapRepository.MasterModel, flR *flavorMapRepository.FlavorToLiquorRepository, fmR *flavorMapRepository.FlavorMapRepository, lId primitive.ObjectID) *customError.Error {
-	cursor, err := fmR.Collection.Find(ctx, bson.M{
-		flavorMapRepository.LiquorID:   lId,
-		flavorMapRepository.CategoryID: mst.CategoryID,
-	})
+// postVote 投票データの投入と統計データへの差分反映を1トランザクションで行う(全件再集計と整合させるため)
+// 読み取った減衰の基準時刻が反映時に置き直されていた場合は、何も反映せずにfalseを返す
+func postVote(ctx context.Context, flR *flavorMapRepository.FlavorToLiquorRepository, fmR *flavorMapRepository.FlavorMapRepository, weighting flavorMapRepository.WeightingModel, reviewCount int, current *flavorMapRepository.FlavorMapModel) (bool, *customError.Error) {
+	tying, err := flR.GetData(ctx, current.LiquorId, current.CategoryId)
 	if err != nil {
-		return errNotFound(err, lId, mst.CategoryID)
+		return false, err
 	}
-	defer cursor.Close(ctx)
-	var models []*flavorMapRepository.FlavorMapModel
-	if err = cursor.All(ctx, &models); err != nil {
-		return errCursor(err)
+	if tying == nil {
+		return false, errVoteConflict(*current)
 	}
+	current.Weight = weighting.VoteWeight(current.UserId != nil, reviewCount, current.UpdatedAt, tying.Landmark())
 
-	//ここから計算開始
-	//有効投票数を走査
-	var guestFullAmount int
-	for _, model := range models {
-		if model.UserId == nil {
-			guestFullAmount++
+	applied, e := db.WithTransaction(ctx, fmR.Db.Client, func(sc mongo.SessionContext) (bool, error) {
+		prev, err := fmR.PostFlavorMap(sc, *current)
+		if err != nil {
+			return false, err
+		}
+		applied, err := flR.ApplyVote(sc, prev, *current, tying.DecayLandmark)
+		if err != nil {
+			return false, err
+		}
+		if !applied {
+			//投票データの投入も取り消す
+			return false, errVoteConflict(*current)
+		}
+		return true, nil
+	})
+	if e != nil {
+		var cErr *customError.Error
+		if errors.As(e, &cErr) {
+			if cErr.ErrorCode == VoteConflict {
+				return false, nil
+			}
+			return false, cErr
 		}
+		return false, errPostFlavorMap(e, *current)
 	}
-	userFullAmount := len(models) - guestFullAmount
+	return applied, nil
+}
 
-	//21*21の長さの配列を準備(21で割れば済むので2次元である必要がない)
-	userVotes := make(map[int]int)
-	guestVotes := make(map[int]int)
+// CalcFlavorMap 統計データを全件集計し直し、類似リストも更新する(マスタデータは取ってきてる前提にする)
+func CalcFlavorMap(ctx context.Context, mst *flavorMapRepository.MasterModel, flR *flavorMapRepository.FlavorToLiquorRepository, fmR *flavorMapRepository.FlavorMapRepository, nR *flavorMapRepository.FlavorNeighbourRepository, lr *liquorRepository.LiquorsRepository, lId primitive.ObjectID) *customError.Error {
+	if err := calcFlavorMap(ctx, mst, flR, fmR, lr, lId); err != nil {
+		return err
+	}
+	return RefreshNeighbours(ctx, mst, flR, nR, lId)
+}
 
-	// 得票数の集計
-	for _, model := range models {
-		xIndex := utilType.GetCoordinateIndex(model.X)
-		yIndex := utilType.GetCoordinateIndex(model.Y)
-		index := xIndex*21 + yIndex // 21x21のグリッドのインデックス計算
+// calcFlavorMap 投票データを全件読み直して統計データを作り直す。差分集計で生じたズレの修復に使う
+// 票の重みもマスタの現在の設定で計算し直す。集計中に投票があった場合は、その投票を取りこぼさないよう集計からやり直す
+// 前方減衰の指数が上限(maxDecayExponent)に届かないよう、減衰の基準時刻は集計時刻に置き直す
+func calcFlavorMap(ctx context.Context, mst *flavorMapRepository.MasterModel, flR *flavorMapRepository.FlavorToLiquorRepository, fmR *flavorMapRepository.FlavorMapRepository, lr *liquorRepository.LiquorsRepository, lId primitive.ObjectID) *customError.Error {
+	weighting := mst.GetWeighting()
+	for attempt := 0; attempt < recalcMaxAttempts; attempt++ {
+		if err := flR.EnsureData(ctx, lId, mst.CategoryID); err != nil {
+			return err
+		}
+		//集計開始時点のseqを控えておく
+		current, err := flR.GetData(ctx, lId, mst.CategoryID)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return errRecalcConflict(lId, mst.CategoryID)
+		}
 
-		// ユーザーごとの集計
-		if model.UserId != nil {
-			userVotes[index]++
-		} else {
-			guestVotes[index]++
+		votes, err := fmR.GetVotes(ctx, lId, mst.CategoryID)
+		if err != nil {
+			return err
+		}
+		landmark := time.Now().Truncate(time.Millisecond)
+		changed, err := reweightVotes(ctx, lr, weighting, votes, landmark)
+		if err != nil {
+			return err
+		}
+		tying := aggregateVotes(lId, mst.CategoryID, votes, weighting)
+		tying.DecayLandmark = &landmark
+
+		//差分集計で差し引く値と揃えるため、計算し直した重みを投票データにも同じトランザクションで反映する
+		replaced, e := db.WithTransaction(ctx, fmR.Db.Client, func(sc mongo.SessionContext) (bool, error) {
+			replaced, err := flR.ReplaceData(sc, tying, current.Seq)
+			if err != nil || !replaced {
+				return false, err
+			}
+			if err = fmR.UpdateWeights(sc, changed); err != nil {
+				return false, err
+			}
+			return true, nil
+		})
+		if e != nil {
+			var cErr *customError.Error
+			if errors.As(e, &cErr) {
+				return cErr
+			}
+			return errReplaceFlavorMap(e, lId, mst.CategoryID)
+		}
+		if replaced {
+			return nil
 		}
 	}
+	return errRecalcConflict(lId, mst.CategoryID)
+}
 
-	var cellData [21 * 21]flavorMapRepository.FlavorCellData
-	var userRate = 1 //ここの倍数を弄ると登録済ユーザーの比重を変えることができる
-	fullAmountForCalc := userFullAmount*userRate + guestFullAmount
-	// 得票率を FlavorCellData に反映
-	for i := 0; i < 21*21; i++ {
-		x, y := utilType.UndoCoordinateIndex(i)
-		cellData[i] = flavorMapRepository.FlavorCellData{
-			Coordinates: utilType.Coordinates{X: customModel.Coordinate(x), Y: customModel.Coordinate(y)},
-			Rate:        float64(userVotes[i]*userRate+guestVotes[i]) / float64(fullAmountForCalc) * 100.0, //整数除算対策
-			UserAmount:  userVotes[i],
-			GuestAmount: guestVotes[i],
+// RecalcCategory 指定したカテゴリの統計データと類似リストを全て作り直す(マスタ更新で投票を移行した後に使う)
+// 1件失敗しても残りの集計は続け、最後に発生したエラーを返す
+func RecalcCategory(ctx context.Context, mst *flavorMapRepository.MasterModel, flR *flavorMapRepository.FlavorToLiquorRepository, fmR *flavorMapRepository.FlavorMapRepository, nR *flavorMapRepository.FlavorNeighbourRepository, lr *liquorRepository.LiquorsRepository) *customError.Error {
+	tyings, err := flR.GetDataByCategory(ctx, mst.CategoryID)
+	if err != nil {
+		return err
+	}
+	var lastErr *customError.Error
+	for _, tying := range tyings {
+		if err := calcFlavorMap(ctx, mst, flR, fmR, lr, tying.LiquorID); err != nil {
+			lastErr = err
 		}
 	}
+	if err := RefreshCategoryNeighbours(ctx, mst, flR, nR); err != nil {
+		lastErr = err
+	}
+	return lastErr
+}
 
-	// 結果を TyingModel に反映
-	cErr := flR.UpsertData(ctx, flavorMapRepository.TyingModel{
-		LiquorID:        lId,
-		CategoryID:      mst.CategoryID,
-		FlavorCellData:  cellData,
-		UserFullAmount:  userFullAmount,
-		GuestFullAmount: guestFullAmount,
-	})
+// reweightVotes 現在の重み付け設定とレビュー投稿数、減衰の基準時刻で票の重みを計算し直し、値が変わった票を返す
+func reweightVotes(ctx context.Context, lr *liquorRepository.LiquorsRepository, weighting flavorMapRepository.WeightingModel, votes []*flavorMapRepository.FlavorMapModel, landmark time.Time) ([]*flavorMapRepository.FlavorMapModel, *customError.Error) {
+	counts := map[primitive.ObjectID]int{}
+	if weighting.ReputationWeight > 0 {
+		var uIds []primitive.ObjectID
+		for _, vote := range votes {
+			if vote.UserId != nil {
+				uIds = append(uIds, *vote.UserId)
+			}
+		}
+		var err *customError.Error
+		counts, err = lr.CountBoardsByUsers(ctx, uIds)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	return cErr
+	var changed []*flavorMapRepository.FlavorMapModel
+	for _, vote := range votes {
+		reviewCount := 0
+		if vote.UserId != nil {
+			reviewCount = counts[*vote.UserId]
+		}
+		weight := weighting.VoteWeight(vote.UserId != nil, reviewCount, vote.UpdatedAt, landmark)
+		if weight != vote.Weight {
+			vote.Weight = weight
+			changed = append(changed, vote)
+		}
+	}
+	return changed, nil
+}
+
+// aggregateVotes 投票データから統計データを作成する
+func aggregateVotes(lId primitive.ObjectID, cId int, votes []*flavorMapRepository.FlavorMapModel, weighting flavorMapRepository.WeightingModel) flavorMapRepository.TyingModel {
+	tying := flavorMapRepository.NewEmptyTyingModel(lId, cId)
+	for _, vote := range votes {
+		xIndex := utilType.GetCoordinateIndex(vote.X)
+		yIndex := utilType.GetCoordinateIndex(vote.Y)
+		index := xIndex*21 + yIndex // 21x21のグリッドのインデックス計算
+
+		// ユーザーごとの集計
+		if vote.UserId != nil {
+			tying.FlavorCellData[index].UserAmount++
+			tying.UserFullAmount++
+		} else {
+			tying.FlavorCellData[index].GuestAmount++
+			tying.GuestFullAmount++
+		}
+		tying.FlavorCellData[index].Score += vote.Weight
+		tying.ScoreTotal += vote.Weight
+	}
+	tying.CalcRates(weighting)
+	return tying
 }
 
 // GetFlavorMasterData 指定されたliquorIdが属するフレーバーマップID(カテゴリID)を取得する
@@ -124,7 +281,12 @@ func GetFlavorMasterData(ctx context.Context, mstR *flavorMapRepository.FlavorMa
 	if err != nil {
 		return nil, err
 	}
-	trail, err := categoryService.GetCategoryTrail(ctx, liquor.CategoryID, c) //パンくずリストなので順番は保証されている
+	return findMaster(ctx, mstR, c, liquor.CategoryID)
+}
+
+// findMaster 指定したカテゴリに適用されるマスタを取得する
+func findMaster(ctx context.Context, mstR *flavorMapRepository.FlavorMapMasterRepository, c *categoriesRepository.CategoryRepository, cId int) (*flavorMapRepository.MasterModel, *customError.Error) {
+	trail, err := categoryService.GetCategoryTrail(ctx, cId, c) //パンくずリストなので順番は保証されている
 	if err != nil {
 		return nil, err
 	}
@@ -133,19 +295,21 @@ func GetFlavorMasterData(ctx context.Context, mstR *flavorMapRepository.FlavorMa
 	if err != nil {
 		return nil, err
 	}
-	// 検索結果を保持する変数
-	var foundMst *flavorMapRepository.MasterModel
+	return nearestMaster(*trail, mst), nil //見つからなかったらnil,nilになる
+}
+
+// nearestMaster パンくずリストを末尾(お酒のカテゴリ)から遡り、最も近いカテゴリに設定されたマスタを返す
+func nearestMaster(trail []categoriesRepository.Model, mst []*flavorMapRepository.MasterModel) *flavorMapRepository.MasterModel {
 	// 逆順でループ
-	for trailIndex := len(*trail) - 1; trailIndex >= 0; trailIndex-- {
+	for trailIndex := len(trail) - 1; trailIndex >= 0; trailIndex-- {
 		// ループを使って検索
 		for mstIndex := range mst {
-			if mst[mstIndex].CategoryID == (*trail)[trailIndex].ID {
-				foundMst = mst[mstIndex]
-				break
+			if mst[mstIndex].CategoryID == trail[trailIndex].ID {
+				return mst[mstIndex]
 			}
 		}
 	}
-	return foundMst, nil //見つからなかったらnil,nilになる
+	return nil
 }
 
 func GetFlavorMap(ctx context.Context, mstR *flavorMapRepository.FlavorMapMasterRepository, flR *flavorMapRepository.FlavorToLiquorRepository, l *liquorRepository.LiquorsRepository, c *categoriesRepository.CategoryRepository, lId primitive.ObjectID) (*flavorMapRepository.FlavorMapResult, *customError.Error) {
@@ -157,38 +321,68 @@ func GetFlavorMap(ctx context.Context, mstR *flavorMapRepository.FlavorMapMaster
 		//該当するフレーバーマップがなかった場合、nil,nilで返す
 		return nil, nil
 	}
-	var model flavorMapRepository.TyingModel
-	rawErr := flR.Collection.FindOne(ctx, bson.M{flavorMapRepository.LiquorID: lId, flavorMapRepository.CategoryID: mst.CategoryID}).Decode(&model)
-
-	if rawErr != nil {
-		if !errors.Is(rawErr, mongo.ErrNoDocuments) {
+	model, err := flR.GetData(ctx, lId, mst.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	if model == nil {
+		//見つからなかった場合は新しく作成し、それを返す
+		if err = flR.EnsureData(ctx, lId, mst.CategoryID); err != nil {
 			return nil, err
 		}
-		//見つからなかった場合は新しく作成し、それを返す
-		var cellData [21 * 21]flavorMapRepository.FlavorCellData
-		for i := 0; i < 21*21; i++ {
-			x, y := utilType.UndoCoordinateIndex(i)
-			cellData[i] = flavorMapRepository.FlavorCellData{
-				Coordinates: utilType.Coordinates{X: customModel.Coordinate(x), Y: customModel.Coordinate(y)},
-			}
+		empty := flavorMapRepository.NewEmptyTyingModel(lId, mst.CategoryID)
+		model = &empty
+	}
+	//得票率は得票から都度計算する
+	model.CalcRates(mst.GetWeighting())
+
+	return &flavorMapRepository.FlavorMapResult{
+		Master: *mst,
+		Tying:  *model,
+	}, nil
+}
+
+// RecalcAllFlavorMaps 投票が存在する全てのお酒について統計データと類似リストを作り直す(定期ジョブ用)
+// 1件失敗しても残りの集計は続け、最後に発生したエラーを返す
+func RecalcAllFlavorMaps(ctx context.Context, mstR *flavorMapRepository.FlavorMapMasterRepository, flR *flavorMapRepository.FlavorToLiquorRepository, fmR *flavorMapRepository.FlavorMapRepository, nR *flavorMapRepository.FlavorNeighbourRepository, lr *liquorRepository.LiquorsRepository) *customError.Error {
+	masters, err := mstR.GetMasterData(ctx)
+	if err != nil {
+		return err
+	}
+	mstByCategory := make(map[int]*flavorMapRepository.MasterModel, len(masters))
+	for _, mst := range masters {
+		mstByCategory[mst.CategoryID] = mst
+	}
+
+	pairs, err := fmR.VotedPairs(ctx)
+	if err != nil {
+		return err
+	}
+
+	var lastErr *customError.Error
+	for _, pair := range pairs {
+		if ctx.Err() != nil {
+			return lastErr
 		}
-		newModel := flavorMapRepository.TyingModel{
-			CategoryID:     mst.CategoryID,
-			LiquorID:       lId,
-			FlavorCellData: cellData,
+		mst, ok := mstByCategory[pair.CategoryID]
+		if !ok {
+			//マスタが削除されたカテゴリの票は、重み付けなしで集計しておく(カテゴリを戻した時に復元できるように)
+			mst = &flavorMapRepository.MasterModel{CategoryID: pair.CategoryID}
+			mstByCategory[pair.CategoryID] = mst
 		}
-		_, rawErr = flR.Collection.InsertOne(ctx, newModel)
-		if rawErr != nil {
-			return nil, errInsertOne(rawErr)
+		//類似リストはカテゴリ単位でまとめて作り直すので、ここでは集計のみ行う
+		if err := calcFlavorMap(ctx, mst, flR, fmR, lr, pair.LiquorID); err != nil {
+			lastErr = err
 		}
-		return &flavorMapRepository.FlavorMapResult{
-			Master: *mst,
-			Tying:  newModel,
-		}, err
 	}
 
-	return &flavorMapRepository.FlavorMapResult{
-		Master: *mst,
-		Tying:  model,
-	}, err
+	for _, mst := range mstByCategory {
+		if ctx.Err() != nil {
+			return lastErr
+		}
+		if err := RefreshCategoryNeighbours(ctx, mst, flR, nR); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
 }
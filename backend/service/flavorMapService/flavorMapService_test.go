@@ -0,0 +1,259 @@
+package flavorMapService
+
+import (
+	"backend/db"
+	"backend/db/repository/categoriesRepository"
+	"backend/db/repository/flavorMapRepository"
+	"backend/graph/graphModel"
+	"backend/graph/schema/customModel"
+	"backend/util/utilType"
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestAggregateVotes_正常系_ユーザーとゲストの得票が集計されること(t *testing.T) {
+	lId := primitive.NewObjectID()
+	uId := primitive.NewObjectID()
+	votes := []*flavorMapRepository.FlavorMapModel{
+		{LiquorId: lId, CategoryId: 1, UserId: &uId, X: 0, Y: 0, Weight: 1},
+		{LiquorId: lId, CategoryId: 1, X: 0, Y: 0, Weight: 1},
+		{LiquorId: lId, CategoryId: 1, X: 10, Y: -10, Weight: 1},
+	}
+
+	tying := aggregateVotes(lId, 1, votes, flavorMapRepository.DefaultWeighting())
+
+	assert.Equal(t, 1, tying.UserFullAmount)
+	assert.Equal(t, 2, tying.GuestFullAmount)
+
+	center := utilType.GetCoordinateIndex(0)*21 + utilType.GetCoordinateIndex(0)
+	assert.Equal(t, 1, tying.FlavorCellData[center].UserAmount)
+	assert.Equal(t, 1, tying.FlavorCellData[center].GuestAmount)
+	assert.InDelta(t, 200.0/3, tying.FlavorCellData[center].Rate, 0.0001)
+
+	corner := utilType.GetCoordinateIndex(10)*21 + utilType.GetCoordinateIndex(-10)
+	assert.EqualValues(t, 10, tying.FlavorCellData[corner].X)
+	assert.EqualValues(t, -10, tying.FlavorCellData[corner].Y)
+	assert.InDelta(t, 100.0/3, tying.FlavorCellData[corner].Rate, 0.0001)
+}
+
+func TestAggregateVotes_正常系_投票がない場合は得票率が0になること(t *testing.T) {
+	tying := aggregateVotes(primitive.NewObjectID(), 1, nil, flavorMapRepository.DefaultWeighting())
+
+	for _, cell := range tying.FlavorCellData {
+		assert.Zero(t, cell.Rate)
+	}
+}
+
+func TestVoteWeight_正常系_半減期が経過した票の重みが半分になること(t *testing.T) {
+	w := flavorMapRepository.WeightingModel{UserWeight: 2, GuestWeight: 1, HalfLifeDays: 30}
+	now := time.Now()
+
+	recent := w.VoteWeight(true, 0, now, now)
+	old := w.VoteWeight(true, 0, now.Add(-30*24*time.Hour), now)
+	guest := w.VoteWeight(false, 0, now, now)
+
+	assert.InDelta(t, 0.5, old/recent, 0.0001)
+	assert.InDelta(t, 0.5, guest/recent, 0.0001)
+}
+
+func TestReweightVotes_正常系_基準時刻を置き直すと重みが基準時刻からの経過で計算し直されること(t *testing.T) {
+	w := flavorMapRepository.WeightingModel{UserWeight: 1, GuestWeight: 1, HalfLifeDays: flavorMapRepository.MinHalfLifeDays}
+	landmark := time.Date(2060, 1, 1, 0, 0, 0, 0, time.UTC)
+	halfLife := time.Duration(flavorMapRepository.MinHalfLifeDays*24) * time.Hour
+	votes := []*flavorMapRepository.FlavorMapModel{
+		{UpdatedAt: landmark, Weight: 1},
+		{UpdatedAt: landmark.Add(-halfLife), Weight: 1},
+	}
+
+	changed, err := reweightVotes(context.Background(), nil, w, votes, landmark)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []*flavorMapRepository.FlavorMapModel{votes[1]}, changed)
+	assert.InDelta(t, 1.0, votes[0].Weight, 1e-9)
+	assert.InDelta(t, 0.5, votes[1].Weight, 1e-9)
+}
+
+func TestCalcRates_正常系_平滑化しても得票率の合計が100のまま隣接セルに広がること(t *testing.T) {
+	lId := primitive.NewObjectID()
+	votes := []*flavorMapRepository.FlavorMapModel{
+		{LiquorId: lId, CategoryId: 1, X: 0, Y: 0, Weight: 1},
+	}
+	w := flavorMapRepository.DefaultWeighting()
+	w.SmoothingSigma = 1
+
+	tying := aggregateVotes(lId, 1, votes, w)
+
+	var sum float64
+	for _, cell := range tying.FlavorCellData {
+		sum += cell.Rate
+	}
+	assert.InDelta(t, 100, sum, 0.0001)
+
+	center := utilType.GetCoordinateIndex(0)*21 + utilType.GetCoordinateIndex(0)
+	neighbour := utilType.GetCoordinateIndex(1)*21 + utilType.GetCoordinateIndex(0)
+	assert.Greater(t, tying.FlavorCellData[neighbour].Rate, 0.0)
+	assert.Greater(t, tying.FlavorCellData[center].Rate, tying.FlavorCellData[neighbour].Rate)
+}
+
+func TestNeighboursOf_正常系_分布が近いお酒ほど上位に並ぶこと(t *testing.T) {
+	newDist := func(x, y int) distribution {
+		lId := primitive.NewObjectID()
+		tying := aggregateVotes(lId, 1, []*flavorMapRepository.FlavorMapModel{
+			{LiquorId: lId, CategoryId: 1, X: customModel.Coordinate(x), Y: customModel.Coordinate(y), Weight: 1},
+		}, flavorMapRepository.WeightingModel{UserWeight: 1, GuestWeight: 1, SmoothingSigma: neighbourSmoothingSigma})
+		return newDistribution(&tying)
+	}
+	target, near, far := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	dists := map[primitive.ObjectID]distribution{
+		target: newDist(0, 0),
+		near:   newDist(1, 1),
+		far:    newDist(8, -8),
+	}
+
+	result := neighboursOf(target, dists)
+
+	assert.Len(t, result, 2)
+	assert.Equal(t, near, result[0].LiquorID)
+	assert.Equal(t, far, result[1].LiquorID)
+	assert.Greater(t, result[0].Similarity, result[1].Similarity)
+	assert.InDelta(t, 1.0, dists[target].similarity(dists[target]), 0.0001)
+}
+
+func TestPreference_正常系_重心が閾値を超えた側の軸名が返ること(t *testing.T) {
+	names := [2]string{"甘口", "辛口"}
+
+	assert.Equal(t, "甘口", *preference(-5, names))
+	assert.Equal(t, "辛口", *preference(3.5, names))
+	assert.Nil(t, preference(1, names))
+
+	// 評価が高いほど重く、未評価は中間の重みになること
+	rate := 5
+	assert.Greater(t, ratingWeight(&rate), ratingWeight(nil))
+}
+
+func TestRatingWeight_正常系_評価3以下のお酒は好みに含めないこと(t *testing.T) {
+	for rate, want := range map[int]float64{1: 0, 2: 0, 3: 0, 4: 0.5, 5: 1} {
+		assert.Equal(t, want, ratingWeight(&rate), rate)
+	}
+	assert.Greater(t, ratingWeight(nil), 0.0)
+}
+
+func TestProfileLimit_正常系_未指定は既定値で上限を超えないこと(t *testing.T) {
+	assert.Equal(t, profileDefaultLimit, profileLimit(0))
+	assert.Equal(t, profileDefaultLimit, profileLimit(-1))
+	assert.Equal(t, 5, profileLimit(5))
+	assert.Equal(t, profileMaxLimit, profileLimit(profileMaxLimit+1))
+}
+
+func TestNearestMaster_正常系_最も近い祖先カテゴリのマスタが選ばれること(t *testing.T) {
+	trail := []categoriesRepository.Model{{ID: 1}, {ID: 2}, {ID: 3}}
+	masters := []*flavorMapRepository.MasterModel{{CategoryID: 1}, {CategoryID: 2}}
+
+	assert.Equal(t, 2, nearestMaster(trail, masters).CategoryID)
+	assert.Equal(t, 1, nearestMaster(trail[:1], masters).CategoryID)
+	assert.Nil(t, nearestMaster(trail, []*flavorMapRepository.MasterModel{{CategoryID: 99}}))
+}
+
+func TestAxisNames_異常系_軸名の数や長さが不正な場合は受け付けないこと(t *testing.T) {
+	names, ok := axisNames([]string{" 甘口 ", "辛口"})
+	assert.True(t, ok)
+	assert.Equal(t, [2]string{"甘口", "辛口"}, names)
+
+	_, ok = axisNames([]string{"甘口"})
+	assert.False(t, ok)
+	_, ok = axisNames([]string{"甘口", " "})
+	assert.False(t, ok)
+	_, ok = axisNames([]string{"甘口", "あいうえおかきくけこさしすせそたちつてとな"})
+	assert.False(t, ok)
+}
+
+func TestWeightingFromInput_正常系_指定した重み付けに変換し未指定はnilになること(t *testing.T) {
+	w, err := weightingFromInput(graphModel.FlavorMapMasterInput{})
+	assert.Nil(t, err)
+	assert.Nil(t, w)
+
+	w, err = weightingFromInput(graphModel.FlavorMapMasterInput{Weighting: &graphModel.FlavorMapWeightingInput{
+		UserWeight: 1, GuestWeight: 0.5, ReputationWeight: 0.2, HalfLifeDays: 30, SmoothingSigma: 1,
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, &flavorMapRepository.WeightingModel{
+		UserWeight: 1, GuestWeight: 0.5, ReputationWeight: 0.2, HalfLifeDays: 30, SmoothingSigma: 1,
+	}, w)
+}
+
+func TestWeightingFromInput_異常系_補正が必要な値は受け付けないこと(t *testing.T) {
+	tests := map[string]graphModel.FlavorMapWeightingInput{
+		"負の重み":        {UserWeight: -1, GuestWeight: 1},
+		"短すぎる半減期":     {UserWeight: 1, GuestWeight: 1, HalfLifeDays: 1},
+		"どの票も数えない":    {UserWeight: 0, GuestWeight: 0},
+		"数値でない平滑化の強さ": {UserWeight: 1, GuestWeight: 1, SmoothingSigma: math.NaN()},
+	}
+	for name, in := range tests {
+		_, err := weightingFromInput(graphModel.FlavorMapMasterInput{Weighting: &in})
+		if assert.NotNil(t, err, name) {
+			assert.Equal(t, InvalidWeighting, err.ErrorCode, name)
+		}
+	}
+}
+
+func TestUpdateFlavorMaster_異常系_不正な重み付けはマスタを読む前に拒否されること(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("reject", func(mt *mtest.T) {
+		mstR := flavorMapRepository.NewFlavorMapMasterRepository(&db.DB{Client: mt.Client, DBName: "test"})
+		input := graphModel.FlavorMapMasterInput{
+			CategoryID: 1,
+			XNames:     []string{"甘口", "辛口"},
+			YNames:     []string{"軽快", "濃醇"},
+			Weighting:  &graphModel.FlavorMapWeightingInput{UserWeight: -1, GuestWeight: 1},
+		}
+
+		_, err := UpdateFlavorMaster(context.Background(), &mstR, nil, nil, nil, nil, nil, primitive.NewObjectID(), input, 1, graphModel.FlavorMapMigrationKeep)
+
+		assert.Equal(mt, InvalidWeighting, err.ErrorCode)
+		assert.Empty(mt, mt.GetAllStartedEvents())
+	})
+}
+
+func TestOverlapCoefficient_正常系_同じ分布は1で重ならない分布は0になること(t *testing.T) {
+	lId := primitive.NewObjectID()
+	a := aggregateVotes(lId, 1, []*flavorMapRepository.FlavorMapModel{
+		{LiquorId: lId, CategoryId: 1, X: -5, Y: 5, Weight: 1},
+		{LiquorId: lId, CategoryId: 1, X: 5, Y: 5, Weight: 1},
+	}, flavorMapRepository.DefaultWeighting())
+	b := aggregateVotes(lId, 1, []*flavorMapRepository.FlavorMapModel{
+		{LiquorId: lId, CategoryId: 1, X: 5, Y: 5, Weight: 1},
+	}, flavorMapRepository.DefaultWeighting())
+	c := aggregateVotes(lId, 1, []*flavorMapRepository.FlavorMapModel{
+		{LiquorId: lId, CategoryId: 1, X: -10, Y: -10, Weight: 1},
+	}, flavorMapRepository.DefaultWeighting())
+	empty := flavorMapRepository.NewEmptyTyingModel(lId, 1)
+
+	assert.InDelta(t, 1.0, overlapCoefficient(&a, &a), 1e-9)
+	assert.InDelta(t, 0.5, overlapCoefficient(&a, &b), 1e-9)
+	assert.InDelta(t, 0.0, overlapCoefficient(&a, &c), 1e-9)
+	assert.InDelta(t, 0.0, overlapCoefficient(&a, &empty), 1e-9)
+}
+
+func TestCompareTargetIds_異常系_件数が範囲外の場合はエラーになること(t *testing.T) {
+	id := primitive.NewObjectID().Hex()
+
+	ids, err := compareTargetIds([]string{id, id})
+	assert.Nil(t, err)
+	assert.Len(t, ids, 1) // 重複は除かれること
+
+	_, err = compareTargetIds(nil)
+	assert.NotNil(t, err)
+
+	var many []string
+	for i := 0; i < compareMaxLiquors+1; i++ {
+		many = append(many, primitive.NewObjectID().Hex())
+	}
+	_, err = compareTargetIds(many)
+	assert.NotNil(t, err)
+}
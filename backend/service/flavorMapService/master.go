@@ -0,0 +1,270 @@
+package flavorMapService
+
+import (
+	"backend/db"
+	"backend/db/repository/categoriesRepository"
+	"backend/db/repository/flavorMapRepository"
+	"backend/db/repository/liquorRepository"
+	"backend/graph/graphModel"
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/logger"
+	"backend/service/categoryService"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const axisNameMaxLength = 20
+
+// MasterWithCategory 管理画面用にカテゴリ名を付けたマスタ
+type MasterWithCategory struct {
+	Master       *flavorMapRepository.MasterModel
+	CategoryName string
+}
+
+func (m *MasterWithCategory) ToGraphQL() *graphModel.FlavorMapMaster {
+	return m.Master.ToMasterGraphQL(m.CategoryName)
+}
+
+// axisNames 軸名を[マイナス側, プラス側]の固定長に変換する
+func axisNames(names []string) ([2]string, bool) {
+	var result [2]string
+	if len(names) != 2 {
+		return result, false
+	}
+	for i, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || utf8.RuneCountInString(name) > axisNameMaxLength {
+			return result, false
+		}
+		result[i] = name
+	}
+	return result, true
+}
+
+func validateMasterInput(input graphModel.FlavorMapMasterInput) ([2]string, [2]string, *customError.Error) {
+	x, okX := axisNames(input.XNames)
+	y, okY := axisNames(input.YNames)
+	if !okX || !okY {
+		return x, y, errInvalidAxisNames(input)
+	}
+	return x, y, nil
+}
+
+// weightingFromInput 重み付けの入力を検証して変換する(未指定の場合はnil)
+// memo:保存済みの値はGetWeightingで補正して使うが、管理画面からの入力は補正せずに弾く
+func weightingFromInput(input graphModel.FlavorMapMasterInput) (*flavorMapRepository.WeightingModel, *customError.Error) {
+	in := input.Weighting
+	if in == nil {
+		return nil, nil
+	}
+	for _, v := range []float64{in.UserWeight, in.GuestWeight, in.ReputationWeight, in.HalfLifeDays, in.SmoothingSigma} {
+		if v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, errInvalidWeighting(input)
+		}
+	}
+	if in.HalfLifeDays > 0 && in.HalfLifeDays < flavorMapRepository.MinHalfLifeDays {
+		return nil, errInvalidWeighting(input)
+	}
+	if in.UserWeight == 0 && in.GuestWeight == 0 {
+		//どの票も数えられなくなる
+		return nil, errInvalidWeighting(input)
+	}
+	return &flavorMapRepository.WeightingModel{
+		UserWeight:       in.UserWeight,
+		GuestWeight:      in.GuestWeight,
+		ReputationWeight: in.ReputationWeight,
+		HalfLifeDays:     in.HalfLifeDays,
+		SmoothingSigma:   in.SmoothingSigma,
+	}, nil
+}
+
+// withCategoryNames マスタにカテゴリ名を付ける(カテゴリが削除されている場合は空文字)
+func withCategoryNames(ctx context.Context, cr *categoriesRepository.CategoryRepository, masters []*flavorMapRepository.MasterModel) ([]*MasterWithCategory, *customError.Error) {
+	result := make([]*MasterWithCategory, len(masters))
+	for i, mst := range masters {
+		category, err := categoryService.PartialLeveledCategoriesGet(ctx, mst.CategoryID, cr)
+		if err != nil {
+			return nil, err
+		}
+		name := ""
+		if category != nil {
+			name = category.Name
+		}
+		result[i] = &MasterWithCategory{Master: mst, CategoryName: name}
+	}
+	return result, nil
+}
+
+// GetFlavorMasters マスタを全件取得する
+func GetFlavorMasters(ctx context.Context, mstR *flavorMapRepository.FlavorMapMasterRepository, cr *categoriesRepository.CategoryRepository) ([]*MasterWithCategory, *customError.Error) {
+	masters, err := mstR.GetMasterData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return withCategoryNames(ctx, cr, masters)
+}
+
+// GetFlavorMasterLogs 指定したカテゴリのマスタの変更履歴を取得する
+func GetFlavorMasterLogs(ctx context.Context, mstR *flavorMapRepository.FlavorMapMasterRepository, cr *categoriesRepository.CategoryRepository, cId int) ([]*MasterWithCategory, *customError.Error) {
+	logs, err := mstR.GetMasterLogs(ctx, cId)
+	if err != nil {
+		return nil, err
+	}
+	return withCategoryNames(ctx, cr, logs)
+}
+
+// CreateFlavorMaster カテゴリにフレーバーマップを新しく設定する
+// 親カテゴリに既にマップがある場合、配下のお酒はこのマップに切り替わる(親カテゴリのマップへの投票はそのまま残るので、削除すれば元に戻る)
+func CreateFlavorMaster(ctx context.Context, mstR *flavorMapRepository.FlavorMapMasterRepository, cr *categoriesRepository.CategoryRepository, uId primitive.ObjectID, input graphModel.FlavorMapMasterInput) (*MasterWithCategory, *customError.Error) {
+	x, y, err := validateMasterInput(input)
+	if err != nil {
+		return nil, err
+	}
+	weighting, err := weightingFromInput(input)
+	if err != nil {
+		return nil, err
+	}
+	category, err := categoryService.PartialLeveledCategoriesGet(ctx, input.CategoryID, cr)
+	if err != nil {
+		return nil, err
+	}
+	if category == nil {
+		return nil, errMasterCategoryNotFound(input.CategoryID)
+	}
+	existing, err := mstR.GetMasterByCategory(ctx, input.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errMasterExists(input.CategoryID)
+	}
+
+	now := time.Now()
+	mst := &flavorMapRepository.MasterModel{
+		CategoryID:   input.CategoryID,
+		XName:        x,
+		YName:        y,
+		Weighting:    weighting,
+		VersionNo:    1,
+		UpdateUserId: &uId,
+		UpdatedAt:    &now,
+	}
+	_, e := db.WithTransaction(ctx, mstR.Db.Client, func(sc mongo.SessionContext) (struct{}, error) {
+		zero := struct{}{}
+		if err := mstR.InsertMaster(sc, mst); err != nil {
+			return zero, err
+		}
+		if err := mstR.InsertMasterLog(sc, mst); err != nil {
+			return zero, err
+		}
+		return zero, nil
+	})
+	if e != nil {
+		var cErr *customError.Error
+		if errors.As(e, &cErr) {
+			return nil, cErr
+		}
+		return nil, errSaveMaster(e, mst)
+	}
+	return &MasterWithCategory{Master: mst, CategoryName: category.Name}, nil
+}
+
+// UpdateFlavorMaster マスタの軸名・重み付けを変更し、指定された方法で既存の投票を移行する
+// マスタの更新と投票の移行は1トランザクションで行い、統計データはコミット後に作り直す(失敗しても定期ジョブで修復される)
+// マスタはカテゴリで指定するので、別のカテゴリに移すことはできない(移し先のカテゴリに作成する)
+func UpdateFlavorMaster(ctx context.Context, mstR *flavorMapRepository.FlavorMapMasterRepository, flR *flavorMapRepository.FlavorToLiquorRepository, fmR *flavorMapRepository.FlavorMapRepository, nR *flavorMapRepository.FlavorNeighbourRepository, lr *liquorRepository.LiquorsRepository, cr *categoriesRepository.CategoryRepository, uId primitive.ObjectID, input graphModel.FlavorMapMasterInput, expectedVersionNo int, migration graphModel.FlavorMapMigration) (*MasterWithCategory, *customError.Error) {
+	if !migration.IsValid() {
+		return nil, errInvalidMigration(migration)
+	}
+	x, y, err := validateMasterInput(input)
+	if err != nil {
+		return nil, err
+	}
+	weighting, err := weightingFromInput(input)
+	if err != nil {
+		return nil, err
+	}
+	current, err := mstR.GetMasterByCategory(ctx, input.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, errMasterNotFound(input.CategoryID)
+	}
+	if current.VersionNo != expectedVersionNo {
+		return nil, errMasterVersion(input.CategoryID, expectedVersionNo)
+	}
+
+	now := time.Now()
+	method := string(migration)
+	next := *current
+	next.XName = x
+	next.YName = y
+	if weighting != nil {
+		next.Weighting = weighting
+	}
+	next.VersionNo = current.VersionNo + 1
+	next.Migration = &method
+	next.UpdateUserId = &uId
+	next.UpdatedAt = &now
+
+	_, e := db.WithTransaction(ctx, mstR.Db.Client, func(sc mongo.SessionContext) (struct{}, error) {
+		zero := struct{}{}
+		if current.VersionNo == 0 {
+			//シードデータは履歴がないので、更新前の状態も残しておく
+			if err := mstR.InsertMasterLog(sc, current); err != nil {
+				return zero, err
+			}
+		}
+		updated, err := mstR.UpdateMaster(sc, &next, expectedVersionNo)
+		if err != nil {
+			return zero, err
+		}
+		if !updated {
+			return zero, errMasterVersion(input.CategoryID, expectedVersionNo)
+		}
+		if err = mstR.InsertMasterLog(sc, &next); err != nil {
+			return zero, err
+		}
+		switch method {
+		case flavorMapRepository.MigrationMirrorX, flavorMapRepository.MigrationMirrorY, flavorMapRepository.MigrationMirrorXY:
+			mirrorX := method != flavorMapRepository.MigrationMirrorY
+			mirrorY := method != flavorMapRepository.MigrationMirrorX
+			if err = fmR.MirrorVotes(sc, input.CategoryID, mirrorX, mirrorY); err != nil {
+				return zero, err
+			}
+		case flavorMapRepository.MigrationReset:
+			if _, err = fmR.ArchiveVotes(sc, input.CategoryID, current.VersionNo); err != nil {
+				return zero, err
+			}
+		}
+		return zero, nil
+	})
+	if e != nil {
+		var cErr *customError.Error
+		if errors.As(e, &cErr) {
+			return nil, cErr
+		}
+		return nil, errSaveMaster(e, &next)
+	}
+
+	if method != flavorMapRepository.MigrationKeep || next.GetWeighting() != current.GetWeighting() {
+		//重み付けを変えた場合も、票の重みと得票率を計算し直す
+		//マスタの更新は完了しているので、集計に失敗してもエラーは記録するだけにする
+		if err := RecalcCategory(ctx, &next, flR, fmR, nR, lr); err != nil {
+			logger.LogError(ctx, err)
+		}
+	}
+
+	result, err := withCategoryNames(ctx, cr, []*flavorMapRepository.MasterModel{&next})
+	if err != nil {
+		return nil, err
+	}
+	return result[0], nil
+}
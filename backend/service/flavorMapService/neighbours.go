@@ -0,0 +1,256 @@
+package flavorMapService
+
+import (
+	"backend/db/repository/categoriesRepository"
+	"backend/db/repository/flavorMapRepository"
+	"backend/db/repository/liquorRepository"
+	"backend/graph/graphModel"
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/logger"
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	neighbourLimit          = 20  //1つのお酒について保存しておく類似お酒の件数
+	neighbourSmoothingSigma = 1.5 //票が少なくても近いセル同士を似ていると判定できるよう、比較前に平滑化する
+)
+
+// refreshing バックグラウンドで類似リストを作成中のお酒(同じお酒の作成が重ならないようにする)
+var refreshing sync.Map
+
+// SimilarLiquor 類似度つきのお酒
+type SimilarLiquor struct {
+	Liquor     liquorRepository.Model
+	Similarity float64
+}
+
+// distribution 比較用に平滑化・正規化した分布(単位ベクトル)
+type distribution []float64
+
+// loadDistributions カテゴリ内の全てのお酒の分布を取得する。票が1件もないお酒は含めない
+func loadDistributions(ctx context.Context, mst *flavorMapRepository.MasterModel, flR *flavorMapRepository.FlavorToLiquorRepository) (map[primitive.ObjectID]distribution, *customError.Error) {
+	models, err := flR.GetDataByCategory(ctx, mst.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	weighting := mst.GetWeighting()
+	weighting.SmoothingSigma = math.Max(weighting.SmoothingSigma, neighbourSmoothingSigma)
+
+	result := make(map[primitive.ObjectID]distribution, len(models))
+	for _, model := range models {
+		if model.UserFullAmount+model.GuestFullAmount == 0 {
+			continue
+		}
+		model.CalcRates(weighting)
+		if d := newDistribution(model); d != nil {
+			result[model.LiquorID] = d
+		}
+	}
+	return result, nil
+}
+
+func newDistribution(model *flavorMapRepository.TyingModel) distribution {
+	d := make(distribution, len(model.FlavorCellData))
+	var norm float64
+	for i, cell := range model.FlavorCellData {
+		d[i] = cell.Rate
+		norm += cell.Rate * cell.Rate
+	}
+	if norm == 0 {
+		return nil
+	}
+	norm = math.Sqrt(norm)
+	for i := range d {
+		d[i] /= norm
+	}
+	return d
+}
+
+// similarity コサイン類似度(分布は非負かつ正規化済なので0～1になる)
+func (d distribution) similarity(other distribution) float64 {
+	var dot float64
+	for i := range d {
+		dot += d[i] * other[i]
+	}
+	return math.Min(dot, 1)
+}
+
+// sortAndTrim 類似度の降順に並べ、上限件数に切り詰める
+func sortAndTrim(entries []flavorMapRepository.NeighbourEntry) []flavorMapRepository.NeighbourEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Similarity != entries[j].Similarity {
+			return entries[i].Similarity > entries[j].Similarity
+		}
+		return entries[i].LiquorID.Hex() < entries[j].LiquorID.Hex()
+	})
+	if len(entries) > neighbourLimit {
+		entries = entries[:neighbourLimit]
+	}
+	return entries
+}
+
+// neighboursOf 対象のお酒と、同じカテゴリの他のお酒との類似リストを作成する
+func neighboursOf(lId primitive.ObjectID, dists map[primitive.ObjectID]distribution) []flavorMapRepository.NeighbourEntry {
+	target, ok := dists[lId]
+	if !ok {
+		return []flavorMapRepository.NeighbourEntry{}
+	}
+	entries := make([]flavorMapRepository.NeighbourEntry, 0, len(dists))
+	for id, d := range dists {
+		if id == lId {
+			continue
+		}
+		entries = append(entries, flavorMapRepository.NeighbourEntry{LiquorID: id, Similarity: target.similarity(d)})
+	}
+	return sortAndTrim(entries)
+}
+
+// RefreshNeighbours 指定したお酒の類似リストを作り直し、他のお酒の類似リストにも対象のお酒の変化を反映する
+func RefreshNeighbours(ctx context.Context, mst *flavorMapRepository.MasterModel, flR *flavorMapRepository.FlavorToLiquorRepository, nR *flavorMapRepository.FlavorNeighbourRepository, lId primitive.ObjectID) *customError.Error {
+	dists, err := loadDistributions(ctx, mst, flR)
+	if err != nil {
+		return err
+	}
+	existing, err := nR.GetNeighboursByCategory(ctx, mst.CategoryID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	changed := []*flavorMapRepository.NeighbourModel{{
+		LiquorID:   lId,
+		CategoryID: mst.CategoryID,
+		Neighbours: neighboursOf(lId, dists),
+		UpdatedAt:  now,
+	}}
+
+	//他のお酒のリストから対象のお酒を取り除き、類似度を計算し直して入れ直す
+	target := dists[lId]
+	for _, n := range existing {
+		if n.LiquorID == lId {
+			continue
+		}
+		entries := make([]flavorMapRepository.NeighbourEntry, 0, len(n.Neighbours)+1)
+		for _, e := range n.Neighbours {
+			if e.LiquorID != lId {
+				entries = append(entries, e)
+			}
+		}
+		if d, ok := dists[n.LiquorID]; ok && target != nil {
+			entries = append(entries, flavorMapRepository.NeighbourEntry{LiquorID: lId, Similarity: d.similarity(target)})
+		}
+		n.Neighbours = sortAndTrim(entries)
+		n.UpdatedAt = now
+		changed = append(changed, n)
+	}
+
+	return nR.SaveNeighbours(ctx, changed)
+}
+
+// RefreshCategoryNeighbours カテゴリ内の全てのお酒の類似リストを作り直す(定期ジョブ用)
+func RefreshCategoryNeighbours(ctx context.Context, mst *flavorMapRepository.MasterModel, flR *flavorMapRepository.FlavorToLiquorRepository, nR *flavorMapRepository.FlavorNeighbourRepository) *customError.Error {
+	dists, err := loadDistributions(ctx, mst, flR)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	neighbours := make([]*flavorMapRepository.NeighbourModel, 0, len(dists))
+	for lId := range dists {
+		neighbours = append(neighbours, &flavorMapRepository.NeighbourModel{
+			LiquorID:   lId,
+			CategoryID: mst.CategoryID,
+			Neighbours: neighboursOf(lId, dists),
+			UpdatedAt:  now,
+		})
+	}
+	return nR.SaveNeighbours(ctx, neighbours)
+}
+
+// GetSimilarByFlavor フレーバーマップの分布が似ているお酒を取得する
+// 類似リストが未計算の場合(新しいお酒など)は、カテゴリ全体の読み込みでリクエストを待たせないよう、
+// 作成をバックグラウンドで始めて空の結果を返す
+func GetSimilarByFlavor(ctx context.Context, mstR *flavorMapRepository.FlavorMapMasterRepository, flR *flavorMapRepository.FlavorToLiquorRepository, nR *flavorMapRepository.FlavorNeighbourRepository, lr *liquorRepository.LiquorsRepository, cr *categoriesRepository.CategoryRepository, lId primitive.ObjectID, limit int) ([]*SimilarLiquor, *customError.Error) {
+	mst, err := GetFlavorMasterData(ctx, mstR, lr, cr, lId)
+	if err != nil {
+		return nil, err
+	}
+	if mst == nil {
+		//フレーバーマップを持たないお酒
+		return []*SimilarLiquor{}, nil
+	}
+
+	n, err := nR.GetNeighbours(ctx, lId, mst.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	if n == nil {
+		refreshNeighboursAsync(ctx, mst, flR, nR, lId)
+		return []*SimilarLiquor{}, nil
+	}
+	if len(n.Neighbours) == 0 {
+		return []*SimilarLiquor{}, nil
+	}
+
+	entries := n.Neighbours
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return toSimilarLiquors(ctx, lr, entries)
+}
+
+// refreshNeighboursAsync 類似リストの作成をバックグラウンドで行う。同じお酒を作成中の場合は何もしない
+func refreshNeighboursAsync(ctx context.Context, mst *flavorMapRepository.MasterModel, flR *flavorMapRepository.FlavorToLiquorRepository, nR *flavorMapRepository.FlavorNeighbourRepository, lId primitive.ObjectID) {
+	if _, running := refreshing.LoadOrStore(lId, struct{}{}); running {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer refreshing.Delete(lId)
+		if err := RefreshNeighbours(ctx, mst, flR, nR, lId); err != nil {
+			logger.LogError(ctx, err)
+		}
+	}()
+}
+
+// toSimilarLiquors お酒のデータを取得し、順番を保ったまま類似度と組み合わせる(削除済のお酒は除外する)
+func toSimilarLiquors(ctx context.Context, lr *liquorRepository.LiquorsRepository, entries []flavorMapRepository.NeighbourEntry) ([]*SimilarLiquor, *customError.Error) {
+	if len(entries) == 0 {
+		return []*SimilarLiquor{}, nil
+	}
+	ids := make([]primitive.ObjectID, len(entries))
+	for i, e := range entries {
+		ids[i] = e.LiquorID
+	}
+	liquors, err := lr.GetLiquorsByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	liquorMap := make(map[primitive.ObjectID]liquorRepository.Model, len(liquors))
+	for _, l := range liquors {
+		liquorMap[l.ID] = l
+	}
+
+	result := make([]*SimilarLiquor, 0, len(entries))
+	for _, e := range entries {
+		l, ok := liquorMap[e.LiquorID]
+		if !ok {
+			continue
+		}
+		result = append(result, &SimilarLiquor{Liquor: l, Similarity: e.Similarity})
+	}
+	return result, nil
+}
+
+func (s *SimilarLiquor) ToGraphQL() *graphModel.SimilarLiquor {
+	return &graphModel.SimilarLiquor{
+		Liquor:     s.Liquor.ToGraphQL(),
+		Similarity: s.Similarity,
+	}
+}
@@ -0,0 +1,227 @@
+package flavorMapService
+
+import (
+	"backend/db/repository/categoriesRepository"
+	"backend/db/repository/flavorMapRepository"
+	"backend/db/repository/liquorRepository"
+	"backend/graph/graphModel"
+	"backend/middlewares/customError"
+	"backend/service/categoryService"
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math"
+	"sort"
+)
+
+const (
+	preferenceThreshold = 2.0 //重心がこの値より中央から離れている場合に、その軸の好みがあると判定する
+	unratedVoteWeight   = 0.5 //掲示板で評価していないお酒への投票の重み(評価4と同じ)
+	profileDefaultLimit = 20  //好みに近いお酒の件数の既定値
+	profileMaxLimit     = 100 //好みに近いお酒の件数の上限
+)
+
+// FlavorProfile ユーザーのフレーバーマップのカテゴリごとの好み
+type FlavorProfile struct {
+	CategoryID   int
+	CategoryName string
+	XName        [2]string
+	YName        [2]string
+	CentroidX    float64
+	CentroidY    float64
+	Spread       float64 //重心からの広がり(小さいほど好みがはっきりしている)
+	VoteCount    int
+	XPreference  *string //中央付近の場合はnil
+	YPreference  *string
+}
+
+// ratingWeight 掲示板の評価(1～5)を投票の重みに変換する。高評価のお酒の味ほど好みとして強く反映する
+// 評価3以下のお酒の味は好みとはいえないので、重みを0にして重心に含めない
+func ratingWeight(rate *int) float64 {
+	if rate == nil {
+		return unratedVoteWeight
+	}
+	return math.Max(float64(*rate-3)/2.0, 0)
+}
+
+// profileLimit 好みに近いお酒の件数(未指定は既定値、多すぎる場合は上限に丸める)
+func profileLimit(limit int) int {
+	if limit <= 0 {
+		return profileDefaultLimit
+	}
+	return min(limit, profileMaxLimit)
+}
+
+// preference 重心の位置から、軸のどちら側を好むかを返す(memo:軸名は[マイナス側, プラス側]の順)
+func preference(centroid float64, names [2]string) *string {
+	switch {
+	case centroid <= -preferenceThreshold:
+		return &names[0]
+	case centroid >= preferenceThreshold:
+		return &names[1]
+	}
+	return nil
+}
+
+// GetUserFlavorProfiles ユーザーの投票を、掲示板の評価で重み付けしてカテゴリごとに集計する
+func GetUserFlavorProfiles(ctx context.Context, mstR *flavorMapRepository.FlavorMapMasterRepository, fmR *flavorMapRepository.FlavorMapRepository, lr *liquorRepository.LiquorsRepository, cr *categoriesRepository.CategoryRepository, uId primitive.ObjectID) ([]*FlavorProfile, *customError.Error) {
+	votes, err := fmR.GetVotesByUser(ctx, uId)
+	if err != nil {
+		return nil, err
+	}
+	if len(votes) == 0 {
+		return []*FlavorProfile{}, nil
+	}
+	rates, err := lr.BoardRatesByUser(ctx, uId)
+	if err != nil {
+		return nil, err
+	}
+	masters, err := mstR.GetMasterData(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildProfiles(ctx, cr, votes, rates, masters)
+}
+
+func buildProfiles(ctx context.Context, cr *categoriesRepository.CategoryRepository, votes []*flavorMapRepository.FlavorMapModel, rates map[primitive.ObjectID]*int, masters []*flavorMapRepository.MasterModel) ([]*FlavorProfile, *customError.Error) {
+	type acc struct {
+		sumX, sumY, sumXX, total float64
+		count                    int
+	}
+	accs := map[int]*acc{}
+	for _, vote := range votes {
+		w := ratingWeight(rates[vote.LiquorId])
+		a, ok := accs[vote.CategoryId]
+		if !ok {
+			a = &acc{}
+			accs[vote.CategoryId] = a
+		}
+		x, y := float64(vote.X), float64(vote.Y)
+		a.sumX += w * x
+		a.sumY += w * y
+		a.sumXX += w * (x*x + y*y)
+		a.total += w
+		a.count++
+	}
+
+	profiles := make([]*FlavorProfile, 0, len(accs))
+	for _, mst := range masters {
+		a, ok := accs[mst.CategoryID]
+		if !ok || a.total <= 0 {
+			continue
+		}
+		cx, cy := a.sumX/a.total, a.sumY/a.total
+		variance := a.sumXX/a.total - (cx*cx + cy*cy)
+		category, err := categoryService.PartialLeveledCategoriesGet(ctx, mst.CategoryID, cr)
+		if err != nil {
+			return nil, err
+		}
+		name := ""
+		if category != nil {
+			name = category.Name
+		}
+		profiles = append(profiles, &FlavorProfile{
+			CategoryID:   mst.CategoryID,
+			CategoryName: name,
+			XName:        mst.XName,
+			YName:        mst.YName,
+			CentroidX:    cx,
+			CentroidY:    cy,
+			Spread:       math.Sqrt(math.Max(variance, 0)),
+			VoteCount:    a.count,
+			XPreference:  preference(cx, mst.XName),
+			YPreference:  preference(cy, mst.YName),
+		})
+	}
+	//投票が多いカテゴリほど好みの信頼度が高いので先に並べる
+	sort.SliceStable(profiles, func(i, j int) bool {
+		return profiles[i].VoteCount > profiles[j].VoteCount
+	})
+	return profiles, nil
+}
+
+// RecommendByFlavorProfile ユーザーの好みの重心に近い分布を持つ、まだ評価していないお酒を順位付けする
+func RecommendByFlavorProfile(ctx context.Context, mstR *flavorMapRepository.FlavorMapMasterRepository, fmR *flavorMapRepository.FlavorMapRepository, flR *flavorMapRepository.FlavorToLiquorRepository, lr *liquorRepository.LiquorsRepository, cr *categoriesRepository.CategoryRepository, uId primitive.ObjectID, limit int) ([]*SimilarLiquor, *customError.Error) {
+	votes, err := fmR.GetVotesByUser(ctx, uId)
+	if err != nil {
+		return nil, err
+	}
+	rates, err := lr.BoardRatesByUser(ctx, uId)
+	if err != nil {
+		return nil, err
+	}
+	masters, err := mstR.GetMasterData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := buildProfiles(ctx, cr, votes, rates, masters)
+	if err != nil {
+		return nil, err
+	}
+
+	//評価済・投票済のお酒は除外する
+	excluded := make(map[primitive.ObjectID]struct{}, len(votes)+len(rates))
+	for _, vote := range votes {
+		excluded[vote.LiquorId] = struct{}{}
+	}
+	for lId := range rates {
+		excluded[lId] = struct{}{}
+	}
+
+	maxDistance := math.Hypot(20, 20) //マップの対角線
+	var candidates []flavorMapRepository.NeighbourEntry
+	for _, profile := range profiles {
+		models, err := flR.GetDataByCategory(ctx, profile.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		for _, model := range models {
+			if _, ok := excluded[model.LiquorID]; ok {
+				continue
+			}
+			x, y, _, ok := model.Centroid()
+			if !ok {
+				continue
+			}
+			distance := math.Hypot(x-profile.CentroidX, y-profile.CentroidY)
+			candidates = append(candidates, flavorMapRepository.NeighbourEntry{
+				LiquorID:   model.LiquorID,
+				Similarity: 1 - distance/maxDistance,
+			})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Similarity > candidates[j].Similarity
+	})
+	//カテゴリ移動前の集計データが残っている場合に重複しうるので、類似度の高い方だけ残す
+	seen := make(map[primitive.ObjectID]struct{}, len(candidates))
+	unique := candidates[:0]
+	for _, c := range candidates {
+		if _, ok := seen[c.LiquorID]; ok {
+			continue
+		}
+		seen[c.LiquorID] = struct{}{}
+		unique = append(unique, c)
+	}
+	candidates = unique
+	if limit = profileLimit(limit); len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	return toSimilarLiquors(ctx, lr, candidates)
+}
+
+func (p *FlavorProfile) ToGraphQL() *graphModel.UserFlavorProfile {
+	return &graphModel.UserFlavorProfile{
+		CategoryID:   p.CategoryID,
+		CategoryName: p.CategoryName,
+		XNames:       p.XName[:],
+		YNames:       p.YName[:],
+		CentroidX:    p.CentroidX,
+		CentroidY:    p.CentroidY,
+		Spread:       p.Spread,
+		VoteCount:    p.VoteCount,
+		XPreference:  p.XPreference,
+		YPreference:  p.YPreference,
+	}
+}
@@ -2,14 +2,21 @@ package liquorService
 
 import (
 	"backend/db"
+	"backend/db/repository/activityRepository"
 	"backend/db/repository/categoriesRepository"
 	"backend/db/repository/liquorRepository"
+	"backend/db/repository/notificationRepository"
 	"backend/db/repository/userRepository"
 	"backend/graph/graphModel"
 	"backend/middlewares/auth"
 	"backend/middlewares/customError"
+	"backend/middlewares/guest"
+	"backend/service/activityService"
 	"backend/service/categoryService"
+	"backend/service/notificationService"
+	"backend/service/subscriptionService"
 	"backend/service/userService"
+	"backend/util/pubsub"
 	"context"
 	"errors"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -54,7 +61,7 @@ func GetLiquor(ctx context.Context, lr liquorRepository.LiquorsRepository, cr ca
 	return result, nil
 }
 
-func PostBoard(ctx context.Context, lr liquorRepository.LiquorsRepository, ur userRepository.UsersRepository, input graphModel.BoardInput) *customError.Error {
+func PostBoard(ctx context.Context, lr liquorRepository.LiquorsRepository, ur userRepository.UsersRepository, ar *activityRepository.ActivityRepository, nr *notificationRepository.NotificationRepository, ps pubsub.PubSub, input graphModel.BoardInput) *customError.Error {
 	//バリデーション処理
 	if len(input.Text) > 500 {
 		return nil
@@ -73,6 +80,14 @@ func PostBoard(ctx context.Context, lr liquorRepository.LiquorsRepository, ur us
 	if user != nil {
 		userID = &user.ID
 	}
+	var visitor *guest.Identity
+	if userID == nil {
+		//ゲストは訪問者ごとに投稿回数を制限する
+		if err = guest.CheckRate(ctx, "postBoard"); err != nil {
+			return err
+		}
+		visitor = guest.GetIdentity(ctx)
+	}
 
 	lId, e := primitive.ObjectIDFromHex(input.LiquorID)
 	if e != nil {
@@ -87,6 +102,10 @@ func PostBoard(ctx context.Context, lr liquorRepository.LiquorsRepository, ur us
 		Rate:      input.Rate,
 		UpdatedAt: time.Now(),
 	}
+	if visitor != nil {
+		//同じ訪問者の再投稿は上書きする
+		model.GuestId = &visitor.ID
+	}
 
 	//トランザクション(返り値を返さないといけない構造になっていたので、boolを返すことにした)
 	_, e = db.WithTransaction(ctx, lr.DB.Client, func(sc mongo.SessionContext) (bool, error) {
@@ -106,6 +125,11 @@ func PostBoard(ctx context.Context, lr liquorRepository.LiquorsRepository, ur us
 	if e != nil {
 		return errPostBoard(e, model)
 	}
+	if userID != nil {
+		activityService.Record(ctx, ar, *userID, activityRepository.TypeBoardPost, lId, &input.Text, input.Rate)
+	}
+	subscriptionService.Publish(ctx, ps, subscriptionService.BoardTopic(lId), model.ID)
+	notificationService.NotifyLiquorCreatorById(ctx, nr, ps, &lr, lId, notificationRepository.TypeBoardPosted, userID, &input.Text, input.Rate)
 	return nil
 }
 
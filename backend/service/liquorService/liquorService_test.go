@@ -0,0 +1,66 @@
+package liquorService
+
+import (
+	"backend/db"
+	"backend/db/repository/activityRepository"
+	"backend/db/repository/liquorRepository"
+	"backend/db/repository/notificationRepository"
+	"backend/db/repository/userRepository"
+	"backend/graph/graphModel"
+	"backend/middlewares/auth"
+	"backend/service/authService/tokenConfig"
+	"backend/util/pubsub"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// loginContext uIdでログインしたリクエストのcontext
+func loginContext(t *testing.T, uId primitive.ObjectID) context.Context {
+	tc := tokenConfig.TokenConfig{AccessSecretKey: []byte("access")}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, auth.Claims{
+		Id:               uId,
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))},
+	}).SignedString(tc.AccessSecretKey)
+	require.NoError(t, err)
+	ctx, err := auth.AuthenticateToken(context.Background(), token, tc)
+	require.NoError(t, err)
+	return ctx
+}
+
+func TestPostBoard_正常系_タイムラインへの記録に失敗しても投稿は成功すること(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("activity", func(mt *mtest.T) {
+		d := &db.DB{Client: mt.Client, DBName: "test"}
+		lr := liquorRepository.NewLiquorsRepository(d)
+		ur := userRepository.NewUsersRepository(d)
+		ar := activityRepository.NewActivityRepository(d)
+		nr := notificationRepository.NewNotificationRepository(d)
+		uId, lId := primitive.NewObjectID(), primitive.NewObjectID()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.users", mtest.FirstBatch, bson.D{{Key: "_id", Value: uId}, {Key: "name", Value: "お酒好き"}}),
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: bson.D{{Key: "_id", Value: primitive.NewObjectID()}}}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+			// タイムラインへの記録が失敗する
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}),
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 11600, Message: "interrupted"}),
+		)
+
+		err := PostBoard(loginContext(mt.T, uId), lr, ur, &ar, &nr, pubsub.NewMemory(), graphModel.BoardInput{LiquorID: lId.Hex(), Text: "美味しい"})
+
+		assert.Nil(mt, err)
+		commands := make([]string, 0)
+		for _, e := range mt.GetAllStartedEvents() {
+			commands = append(commands, e.CommandName)
+		}
+		// 投稿と評価を保存した後に、タイムラインへ記録している
+		assert.Equal(mt, []string{"find", "findAndModify", "update", "delete", "insert"}, commands[:5])
+	})
+}
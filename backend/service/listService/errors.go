@@ -0,0 +1,57 @@
+package listService
+
+import (
+	"backend/graph/graphModel"
+	"backend/middlewares/customError"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+)
+
+const (
+	ListInvalid     = "LIST-SERVICE-001-ListInvalid"
+	ListNotFound    = "LIST-SERVICE-002-ListNotFound"
+	LiquorNotExists = "LIST-SERVICE-003-LiquorNotExists"
+	BookmarkOwnList = "LIST-SERVICE-004-BookmarkOwnList"
+)
+
+func errListInvalid(msg string, input graphModel.LiquorListInput) *customError.Error {
+	return customError.NewError(errors.New(msg), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    ListInvalid,
+		UserMsg:    msg,
+		Level:      logrus.InfoLevel,
+		Input:      input,
+	})
+}
+
+func errListNotFound(id string) *customError.Error {
+	return customError.NewError(errors.New("リストが見つかりません"), customError.Params{
+		StatusCode: http.StatusNotFound,
+		ErrCode:    ListNotFound,
+		UserMsg:    "リストが見つかりません",
+		Level:      logrus.InfoLevel,
+		Input:      id,
+	})
+}
+
+func errLiquorNotExists(ids []primitive.ObjectID) *customError.Error {
+	return customError.NewError(errors.New("存在しないお酒が含まれています"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    LiquorNotExists,
+		UserMsg:    "存在しないお酒が含まれています",
+		Level:      logrus.InfoLevel,
+		Input:      ids,
+	})
+}
+
+func errBookmarkOwnList(id string) *customError.Error {
+	return customError.NewError(errors.New("自分のリストはブックマークできません"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    BookmarkOwnList,
+		UserMsg:    "自分のリストはブックマークできません",
+		Level:      logrus.InfoLevel,
+		Input:      id,
+	})
+}
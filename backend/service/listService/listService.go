@@ -0,0 +1,426 @@
+package listService
+
+import (
+	"backend/db/repository/liquorRepository"
+	"backend/db/repository/listRepository"
+	"backend/graph/graphModel"
+	"backend/middlewares/customError"
+	"backend/util/helper"
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	pageSize          = 20
+	mentionLimit      = 10
+	titleMaxLength    = 50
+	descriptionMaxLen = 500
+	commentMaxLength  = 200
+	entriesMax        = 100
+)
+
+// List 作成者名・お酒の情報・閲覧者のブックマーク状態をまとめたリスト
+type List struct {
+	Model        *listRepository.ListWithOwnerModel
+	Liquors      map[primitive.ObjectID]*liquorRepository.Model
+	IsBookmarked bool
+}
+
+// Mention お酒ページに表示するリストと、そのお酒へのコメント
+type Mention struct {
+	List    *listRepository.ListWithOwnerModel
+	Comment *string
+}
+
+// GetList リストを取得する。非公開のリストは作成者以外にはnilを返す
+func GetList(ctx context.Context, lsr *listRepository.ListRepository, lr *liquorRepository.LiquorsRepository, viewer *primitive.ObjectID, id string) (*List, *customError.Error) {
+	listId, err := helper.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	model, err := lsr.GetList(ctx, listId)
+	if err != nil {
+		return nil, err
+	}
+	if model == nil || !canView(&model.ListModel, viewer) {
+		return nil, nil
+	}
+	lists, err := decorate(ctx, lsr, lr, viewer, []*listRepository.ListWithOwnerModel{model})
+	if err != nil {
+		return nil, err
+	}
+	return lists[0], nil
+}
+
+// GetUserLists ユーザーが作成したリストを取得する。閲覧者が本人の場合は非公開のリストも含める
+func GetUserLists(ctx context.Context, lsr *listRepository.ListRepository, lr *liquorRepository.LiquorsRepository, viewer *primitive.ObjectID, userId string) ([]*List, *customError.Error) {
+	uId, err := helper.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, err
+	}
+	isOwner := viewer != nil && *viewer == uId
+	models, err := lsr.ListsByUser(ctx, uId, !isOwner)
+	if err != nil {
+		return nil, err
+	}
+	return decorate(ctx, lsr, lr, viewer, models)
+}
+
+// GetPublicLists 公開リストをフォロワーの多い順に取得する(pageは1始まり)
+func GetPublicLists(ctx context.Context, lsr *listRepository.ListRepository, lr *liquorRepository.LiquorsRepository, viewer *primitive.ObjectID, page *int) ([]*List, int, *customError.Error) {
+	p := helper.NilToZero(page)
+	if p < 1 {
+		p = 1
+	}
+	models, err := lsr.PublicLists(ctx, int64((p-1)*pageSize), pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := lsr.CountPublicLists(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	lists, err := decorate(ctx, lsr, lr, viewer, models)
+	if err != nil {
+		return nil, 0, err
+	}
+	return lists, total, nil
+}
+
+// GetBookmarkedLists ブックマークしたリストを新しい順に取得する(非公開に変更されたリストは含めない)
+func GetBookmarkedLists(ctx context.Context, lsr *listRepository.ListRepository, lr *liquorRepository.LiquorsRepository, uId primitive.ObjectID) ([]*List, *customError.Error) {
+	ids, err := lsr.BookmarkedListIds(ctx, uId)
+	if err != nil {
+		return nil, err
+	}
+	models, err := lsr.GetListsByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[primitive.ObjectID]*listRepository.ListWithOwnerModel, len(models))
+	for _, m := range models {
+		byId[m.ID] = m
+	}
+	ordered := make([]*listRepository.ListWithOwnerModel, 0, len(ids))
+	for _, id := range ids {
+		if m, ok := byId[id]; ok && canView(&m.ListModel, &uId) {
+			ordered = append(ordered, m)
+		}
+	}
+	return decorate(ctx, lsr, lr, &uId, ordered)
+}
+
+// GetMentions 指定したお酒を含む公開リストを取得する
+func GetMentions(ctx context.Context, lsr *listRepository.ListRepository, liquorId string) ([]*Mention, *customError.Error) {
+	lId, err := helper.ObjectIDFromHex(liquorId)
+	if err != nil {
+		return nil, err
+	}
+	models, err := lsr.PublicListsByLiquor(ctx, lId, mentionLimit)
+	if err != nil {
+		return nil, err
+	}
+	mentions := make([]*Mention, 0, len(models))
+	for _, m := range models {
+		mention := &Mention{List: m}
+		for _, entry := range m.Entries {
+			if entry.LiquorID == lId {
+				mention.Comment = entry.Comment
+				break
+			}
+		}
+		mentions = append(mentions, mention)
+	}
+	return mentions, nil
+}
+
+// CreateList リストを作成する
+func CreateList(ctx context.Context, lsr *listRepository.ListRepository, lr *liquorRepository.LiquorsRepository, uId primitive.ObjectID, input graphModel.LiquorListInput) (*List, *customError.Error) {
+	model, err := buildList(uId, input, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err = checkLiquorsExist(ctx, lr, model.Entries); err != nil {
+		return nil, err
+	}
+	model.CreatedAt = model.UpdatedAt
+	if err = lsr.InsertList(ctx, model); err != nil {
+		return nil, err
+	}
+	return reload(ctx, lsr, lr, uId, model.ID)
+}
+
+// UpdateList 自分のリストを更新する
+func UpdateList(ctx context.Context, lsr *listRepository.ListRepository, lr *liquorRepository.LiquorsRepository, uId primitive.ObjectID, id string, input graphModel.LiquorListInput) (*List, *customError.Error) {
+	listId, err := helper.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	model, err := buildList(uId, input, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err = checkLiquorsExist(ctx, lr, model.Entries); err != nil {
+		return nil, err
+	}
+	model.ID = listId
+	updated, err := lsr.UpdateList(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, errListNotFound(id)
+	}
+	return reload(ctx, lsr, lr, uId, listId)
+}
+
+// DeleteList 自分のリストを削除する
+func DeleteList(ctx context.Context, lsr *listRepository.ListRepository, uId primitive.ObjectID, id string) *customError.Error {
+	listId, err := helper.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	deleted, err := lsr.DeleteList(ctx, uId, listId)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errListNotFound(id)
+	}
+	return nil
+}
+
+// ForkList 閲覧できるリストを、非公開のリストとして自分用にコピーする
+func ForkList(ctx context.Context, lsr *listRepository.ListRepository, lr *liquorRepository.LiquorsRepository, uId primitive.ObjectID, id string) (*List, *customError.Error) {
+	listId, err := helper.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	source, err := lsr.GetList(ctx, listId)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil || !canView(&source.ListModel, &uId) {
+		return nil, errListNotFound(id)
+	}
+
+	now := time.Now()
+	model := &listRepository.ListModel{
+		UserID:      uId,
+		Title:       source.Title,
+		Description: source.Description,
+		IsPublic:    false,
+		Entries:     append([]listRepository.EntryModel{}, source.Entries...),
+		ForkedFrom:  &source.ID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err = lsr.InsertList(ctx, model); err != nil {
+		return nil, err
+	}
+	return reload(ctx, lsr, lr, uId, model.ID)
+}
+
+// BookmarkList 他のユーザーの公開リストをブックマークする
+func BookmarkList(ctx context.Context, lsr *listRepository.ListRepository, uId primitive.ObjectID, id string) *customError.Error {
+	listId, err := helper.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	list, err := lsr.GetList(ctx, listId)
+	if err != nil {
+		return err
+	}
+	if list == nil || !list.IsPublic {
+		return errListNotFound(id)
+	}
+	if list.UserID == uId {
+		return errBookmarkOwnList(id)
+	}
+	return lsr.AddBookmark(ctx, uId, listId)
+}
+
+func UnbookmarkList(ctx context.Context, lsr *listRepository.ListRepository, uId primitive.ObjectID, id string) *customError.Error {
+	listId, err := helper.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	return lsr.RemoveBookmark(ctx, uId, listId)
+}
+
+// canView 非公開のリストは作成者のみ閲覧できる
+func canView(list *listRepository.ListModel, viewer *primitive.ObjectID) bool {
+	return list.IsPublic || (viewer != nil && *viewer == list.UserID)
+}
+
+// buildList 入力値を検証して保存用のモデルを作る
+func buildList(uId primitive.ObjectID, input graphModel.LiquorListInput, now time.Time) (*listRepository.ListModel, *customError.Error) {
+	title := strings.TrimSpace(input.Title)
+	if title == "" || utf8.RuneCountInString(title) > titleMaxLength {
+		return nil, errListInvalid("タイトルは1～50文字で入力してください", input)
+	}
+	description, ok := optionalText(input.Description, descriptionMaxLen)
+	if !ok {
+		return nil, errListInvalid("説明が長すぎます", input)
+	}
+	if len(input.Entries) > entriesMax {
+		return nil, errListInvalid("1つのリストに登録できるお酒は100件までです", input)
+	}
+
+	entries := make([]listRepository.EntryModel, len(input.Entries))
+	seen := make(map[primitive.ObjectID]bool, len(input.Entries))
+	for i, e := range input.Entries {
+		lId, err := helper.ObjectIDFromHex(e.LiquorID)
+		if err != nil {
+			return nil, err
+		}
+		if seen[lId] {
+			return nil, errListInvalid("同じお酒が重複しています", input)
+		}
+		seen[lId] = true
+		comment, ok := optionalText(e.Comment, commentMaxLength)
+		if !ok {
+			return nil, errListInvalid("コメントが長すぎます", input)
+		}
+		entries[i] = listRepository.EntryModel{LiquorID: lId, Comment: comment}
+	}
+
+	return &listRepository.ListModel{
+		UserID:      uId,
+		Title:       title,
+		Description: description,
+		IsPublic:    input.IsPublic,
+		Entries:     entries,
+		UpdatedAt:   now,
+	}, nil
+}
+
+// optionalText 前後の空白を除き、空文字はnilにする。長さが上限を超える場合はfalse
+func optionalText(text *string, maxLength int) (*string, bool) {
+	if text == nil {
+		return nil, true
+	}
+	trimmed := strings.TrimSpace(*text)
+	if trimmed == "" {
+		return nil, true
+	}
+	if utf8.RuneCountInString(trimmed) > maxLength {
+		return nil, false
+	}
+	return &trimmed, true
+}
+
+func checkLiquorsExist(ctx context.Context, lr *liquorRepository.LiquorsRepository, entries []listRepository.EntryModel) *customError.Error {
+	if len(entries) == 0 {
+		return nil
+	}
+	ids := make([]primitive.ObjectID, len(entries))
+	for i, e := range entries {
+		ids[i] = e.LiquorID
+	}
+	liquors, err := lr.GetLiquorsByIds(ctx, ids)
+	if err != nil {
+		return err
+	}
+	if len(liquors) != len(ids) {
+		return errLiquorNotExists(ids)
+	}
+	return nil
+}
+
+// reload 保存後のリストを作成者名込みで取得し直す
+func reload(ctx context.Context, lsr *listRepository.ListRepository, lr *liquorRepository.LiquorsRepository, uId primitive.ObjectID, listId primitive.ObjectID) (*List, *customError.Error) {
+	model, err := lsr.GetList(ctx, listId)
+	if err != nil {
+		return nil, err
+	}
+	if model == nil {
+		//保存直後に削除された
+		return nil, errListNotFound(listId.Hex())
+	}
+	lists, err := decorate(ctx, lsr, lr, &uId, []*listRepository.ListWithOwnerModel{model})
+	if err != nil {
+		return nil, err
+	}
+	return lists[0], nil
+}
+
+// decorate リストにお酒の情報と閲覧者のブックマーク状態をつける。お酒はまとめて1回で取得する
+func decorate(ctx context.Context, lsr *listRepository.ListRepository, lr *liquorRepository.LiquorsRepository, viewer *primitive.ObjectID, models []*listRepository.ListWithOwnerModel) ([]*List, *customError.Error) {
+	var ids []primitive.ObjectID
+	for _, m := range models {
+		for _, e := range m.Entries {
+			ids = append(ids, e.LiquorID)
+		}
+	}
+	liquors := map[primitive.ObjectID]*liquorRepository.Model{}
+	if len(ids) > 0 {
+		found, err := lr.GetLiquorsByIds(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for i := range found {
+			liquors[found[i].ID] = &found[i]
+		}
+	}
+
+	bookmarked := map[primitive.ObjectID]bool{}
+	if viewer != nil && len(models) > 0 {
+		listIds, err := lsr.BookmarkedListIds(ctx, *viewer)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range listIds {
+			bookmarked[id] = true
+		}
+	}
+
+	result := make([]*List, len(models))
+	for i, m := range models {
+		result[i] = &List{Model: m, Liquors: liquors, IsBookmarked: bookmarked[m.ID]}
+	}
+	return result, nil
+}
+
+// ToGraphQL 削除されたお酒はエントリに含めない
+func (l *List) ToGraphQL() *graphModel.LiquorList {
+	entries := make([]*graphModel.LiquorListEntry, 0, len(l.Model.Entries))
+	for _, e := range l.Model.Entries {
+		if liquor, ok := l.Liquors[e.LiquorID]; ok {
+			entries = append(entries, &graphModel.LiquorListEntry{Liquor: liquor.ToGraphQL(), Comment: e.Comment})
+		}
+	}
+	var forkedFrom *string
+	if l.Model.ForkedFrom != nil {
+		id := l.Model.ForkedFrom.Hex()
+		forkedFrom = &id
+	}
+	return &graphModel.LiquorList{
+		ID:            l.Model.ID.Hex(),
+		UserID:        l.Model.UserID.Hex(),
+		UserName:      l.Model.UserName,
+		Title:         l.Model.Title,
+		Description:   l.Model.Description,
+		IsPublic:      l.Model.IsPublic,
+		Entries:       entries,
+		ForkedFrom:    forkedFrom,
+		FollowerCount: l.Model.FollowerCount,
+		IsBookmarked:  l.IsBookmarked,
+		CreatedAt:     l.Model.CreatedAt,
+		UpdatedAt:     l.Model.UpdatedAt,
+	}
+}
+
+func (m *Mention) ToGraphQL() *graphModel.LiquorListMention {
+	return &graphModel.LiquorListMention{
+		ListID:        m.List.ID.Hex(),
+		Title:         m.List.Title,
+		UserID:        m.List.UserID.Hex(),
+		UserName:      m.List.UserName,
+		FollowerCount: m.List.FollowerCount,
+		Comment:       m.Comment,
+	}
+}
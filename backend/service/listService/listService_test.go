@@ -0,0 +1,62 @@
+package listService
+
+import (
+	"backend/db/repository/listRepository"
+	"backend/graph/graphModel"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestBuildList_正常系_入力した順にエントリが並ぶこと(t *testing.T) {
+	now := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	first, second := primitive.NewObjectID(), primitive.NewObjectID()
+	model, err := buildList(primitive.NewObjectID(), graphModel.LiquorListInput{
+		Title:    " 夏に飲みたい日本酒 ",
+		IsPublic: true,
+		Entries: []*graphModel.LiquorListEntryInput{
+			{LiquorID: second.Hex(), Comment: strPtr(" 冷やで ")},
+			{LiquorID: first.Hex(), Comment: strPtr("")},
+		},
+	}, now)
+	require.Nil(t, err)
+
+	assert.Equal(t, "夏に飲みたい日本酒", model.Title)
+	require.Len(t, model.Entries, 2)
+	assert.Equal(t, second, model.Entries[0].LiquorID)
+	assert.Equal(t, "冷やで", *model.Entries[0].Comment)
+	assert.Equal(t, first, model.Entries[1].LiquorID)
+	assert.Nil(t, model.Entries[1].Comment)
+}
+
+func TestBuildList_異常系_空のタイトルや重複したお酒を弾くこと(t *testing.T) {
+	lId := primitive.NewObjectID().Hex()
+	inputs := map[string]graphModel.LiquorListInput{
+		"空のタイトル": {Title: "  "},
+		"重複したお酒": {Title: "リスト", Entries: []*graphModel.LiquorListEntryInput{{LiquorID: lId}, {LiquorID: lId}}},
+	}
+	for name, input := range inputs {
+		_, err := buildList(primitive.NewObjectID(), input, time.Now())
+		require.NotNil(t, err, name)
+		assert.Equal(t, ListInvalid, err.ErrorCode, name)
+	}
+}
+
+func TestCanView_正常系_非公開のリストは作成者のみ閲覧できること(t *testing.T) {
+	owner, other := primitive.NewObjectID(), primitive.NewObjectID()
+	list := &listRepository.ListModel{UserID: owner, IsPublic: false}
+
+	assert.True(t, canView(list, &owner))
+	assert.False(t, canView(list, &other))
+	assert.False(t, canView(list, nil))
+
+	list.IsPublic = true
+	assert.True(t, canView(list, nil))
+}
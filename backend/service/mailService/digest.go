@@ -0,0 +1,217 @@
+package mailService
+
+import (
+	"backend/db/repository/activityRepository"
+	"backend/db/repository/bookmarkRepository"
+	"backend/db/repository/liquorRepository"
+	"backend/db/repository/userRepository"
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/logger"
+	"backend/service/activityService"
+	"backend/util/helper"
+	"backend/util/mailer"
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"os"
+	"time"
+)
+
+const (
+	digestInterval    = 7 * 24 * time.Hour //週間ダイジェストを送る間隔
+	digestBatchSize   = 100                //送信対象のユーザーをまとめて取得する件数
+	digestSectionSize = 5                  //項目ごとに載せる最大件数
+	digestTextLength  = 100                //レビュー本文を載せる最大文字数
+)
+
+// DigestData 週間ダイジェストのテンプレートに渡す値
+type DigestData struct {
+	UserName   string
+	Reviews    []DigestReview
+	Activities []DigestActivity
+	Trending   []DigestTrending
+}
+
+// DigestReview 評価したお酒への新しいレビュー
+type DigestReview struct {
+	LiquorName string
+	LiquorURL  string
+	UserName   string //ゲストの場合は空
+	Rate       int    //評価なしの場合は0
+	Text       string
+}
+
+// DigestActivity ブックマークしたユーザーの行動
+type DigestActivity struct {
+	UserName   string
+	Type       string //activityRepository.Type～
+	LiquorName string
+	LiquorURL  string
+}
+
+// DigestTrending 期間中に投稿が多かったお酒
+type DigestTrending struct {
+	LiquorName string
+	LiquorURL  string
+	Count      int
+}
+
+// SendWeeklyDigests 前回の送信から1週間以上経ったユーザーに週間ダイジェストを送る(定期ジョブ用)
+// 送信前に送信日時を記録するので、複数インスタンスで同時に実行しても二重には送らない
+// 1件失敗しても残りの送信は続け、最後に発生したエラーを返す
+func SendWeeklyDigests(ctx context.Context, m mailer.Mailer, ur *userRepository.UsersRepository, lr *liquorRepository.LiquorsRepository, ar *activityRepository.ActivityRepository, br *bookmarkRepository.BookMarkRepository) *customError.Error {
+	now := time.Now()
+	since := now.Add(-digestInterval)
+	frontURI := os.Getenv("FRONT_URI")
+
+	//話題のお酒は全員共通なので先に集計しておく
+	trending, err := getTrending(ctx, lr, since, frontURI)
+	if err != nil {
+		return err
+	}
+
+	var lastErr *customError.Error
+	for {
+		users, err := ur.ListDigestTargets(ctx, since, digestBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return lastErr
+		}
+		for _, user := range users {
+			claimed, err := ur.ClaimDigest(ctx, user.ID, since, now)
+			if err != nil {
+				return err
+			}
+			if !claimed {
+				//他のインスタンスが送信済み
+				continue
+			}
+			if err = sendDigest(ctx, m, lr, ar, br, user, since, frontURI, trending); err != nil {
+				logger.LogError(ctx, err)
+				lastErr = err
+			}
+		}
+	}
+}
+
+func sendDigest(ctx context.Context, m mailer.Mailer, lr *liquorRepository.LiquorsRepository, ar *activityRepository.ActivityRepository, br *bookmarkRepository.BookMarkRepository, user *userRepository.Model, since time.Time, frontURI string, trending []DigestTrending) *customError.Error {
+	reviews, err := getReviews(ctx, lr, user.ID, since, frontURI)
+	if err != nil {
+		return err
+	}
+	activities, err := getActivities(ctx, ar, br, lr, user.ID, since, frontURI)
+	if err != nil {
+		return err
+	}
+	data := DigestData{
+		UserName:   user.Name,
+		Reviews:    reviews,
+		Activities: activities,
+		Trending:   trending,
+	}
+	if data.isEmpty() {
+		return nil
+	}
+	return sendToUser(ctx, m, user, mailer.TemplateWeeklyDigest, userRepository.EmailKindWeeklyDigest, data)
+}
+
+// getReviews 自分が評価したお酒への、他のユーザーの新しいレビュー
+func getReviews(ctx context.Context, lr *liquorRepository.LiquorsRepository, uId primitive.ObjectID, since time.Time, frontURI string) ([]DigestReview, *customError.Error) {
+	rates, err := lr.BoardRatesByUser(ctx, uId)
+	if err != nil {
+		return nil, err
+	}
+	var lIds []primitive.ObjectID
+	for lId, rate := range rates {
+		if rate != nil {
+			lIds = append(lIds, lId)
+		}
+	}
+	boards, err := lr.BoardsSince(ctx, lIds, since, uId, digestSectionSize)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]DigestReview, len(boards))
+	for i, b := range boards {
+		result[i] = DigestReview{
+			LiquorName: b.LiquorName,
+			LiquorURL:  liquorURL(frontURI, b.LiquorID),
+			UserName:   helper.NilToZero(b.UserName),
+			Rate:       helper.NilToZero(b.Rate),
+			Text:       truncate(b.Text, digestTextLength),
+		}
+	}
+	return result, nil
+}
+
+// getActivities ブックマークしたユーザーの期間中の行動(タイムラインの先頭から取得する)
+func getActivities(ctx context.Context, ar *activityRepository.ActivityRepository, br *bookmarkRepository.BookMarkRepository, lr *liquorRepository.LiquorsRepository, uId primitive.ObjectID, since time.Time, frontURI string) ([]DigestActivity, *customError.Error) {
+	size := digestSectionSize
+	feed, err := activityService.GetFeed(ctx, ar, br, lr, uId, &size, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []DigestActivity
+	for _, item := range feed.Items {
+		//削除されたお酒・退会したユーザーの行動は載せない
+		if item.Model.CreatedAt.Before(since) || item.Liquor == nil || item.User == nil {
+			continue
+		}
+		result = append(result, DigestActivity{
+			UserName:   item.User.UserName,
+			Type:       item.Model.Type,
+			LiquorName: item.Liquor.Name,
+			LiquorURL:  liquorURL(frontURI, item.Liquor.ID),
+		})
+	}
+	return result, nil
+}
+
+// getTrending 期間中に投稿が多かったお酒
+func getTrending(ctx context.Context, lr *liquorRepository.LiquorsRepository, since time.Time, frontURI string) ([]DigestTrending, *customError.Error) {
+	trending, err := lr.TrendingLiquors(ctx, since, digestSectionSize)
+	if err != nil {
+		return nil, err
+	}
+	if len(trending) == 0 {
+		return nil, nil
+	}
+	lIds := make([]primitive.ObjectID, len(trending))
+	for i, t := range trending {
+		lIds[i] = t.LiquorID
+	}
+	liquors, err := lr.GetLiquorsByIds(ctx, lIds)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[primitive.ObjectID]string, len(liquors))
+	for _, l := range liquors {
+		names[l.ID] = l.Name
+	}
+
+	var result []DigestTrending
+	for _, t := range trending {
+		name, ok := names[t.LiquorID]
+		if !ok {
+			continue
+		}
+		result = append(result, DigestTrending{LiquorName: name, LiquorURL: liquorURL(frontURI, t.LiquorID), Count: t.Count})
+	}
+	return result, nil
+}
+
+func (d *DigestData) isEmpty() bool {
+	return len(d.Reviews) == 0 && len(d.Activities) == 0 && len(d.Trending) == 0
+}
+
+// truncate 文字数(バイト数ではない)で切り詰める
+func truncate(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	return string(runes[:length]) + "…"
+}
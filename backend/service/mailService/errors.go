@@ -0,0 +1,91 @@
+package mailService
+
+import (
+	"backend/db/repository/mailQueueRepository"
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/errorMsg"
+	"backend/util/mailer"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+)
+
+const (
+	RenderMail       = "MAIL-SERVICE-001-RenderMail"
+	SendMail         = "MAIL-SERVICE-002-SendMail"
+	InvalidToken     = "MAIL-SERVICE-003-InvalidToken"
+	UserNotFound     = "MAIL-SERVICE-004-UserNotFound"
+	InvalidEmailKind = "MAIL-SERVICE-005-InvalidEmailKind"
+	QueueMail        = "MAIL-SERVICE-006-QueueMail"
+	RetryMail        = "MAIL-SERVICE-007-RetryMail"
+)
+
+func errRenderMail(err error, name string, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    RenderMail,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      map[string]interface{}{"template": name, "userId": uId},
+	})
+}
+
+func errSendMail(err error, name string, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    SendMail,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      map[string]interface{}{"template": name, "userId": uId},
+	})
+}
+
+func errInvalidToken(err error) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    InvalidToken,
+		UserMsg:    "リンクが正しくありません。",
+		Level:      logrus.InfoLevel,
+	})
+}
+
+func errUserNotFound(uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(errors.New("ユーザーが見つかりません"), customError.Params{
+		StatusCode: http.StatusNotFound,
+		ErrCode:    UserNotFound,
+		UserMsg:    "ユーザーが見つかりません。",
+		Level:      logrus.InfoLevel,
+		Input:      uId,
+	})
+}
+
+func errInvalidEmailKind(kind string) *customError.Error {
+	return customError.NewError(errors.New("メールの種類が正しくありません"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    InvalidEmailKind,
+		UserMsg:    "リンクが正しくありません。",
+		Level:      logrus.InfoLevel,
+		Input:      kind,
+	})
+}
+
+func errQueueMail(err error, msg *mailer.Message) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    QueueMail,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.WarnLevel,
+		Input:      map[string]interface{}{"to": msg.To, "subject": msg.Subject},
+	})
+}
+
+func errRetryMail(err error, m *mailQueueRepository.Model) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    RetryMail,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      map[string]interface{}{"id": m.ID, "to": m.Message.To, "attempts": m.Attempts},
+	})
+}
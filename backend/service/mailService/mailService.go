@@ -0,0 +1,110 @@
+package mailService
+
+import (
+	"backend/db/repository/identityRepository"
+	"backend/db/repository/userRepository"
+	"backend/middlewares/customError"
+	"backend/util/mailer"
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// VerifyEmailExpire メールアドレス確認リンクの有効期限
+const VerifyEmailExpire = 24 * time.Hour
+
+type passwordReset struct {
+	Token string
+}
+
+type verifyEmail struct {
+	URL string
+}
+
+type identityMoved struct {
+	Provider string //表示名
+}
+
+// providerNames メールに載せる外部サービスの表示名
+var providerNames = map[string]string{
+	identityRepository.ProviderX:      "X",
+	identityRepository.ProviderGoogle: "Google",
+	identityRepository.ProviderLine:   "LINE",
+	identityRepository.ProviderGitHub: "GitHub",
+}
+
+// SendPasswordReset パスワードリセットのメールを送る(手続きのメールなので受け取り設定に関わらず送る)
+// 本文にトークンをそのまま載せるので、失敗しても再送キューには積まない
+func SendPasswordReset(ctx context.Context, m mailer.Mailer, email string, locale string, token string) error {
+	msg, err := mailer.Render(mailer.TemplatePasswordReset, mailer.Params{
+		To:     email,
+		Locale: locale,
+		Data:   passwordReset{Token: token},
+	})
+	if err != nil {
+		return err
+	}
+	msg.NoQueue = true
+	return m.Send(ctx, msg)
+}
+
+// SendVerifyEmail メールアドレス確認のメールを送る(変更の場合は新しいメールアドレスに送る)
+func SendVerifyEmail(ctx context.Context, m mailer.Mailer, user *userRepository.Model, email string) error {
+	expiresAt := time.Now().Add(VerifyEmailExpire)
+	url, err := mailer.VerifyEmailURL(mailer.VerifyEmailClaims{
+		UserID:    user.ID,
+		Email:     email,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+	msg, err := mailer.Render(mailer.TemplateVerifyEmail, mailer.Params{
+		To:     email,
+		Locale: user.EmailPreference.GetLocale(),
+		Data:   verifyEmail{URL: url},
+	})
+	if err != nil {
+		return err
+	}
+	msg.ExpiresAt = expiresAt
+	return m.Send(ctx, msg)
+}
+
+// SendIdentityMoved 連携していた外部サービスのアカウントが別のユーザーに付け替えられたことを、元のユーザーに知らせる(手続きのメールなので受け取り設定に関わらず送る)
+// メールアドレスを登録していないユーザーには送れないので何もしない
+func SendIdentityMoved(ctx context.Context, m mailer.Mailer, user *userRepository.Model, provider string) *customError.Error {
+	if user.Email == nil {
+		return nil
+	}
+	name, ok := providerNames[provider]
+	if !ok {
+		name = provider
+	}
+	return sendToUser(ctx, m, user, mailer.TemplateIdentityMoved, "", identityMoved{Provider: name})
+}
+
+// sendToUser 登録済みユーザーにメールを送る。kindを指定した場合は配信停止リンクをつける
+func sendToUser(ctx context.Context, m mailer.Mailer, user *userRepository.Model, name string, kind string, data any) *customError.Error {
+	params := mailer.Params{
+		To:     *user.Email,
+		Locale: user.EmailPreference.GetLocale(),
+		Data:   data,
+	}
+	if kind != "" {
+		params.Unsubscribe = &mailer.UnsubscribeClaims{UserID: user.ID, Kind: kind}
+	}
+	msg, err := mailer.Render(name, params)
+	if err != nil {
+		return errRenderMail(err, name, user.ID)
+	}
+	if err = m.Send(ctx, msg); err != nil {
+		return errSendMail(err, name, user.ID)
+	}
+	return nil
+}
+
+// liquorURL メールに載せるお酒のページのURL
+func liquorURL(frontURI string, lId primitive.ObjectID) string {
+	return frontURI + "/liquor/" + lId.Hex()
+}
@@ -0,0 +1,155 @@
+package mailService
+
+import (
+	"backend/db"
+	"backend/db/repository/identityRepository"
+	"backend/db/repository/liquorRepository"
+	"backend/db/repository/mailQueueRepository"
+	"backend/db/repository/userRepository"
+	"backend/graph/graphModel"
+	"backend/util/mailer"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestApplyPreference_正常系_指定した項目のみ変更されること(t *testing.T) {
+	en := graphModel.EmailLocaleEn
+	current := &userRepository.EmailPreferenceModel{Locale: "ja"}
+
+	// 言語だけ変更すると、受け取り設定はそのまま
+	result := applyPreference(current, graphModel.EmailPreferenceInput{Locale: &en})
+	assert.Equal(t, "en", result.Locale)
+	assert.Equal(t, []string{}, result.Unsubscribed)
+
+	// 受け取り設定だけ変更すると、言語はそのまま
+	result = applyPreference(&result, graphModel.EmailPreferenceInput{
+		Subscriptions: []*graphModel.EmailSubscriptionInput{{Kind: graphModel.EmailKindWeeklyDigest, Enabled: false}},
+	})
+	assert.Equal(t, "en", result.Locale)
+	assert.Equal(t, []string{userRepository.EmailKindWeeklyDigest}, result.Unsubscribed)
+	assert.False(t, result.IsSubscribed(userRepository.EmailKindWeeklyDigest))
+}
+
+func TestToPreference_正常系_未設定の場合は既定の言語ですべて受け取ること(t *testing.T) {
+	pref := toPreference(nil)
+
+	assert.Equal(t, "ja", pref.Locale)
+	assert.Len(t, pref.Subscriptions, len(userRepository.EmailKinds))
+	for _, s := range pref.Subscriptions {
+		assert.True(t, s.Enabled, s.Kind)
+	}
+	assert.Equal(t, graphModel.EmailLocaleJa, pref.ToGraphQL().Locale)
+}
+
+func TestTruncate_正常系_文字数で切り詰めること(t *testing.T) {
+	assert.Equal(t, "あいう", truncate("あいう", 3))
+	assert.Equal(t, "あい…", truncate("あいう", 2))
+}
+
+func TestDigestData_正常系_載せる内容がない場合は空と判定されること(t *testing.T) {
+	assert.True(t, (&DigestData{UserName: "a"}).isEmpty())
+	assert.False(t, (&DigestData{Trending: []DigestTrending{{LiquorName: "a"}}}).isEmpty())
+}
+
+func TestRetryDelay_正常系_失敗するたびに間隔が倍になり上限で止まること(t *testing.T) {
+	assert.Equal(t, retryBaseDelay, retryDelay(1))
+	assert.Equal(t, 2*retryBaseDelay, retryDelay(2))
+	assert.Equal(t, 8*retryBaseDelay, retryDelay(4))
+	assert.Equal(t, retryMaxDelay, retryDelay(retryMaxAttempts))
+}
+
+func TestNextRetry_正常系_上限回数に達すると再送を諦めること(t *testing.T) {
+	now := time.Now()
+	model := &mailQueueRepository.Model{Status: mailQueueRepository.StatusPending, Attempts: 1}
+
+	nextRetry(model, errors.New("timeout"), now)
+	assert.Equal(t, mailQueueRepository.StatusPending, model.Status)
+	assert.Equal(t, 2, model.Attempts)
+	assert.Equal(t, now.Add(retryDelay(2)), model.NextAttemptAt)
+	assert.Equal(t, "timeout", model.LastError)
+
+	model.Attempts = retryMaxAttempts - 1
+	nextRetry(model, errors.New("timeout"), now)
+	assert.Equal(t, mailQueueRepository.StatusFailed, model.Status)
+}
+
+type failingTransport struct{ sent int }
+
+func (f *failingTransport) Send(_ context.Context, _ *mailer.Message) error {
+	f.sent++
+	return errors.New("timeout")
+}
+
+func TestSend_正常系_NoQueueのメールは失敗しても再送キューに積まないこと(t *testing.T) {
+	transport := &failingTransport{}
+	// 再送キューを使おうとするとnilの参照でpanicする
+	m := NewQueuedMailer(transport, nil)
+
+	err := m.Send(context.Background(), &mailer.Message{To: "a@example.com", Text: "token", NoQueue: true})
+	assert.EqualError(t, err, "timeout")
+	assert.Equal(t, 1, transport.sent)
+}
+
+func TestSendPasswordReset_正常系_再送キューに積まないメールとして送ること(t *testing.T) {
+	transport := &recordingTransport{}
+	assert.NoError(t, SendPasswordReset(context.Background(), transport, "a@example.com", "ja", "secret-token"))
+	assert.True(t, transport.msg.NoQueue)
+}
+
+func TestSendIdentityMoved_正常系_サービスの表示名を載せて送りメールアドレスがなければ送らないこと(t *testing.T) {
+	email := "owner@example.com"
+	transport := &recordingTransport{}
+	user := &userRepository.Model{Email: &email, EmailPreference: &userRepository.EmailPreferenceModel{Locale: "en"}}
+	assert.Nil(t, SendIdentityMoved(context.Background(), transport, user, identityRepository.ProviderGitHub))
+	assert.Equal(t, "Your GitHub account was unlinked", transport.msg.Subject)
+
+	transport = &recordingTransport{}
+	assert.Nil(t, SendIdentityMoved(context.Background(), transport, &userRepository.Model{}, identityRepository.ProviderX))
+	assert.Nil(t, transport.msg)
+}
+
+func TestIsExpired_正常系_有効期限を過ぎたメールのみ期限切れと判定されること(t *testing.T) {
+	now := time.Now()
+	assert.False(t, isExpired(&mailer.Message{}, now))
+	assert.False(t, isExpired(&mailer.Message{ExpiresAt: now.Add(time.Minute)}, now))
+	assert.True(t, isExpired(&mailer.Message{ExpiresAt: now}, now))
+	assert.True(t, isExpired(&mailer.Message{ExpiresAt: now.Add(-time.Minute)}, now))
+}
+
+type recordingTransport struct{ msg *mailer.Message }
+
+func (r *recordingTransport) Send(_ context.Context, msg *mailer.Message) error {
+	r.msg = msg
+	return nil
+}
+
+func TestSendWeeklyDigests_正常系_確認済みのメールアドレスにだけ送ること(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("verified", func(mt *mtest.T) {
+		d := &db.DB{Client: mt.Client, DBName: "test"}
+		ur := userRepository.NewUsersRepository(d)
+		lr := liquorRepository.NewLiquorsRepository(d)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.board", mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "test.users", mtest.FirstBatch),
+		)
+
+		assert.Nil(mt, SendWeeklyDigests(context.Background(), &recordingTransport{}, &ur, &lr, nil, nil))
+
+		var filter bson.Raw
+		for _, e := range mt.GetAllStartedEvents() {
+			if e.CommandName == "find" && e.Command.Lookup("find").StringValue() == "users" {
+				filter = e.Command.Lookup("filter").Document()
+			}
+		}
+		if assert.NotNil(mt, filter) {
+			assert.True(mt, filter.Lookup(userRepository.EmailVerified).Boolean())
+		}
+	})
+}
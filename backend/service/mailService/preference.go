@@ -0,0 +1,118 @@
+package mailService
+
+import (
+	"backend/db/repository/userRepository"
+	"backend/graph/graphModel"
+	"backend/middlewares/customError"
+	"backend/util/mailer"
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+)
+
+// Preference メールの受け取り設定
+type Preference struct {
+	Locale        string
+	Subscriptions []*Subscription
+}
+
+// Subscription 種類ごとの受け取り設定
+type Subscription struct {
+	Kind    string
+	Enabled bool
+}
+
+func GetPreference(ctx context.Context, ur *userRepository.UsersRepository, uId primitive.ObjectID) (*Preference, *customError.Error) {
+	user, err := ur.GetById(ctx, uId)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errUserNotFound(uId)
+	}
+	return toPreference(user.EmailPreference), nil
+}
+
+// UpdatePreference 指定した項目だけ受け取り設定を変更する
+func UpdatePreference(ctx context.Context, ur *userRepository.UsersRepository, uId primitive.ObjectID, input graphModel.EmailPreferenceInput) (*Preference, *customError.Error) {
+	user, err := ur.GetById(ctx, uId)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errUserNotFound(uId)
+	}
+	pref := applyPreference(user.EmailPreference, input)
+	if err = ur.SaveEmailPreference(ctx, uId, pref); err != nil {
+		return nil, err
+	}
+	return toPreference(&pref), nil
+}
+
+// Unsubscribe メールの配信停止リンクから、その種類のメールの受け取りを止める(ログイン不要)
+func Unsubscribe(ctx context.Context, ur *userRepository.UsersRepository, token string) *customError.Error {
+	claims, rawErr := mailer.ParseUnsubscribeToken(token)
+	if rawErr != nil {
+		return errInvalidToken(rawErr)
+	}
+	kind := graphModel.EmailKind(claims.Kind)
+	if !kind.IsValid() {
+		return errInvalidEmailKind(claims.Kind)
+	}
+	user, err := ur.GetById(ctx, claims.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errUserNotFound(claims.UserID)
+	}
+	pref := applyPreference(user.EmailPreference, graphModel.EmailPreferenceInput{
+		Subscriptions: []*graphModel.EmailSubscriptionInput{{Kind: kind, Enabled: false}},
+	})
+	return ur.SaveEmailPreference(ctx, user.ID, pref)
+}
+
+// applyPreference 現在の設定に入力を反映する(受け取らない種類の並びはメールの種類の一覧に合わせる)
+func applyPreference(current *userRepository.EmailPreferenceModel, input graphModel.EmailPreferenceInput) userRepository.EmailPreferenceModel {
+	result := userRepository.EmailPreferenceModel{Locale: current.GetLocale()}
+	if input.Locale != nil {
+		result.Locale = strings.ToLower(string(*input.Locale))
+	}
+
+	unsubscribed := map[string]bool{}
+	for _, kind := range userRepository.EmailKinds {
+		unsubscribed[kind] = !current.IsSubscribed(kind)
+	}
+	for _, in := range input.Subscriptions {
+		unsubscribed[string(in.Kind)] = !in.Enabled
+	}
+	result.Unsubscribed = []string{}
+	for _, kind := range userRepository.EmailKinds {
+		if unsubscribed[kind] {
+			result.Unsubscribed = append(result.Unsubscribed, kind)
+		}
+	}
+	return result
+}
+
+func toPreference(pref *userRepository.EmailPreferenceModel) *Preference {
+	result := &Preference{
+		Locale:        mailer.NormalizeLocale(pref.GetLocale()),
+		Subscriptions: make([]*Subscription, len(userRepository.EmailKinds)),
+	}
+	for i, kind := range userRepository.EmailKinds {
+		result.Subscriptions[i] = &Subscription{Kind: kind, Enabled: pref.IsSubscribed(kind)}
+	}
+	return result
+}
+
+func (p *Preference) ToGraphQL() *graphModel.EmailPreference {
+	subscriptions := make([]*graphModel.EmailSubscription, len(p.Subscriptions))
+	for i, s := range p.Subscriptions {
+		subscriptions[i] = &graphModel.EmailSubscription{Kind: graphModel.EmailKind(s.Kind), Enabled: s.Enabled}
+	}
+	return &graphModel.EmailPreference{
+		Locale:        graphModel.EmailLocale(strings.ToUpper(p.Locale)),
+		Subscriptions: subscriptions,
+	}
+}
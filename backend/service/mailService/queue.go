@@ -0,0 +1,166 @@
+package mailService
+
+import (
+	"backend/db/repository/mailQueueRepository"
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/logger"
+	"backend/util/amazon/ses"
+	"backend/util/mailer"
+	"backend/util/mailer/file"
+	"backend/util/mailer/smtp"
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// 送信方法(環境変数MAIL_DRIVERで指定する)
+const (
+	DriverSES  = "ses"
+	DriverSMTP = "smtp" //開発環境ではMailHogに向ける
+	DriverFile = "file" //Maildir形式で書き出す(テスト用)
+)
+
+const (
+	retryBaseDelay   = 1 * time.Minute  //1回目の再送までの間隔(以降は倍々に伸ばす)
+	retryMaxDelay    = 6 * time.Hour    //再送の間隔の上限
+	retryMaxAttempts = 10               //この回数まで失敗したら再送を諦める
+	retryLease       = 10 * time.Minute //再送中のメールを他のインスタンスが取らないようにする時間
+	retryBatchSize   = 100              //1回のジョブで再送する最大件数
+)
+
+// Mailer 送信に失敗したメールを再送キューに積むMailer
+type Mailer struct {
+	transport mailer.Mailer
+	qr        *mailQueueRepository.MailQueueRepository
+}
+
+func NewMailer(qr mailQueueRepository.MailQueueRepository) (*Mailer, error) {
+	transport, err := newTransport()
+	if err != nil {
+		return nil, err
+	}
+	return NewQueuedMailer(transport, &qr), nil
+}
+
+// NewQueuedMailer 送信方法を指定して作る(テスト用)
+func NewQueuedMailer(transport mailer.Mailer, qr *mailQueueRepository.MailQueueRepository) *Mailer {
+	return &Mailer{transport: transport, qr: qr}
+}
+
+// newTransport MAIL_DRIVERに応じた送信方法を返す(未指定の場合はSES)
+func newTransport() (mailer.Mailer, error) {
+	switch driver := os.Getenv("MAIL_DRIVER"); driver {
+	case "", DriverSES:
+		m, err := ses.NewMailer()
+		if err != nil {
+			return nil, err
+		}
+		return m, nil
+	case DriverSMTP:
+		return smtp.NewMailer(os.Getenv("SMTP_HOST"), os.Getenv("SMTP_PORT"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD")), nil
+	case DriverFile:
+		m, err := file.NewMailer(os.Getenv("MAIL_FILE_DIR"))
+		if err != nil {
+			return nil, err
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER: %s", driver)
+	}
+}
+
+// Send メールを送信する。失敗した場合は再送キューに積み、後で定期ジョブから送り直す
+// キューに積めた場合は送信済みとして扱い、エラーは返さない(NoQueueのメールは積まずにエラーを返す)
+func (m *Mailer) Send(ctx context.Context, msg *mailer.Message) error {
+	err := m.transport.Send(ctx, msg)
+	if err == nil || msg.NoQueue {
+		return err
+	}
+
+	now := time.Now()
+	model := &mailQueueRepository.Model{
+		Message:       *msg,
+		Status:        mailQueueRepository.StatusPending,
+		Attempts:      1,
+		NextAttemptAt: now.Add(retryDelay(1)),
+		LastError:     err.Error(),
+		CreatedAt:     now,
+	}
+	if cErr := m.qr.Insert(ctx, model); cErr != nil {
+		logger.LogError(ctx, cErr)
+		return err
+	}
+	logger.LogError(ctx, errQueueMail(err, msg))
+	return nil
+}
+
+// RetryQueued 再送日時を過ぎたメールを送り直す(定期ジョブ用)
+// 送信前にキューから取り出しておくので、複数インスタンスで同時に実行しても二重には送らない
+// 1件失敗しても残りの再送は続け、最後に発生したエラーを返す
+func (m *Mailer) RetryQueued(ctx context.Context) *customError.Error {
+	var lastErr *customError.Error
+	for i := 0; i < retryBatchSize; i++ {
+		now := time.Now()
+		model, err := m.qr.ClaimDue(ctx, now, retryLease)
+		if err != nil {
+			return err
+		}
+		if model == nil {
+			return lastErr
+		}
+		//リンクの有効期限が切れたメールは送っても意味がないので捨てる
+		if isExpired(&model.Message, now) {
+			if err = m.qr.Delete(ctx, model.ID); err != nil {
+				lastErr = err
+			}
+			continue
+		}
+
+		sendErr := m.transport.Send(ctx, &model.Message)
+		if sendErr == nil {
+			if err = m.qr.Delete(ctx, model.ID); err != nil {
+				lastErr = err
+			}
+			continue
+		}
+
+		nextRetry(model, sendErr, now)
+		if err = m.qr.Reschedule(ctx, model); err != nil {
+			return err
+		}
+		if model.Status == mailQueueRepository.StatusFailed {
+			lastErr = errRetryMail(sendErr, model)
+			logger.LogError(ctx, lastErr)
+		}
+	}
+	return lastErr
+}
+
+// isExpired 本文のリンクの有効期限が切れているか
+func isExpired(msg *mailer.Message, now time.Time) bool {
+	return !msg.ExpiresAt.IsZero() && !now.Before(msg.ExpiresAt)
+}
+
+// nextRetry 再送に失敗したメールの試行回数を増やし、次の再送日時を決める。上限に達した場合は再送を諦める
+func nextRetry(model *mailQueueRepository.Model, sendErr error, now time.Time) {
+	model.Attempts++
+	model.LastError = sendErr.Error()
+	if model.Attempts >= retryMaxAttempts {
+		model.Status = mailQueueRepository.StatusFailed
+		return
+	}
+	model.NextAttemptAt = now.Add(retryDelay(model.Attempts))
+}
+
+// retryDelay attempts回失敗した後の再送までの間隔
+func retryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= retryMaxDelay {
+			return retryMaxDelay
+		}
+	}
+	return delay
+}
@@ -5,12 +5,15 @@ import (
 	"backend/middlewares/customError/errorMsg"
 	"errors"
 	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"net/http"
 )
 
 const (
 	TooShortPassword     = "MYPAGE-SERVICE-001-TooShortPassword"
 	GenerateFromPassword = "MYPAGE-SERVICE-002-GenerateFromPassword"
+	SendVerifyEmail      = "MYPAGE-SERVICE-003-SendVerifyEmail"
+	PasswordNotSet       = "MYPAGE-SERVICE-004-PasswordNotSet"
 )
 
 func errTooShortPassword() *customError.Error {
@@ -29,3 +32,23 @@ func errGenerateFromPassword(err error) *customError.Error {
 		Level:      logrus.ErrorLevel,
 	})
 }
+
+func errSendVerifyEmail(err error, uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    SendVerifyEmail,
+		UserMsg:    "確認メールの送信に失敗しました。",
+		Level:      logrus.ErrorLevel,
+		Input:      uId,
+	})
+}
+
+func errPasswordNotSet(uId primitive.ObjectID) *customError.Error {
+	return customError.NewError(errors.New("パスワードが未設定のユーザーのパスワード変更"), customError.Params{
+		StatusCode: http.StatusBadRequest,
+		ErrCode:    PasswordNotSet,
+		UserMsg:    "パスワードが設定されていません。メールアドレスの確認を済ませてから、パスワードを設定してください",
+		Level:      logrus.InfoLevel,
+		Input:      uId,
+	})
+}
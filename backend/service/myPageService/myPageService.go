@@ -1,15 +1,23 @@
 package myPageService
 
 import (
+	"backend/db/repository/sessionRepository"
 	"backend/db/repository/userRepository"
 	"backend/graph/graphModel"
 	"backend/middlewares/customError"
+	"backend/service/authService"
+	"backend/service/authService/tokenConfig"
+	"backend/service/mailService"
 	"backend/service/userService"
+	"backend/util/mailer"
 	"context"
 	"golang.org/x/crypto/bcrypt"
+	"net/http"
 )
 
-func UpdateUser(ctx context.Context, r userRepository.UsersRepository, input graphModel.RegisterInput) *customError.Error {
+// UpdateUser ログイン中のユーザーの情報を更新する
+// パスワードを変更した場合は、このリクエストを送った端末以外のセッションを失効させる
+func UpdateUser(ctx context.Context, req *http.Request, tokenConfig tokenConfig.TokenConfig, r userRepository.UsersRepository, sr *sessionRepository.SessionRepository, m mailer.Mailer, input graphModel.RegisterInput) *customError.Error {
 	loginUser, err := userService.GetUserData(ctx, r) //未ログイン状態ならuserIDはnilになる
 	if err != nil {
 		return err
@@ -20,10 +28,14 @@ func UpdateUser(ctx context.Context, r userRepository.UsersRepository, input gra
 		return err
 	}
 
-	//新しいパスワードを生成する(入力が空であれば前の値を代入する)
+	//新しいパスワードを生成する(入力が空であれば変更しない)
 	var newPassword []byte
 
 	if input.Password != nil && len(*input.Password) != 0 { //空文字もnilと同等に扱う
+		if !oldUser.HasPassword() {
+			//外部サービスだけで登録したユーザーは、確認済みのメールアドレスを確かめるsetPasswordで設定してもらう
+			return errPasswordNotSet(id)
+		}
 		if len(*input.Password) < 8 {
 			return errTooShortPassword()
 		}
@@ -33,23 +45,45 @@ func UpdateUser(ctx context.Context, r userRepository.UsersRepository, input gra
 		if rawErr != nil {
 			return errGenerateFromPassword(rawErr)
 		}
-	} else {
-		newPassword = oldUser.Password
-	}
-	//ユーザー構造体の定義
-	user := &userRepository.Model{
-		ID:          oldUser.ID,
-		Name:        input.Name,
-		Email:       &input.Email,
-		Password:    newPassword,
-		ImageBase64: input.ImageBase64,
-		Profile:     input.Profile,
 	}
-
-	err = r.Update(ctx, user)
+	//編集した項目だけを更新する(メールアドレスは確認が済むまで変えない)
+	err = r.UpdateProfile(ctx, id, input.Name, input.ImageBase64, input.Profile, newPassword)
 	if err != nil {
 		return err
 	}
+	//パスワードを変更した場合は、発行済みのパスワードリセットのURLと他の端末のセッションを無効にする
+	if input.Password != nil && len(*input.Password) != 0 {
+		if err = r.ClearPasswordToken(ctx, id); err != nil {
+			return err
+		}
+		if err = authService.LogoutOtherDevices(ctx, req, tokenConfig, sr, id); err != nil {
+			return err
+		}
+	}
+
+	return changeEmail(ctx, r, m, oldUser, input.Email)
+}
 
+// changeEmail メールアドレスの変更を確認待ちにして、新しいメールアドレスに確認メールを送る
+// 入力ミスのアドレスにパスワードリセットのメールが届かないよう、確認が済むまでは以前のメールアドレスを使う
+func changeEmail(ctx context.Context, r userRepository.UsersRepository, m mailer.Mailer, oldUser *userRepository.Model, email string) *customError.Error {
+	switch {
+	case email == "" || (oldUser.PendingEmail != nil && *oldUser.PendingEmail == email):
+		//未入力・確認待ちのまま再保存した場合は何もしない(確認メールの再送は別の導線で行う)
+		return nil
+	case oldUser.Email != nil && *oldUser.Email == email:
+		//元のアドレスに戻した場合は変更を取り消す
+		if oldUser.PendingEmail == nil {
+			return nil
+		}
+		return r.SetPendingEmail(ctx, oldUser.ID, nil)
+	}
+
+	if err := r.SetPendingEmail(ctx, oldUser.ID, &email); err != nil {
+		return err
+	}
+	if rawErr := mailService.SendVerifyEmail(ctx, m, oldUser, email); rawErr != nil {
+		return errSendVerifyEmail(rawErr, oldUser.ID)
+	}
 	return nil
 }
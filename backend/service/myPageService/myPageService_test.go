@@ -0,0 +1,75 @@
+package myPageService
+
+import (
+	"backend/db"
+	"backend/db/repository/userRepository"
+	"backend/graph/graphModel"
+	"backend/middlewares/auth"
+	"backend/service/authService/tokenConfig"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// loginContext uIdでログインしたリクエストのcontext
+func loginContext(t *testing.T, uId primitive.ObjectID) context.Context {
+	tc := tokenConfig.TokenConfig{AccessSecretKey: []byte("access")}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, auth.Claims{
+		Id:               uId,
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))},
+	}).SignedString(tc.AccessSecretKey)
+	require.NoError(t, err)
+	ctx, err := auth.AuthenticateToken(context.Background(), token, tc)
+	require.NoError(t, err)
+	return ctx
+}
+
+func TestUpdateUser_正常系_ロールや外部サービスの連携を上書きせずに編集した項目だけを更新すること(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("profile", func(mt *mtest.T) {
+		uId := primitive.NewObjectID()
+		user := bson.D{
+			{Key: "_id", Value: uId},
+			{Key: "name", Value: "管理者"},
+			{Key: "email", Value: "admin@example.com"},
+			{Key: "roles", Value: bson.A{userRepository.RoleAdmin}},
+			{Key: "twitter_id", Value: "12345"},
+			{Key: "password", Value: []byte("hashed")},
+		}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.users", mtest.FirstBatch, user),
+			mtest.CreateCursorResponse(0, "test.users", mtest.FirstBatch, user),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+		)
+		r := userRepository.NewUsersRepository(&db.DB{Client: mt.Client, DBName: "test"})
+		profile := "日本酒が好きです"
+
+		err := UpdateUser(loginContext(mt.T, uId), nil, tokenConfig.TokenConfig{}, r, nil, nil, graphModel.RegisterInput{
+			Name:    "新しい名前",
+			Email:   "admin@example.com",
+			Profile: &profile,
+		})
+
+		assert.Nil(mt, err)
+		var set bson.Raw
+		for _, e := range mt.GetAllStartedEvents() {
+			if e.CommandName == "update" {
+				set = e.Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u", "$set").Document()
+			}
+		}
+		require.NotNil(mt, set)
+		assert.Equal(mt, "新しい名前", set.Lookup(userRepository.Name).StringValue())
+		assert.Equal(mt, profile, set.Lookup(userRepository.Profile).StringValue())
+		for _, key := range []string{"roles", userRepository.TwitterId, userRepository.Email, userRepository.EmailVerified, userRepository.Password} {
+			_, lookupErr := set.LookupErr(key)
+			assert.Error(mt, lookupErr, key)
+		}
+	})
+}
@@ -0,0 +1,254 @@
+package notificationService
+
+import (
+	"backend/db/repository/liquorRepository"
+	"backend/db/repository/notificationRepository"
+	"backend/graph/graphModel"
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/logger"
+	"backend/service/subscriptionService"
+	"backend/util/helper"
+	"backend/util/pubsub"
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const (
+	pageDefaultSize = 20
+	pageMaxSize     = 50
+)
+
+// Item 通知の1件(お酒の情報つき)
+type Item struct {
+	Model  *notificationRepository.WithActorModel
+	Liquor *liquorRepository.Model
+}
+
+// Page 通知の1ページ分
+type Page struct {
+	Items       []*Item
+	HasNextPage bool
+}
+
+// Preference 種類ごとの受け取り設定
+type Preference struct {
+	Type    string
+	Enabled bool
+}
+
+// Notify 通知を登録する。自分自身の行動や、受け取らない設定の種類は通知しない
+// 通知に失敗しても元の処理は成功させたいので、エラーはログに残すだけにする
+func Notify(ctx context.Context, nr *notificationRepository.NotificationRepository, ps pubsub.PubSub, m notificationRepository.Model) {
+	if m.ActorID != nil && *m.ActorID == m.UserID {
+		return
+	}
+	pref, err := nr.GetPreference(ctx, m.UserID)
+	if err != nil {
+		logger.LogError(ctx, err)
+		return
+	}
+	if pref.IsMuted(m.Type) {
+		return
+	}
+	m.Read = false
+	m.CreatedAt = time.Now()
+	if err = nr.Insert(ctx, &m); err != nil {
+		logger.LogError(ctx, err)
+		return
+	}
+	subscriptionService.Publish(ctx, ps, subscriptionService.NotificationTopic(m.UserID), m.ID)
+}
+
+// NotifyLiquorCreator お酒を登録したユーザーに通知する(登録者が不明な初期データは通知しない)
+func NotifyLiquorCreator(ctx context.Context, nr *notificationRepository.NotificationRepository, ps pubsub.PubSub, liquor *liquorRepository.Model, notificationType string, actor *primitive.ObjectID, text *string, rate *int) {
+	if liquor == nil || liquor.CreateUserId == nil {
+		return
+	}
+	Notify(ctx, nr, ps, notificationRepository.Model{
+		UserID:   *liquor.CreateUserId,
+		Type:     notificationType,
+		ActorID:  actor,
+		LiquorID: &liquor.ID,
+		Text:     text,
+		Rate:     rate,
+	})
+}
+
+// NotifyLiquorCreatorById お酒を取得して、登録したユーザーに通知する
+func NotifyLiquorCreatorById(ctx context.Context, nr *notificationRepository.NotificationRepository, ps pubsub.PubSub, lr *liquorRepository.LiquorsRepository, lId primitive.ObjectID, notificationType string, actor *primitive.ObjectID, text *string, rate *int) {
+	liquor, err := lr.GetLiquorById(ctx, lId)
+	if err != nil {
+		logger.LogError(ctx, err)
+		return
+	}
+	NotifyLiquorCreator(ctx, nr, ps, liquor, notificationType, actor, text, rate)
+}
+
+// GetNotifications 通知を新しい順に取得する
+func GetNotifications(ctx context.Context, nr *notificationRepository.NotificationRepository, lr *liquorRepository.LiquorsRepository, uId primitive.ObjectID, first *int, after *string) (*Page, *customError.Error) {
+	var cursor *primitive.ObjectID
+	if after != nil && *after != "" {
+		id, err := helper.ObjectIDFromHex(*after)
+		if err != nil {
+			return nil, err
+		}
+		cursor = &id
+	}
+
+	//次のページの有無を判定するため1件多く取得する
+	size := pageSize(first)
+	models, err := nr.List(ctx, uId, cursor, int64(size+1))
+	if err != nil {
+		return nil, err
+	}
+	page := &Page{HasNextPage: len(models) > size}
+	if page.HasNextPage {
+		models = models[:size]
+	}
+
+	var lIds []primitive.ObjectID
+	for _, m := range models {
+		if m.LiquorID != nil {
+			lIds = append(lIds, *m.LiquorID)
+		}
+	}
+	liquors := map[primitive.ObjectID]*liquorRepository.Model{}
+	if len(lIds) > 0 {
+		found, err := lr.GetLiquorsByIds(ctx, lIds)
+		if err != nil {
+			return nil, err
+		}
+		for i := range found {
+			liquors[found[i].ID] = &found[i]
+		}
+	}
+
+	page.Items = make([]*Item, len(models))
+	for i, m := range models {
+		item := &Item{Model: m}
+		if m.LiquorID != nil {
+			item.Liquor = liquors[*m.LiquorID]
+		}
+		page.Items[i] = item
+	}
+	return page, nil
+}
+
+// MarkRead 通知を既読にする。idsがnilの場合は未読をすべて既読にする
+func MarkRead(ctx context.Context, nr *notificationRepository.NotificationRepository, uId primitive.ObjectID, ids []string) (int, *customError.Error) {
+	var oIds []primitive.ObjectID
+	if ids != nil {
+		oIds = make([]primitive.ObjectID, len(ids))
+		for i, id := range ids {
+			oId, err := helper.ObjectIDFromHex(id)
+			if err != nil {
+				return 0, err
+			}
+			oIds[i] = oId
+		}
+	}
+	return nr.MarkRead(ctx, uId, oIds)
+}
+
+func GetPreferences(ctx context.Context, nr *notificationRepository.NotificationRepository, uId primitive.ObjectID) ([]*Preference, *customError.Error) {
+	pref, err := nr.GetPreference(ctx, uId)
+	if err != nil {
+		return nil, err
+	}
+	return toPreferences(pref), nil
+}
+
+// UpdatePreferences 指定した種類の受け取り設定を変更する
+func UpdatePreferences(ctx context.Context, nr *notificationRepository.NotificationRepository, uId primitive.ObjectID, input []*graphModel.NotificationPreferenceInput) ([]*Preference, *customError.Error) {
+	pref, err := nr.GetPreference(ctx, uId)
+	if err != nil {
+		return nil, err
+	}
+	if pref == nil {
+		pref = &notificationRepository.PreferenceModel{UserID: uId}
+	}
+	pref.MutedTypes = applyPreferences(pref.MutedTypes, input)
+	if err = nr.SavePreference(ctx, pref); err != nil {
+		return nil, err
+	}
+	return toPreferences(pref), nil
+}
+
+// applyPreferences 受け取らない種類の一覧に入力を反映する(種類の並びは通知の種類の一覧に合わせる)
+func applyPreferences(muted []string, input []*graphModel.NotificationPreferenceInput) []string {
+	mutedSet := map[string]bool{}
+	for _, t := range muted {
+		mutedSet[t] = true
+	}
+	for _, in := range input {
+		mutedSet[string(in.Type)] = !in.Enabled
+	}
+	result := []string{}
+	for _, t := range notificationRepository.Types {
+		if mutedSet[t] {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+func toPreferences(pref *notificationRepository.PreferenceModel) []*Preference {
+	result := make([]*Preference, len(notificationRepository.Types))
+	for i, t := range notificationRepository.Types {
+		result[i] = &Preference{Type: t, Enabled: !pref.IsMuted(t)}
+	}
+	return result
+}
+
+// pageSize 取得件数を既定値・上限に丸める
+func pageSize(first *int) int {
+	if first == nil || *first <= 0 {
+		return pageDefaultSize
+	}
+	if *first > pageMaxSize {
+		return pageMaxSize
+	}
+	return *first
+}
+
+func (p *Page) ToGraphQL() *graphModel.NotificationConnection {
+	edges := make([]*graphModel.NotificationEdge, len(p.Items))
+	for i, item := range p.Items {
+		edges[i] = &graphModel.NotificationEdge{Cursor: item.Model.ID.Hex(), Node: item.ToGraphQL()}
+	}
+	pageInfo := &graphModel.PageInfo{HasNextPage: p.HasNextPage}
+	if len(edges) > 0 {
+		pageInfo.EndCursor = &edges[len(edges)-1].Cursor
+	}
+	return &graphModel.NotificationConnection{Edges: edges, PageInfo: pageInfo}
+}
+
+func (i *Item) ToGraphQL() *graphModel.Notification {
+	var actorId *string
+	if i.Model.ActorID != nil {
+		id := i.Model.ActorID.Hex()
+		actorId = &id
+	}
+	result := &graphModel.Notification{
+		ID:        i.Model.ID.Hex(),
+		Type:      graphModel.NotificationType(i.Model.Type),
+		ActorID:   actorId,
+		ActorName: i.Model.ActorName,
+		Text:      i.Model.Text,
+		Rate:      i.Model.Rate,
+		Read:      i.Model.Read,
+		CreatedAt: i.Model.CreatedAt,
+	}
+	if i.Liquor != nil {
+		result.Liquor = i.Liquor.ToGraphQL()
+	}
+	return result
+}
+
+func (p *Preference) ToGraphQL() *graphModel.NotificationPreference {
+	return &graphModel.NotificationPreference{
+		Type:    graphModel.NotificationType(p.Type),
+		Enabled: p.Enabled,
+	}
+}
@@ -0,0 +1,35 @@
+package notificationService
+
+import (
+	"backend/db/repository/notificationRepository"
+	"backend/graph/graphModel"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyPreferences_正常系_指定した種類のみ変更されること(t *testing.T) {
+	muted := []string{notificationRepository.TypeTagAdded}
+	result := applyPreferences(muted, []*graphModel.NotificationPreferenceInput{
+		{Type: graphModel.NotificationTypeBookmarked, Enabled: false},
+		{Type: graphModel.NotificationTypeBoardPosted, Enabled: true},
+	})
+
+	// 一覧の並び順で返ること
+	assert.Equal(t, []string{notificationRepository.TypeBookmarked, notificationRepository.TypeTagAdded}, result)
+
+	// 有効に戻すと一覧から外れること
+	result = applyPreferences(result, []*graphModel.NotificationPreferenceInput{
+		{Type: graphModel.NotificationTypeTagAdded, Enabled: true},
+	})
+	assert.Equal(t, []string{notificationRepository.TypeBookmarked}, result)
+}
+
+func TestToPreferences_正常系_未設定の場合はすべて有効になること(t *testing.T) {
+	prefs := toPreferences(nil)
+
+	assert.Len(t, prefs, len(notificationRepository.Types))
+	for _, p := range prefs {
+		assert.True(t, p.Enabled, p.Type)
+	}
+}
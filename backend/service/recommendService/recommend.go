@@ -0,0 +1,226 @@
+package recommendService
+
+import (
+	"backend/db/repository/bookmarkRepository"
+	"backend/db/repository/liquorRepository"
+	"backend/db/repository/similarityRepository"
+	"backend/graph/graphModel"
+	"backend/middlewares/customError"
+	"bytes"
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+)
+
+const (
+	recommendDefaultLimit = 10
+	recommendMaxLimit     = 50
+	rateMidpoint          = 3 //これより高い評価を「好き」、低い評価を「苦手」とみなす
+	highRate              = 4 //理由として挙げる評価の下限
+)
+
+// おすすめの根拠
+const (
+	SourceSimilarity = "SIMILARITY" //評価の似たお酒から
+	SourceBookmark   = "BOOKMARK"   //ブックマークしたユーザーの高評価から(評価の少ないユーザー向け)
+)
+
+// Recommendation おすすめのお酒と、その理由
+type Recommendation struct {
+	Liquor  *liquorRepository.Model
+	Score   float64
+	Reason  string
+	BasedOn *primitive.ObjectID //理由になった、自分が高く評価したお酒
+	Source  string
+}
+
+type candidate struct {
+	liquorId     primitive.ObjectID
+	score        float64
+	basedOn      primitive.ObjectID
+	contribution float64 //basedOnによる加点
+}
+
+// Recommend 自分の評価と類似度からおすすめのお酒を選ぶ。足りない分はブックマークしたユーザーの高評価で補う
+func Recommend(ctx context.Context, lr *liquorRepository.LiquorsRepository, sr *similarityRepository.SimilarityRepository, br *bookmarkRepository.BookMarkRepository, uId primitive.ObjectID, limitArg *int) ([]*Recommendation, *customError.Error) {
+	limit := recommendLimit(limitArg)
+	rates, err := lr.BoardRatesByUser(ctx, uId)
+	if err != nil {
+		return nil, err
+	}
+
+	var seeds []primitive.ObjectID
+	for lId, rate := range rates {
+		if rate != nil {
+			seeds = append(seeds, lId)
+		}
+	}
+	similarities, err := sr.GetByLiquorIds(ctx, seeds)
+	if err != nil {
+		return nil, err
+	}
+	candidates := scoreCandidates(rates, similarities)
+
+	//削除されたお酒を読み飛ばせるよう、多めに取得しておく
+	var ids []primitive.ObjectID
+	for i, c := range candidates {
+		if i >= limit*2 {
+			break
+		}
+		ids = append(ids, c.liquorId, c.basedOn)
+	}
+	liquors, err := liquorsById(ctx, lr, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Recommendation, 0, limit)
+	included := map[primitive.ObjectID]bool{}
+	for _, c := range candidates {
+		if len(result) >= limit {
+			break
+		}
+		liquor, ok := liquors[c.liquorId]
+		if !ok {
+			continue
+		}
+		basedOn := c.basedOn
+		reason := "あなたの評価と似た傾向のユーザーに好まれています"
+		if seed, ok := liquors[c.basedOn]; ok {
+			reason = fmt.Sprintf("「%s」を高く評価したあなたへ", seed.Name)
+		}
+		result = append(result, &Recommendation{Liquor: liquor, Score: c.score, Reason: reason, BasedOn: &basedOn, Source: SourceSimilarity})
+		included[c.liquorId] = true
+	}
+	if len(result) >= limit {
+		return result, nil
+	}
+
+	//評価が少なく類似度から選べない場合は、ブックマークしたユーザーの高評価で補う
+	fallback, err := fromBookmarks(ctx, lr, br, uId, rates, included, limit-len(result))
+	if err != nil {
+		return nil, err
+	}
+	return append(result, fallback...), nil
+}
+
+// scoreCandidates 評価済のお酒の類似アイテムを、評価の高さで重み付けして合算する。評価済のお酒は除外する
+func scoreCandidates(rates map[primitive.ObjectID]*int, similarities []*similarityRepository.Model) []candidate {
+	byId := map[primitive.ObjectID]*candidate{}
+	for _, s := range similarities {
+		rate := rates[s.LiquorID]
+		if rate == nil {
+			continue
+		}
+		weight := float64(*rate - rateMidpoint)
+		for _, e := range s.Similars {
+			if _, rated := rates[e.LiquorID]; rated {
+				continue
+			}
+			c, ok := byId[e.LiquorID]
+			if !ok {
+				c = &candidate{liquorId: e.LiquorID}
+				byId[e.LiquorID] = c
+			}
+			contribution := e.Similarity * weight
+			c.score += contribution
+			if *rate >= highRate && contribution > c.contribution {
+				c.basedOn = s.LiquorID
+				c.contribution = contribution
+			}
+		}
+	}
+
+	result := make([]candidate, 0, len(byId))
+	for _, c := range byId {
+		//苦手なお酒に似ているものや、高評価のお酒を根拠にできないものは勧めない
+		if c.score > 0 && c.contribution > 0 {
+			result = append(result, *c)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].score != result[j].score {
+			return result[i].score > result[j].score
+		}
+		return bytes.Compare(result[i].liquorId[:], result[j].liquorId[:]) < 0
+	})
+	return result
+}
+
+// fromBookmarks ブックマークしたユーザーが高く評価したお酒から選ぶ
+func fromBookmarks(ctx context.Context, lr *liquorRepository.LiquorsRepository, br *bookmarkRepository.BookMarkRepository, uId primitive.ObjectID, rates map[primitive.ObjectID]*int, included map[primitive.ObjectID]bool, limit int) ([]*Recommendation, *customError.Error) {
+	fetch := limit * 2
+	list, err := br.GetRecommendLiquors(ctx, uId, &fetch)
+	if err != nil {
+		return nil, err
+	}
+	var ids []primitive.ObjectID
+	for _, r := range *list {
+		ids = append(ids, r.Liquor.ID)
+	}
+	liquors, err := liquorsById(ctx, lr, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*Recommendation
+	for _, r := range *list {
+		if len(result) >= limit {
+			break
+		}
+		lId := r.Liquor.ID
+		liquor, ok := liquors[lId]
+		if _, rated := rates[lId]; rated || included[lId] || !ok {
+			continue
+		}
+		result = append(result, &Recommendation{
+			Liquor: liquor,
+			Score:  float64(r.Rate),
+			Reason: fmt.Sprintf("ブックマークしている%sさんが高く評価しています", r.User.Name),
+			Source: SourceBookmark,
+		})
+		included[lId] = true
+	}
+	return result, nil
+}
+
+func recommendLimit(limit *int) int {
+	if limit == nil || *limit <= 0 {
+		return recommendDefaultLimit
+	}
+	if *limit > recommendMaxLimit {
+		return recommendMaxLimit
+	}
+	return *limit
+}
+
+func liquorsById(ctx context.Context, lr *liquorRepository.LiquorsRepository, ids []primitive.ObjectID) (map[primitive.ObjectID]*liquorRepository.Model, *customError.Error) {
+	result := map[primitive.ObjectID]*liquorRepository.Model{}
+	if len(ids) == 0 {
+		return result, nil
+	}
+	liquors, err := lr.GetLiquorsByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range liquors {
+		result[liquors[i].ID] = &liquors[i]
+	}
+	return result, nil
+}
+
+func (r *Recommendation) ToGraphQL() *graphModel.RecommendedLiquor {
+	var basedOn *string
+	if r.BasedOn != nil {
+		id := r.BasedOn.Hex()
+		basedOn = &id
+	}
+	return &graphModel.RecommendedLiquor{
+		Liquor:          r.Liquor.ToGraphQL(),
+		Score:           r.Score,
+		Reason:          r.Reason,
+		BasedOnLiquorID: basedOn,
+		Source:          graphModel.RecommendationSource(r.Source),
+	}
+}
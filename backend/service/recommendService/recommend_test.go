@@ -0,0 +1,61 @@
+package recommendService
+
+import (
+	"backend/db/repository/liquorRepository"
+	"backend/db/repository/similarityRepository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func board(uId primitive.ObjectID, lId primitive.ObjectID, rate int) liquorRepository.BoardModel {
+	return liquorRepository.BoardModel{UserId: &uId, LiquorID: lId, Rate: &rate}
+}
+
+func TestComputeSimilarities_正常系_評価の傾向が同じお酒が類似すること(t *testing.T) {
+	a, b, c := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	u1, u2, u3 := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	// aとbは同じ人に好まれ、cは逆の評価を受けている
+	boards := []liquorRepository.BoardModel{
+		board(u1, a, 5), board(u1, b, 5), board(u1, c, 1),
+		board(u2, a, 4), board(u2, b, 5), board(u2, c, 2),
+		board(u3, a, 1), board(u3, b, 2), board(u3, c, 5),
+	}
+	result := computeSimilarities(boards)
+
+	require.Len(t, result[a], 1)
+	assert.Equal(t, b, result[a][0].LiquorID)
+	assert.Equal(t, 3, result[a][0].CoRaters)
+	assert.Greater(t, result[a][0].Similarity, 0.5)
+	// 負の類似度は保存しない
+	assert.Empty(t, result[c])
+}
+
+func TestComputeSimilarities_異常系_共通の評価者が少ない組み合わせは除外すること(t *testing.T) {
+	a, b := primitive.NewObjectID(), primitive.NewObjectID()
+	u1 := primitive.NewObjectID()
+	result := computeSimilarities([]liquorRepository.BoardModel{board(u1, a, 5), board(u1, b, 1)})
+	assert.Empty(t, result)
+}
+
+func TestScoreCandidates_正常系_評価済のお酒を除外し理由のお酒を選ぶこと(t *testing.T) {
+	liked, disliked, rated := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	x, y := primitive.NewObjectID(), primitive.NewObjectID()
+	rates := map[primitive.ObjectID]*int{liked: intPtr(5), disliked: intPtr(1), rated: nil}
+	similarities := []*similarityRepository.Model{
+		{LiquorID: liked, Similars: []similarityRepository.Entry{{LiquorID: x, Similarity: 0.9}, {LiquorID: rated, Similarity: 0.9}, {LiquorID: y, Similarity: 0.2}}},
+		{LiquorID: disliked, Similars: []similarityRepository.Entry{{LiquorID: y, Similarity: 0.8}}},
+	}
+	result := scoreCandidates(rates, similarities)
+
+	// yは苦手なお酒に強く似ているので勧めない。評価済のお酒も含めない
+	require.Len(t, result, 1)
+	assert.Equal(t, x, result[0].liquorId)
+	assert.Equal(t, liked, result[0].basedOn)
+}
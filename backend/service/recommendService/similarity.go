@@ -0,0 +1,122 @@
+package recommendService
+
+import (
+	"backend/db/repository/liquorRepository"
+	"backend/db/repository/similarityRepository"
+	"backend/middlewares/customError"
+	"bytes"
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	similarityMinCoRaters = 2  //これより少ない人数しか両方を評価していない組み合わせは、偶然の一致とみなして除外する
+	similarityTopK        = 30 //1つのお酒につき保存する類似アイテムの数
+)
+
+type userRate struct {
+	liquorId primitive.ObjectID
+	rate     float64
+}
+
+type pairKey [2]primitive.ObjectID
+
+type pairSum struct {
+	num, denA, denB float64
+	coRaters        int
+}
+
+// RecalcSimilarities 掲示板の評価からお酒同士の類似度を計算し直して保存する(定期ジョブ用)
+func RecalcSimilarities(ctx context.Context, lr *liquorRepository.LiquorsRepository, sr *similarityRepository.SimilarityRepository) *customError.Error {
+	startedAt := time.Now()
+	boards, err := lr.AllBoardRates(ctx)
+	if err != nil {
+		return err
+	}
+	similarities := computeSimilarities(boards)
+
+	models := make([]similarityRepository.Model, 0, len(similarities))
+	for lId, entries := range similarities {
+		models = append(models, similarityRepository.Model{
+			LiquorID:  lId,
+			Similars:  entries,
+			UpdatedAt: startedAt,
+		})
+	}
+	return sr.ReplaceAll(ctx, models, startedAt)
+}
+
+// computeSimilarities 調整コサイン類似度でお酒同士の類似度を計算する
+// ユーザーごとの評価の甘さ・辛さを打ち消すため、各評価からそのユーザーの平均評価を引いてからコサイン類似度をとる
+func computeSimilarities(boards []liquorRepository.BoardModel) map[primitive.ObjectID][]similarityRepository.Entry {
+	byUser := map[primitive.ObjectID][]userRate{}
+	for _, b := range boards {
+		if b.UserId == nil || b.Rate == nil {
+			continue
+		}
+		byUser[*b.UserId] = append(byUser[*b.UserId], userRate{liquorId: b.LiquorID, rate: float64(*b.Rate)})
+	}
+
+	sums := map[pairKey]*pairSum{}
+	for _, rates := range byUser {
+		if len(rates) < 2 {
+			continue
+		}
+		var mean float64
+		for _, r := range rates {
+			mean += r.rate
+		}
+		mean /= float64(len(rates))
+
+		for i := 0; i < len(rates); i++ {
+			for j := i + 1; j < len(rates); j++ {
+				a, b := rates[i], rates[j]
+				if bytes.Compare(a.liquorId[:], b.liquorId[:]) > 0 {
+					a, b = b, a
+				}
+				key := pairKey{a.liquorId, b.liquorId}
+				s, ok := sums[key]
+				if !ok {
+					s = &pairSum{}
+					sums[key] = s
+				}
+				devA, devB := a.rate-mean, b.rate-mean
+				s.num += devA * devB
+				s.denA += devA * devA
+				s.denB += devB * devB
+				s.coRaters++
+			}
+		}
+	}
+
+	result := map[primitive.ObjectID][]similarityRepository.Entry{}
+	for key, s := range sums {
+		if s.coRaters < similarityMinCoRaters || s.denA == 0 || s.denB == 0 {
+			continue
+		}
+		similarity := s.num / (math.Sqrt(s.denA) * math.Sqrt(s.denB))
+		if similarity <= 0 {
+			continue
+		}
+		result[key[0]] = append(result[key[0]], similarityRepository.Entry{LiquorID: key[1], Similarity: similarity, CoRaters: s.coRaters})
+		result[key[1]] = append(result[key[1]], similarityRepository.Entry{LiquorID: key[0], Similarity: similarity, CoRaters: s.coRaters})
+	}
+	for lId, entries := range result {
+		sort.Slice(entries, func(i, j int) bool {
+			if entries[i].Similarity != entries[j].Similarity {
+				return entries[i].Similarity > entries[j].Similarity
+			}
+			if entries[i].CoRaters != entries[j].CoRaters {
+				return entries[i].CoRaters > entries[j].CoRaters
+			}
+			return bytes.Compare(entries[i].LiquorID[:], entries[j].LiquorID[:]) < 0
+		})
+		if len(entries) > similarityTopK {
+			result[lId] = entries[:similarityTopK]
+		}
+	}
+	return result
+}
@@ -0,0 +1,44 @@
+package subscriptionService
+
+import (
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/errorMsg"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+const (
+	PublishFailed   = "SUBSCRIPTION-SERVICE-001-PublishFailed"
+	SubscribeFailed = "SUBSCRIPTION-SERVICE-002-SubscribeFailed"
+	PayloadId       = "SUBSCRIPTION-SERVICE-003-PayloadId"
+)
+
+func errPublish(err error, topic string) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    PublishFailed,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      topic,
+	})
+}
+
+func errSubscribe(err error, topic string) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    SubscribeFailed,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      topic,
+	})
+}
+
+func errPayloadId(err error, topic string, payload []byte) *customError.Error {
+	return customError.NewError(err, customError.Params{
+		StatusCode: http.StatusInternalServerError,
+		ErrCode:    PayloadId,
+		UserMsg:    errorMsg.SERVER,
+		Level:      logrus.ErrorLevel,
+		Input:      map[string]string{"topic": topic, "payload": string(payload)},
+	})
+}
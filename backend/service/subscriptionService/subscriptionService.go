@@ -0,0 +1,68 @@
+package subscriptionService
+
+import (
+	"backend/middlewares/customError"
+	"backend/middlewares/customError/logger"
+	"backend/util/pubsub"
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memo:イベントには変更のあったドキュメントのIDだけを載せ、購読側で最新の状態を取得し直す(ブローカーに差し替えてもそのまま送れるようにするため)
+
+// BoardTopic お酒の掲示板への投稿(ペイロードは投稿のID)
+func BoardTopic(lId primitive.ObjectID) string {
+	return "board:" + lId.Hex()
+}
+
+// NotificationTopic ユーザーへの通知(ペイロードは通知のID)
+func NotificationTopic(uId primitive.ObjectID) string {
+	return "notification:" + uId.Hex()
+}
+
+// FlavorMapTopic お酒のフレーバーマップへの投票(ペイロードはお酒のID)
+func FlavorMapTopic(lId primitive.ObjectID) string {
+	return "flavorMap:" + lId.Hex()
+}
+
+// Publish 変更のあったドキュメントのIDを配信する。配信に失敗しても元の処理は成功させたいので、エラーはログに残すだけにする
+func Publish(ctx context.Context, ps pubsub.PubSub, topic string, id primitive.ObjectID) {
+	if err := ps.Publish(ctx, topic, []byte(id.Hex())); err != nil {
+		logger.LogError(ctx, errPublish(err, topic))
+	}
+}
+
+// Subscribe トピックを購読し、受け取ったIDをloadでGraphQLの型に変換して流す
+// 取得に失敗したイベントはログに残して読み飛ばし、nilが返った場合(削除済みなど)も流さない
+func Subscribe[T any](ctx context.Context, ps pubsub.PubSub, topic string, load func(ctx context.Context, id primitive.ObjectID) (*T, *customError.Error)) (<-chan *T, *customError.Error) {
+	events, err := ps.Subscribe(ctx, topic)
+	if err != nil {
+		return nil, errSubscribe(err, topic)
+	}
+
+	result := make(chan *T, 1)
+	go func() {
+		defer close(result)
+		for payload := range events {
+			id, e := primitive.ObjectIDFromHex(string(payload))
+			if e != nil {
+				logger.LogError(ctx, errPayloadId(e, topic, payload))
+				continue
+			}
+			value, cErr := load(ctx, id)
+			if cErr != nil {
+				logger.LogError(ctx, cErr)
+				continue
+			}
+			if value == nil {
+				continue
+			}
+			select {
+			case result <- value:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return result, nil
+}
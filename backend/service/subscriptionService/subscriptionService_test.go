@@ -0,0 +1,65 @@
+package subscriptionService
+
+import (
+	"backend/middlewares/customError"
+	"backend/util/pubsub"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSubscribe_正常系_取得できたイベントだけが流れること(t *testing.T) {
+	ps := pubsub.NewMemory()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lId := primitive.NewObjectID()
+	deleted := primitive.NewObjectID()
+	posted := primitive.NewObjectID()
+	ch, err := Subscribe(ctx, ps, BoardTopic(lId), func(_ context.Context, id primitive.ObjectID) (*string, *customError.Error) {
+		// 削除済みの投稿はnilを返す
+		if id == deleted {
+			return nil, nil
+		}
+		hex := id.Hex()
+		return &hex, nil
+	})
+	assert.Nil(t, err)
+
+	Publish(ctx, ps, BoardTopic(lId), deleted)
+	Publish(ctx, ps, BoardTopic(lId), posted)
+	// 別のお酒への投稿は届かないこと
+	Publish(ctx, ps, BoardTopic(primitive.NewObjectID()), primitive.NewObjectID())
+
+	select {
+	case v := <-ch:
+		assert.Equal(t, posted.Hex(), *v)
+	case <-time.After(time.Second):
+		t.Fatal("イベントが届かない")
+	}
+	select {
+	case v := <-ch:
+		t.Fatalf("余分なイベントが届いた: %v", *v)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSubscribe_正常系_ctxが終了するとチャネルが閉じられること(t *testing.T) {
+	ps := pubsub.NewMemory()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ch, _ := Subscribe(ctx, ps, NotificationTopic(primitive.NewObjectID()), func(_ context.Context, id primitive.ObjectID) (*string, *customError.Error) {
+		return nil, nil
+	})
+	cancel()
+
+	select {
+	case _, ok := <-ch:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("チャネルが閉じられていない")
+	}
+}
@@ -1,6 +1,7 @@
 package userService
 
 import (
+	"backend/db/repository/settingRepository"
 	"backend/db/repository/userRepository"
 	"backend/middlewares/auth"
 	"backend/middlewares/customError"
@@ -37,3 +38,53 @@ func IsLogin(ctx context.Context) bool {
 	//}
 	return true
 }
+
+// HasRole ユーザーが指定されたロールを持っているか
+func HasRole(user *userRepository.Model, role string) bool {
+	if user == nil {
+		return false
+	}
+	for _, v := range user.Roles {
+		if v == role {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdmin ログインユーザーが管理者権限を持っているか
+// 管理者に2段階認証を必須にしている場合、有効にしていない管理者は権限なしとみなす
+func IsAdmin(ctx context.Context, repo userRepository.UsersRepository, sr settingRepository.SettingRepository) (bool, *customError.Error) {
+	user, err := GetUserData(ctx, repo)
+	if err != nil {
+		return false, err
+	}
+	if !HasRole(user, userRepository.RoleAdmin) {
+		return false, nil
+	}
+	return SatisfiesTwoFactorRequirement(ctx, user, userRepository.RoleAdmin, sr)
+}
+
+// SatisfiesTwoFactorRequirement ロールに2段階認証が必須の場合、ユーザーが有効にしているか
+func SatisfiesTwoFactorRequirement(ctx context.Context, user *userRepository.Model, role string, sr settingRepository.SettingRepository) (bool, *customError.Error) {
+	if user.TwoFactor.IsEnabled() {
+		return true, nil
+	}
+	required, err := IsTwoFactorRequired(ctx, role, sr)
+	if err != nil {
+		return false, err
+	}
+	return !required, nil
+}
+
+// IsTwoFactorRequired ロールに2段階認証が必須になっているか(現在は管理者のみ必須にできる)
+func IsTwoFactorRequired(ctx context.Context, role string, sr settingRepository.SettingRepository) (bool, *customError.Error) {
+	if role != userRepository.RoleAdmin {
+		return false, nil
+	}
+	setting, err := sr.GetAuth(ctx)
+	if err != nil {
+		return false, err
+	}
+	return setting.AdminTwoFactorRequired, nil
+}
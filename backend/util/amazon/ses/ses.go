@@ -1,7 +1,9 @@
 package ses
 
 import (
+	"backend/util/mailer"
 	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/sesv2"
@@ -9,64 +11,57 @@ import (
 	"os"
 )
 
-const (
-	pwResetTitle = "パスワードリセット"
-)
-
-type emailContent struct {
-	Subject string
-	To      string
-	//Bcc *[]string
-	Text string
-}
-
-func SendPasswordReset(ctx context.Context, email string, token string) error {
-	//メールテンプレートを作る
-	msg, err := pwRstTemp(&passwordReset{
-		Token: token,
-	})
-	//メールを送信する
-	err = sendMail(ctx, &emailContent{
-		Subject: pwResetTitle,
-		To:      email,
-		Text:    msg,
-	})
-	return err
+// Mailer SESで送信するMailer
+type Mailer struct {
+	client *sesv2.Client
+	from   string
 }
 
-func sendMail(ctx context.Context, content *emailContent) error {
-	// 1. AWSの設定を読み込む
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(os.Getenv("AWS_REGION")), config.WithCredentialsProvider(
+// NewMailer AWSの設定を読み込み、SESクライアントを作成する(送信のたびに読み込まないよう、起動時に1度だけ作る)
+func NewMailer() (*Mailer, error) {
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(os.Getenv("AWS_REGION")), config.WithCredentialsProvider(
 		credentials.NewStaticCredentialsProvider(os.Getenv("AWS_SES_ACCESS_KEY"), os.Getenv("AWS_SES_ACCESS_SECRET"), ""),
 	))
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return &Mailer{client: sesv2.NewFromConfig(cfg), from: mailer.From()}, nil
+}
 
-	// 2. SESクライアントを作成
-	client := sesv2.NewFromConfig(cfg)
-	from := os.Getenv("AWS_SES_FROM")
+// Send 組み立て済みのメールをSESで送信する(HTMLとテキストの両方を含める)
+func (m *Mailer) Send(ctx context.Context, msg *mailer.Message) error {
+	var headers []types.MessageHeader
+	for name, value := range msg.Headers {
+		headers = append(headers, types.MessageHeader{Name: aws.String(name), Value: aws.String(value)})
+	}
 
 	input := &sesv2.SendEmailInput{
 		Destination: &types.Destination{
-			ToAddresses: []string{content.To},
+			ToAddresses: []string{msg.To},
 		},
 		Content: &types.EmailContent{
 			Simple: &types.Message{
 				Body: &types.Body{
 					Text: &types.Content{
-						Data: &content.Text,
+						Data:    aws.String(msg.Text),
+						Charset: aws.String("UTF-8"),
+					},
+					Html: &types.Content{
+						Data:    aws.String(msg.HTML),
+						Charset: aws.String("UTF-8"),
 					},
 				},
 				Subject: &types.Content{
-					Data: &content.Subject,
+					Data:    aws.String(msg.Subject),
+					Charset: aws.String("UTF-8"),
 				},
+				Headers: headers,
 			},
 		},
-		FromEmailAddress: &from,
+		FromEmailAddress: &m.from,
 	}
 
-	_, err = client.SendEmail(ctx, input)
+	_, err := m.client.SendEmail(ctx, input)
 
 	return err
 }
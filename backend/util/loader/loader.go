@@ -0,0 +1,65 @@
+package loader
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Loader 待ち時間の間に要求されたキーをまとめて1回で取得する(フィールドリゾルバのN+1対策)
+// memo:リクエストごとに作成し、contextに入れて使う
+type Loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+	wait  time.Duration
+	mu    sync.Mutex
+	batch *batch[K, V]
+}
+
+type batch[K comparable, V any] struct {
+	keys   []K
+	seen   map[K]struct{}
+	done   chan struct{}
+	values map[K]V
+	err    error
+}
+
+func New[K comparable, V any](wait time.Duration, fetch func(ctx context.Context, keys []K) (map[K]V, error)) *Loader[K, V] {
+	return &Loader[K, V]{fetch: fetch, wait: wait}
+}
+
+// Load keyの値を取得する。取得結果に含まれないキーはゼロ値を返す
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	b := l.add(ctx, key)
+	select {
+	case <-b.done:
+		return b.values[key], b.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// add 待機中のバッチにキーを追加する。バッチがなければ作成し、待ち時間の後にまとめて取得する
+func (l *Loader[K, V]) add(ctx context.Context, key K) *batch[K, V] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.batch == nil {
+		b := &batch[K, V]{seen: map[K]struct{}{}, done: make(chan struct{})}
+		l.batch = b
+		time.AfterFunc(l.wait, func() {
+			l.mu.Lock()
+			l.batch = nil
+			l.mu.Unlock()
+
+			// 最初に要求したフィールドのcontextがキャンセルされても、他のフィールドの分は取得する
+			b.values, b.err = l.fetch(context.WithoutCancel(ctx), b.keys)
+			close(b.done)
+		})
+	}
+	if _, ok := l.batch.seen[key]; !ok {
+		l.batch.seen[key] = struct{}{}
+		l.batch.keys = append(l.batch.keys, key)
+	}
+	return l.batch
+}
@@ -0,0 +1,75 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad_正常系_同時に要求したキーは重複を除いて1回で取得すること(t *testing.T) {
+	var calls [][]string
+	var mu sync.Mutex
+	l := New(10*time.Millisecond, func(_ context.Context, keys []string) (map[string]int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, keys)
+		return map[string]int{"a": 1, "b": 2}, nil
+	})
+
+	keys := []string{"a", "b", "a", "c"}
+	results := make([]int, len(keys))
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = l.Load(context.Background(), key)
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, calls, 1)
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, calls[0])
+	// 取得結果に含まれないキーはゼロ値になる
+	assert.Equal(t, []int{1, 2, 1, 0}, results)
+}
+
+func TestLoad_正常系_待ち時間を過ぎた要求は次のバッチで取得すること(t *testing.T) {
+	calls := 0
+	l := New(time.Millisecond, func(_ context.Context, keys []string) (map[string]int, error) {
+		calls++
+		return map[string]int{keys[0]: len(keys)}, nil
+	})
+
+	first, _ := l.Load(context.Background(), "a")
+	second, _ := l.Load(context.Background(), "b")
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 1, first)
+	assert.Equal(t, 1, second)
+}
+
+func TestLoad_異常系_取得に失敗した場合はバッチ内のすべての要求にエラーを返すこと(t *testing.T) {
+	fetchErr := errors.New("timeout")
+	l := New(10*time.Millisecond, func(_ context.Context, _ []string) (map[string]int, error) {
+		return nil, fetchErr
+	})
+
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i, key := range []string{"a", "b"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = l.Load(context.Background(), key)
+		}()
+	}
+	wg.Wait()
+
+	assert.ErrorIs(t, errs[0], fetchErr)
+	assert.ErrorIs(t, errs[1], fetchErr)
+}
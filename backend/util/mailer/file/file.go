@@ -0,0 +1,49 @@
+package file
+
+import (
+	"backend/util/mailer"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// Mailer 送信せずにMaildir形式でファイルに書き出すMailer(開発・テスト用)
+type Mailer struct {
+	dir   string
+	from  string
+	count atomic.Int64
+}
+
+// NewMailer dir以下にMaildirのディレクトリ(tmp・new・cur)を作成する
+func NewMailer(dir string) (*Mailer, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &Mailer{dir: dir, from: mailer.From()}, nil
+}
+
+// Send tmpに書き込んでからnewに移動する(読み込み側が書き込み途中のファイルを読まないようにする)
+func (m *Mailer) Send(_ context.Context, msg *mailer.Message) error {
+	now := time.Now()
+	data, err := msg.MIME(m.from, now)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d.%d_%d.eml", now.UnixNano(), os.Getpid(), m.count.Add(1))
+	tmp := filepath.Join(m.dir, "tmp", name)
+	if err = os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(m.dir, "new", name))
+}
+
+// Received 書き出したメールのパスを古い順に返す(テスト用)
+func (m *Mailer) Received() ([]string, error) {
+	return filepath.Glob(filepath.Join(m.dir, "new", "*.eml"))
+}
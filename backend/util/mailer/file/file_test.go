@@ -0,0 +1,31 @@
+package file
+
+import (
+	"backend/util/mailer"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMailer_正常系_Maildirのnewにメールが書き出されること(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewMailer(dir)
+	assert.NoError(t, err)
+
+	for _, to := range []string{"a@example.com", "b@example.com"} {
+		assert.NoError(t, m.Send(context.Background(), &mailer.Message{To: to, Subject: "件名", Text: "本文", HTML: "<p>本文</p>"}))
+	}
+
+	paths, err := m.Received()
+	assert.NoError(t, err)
+	assert.Len(t, paths, 2)
+	data, err := os.ReadFile(paths[0])
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "To: a@example.com")
+
+	tmp, _ := filepath.Glob(filepath.Join(dir, "tmp", "*"))
+	assert.Empty(t, tmp)
+}
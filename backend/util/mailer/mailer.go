@@ -0,0 +1,161 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmlTemplate "html/template"
+	"os"
+	"strings"
+	textTemplate "text/template"
+	"time"
+)
+
+// メールテンプレートの名前(templates/<言語>/<名前>.txt と .html の組で用意する)
+const (
+	TemplatePasswordReset = "password_reset"
+	TemplateWeeklyDigest  = "weekly_digest"
+	TemplateVerifyEmail   = "verify_email"
+	TemplateIdentityMoved = "identity_moved"
+)
+
+// 対応している言語
+const (
+	LocaleJa = "ja"
+	LocaleEn = "en"
+)
+
+// DefaultLocale 設定がない・未対応の言語の場合に使う
+const DefaultLocale = LocaleJa
+
+// Locales 対応している言語の一覧
+var Locales = []string{LocaleJa, LocaleEn}
+
+var templateNames = []string{TemplatePasswordReset, TemplateWeeklyDigest, TemplateVerifyEmail, TemplateIdentityMoved}
+
+//go:embed templates
+var templateFS embed.FS
+
+// mailTemplate 1通分のテンプレート。textは件名("subject")と本文を、htmlは共通のレイアウトと本文("content")を持つ
+type mailTemplate struct {
+	text *textTemplate.Template
+	html *htmlTemplate.Template
+}
+
+// 起動時に全テンプレートを読み込んでおく(テンプレートの誤りは起動時に気付けるようにする)
+var templates = mustLoadTemplates()
+
+// Mailer 組み立て済みのメールを送信する(SES・SMTP・ファイル出力などに差し替えられるようにする)
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// From 送信元のアドレス(未設定の場合はSES用の設定を使う)
+func From() string {
+	if from := os.Getenv("MAIL_FROM"); from != "" {
+		return from
+	}
+	return os.Getenv("AWS_SES_FROM")
+}
+
+// Message 送信するメール
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	Headers map[string]string
+	// NoQueue 本文に秘密のトークンを含むため、送信に失敗しても再送キューに保存しない
+	NoQueue bool
+	// ExpiresAt 本文のリンクの有効期限。過ぎたら再送しない(ゼロ値は期限なし)
+	ExpiresAt time.Time
+}
+
+// Params テンプレートに渡す値
+type Params struct {
+	To     string
+	Locale string
+	// Unsubscribe 配信停止リンクをつける場合のみ指定する(パスワードリセットなどの手続きのメールにはつけない)
+	Unsubscribe *UnsubscribeClaims
+	Data        any
+}
+
+// page テンプレートから参照できる値
+type page struct {
+	Locale         string
+	FrontURI       string
+	UnsubscribeURL string
+	Data           any
+}
+
+// Render テンプレートからメールを組み立てる
+func Render(name string, p Params) (*Message, error) {
+	locale := NormalizeLocale(p.Locale)
+	tmpl, ok := templates[templateKey(locale, name)]
+	if !ok {
+		return nil, fmt.Errorf("mail template not found: %s/%s", locale, name)
+	}
+
+	data := page{Locale: locale, FrontURI: os.Getenv("FRONT_URI"), Data: p.Data}
+	msg := &Message{To: p.To, Headers: map[string]string{}}
+	if p.Unsubscribe != nil {
+		url, err := UnsubscribeURL(*p.Unsubscribe)
+		if err != nil {
+			return nil, err
+		}
+		data.UnsubscribeURL = url
+		//メールソフトの配信停止ボタン用(RFC 8058のワンクリック配信停止)
+		msg.Headers["List-Unsubscribe"] = "<" + url + ">"
+		msg.Headers["List-Unsubscribe-Post"] = "List-Unsubscribe=One-Click"
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return nil, err
+	}
+	msg.Subject = strings.TrimSpace(subject.String())
+	msg.Text = strings.TrimSpace(text.String()) + "\n"
+	msg.HTML = html.String()
+	return msg, nil
+}
+
+// NormalizeLocale 対応している言語に丸める("en-US"なども受け付ける)
+func NormalizeLocale(locale string) string {
+	lower := strings.ToLower(locale)
+	for _, l := range Locales {
+		if lower == l || strings.HasPrefix(lower, l+"-") || strings.HasPrefix(lower, l+"_") {
+			return l
+		}
+	}
+	return DefaultLocale
+}
+
+func templateKey(locale string, name string) string {
+	return locale + "/" + name
+}
+
+func mustLoadTemplates() map[string]*mailTemplate {
+	result := map[string]*mailTemplate{}
+	for _, locale := range Locales {
+		for _, name := range templateNames {
+			text := textTemplate.Must(textTemplate.New(name+".txt").ParseFS(templateFS,
+				"templates/"+locale+"/"+name+".txt",
+				"templates/"+locale+"/footer.txt",
+			))
+			html := htmlTemplate.Must(htmlTemplate.New(name+".html").ParseFS(templateFS,
+				"templates/layout.html",
+				"templates/"+locale+"/"+name+".html",
+				"templates/"+locale+"/footer.html",
+			))
+			result[templateKey(locale, name)] = &mailTemplate{text: text, html: html}
+		}
+	}
+	return result
+}
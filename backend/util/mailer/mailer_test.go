@@ -0,0 +1,153 @@
+package mailer
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	netMail "net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNormalizeLocale_正常系_未対応の言語は既定の言語になること(t *testing.T) {
+	assert.Equal(t, LocaleEn, NormalizeLocale("en-US"))
+	assert.Equal(t, LocaleJa, NormalizeLocale("ja"))
+	assert.Equal(t, DefaultLocale, NormalizeLocale("fr"))
+	assert.Equal(t, DefaultLocale, NormalizeLocale(""))
+}
+
+func TestRender_正常系_言語ごとの件名と本文が組み立てられること(t *testing.T) {
+	t.Setenv("FRONT_URI", "https://example.com")
+	data := struct{ Token string }{Token: "abc"}
+
+	ja, err := Render(TemplatePasswordReset, Params{To: "a@example.com", Locale: "ja", Data: data})
+	assert.NoError(t, err)
+	assert.Equal(t, "パスワードリセット", ja.Subject)
+	assert.Contains(t, ja.Text, "https://example.com/auth/password/reset/abc")
+	assert.Contains(t, ja.HTML, `href="https://example.com/auth/password/reset/abc"`)
+	// 手続きのメールには配信停止リンクをつけない
+	assert.NotContains(t, ja.Text, "配信停止")
+	assert.Empty(t, ja.Headers)
+
+	en, err := Render(TemplatePasswordReset, Params{To: "a@example.com", Locale: "en", Data: data})
+	assert.NoError(t, err)
+	assert.Equal(t, "Reset your password", en.Subject)
+}
+
+func TestRender_正常系_配信停止リンクとヘッダーがつくこと(t *testing.T) {
+	t.Setenv("BACK_URI", "https://example.com/api")
+	t.Setenv("MAIL_SECRET_KEY", "secret")
+	claims := UnsubscribeClaims{UserID: primitive.NewObjectID(), Kind: "WEEKLY_DIGEST"}
+
+	msg, err := Render(TemplateWeeklyDigest, Params{
+		Locale:      "ja",
+		Unsubscribe: &claims,
+		Data: map[string]any{
+			"UserName": "<b>太郎</b>",
+			"Trending": []map[string]any{{"LiquorName": "獺祭", "LiquorURL": "https://example.com/liquor/1", "Count": 3}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Text, "獺祭(投稿3件)")
+	// HTMLではエスケープされること
+	assert.Contains(t, msg.HTML, "&lt;b&gt;太郎&lt;/b&gt;")
+	assert.True(t, strings.HasPrefix(msg.Headers["List-Unsubscribe"], "<https://example.com/api/email/unsubscribe?token="))
+	assert.Contains(t, msg.Text, "このメールの配信停止: https://example.com/api/email/unsubscribe?token=")
+}
+
+func TestParseUnsubscribeToken_正常系_作成したトークンから内容を取り出せること(t *testing.T) {
+	t.Setenv("MAIL_SECRET_KEY", "secret")
+	claims := UnsubscribeClaims{UserID: primitive.NewObjectID(), Kind: "WEEKLY_DIGEST"}
+
+	token, err := NewUnsubscribeToken(claims)
+	assert.NoError(t, err)
+	parsed, err := ParseUnsubscribeToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, claims, *parsed)
+}
+
+func TestParseUnsubscribeToken_異常系_改ざんされたトークンは拒否されること(t *testing.T) {
+	t.Setenv("MAIL_SECRET_KEY", "secret")
+	token, _ := NewUnsubscribeToken(UnsubscribeClaims{UserID: primitive.NewObjectID(), Kind: "WEEKLY_DIGEST"})
+
+	payload, mac, _ := strings.Cut(token, ".")
+	other, _ := NewUnsubscribeToken(UnsubscribeClaims{UserID: primitive.NewObjectID(), Kind: "WEEKLY_DIGEST"})
+	otherPayload, _, _ := strings.Cut(other, ".")
+
+	_, err := ParseUnsubscribeToken(otherPayload + "." + mac)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = ParseUnsubscribeToken(payload)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// 鍵が変わると無効になること
+	t.Setenv("MAIL_SECRET_KEY", "rotated")
+	_, err = ParseUnsubscribeToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestParseVerifyEmailToken_正常系_作成したトークンから内容を取り出せること(t *testing.T) {
+	t.Setenv("MAIL_SECRET_KEY", "secret")
+	now := time.Unix(1700000000, 0)
+	claims := VerifyEmailClaims{UserID: primitive.NewObjectID(), Email: "\"a:b\"@example.com", ExpiresAt: now.Add(time.Hour)}
+
+	token, err := NewVerifyEmailToken(claims)
+	assert.NoError(t, err)
+	parsed, err := ParseVerifyEmailToken(token, now)
+	assert.NoError(t, err)
+	assert.Equal(t, claims, *parsed)
+}
+
+func TestParseVerifyEmailToken_異常系_期限切れや用途違いのトークンは拒否されること(t *testing.T) {
+	t.Setenv("MAIL_SECRET_KEY", "secret")
+	now := time.Unix(1700000000, 0)
+	token, _ := NewVerifyEmailToken(VerifyEmailClaims{UserID: primitive.NewObjectID(), Email: "a@example.com", ExpiresAt: now})
+
+	_, err := ParseVerifyEmailToken(token, now)
+	assert.ErrorIs(t, err, ErrExpiredToken)
+
+	// 配信停止用のトークンは使えないこと
+	unsubscribe, _ := NewUnsubscribeToken(UnsubscribeClaims{UserID: primitive.NewObjectID(), Kind: "WEEKLY_DIGEST"})
+	_, err = ParseVerifyEmailToken(unsubscribe, now)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestMessageMIME_正常系_テキストとHTMLを含むメールが組み立てられること(t *testing.T) {
+	msg := &Message{
+		To:      "to@example.com",
+		Subject: "パスワードリセット",
+		Text:    "本文\nです",
+		HTML:    "<p>本文</p>",
+		Headers: map[string]string{"List-Unsubscribe": "<https://example.com>"},
+	}
+
+	data, err := msg.MIME("Sake DB <from@example.com>", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	assert.NoError(t, err)
+
+	parsed, err := netMail.ReadMessage(bytes.NewReader(data))
+	assert.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	assert.NoError(t, err)
+	assert.Equal(t, "パスワードリセット", subject)
+	assert.Equal(t, "to@example.com", parsed.Header.Get("To"))
+	assert.Equal(t, "<https://example.com>", parsed.Header.Get("List-Unsubscribe"))
+	assert.True(t, strings.HasSuffix(parsed.Header.Get("Message-ID"), "@example.com>"))
+
+	_, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		b, _ := io.ReadAll(part)
+		bodies = append(bodies, string(b))
+	}
+	assert.Equal(t, []string{"本文\r\nです", "<p>本文</p>"}, bodies)
+}
@@ -0,0 +1,97 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MIME SMTPやファイルに書き出す用に、テキストとHTMLを含むメール(multipart/alternative)を組み立てる
+func (m *Message) MIME(from string, now time.Time) ([]byte, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		contentType string
+		content     string
+	}{
+		//メールソフトは後ろのパートを優先して表示するので、HTMLを後ろにする
+		{"text/plain; charset=UTF-8", m.Text},
+		{"text/html; charset=UTF-8", m.HTML},
+	} {
+		pw, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err = qw.Write([]byte(toCRLF(part.content))); err != nil {
+			return nil, err
+		}
+		if err = qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	messageID, err := newMessageID(from)
+	if err != nil {
+		return nil, err
+	}
+	headers := map[string]string{
+		"From":         from,
+		"To":           m.To,
+		"Subject":      mime.BEncoding.Encode("UTF-8", m.Subject),
+		"Date":         now.Format(time.RFC1123Z),
+		"Message-ID":   messageID,
+		"MIME-Version": "1.0",
+		"Content-Type": "multipart/alternative; boundary=" + w.Boundary(),
+	}
+	for name, value := range m.Headers {
+		headers[name] = value
+	}
+
+	//出力を安定させるため、ヘッダーは名前順に並べる
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, headers[name])
+	}
+	buf.WriteString("\r\n")
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
+
+// newMessageID 送信元のドメインを使ってMessage-IDを作る
+func newMessageID(from string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	domain := "localhost"
+	if i := strings.LastIndex(from, "@"); i >= 0 {
+		domain = strings.TrimSuffix(from[i+1:], ">")
+	}
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">", nil
+}
+
+// toCRLF メールの改行コードはCRLFにする
+func toCRLF(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\n", "\r\n")
+}
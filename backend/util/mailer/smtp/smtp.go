@@ -0,0 +1,85 @@
+package smtp
+
+import (
+	"backend/util/mailer"
+	"context"
+	"crypto/tls"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// Mailer SMTPサーバー経由で送信するMailer(開発環境ではMailHogなどに向ける)
+type Mailer struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+// NewMailer usernameが空の場合は認証せずに送信する
+func NewMailer(host string, port string, username string, password string) *Mailer {
+	return &Mailer{
+		addr:     net.JoinHostPort(host, port),
+		host:     host,
+		username: username,
+		password: password,
+		from:     mailer.From(),
+	}
+}
+
+// Send サーバーが対応していればSTARTTLSで暗号化してから送信する
+func (m *Mailer) Send(ctx context.Context, msg *mailer.Message) error {
+	data, err := msg.MIME(m.from, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	//net/smtpはctxを受け取らないので、期限は接続に設定する
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err = c.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+	if m.username != "" {
+		if err = c.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return err
+		}
+	}
+	if err = c.Mail(m.from); err != nil {
+		return err
+	}
+	if err = c.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(data); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,149 @@
+package mailer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrMissingSecret = errors.New("MAIL_SECRET_KEY is not set")
+	ErrInvalidToken  = errors.New("invalid mail token")
+	ErrExpiredToken  = errors.New("mail token expired")
+)
+
+// UnsubscribeClaims 配信停止リンクに含める内容
+type UnsubscribeClaims struct {
+	UserID primitive.ObjectID
+	Kind   string //userRepository.EmailKind～
+}
+
+// UnsubscribeURL 配信停止リンクのURL(ログインせずに使えるよう、署名つきのトークンを含める)
+func UnsubscribeURL(c UnsubscribeClaims) (string, error) {
+	token, err := NewUnsubscribeToken(c)
+	if err != nil {
+		return "", err
+	}
+	return os.Getenv("BACK_URI") + "/email/unsubscribe?token=" + url.QueryEscape(token), nil
+}
+
+// NewUnsubscribeToken 配信停止用のトークンを作る
+// memo:メールは後から開かれることも多いので、有効期限はつけない
+func NewUnsubscribeToken(c UnsubscribeClaims) (string, error) {
+	return sign("unsubscribe", c.UserID.Hex()+":"+c.Kind)
+}
+
+// ParseUnsubscribeToken 配信停止用のトークンを検証して内容を取り出す
+func ParseUnsubscribeToken(token string) (*UnsubscribeClaims, error) {
+	payload, err := verify("unsubscribe", token)
+	if err != nil {
+		return nil, err
+	}
+	uId, kind, ok := strings.Cut(payload, ":")
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	id, err := primitive.ObjectIDFromHex(uId)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return &UnsubscribeClaims{UserID: id, Kind: kind}, nil
+}
+
+// sign 用途ごとに区別してpayloadに署名する(<payload>.<署名> をそれぞれbase64urlにしたもの)
+func sign(purpose string, payload string) (string, error) {
+	mac, err := signature(purpose, payload)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(mac), nil
+}
+
+// verify 署名を検証してpayloadを取り出す
+func verify(purpose string, token string) (string, error) {
+	encoded, encodedMac, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMac)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	expected, err := signature(purpose, string(payload))
+	if err != nil {
+		return "", err
+	}
+	if !hmac.Equal(mac, expected) {
+		return "", ErrInvalidToken
+	}
+	return string(payload), nil
+}
+
+func signature(purpose string, payload string) ([]byte, error) {
+	secret := os.Getenv("MAIL_SECRET_KEY")
+	if secret == "" {
+		return nil, ErrMissingSecret
+	}
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(purpose + "\n" + payload))
+	return h.Sum(nil), nil
+}
+
+// VerifyEmailClaims メールアドレス確認リンクに含める内容
+type VerifyEmailClaims struct {
+	UserID    primitive.ObjectID
+	Email     string //確認するメールアドレス(確認中に別のアドレスに変更された場合はトークンを無効にする)
+	ExpiresAt time.Time
+}
+
+// VerifyEmailURL メールアドレス確認リンクのURL
+func VerifyEmailURL(c VerifyEmailClaims) (string, error) {
+	token, err := NewVerifyEmailToken(c)
+	if err != nil {
+		return "", err
+	}
+	return os.Getenv("FRONT_URI") + "/auth/email/verify/" + url.PathEscape(token), nil
+}
+
+// NewVerifyEmailToken メールアドレス確認用のトークンを作る
+func NewVerifyEmailToken(c VerifyEmailClaims) (string, error) {
+	//メールアドレスには":"が含まれうるので最後に置く
+	return sign("verify_email", c.UserID.Hex()+":"+strconv.FormatInt(c.ExpiresAt.Unix(), 10)+":"+c.Email)
+}
+
+// ParseVerifyEmailToken メールアドレス確認用のトークンを検証して内容を取り出す
+func ParseVerifyEmailToken(token string, now time.Time) (*VerifyEmailClaims, error) {
+	payload, err := verify("verify_email", token)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.SplitN(payload, ":", 3)
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+	id, err := primitive.ObjectIDFromHex(parts[0])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	claims := &VerifyEmailClaims{UserID: id, Email: parts[2], ExpiresAt: time.Unix(expires, 0)}
+	if !now.Before(claims.ExpiresAt) {
+		return nil, ErrExpiredToken
+	}
+	return claims, nil
+}
@@ -0,0 +1,75 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+// subscriberBuffer 購読者ごとに溜めておけるイベント数(溢れた分は捨てる)
+const subscriberBuffer = 16
+
+// PubSub トピック単位でイベントを配信する
+// memo:サーバーを複数台で動かす場合は、Redisなどのブローカーを使う実装に差し替える(ペイロードはそのまま送れるようにバイト列にしている)
+type PubSub interface {
+	// Publish 購読者にイベントを配信する。購読者がいない場合は何もしない
+	Publish(ctx context.Context, topic string, payload []byte) error
+	// Subscribe トピックを購読する。ctxが終了すると購読を解除してチャネルを閉じる
+	Subscribe(ctx context.Context, topic string) (<-chan []byte, error)
+}
+
+// NewPubSub 既定の実装(プロセス内で配信する)を返す
+func NewPubSub() PubSub {
+	return NewMemory()
+}
+
+// Memory プロセス内だけで配信するPubSub
+type Memory struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[chan []byte]struct{}
+}
+
+func NewMemory() *Memory {
+	return &Memory{subscribers: map[string]map[chan []byte]struct{}{}}
+}
+
+func (m *Memory) Publish(_ context.Context, topic string, payload []byte) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for ch := range m.subscribers[topic] {
+		//受信が追いつかない購読者のせいで投稿処理を止めないよう、溢れた場合は捨てる
+		select {
+		case ch <- payload:
+		default:
+		}
+	}
+	return nil
+}
+
+func (m *Memory) Subscribe(ctx context.Context, topic string) (<-chan []byte, error) {
+	ch := make(chan []byte, subscriberBuffer)
+	m.mu.Lock()
+	if m.subscribers[topic] == nil {
+		m.subscribers[topic] = map[chan []byte]struct{}{}
+	}
+	m.subscribers[topic][ch] = struct{}{}
+	m.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		m.mu.Lock()
+		delete(m.subscribers[topic], ch)
+		if len(m.subscribers[topic]) == 0 {
+			delete(m.subscribers, topic)
+		}
+		m.mu.Unlock()
+		close(ch)
+	}()
+	return ch, nil
+}
+
+// countSubscribers テスト用
+func (m *Memory) countSubscribers(topic string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.subscribers[topic])
+}
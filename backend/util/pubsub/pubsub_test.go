@@ -0,0 +1,52 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory_正常系_購読中のトピックにだけ配信されること(t *testing.T) {
+	m := NewMemory()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	board, _ := m.Subscribe(ctx, "board:a")
+	other, _ := m.Subscribe(ctx, "board:b")
+	_ = m.Publish(ctx, "board:a", []byte("1"))
+
+	assert.Equal(t, []byte("1"), <-board)
+	assert.Len(t, other, 0)
+}
+
+func TestMemory_正常系_ctxが終了すると購読が解除されること(t *testing.T) {
+	m := NewMemory()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ch, _ := m.Subscribe(ctx, "topic")
+	cancel()
+
+	select {
+	case _, ok := <-ch:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("チャネルが閉じられていない")
+	}
+	assert.Equal(t, 0, m.countSubscribers("topic"))
+	// 解除後の配信でpanicしないこと
+	assert.NoError(t, m.Publish(context.Background(), "topic", []byte("1")))
+}
+
+func TestMemory_正常系_受信が追いつかない場合は溢れた分を捨てること(t *testing.T) {
+	m := NewMemory()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, _ := m.Subscribe(ctx, "topic")
+	for i := 0; i < subscriberBuffer+5; i++ {
+		_ = m.Publish(ctx, "topic", []byte{byte(i)})
+	}
+	assert.Len(t, ch, subscriberBuffer)
+}
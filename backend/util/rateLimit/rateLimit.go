@@ -0,0 +1,64 @@
+package rateLimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter キーごとに一定時間内の実行回数を制限する(固定ウィンドウ)
+// memo:プロセス内で保持するだけなので、複数台構成にする場合は共有ストアに置き換えること
+type Limiter struct {
+	limit   int
+	window  time.Duration
+	mu      sync.Mutex
+	entries map[string]*entry
+	swept   time.Time
+}
+
+type entry struct {
+	count   int
+	resetAt time.Time
+}
+
+func NewLimiter(limit int, window time.Duration) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		window:  window,
+		entries: map[string]*entry{},
+	}
+}
+
+// Allow 実行可能であれば回数を加算してtrueを返す
+func (l *Limiter) Allow(key string) bool {
+	return l.allowAt(key, time.Now())
+}
+
+func (l *Limiter) allowAt(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	e, ok := l.entries[key]
+	if !ok || !now.Before(e.resetAt) {
+		e = &entry{resetAt: now.Add(l.window)}
+		l.entries[key] = e
+	}
+	if e.count >= l.limit {
+		return false
+	}
+	e.count++
+	return true
+}
+
+// sweep 期限切れのキーを定期的に削除する(キーが増え続けないようにする)
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < l.window {
+		return
+	}
+	for key, e := range l.entries {
+		if !now.Before(e.resetAt) {
+			delete(l.entries, key)
+		}
+	}
+	l.swept = now
+}
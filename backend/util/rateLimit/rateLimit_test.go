@@ -0,0 +1,23 @@
+package rateLimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter_正常系_上限を超えるとウィンドウが切り替わるまで拒否されること(t *testing.T) {
+	l := NewLimiter(2, time.Minute)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.True(t, l.allowAt("a", now))
+	assert.True(t, l.allowAt("a", now.Add(time.Second)))
+	assert.False(t, l.allowAt("a", now.Add(2*time.Second)))
+
+	// 別のキーには影響しないこと
+	assert.True(t, l.allowAt("b", now.Add(2*time.Second)))
+
+	// ウィンドウが切り替わると再び許可されること
+	assert.True(t, l.allowAt("a", now.Add(time.Minute)))
+}
@@ -0,0 +1,61 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 6238 付録Bのテストベクタ(SHA-1)の下6桁
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode_正常系_RFCのテストベクタと一致すること(t *testing.T) {
+	cases := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, expected := range cases {
+		code, err := Code(rfcSecret, Step(time.Unix(unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, code, unix)
+	}
+}
+
+func TestValidate_正常系_前後1ステップのずれを許容すること(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	previous, _ := Code(rfcSecret, Step(now)-1)
+
+	step, ok := Validate(rfcSecret, previous, now)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now)-1, step)
+}
+
+func TestValidate_異常系_ずれが大きいコードや形式の違うコードは拒否すること(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	old, _ := Code(rfcSecret, Step(now)-2)
+
+	_, ok := Validate(rfcSecret, old, now)
+	assert.False(t, ok)
+	_, ok = Validate(rfcSecret, "12345", now)
+	assert.False(t, ok)
+	_, ok = Validate("not base32!", "123456", now)
+	assert.False(t, ok)
+}
+
+func TestURI_正常系_認証アプリで読み込める形式になること(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	uri := URI("Sake DB", "a@example.com", secret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Sake%20DB:a@example.com?"))
+	assert.Contains(t, uri, "secret="+secret)
+	assert.Contains(t, uri, "issuer=Sake+DB")
+}